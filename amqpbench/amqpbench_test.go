@@ -0,0 +1,80 @@
+package amqpbench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	amqp "github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopbackSendReceive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := amqp.NewConn(ctx, NewLoopback(&LoopbackOptions{MessageSize: 16}), nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	session, err := client.NewSession(ctx, nil)
+	require.NoError(t, err)
+
+	sndr, err := session.NewSender(ctx, "target", nil)
+	require.NoError(t, err)
+	require.NoError(t, sndr.Send(ctx, amqp.NewMessage([]byte("hello")), nil))
+
+	rcvr, err := session.NewReceiver(ctx, "source", nil)
+	require.NoError(t, err)
+	msg, err := rcvr.Receive(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, msg.GetData(), 16)
+	require.NoError(t, rcvr.AcceptMessage(ctx, msg))
+}
+
+func TestLoopbackReceiverSettleModeSecond(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := amqp.NewConn(ctx, NewLoopback(nil), nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	session, err := client.NewSession(ctx, nil)
+	require.NoError(t, err)
+
+	rcvr, err := session.NewReceiver(ctx, "source", &amqp.ReceiverOptions{
+		SettlementMode: amqp.ReceiverSettleModeSecond.Ptr(),
+	})
+	require.NoError(t, err)
+	msg, err := rcvr.Receive(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, rcvr.AcceptMessage(ctx, msg))
+}
+
+func TestScenarios(t *testing.T) {
+	scenarios := map[string]func(*testing.B, Options){
+		"Send":    Send,
+		"Receive": Receive,
+		"Settle":  Settle,
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			result := testing.Benchmark(func(b *testing.B) {
+				scenario(b, Options{MessageSize: 64})
+			})
+			require.NotZero(t, result.N)
+		})
+	}
+}
+
+func TestScenarioSendSettled(t *testing.T) {
+	result := testing.Benchmark(func(b *testing.B) {
+		Send(b, Options{
+			Sender: &amqp.SenderOptions{
+				SettlementMode: amqp.SenderSettleModeSettled.Ptr(),
+			},
+		})
+	})
+	require.NotZero(t, result.N)
+}
@@ -0,0 +1,182 @@
+package amqpbench
+
+import (
+	"net"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+)
+
+// LoopbackOptions contains the optional settings for configuring a
+// loopback connection.
+type LoopbackOptions struct {
+	// MessageSize is the size in bytes of the message bodies generated
+	// for receiving links.
+	//
+	// Default: 128.
+	MessageSize int
+}
+
+// NewLoopback returns the client half of an in-process connection whose
+// peer implements a minimal AMQP broker: the protocol handshake, a
+// single session, and links in either role.  Deliveries to sending
+// links are accepted as they arrive, and credit issued by receiving
+// links is answered with generated messages.  Pass the result to
+// [amqp.NewConn]:
+//
+//		client, err := amqp.NewConn(ctx, amqpbench.NewLoopback(nil), nil)
+//
+//	  - opts contains optional values, pass nil to accept the defaults
+func NewLoopback(opts *LoopbackOptions) net.Conn {
+	size := defaultMessageSize
+	if opts != nil && opts.MessageSize > 0 {
+		size = opts.MessageSize
+	}
+	return fake.NewNetConn(newLoopbackResponder(size), fake.NetConnOptions{})
+}
+
+// loopbackLink is the peer-side state of an attached link, keyed by the
+// client's handle.
+type loopbackLink struct {
+	handle    uint32 // the handle assigned by the loopback peer
+	receiving bool   // true when the client is the receiving end
+	sent      uint32 // count of deliveries generated for a receiving client
+}
+
+// newLoopbackResponder returns the frame callback backing a loopback
+// connection.  The callback runs on a single goroutine so its state
+// needs no synchronization.
+func newLoopbackResponder(messageSize int) func(uint16, frames.FrameBody) (fake.Response, error) {
+	var (
+		body           = make([]byte, messageSize)
+		nextHandle     uint32
+		nextDeliveryID uint32
+		lastDeliveryID uint32
+		links          = map[uint32]*loopbackLink{}
+	)
+	return func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *fake.KeepAlive:
+			return fake.Response{}, nil
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("loopback"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			ll := &loopbackLink{handle: nextHandle, receiving: tt.Role == encoding.RoleReceiver}
+			nextHandle++
+			links[tt.Handle] = ll
+			if ll.receiving {
+				mode := encoding.ReceiverSettleModeFirst
+				if tt.ReceiverSettleMode != nil {
+					mode = *tt.ReceiverSettleMode
+				}
+				var filter encoding.Filter
+				if tt.Source != nil {
+					filter = tt.Source.Filter
+				}
+				return newResponse(fake.ReceiverAttach(0, tt.Name, ll.handle, mode, filter))
+			}
+			mode := encoding.SenderSettleModeMixed
+			if tt.SenderSettleMode != nil {
+				mode = *tt.SenderSettleMode
+			}
+			ab, err := fake.SenderAttach(0, tt.Name, ll.handle, mode)
+			if err != nil {
+				return fake.Response{}, err
+			}
+			// grant the sending link enough credit and incoming window
+			// that neither is exhausted over the course of a benchmark.
+			fb, err := linkCreditFlow(ll.handle)
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: append(ab, fb...)}, nil
+		case *frames.PerformFlow:
+			if tt.Handle == nil || tt.LinkCredit == nil {
+				return fake.Response{}, nil
+			}
+			ll := links[*tt.Handle]
+			if ll == nil || !ll.receiving {
+				return fake.Response{}, nil
+			}
+			// the client's view of our delivery count plus the credit it
+			// issued is the ceiling on deliveries we may generate.
+			var count uint32
+			if tt.DeliveryCount != nil {
+				count = *tt.DeliveryCount
+			}
+			var payload []byte
+			for limit := count + *tt.LinkCredit; ll.sent < limit; ll.sent++ {
+				fr, err := fake.PerformTransfer(0, ll.handle, nextDeliveryID, body)
+				if err != nil {
+					return fake.Response{}, err
+				}
+				nextDeliveryID++
+				payload = append(payload, fr...)
+			}
+			// chunked because [fake.NetConn.Read] drops any bytes beyond
+			// the reader's buffer, whose capacity is at least 512.
+			return fake.Response{Payload: payload, ChunkSize: 512}, nil
+		case *frames.PerformTransfer:
+			// multi-frame deliveries carry the delivery ID on the first
+			// frame only; settle once the final frame arrives.
+			if tt.DeliveryID != nil {
+				lastDeliveryID = *tt.DeliveryID
+			}
+			if tt.Settled || tt.More {
+				return fake.Response{}, nil
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, lastDeliveryID, nil, &encoding.StateAccepted{}))
+		case *frames.PerformDisposition:
+			if tt.Settled {
+				return fake.Response{}, nil
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleSender, 0, tt.First, tt.Last, tt.State))
+		case *frames.PerformDetach:
+			ll := links[tt.Handle]
+			if ll == nil {
+				return fake.Response{}, nil
+			}
+			delete(links, tt.Handle)
+			return newResponse(fake.PerformDetach(0, ll.handle, nil))
+		default:
+			return fake.Response{}, nil
+		}
+	}
+}
+
+// linkCreditFlow encodes a flow frame granting handle 2^31 link credit
+// along with a matching session incoming window.
+func linkCreditFlow(handle uint32) ([]byte, error) {
+	var (
+		nextIncoming uint32
+		count        uint32
+		available    uint32
+		credit       uint32 = 1 << 31
+	)
+	return fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+		NextIncomingID: &nextIncoming,
+		IncomingWindow: 1 << 31,
+		OutgoingWindow: 1 << 31,
+		NextOutgoingID: nextIncoming + 1,
+		Handle:         &handle,
+		DeliveryCount:  &count,
+		LinkCredit:     &credit,
+		Available:      &available,
+	})
+}
+
+func newResponse(b []byte, err error) (fake.Response, error) {
+	if err != nil {
+		return fake.Response{}, err
+	}
+	return fake.Response{Payload: b}, nil
+}
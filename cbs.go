@@ -0,0 +1,252 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultCBSAddress is the name of the CBS node defined by the AMQP
+// claims-based security specification.
+const defaultCBSAddress = "$cbs"
+
+// defaultCBSRefreshWindow is how long before a token's expiry it's renewed.
+const defaultCBSRefreshWindow = 2 * time.Minute
+
+// application property keys and values defined by the CBS specification.
+const (
+	cbsKeyOperation         = "operation"
+	cbsKeyType              = "type"
+	cbsKeyName              = "name"
+	cbsKeyStatusCode        = "status-code"
+	cbsKeyStatusDescription = "status-description"
+	cbsOperationPutToken    = "put-token"
+)
+
+// Token is a security token put to the CBS node.
+type Token struct {
+	// Type identifies the kind of token to the broker,
+	// e.g. "jwt" or "servicebus.windows.net:sastoken".
+	Type string
+
+	// Value is the token itself.
+	Value string
+
+	// ExpiresAt is when the token expires, used to schedule its renewal.
+	// The zero value disables background renewal.
+	ExpiresAt time.Time
+}
+
+// TokenProvider supplies security tokens for a [TokenRefresher].
+type TokenProvider interface {
+	// GetToken returns a token authorizing access to audience.
+	//   - ctx controls waiting for the token to be acquired
+	//   - audience is the entity path or URI being authorized
+	GetToken(ctx context.Context, audience string) (Token, error)
+}
+
+// TokenRefresherOptions contains the optional settings for configuring a TokenRefresher.
+type TokenRefresherOptions struct {
+	// Address is the name of the CBS node tokens are put to.
+	//
+	// Default: "$cbs".
+	Address string
+
+	// OnError is invoked with the terminal error when a background
+	// renewal fails after the configured retries are exhausted; the
+	// refresher stops renewing and should be closed and re-created.
+	// It is called from the refresher's goroutine so it MUST NOT block.
+	//
+	// Default: nil, meaning failures are only observable through the
+	// broker eventually revoking access.
+	OnError func(error)
+
+	// RefreshWindow is how long before a token's expiry the renewal is
+	// performed.
+	//
+	// Default: 2 minutes (120000000000).
+	RefreshWindow time.Duration
+
+	// Retry bounds the attempts for a single put-token operation.
+	//
+	// Default: nil, meaning the [RetryOptions] defaults.
+	Retry *RetryOptions
+}
+
+// TokenRefresher authorizes a connection via the claims-based security
+// (CBS) mechanism: it attaches a request/response link pair to the CBS
+// node, puts a token from a [TokenProvider], and renews it in the
+// background before it expires.
+//
+// A refresher authorizes a single audience; create one per entity that
+// requires authorization.
+type TokenRefresher struct {
+	audience string
+	provider TokenProvider
+	opts     TokenRefresherOptions
+
+	session *Session
+	rpc     *RPCLink
+
+	ctx    context.Context // cancelled by Close to stop in-flight renewals
+	cancel context.CancelFunc
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewTokenRefresher attaches a link pair to conn's CBS node, puts an
+// initial token for audience, and starts renewing it in the background
+// per the token's expiry.  Close the refresher to stop renewal and
+// detach the links.
+//   - ctx controls waiting for the links to attach and the initial
+//     put-token to complete
+//   - conn is the connection to authorize
+//   - audience is the entity path or URI being authorized
+//   - provider supplies the tokens
+//   - opts contains optional values, pass nil to accept the defaults
+func NewTokenRefresher(ctx context.Context, conn *Conn, audience string, provider TokenProvider, opts *TokenRefresherOptions) (*TokenRefresher, error) {
+	tr := &TokenRefresher{
+		audience: audience,
+		provider: provider,
+	}
+	if opts != nil {
+		tr.opts = *opts
+	}
+	if tr.opts.Address == "" {
+		tr.opts.Address = defaultCBSAddress
+	}
+	if tr.opts.RefreshWindow <= 0 {
+		tr.opts.RefreshWindow = defaultCBSRefreshWindow
+	}
+	tr.ctx, tr.cancel = context.WithCancel(context.Background())
+
+	session, err := conn.NewSession(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	tr.session = session
+	if tr.rpc, err = NewRPCLink(ctx, session, tr.opts.Address, nil); err != nil {
+		_ = session.Close(ctx)
+		return nil, err
+	}
+
+	var token Token
+	if err = withRetry(ctx, tr.opts.Retry, func() error {
+		var putErr error
+		token, putErr = tr.putToken(ctx)
+		return putErr
+	}); err != nil {
+		_ = session.Close(ctx)
+		return nil, err
+	}
+
+	if !token.ExpiresAt.IsZero() {
+		go tr.refresh(token.ExpiresAt)
+	}
+	return tr, nil
+}
+
+// Close stops background renewal and detaches the links by closing the
+// underlying session.  It does not delete the token from the broker.
+//   - ctx controls waiting for the peer to acknowledge the session is closed
+func (tr *TokenRefresher) Close(ctx context.Context) error {
+	tr.closeOnce.Do(func() {
+		tr.cancel()
+		tr.closeErr = tr.session.Close(ctx)
+	})
+	return tr.closeErr
+}
+
+// refresh renews the token before each expiry until Close is called,
+// renewal fails, or a token without an expiry is put.
+func (tr *TokenRefresher) refresh(expiry time.Time) {
+	for {
+		wait := time.Until(expiry) - tr.opts.RefreshWindow
+		if wait < time.Second {
+			// expiry is inside the refresh window; renew shortly but
+			// don't spin on a provider that keeps returning short-lived
+			// tokens
+			wait = time.Second
+		}
+		select {
+		case <-tr.ctx.Done():
+			return
+		case <-time.After(wait):
+			// time to renew
+		}
+
+		var token Token
+		err := withRetry(tr.ctx, tr.opts.Retry, func() error {
+			var putErr error
+			token, putErr = tr.putToken(tr.ctx)
+			return putErr
+		})
+		if err != nil {
+			if tr.ctx.Err() == nil && tr.opts.OnError != nil {
+				tr.opts.OnError(err)
+			}
+			return
+		}
+		if token.ExpiresAt.IsZero() {
+			return
+		}
+		expiry = token.ExpiresAt
+	}
+}
+
+// putToken acquires a token from the provider and performs a single
+// put-token request/response exchange with the CBS node.
+func (tr *TokenRefresher) putToken(ctx context.Context) (Token, error) {
+	token, err := tr.provider.GetToken(ctx, tr.audience)
+	if err != nil {
+		return Token{}, fmt.Errorf("amqp: acquiring token: %w", err)
+	}
+
+	req := &Message{
+		ApplicationProperties: map[string]any{
+			cbsKeyOperation: cbsOperationPutToken,
+			cbsKeyType:      token.Type,
+			cbsKeyName:      tr.audience,
+		},
+		Value: token.Value,
+	}
+	resp, err := tr.rpc.Call(ctx, req)
+	if err != nil {
+		return Token{}, err
+	}
+
+	code, ok := cbsStatusCode(resp.ApplicationProperties[cbsKeyStatusCode])
+	if !ok {
+		return Token{}, fmt.Errorf("amqp: put-token response without a status code")
+	}
+	if code < 200 || code > 299 {
+		desc, _ := resp.ApplicationProperties[cbsKeyStatusDescription].(string)
+		return Token{}, fmt.Errorf("amqp: put-token failed with status %d: %s", code, desc)
+	}
+	return token, nil
+}
+
+// cbsStatusCode converts the status-code application property, whose
+// integral type varies by broker, to an int.
+func cbsStatusCode(v any) (int, bool) {
+	switch code := v.(type) {
+	case int:
+		return code, true
+	case int16:
+		return int(code), true
+	case int32:
+		return int(code), true
+	case int64:
+		return int(code), true
+	case uint16:
+		return int(code), true
+	case uint32:
+		return int(code), true
+	case uint64:
+		return int(code), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,222 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// cbsPutToken captures a put-token request observed by cbsFrameHandler.
+type cbsPutToken struct {
+	operation string
+	tokenType string
+	name      string
+	value     any
+	replyTo   string
+}
+
+// cbsFrameHandler responds as a CBS node: it attaches link pairs, grants
+// the client's sender credit, and answers each put-token request with
+// the specified status.  Requests are sent to the requests channel when
+// non-nil.
+func cbsFrameHandler(requests chan<- cbsPutToken, statusCode int32, statusDesc string) frameHandler {
+	var deliveryID uint32
+	return func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			if tt.Role == encoding.RoleSender {
+				ab, err := fake.SenderAttach(0, tt.Name, 0, encoding.SenderSettleModeUnsettled)
+				if err != nil {
+					return fake.Response{}, err
+				}
+				// grant the client's sender credit so it can send requests
+				var (
+					nextIncoming uint32
+					count        uint32
+					handle       uint32
+					credit       uint32 = 100
+				)
+				fb, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+					NextIncomingID: &nextIncoming,
+					IncomingWindow: 1000,
+					OutgoingWindow: 1000,
+					NextOutgoingID: nextIncoming + 1,
+					Handle:         &handle,
+					DeliveryCount:  &count,
+					LinkCredit:     &credit,
+				})
+				if err != nil {
+					return fake.Response{}, err
+				}
+				return fake.Response{Payload: append(ab, fb...)}, nil
+			}
+			return newResponse(fake.ReceiverAttach(0, tt.Name, 1, encoding.ReceiverSettleModeFirst, nil))
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			return fake.Response{}, nil
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, tt.Handle, nil))
+		case *frames.PerformTransfer:
+			var msg Message
+			if err := msg.UnmarshalBinary(tt.Payload); err != nil {
+				return fake.Response{}, err
+			}
+			if requests != nil {
+				var replyTo string
+				if msg.Properties.ReplyTo != nil {
+					replyTo = *msg.Properties.ReplyTo
+				}
+				requests <- cbsPutToken{
+					operation: msg.ApplicationProperties[cbsKeyOperation].(string),
+					tokenType: msg.ApplicationProperties[cbsKeyType].(string),
+					name:      msg.ApplicationProperties[cbsKeyName].(string),
+					value:     msg.Value,
+					replyTo:   replyTo,
+				}
+			}
+			dispo, err := fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			resp := &Message{
+				Properties: &MessageProperties{
+					CorrelationID: msg.Properties.MessageID,
+				},
+				ApplicationProperties: map[string]any{
+					cbsKeyStatusCode:        statusCode,
+					cbsKeyStatusDescription: statusDesc,
+				},
+			}
+			respPayload, err := resp.MarshalBinary()
+			if err != nil {
+				return fake.Response{}, err
+			}
+			format := uint32(0)
+			xfer, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{
+				Handle:        1,
+				DeliveryID:    &deliveryID,
+				DeliveryTag:   []byte("cbs-resp"),
+				MessageFormat: &format,
+				Payload:       respPayload,
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			deliveryID++
+			return fake.Response{Payload: append(dispo, xfer...)}, nil
+		default:
+			return fake.Response{}, nil
+		}
+	}
+}
+
+// fakeTokenProvider returns tokens with the configured lifetime, or err.
+type fakeTokenProvider struct {
+	lifetime time.Duration
+	err      error
+}
+
+func (p fakeTokenProvider) GetToken(ctx context.Context, audience string) (Token, error) {
+	if p.err != nil {
+		return Token{}, p.err
+	}
+	token := Token{Type: "jwt", Value: "token-" + audience}
+	if p.lifetime > 0 {
+		token.ExpiresAt = time.Now().Add(p.lifetime)
+	}
+	return token, nil
+}
+
+func TestTokenRefresherPutToken(t *testing.T) {
+	requests := make(chan cbsPutToken, 1)
+	netConn := fake.NewNetConn(cbsFrameHandler(requests, 200, "OK"), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := NewConn(ctx, netConn, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	refresher, err := NewTokenRefresher(ctx, client, "myqueue", fakeTokenProvider{}, nil)
+	require.NoError(t, err)
+
+	request := <-requests
+	require.Equal(t, "put-token", request.operation)
+	require.Equal(t, "jwt", request.tokenType)
+	require.Equal(t, "myqueue", request.name)
+	require.Equal(t, "token-myqueue", request.value)
+	require.Contains(t, request.replyTo, "cbs-reply-")
+
+	require.NoError(t, refresher.Close(ctx))
+	// Close is idempotent
+	require.NoError(t, refresher.Close(ctx))
+}
+
+func TestTokenRefresherRenewal(t *testing.T) {
+	requests := make(chan cbsPutToken, 5)
+	netConn := fake.NewNetConn(cbsFrameHandler(requests, 200, "OK"), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := NewConn(ctx, netConn, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	refresher, err := NewTokenRefresher(ctx, client, "myqueue", fakeTokenProvider{lifetime: 500 * time.Millisecond}, nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, refresher.Close(ctx)) }()
+
+	// the initial put plus at least one background renewal; renewals are
+	// floored at one second apart
+	for i := 0; i < 2; i++ {
+		select {
+		case <-requests:
+			// put-token request observed
+		case <-time.After(3 * time.Second):
+			t.Fatalf("didn't receive put-token request %d", i+1)
+		}
+	}
+}
+
+func TestTokenRefresherStatusError(t *testing.T) {
+	netConn := fake.NewNetConn(cbsFrameHandler(nil, 401, "token rejected"), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := NewConn(ctx, netConn, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	refresher, err := NewTokenRefresher(ctx, client, "myqueue", fakeTokenProvider{}, nil)
+	require.Nil(t, refresher)
+	require.ErrorContains(t, err, "401")
+	require.ErrorContains(t, err, "token rejected")
+}
+
+func TestTokenRefresherProviderError(t *testing.T) {
+	netConn := fake.NewNetConn(cbsFrameHandler(nil, 200, "OK"), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := NewConn(ctx, netConn, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	providerErr := errors.New("credential expired")
+	refresher, err := NewTokenRefresher(ctx, client, "myqueue", fakeTokenProvider{err: providerErr}, nil)
+	require.Nil(t, refresher)
+	require.ErrorIs(t, err, providerErr)
+}
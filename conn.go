@@ -6,10 +6,14 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"math"
 	"net"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/go-amqp/internal/bitmap"
@@ -26,27 +30,143 @@ const (
 	defaultMaxFrameSize = 65536
 	defaultMaxSessions  = 65536
 	defaultWriteTimeout = 30 * time.Second
+
+	// cap on bytes buffered while coalescing outgoing frames
+	// (ConnOptions.WriteBatchDelay); reaching it forces a flush
+	defaultWriteBatchBufferSize = 65536
 )
 
 // ConnOptions contains the optional settings for configuring an AMQP connection.
 type ConnOptions struct {
+	// ALPNProtocols sets the protocols advertised during ALPN negotiation,
+	// in order of preference, on the implicit TLS config.  After the TLS
+	// handshake, the connection fails if the peer didn't negotiate one of
+	// them.  Needed by gateways that multiplex AMQP with other protocols
+	// on a single port.
+	//
+	// Ignored when TLSConfig is set; assign its NextProtos field instead.
+	//
+	// Default: nil, meaning no protocols are advertised.
+	ALPNProtocols []string
+
+	// BufferPool provides the byte slices that hold incoming transfer
+	// frame payloads.  Without a pool, a fresh slice is allocated for
+	// every transfer; supplying one lets high-throughput receivers
+	// recycle payload buffers instead of pressuring the garbage
+	// collector.
+	//
+	// Default: nil, meaning payload buffers are allocated per transfer.
+	BufferPool BufferPool
+
 	// ContainerID sets the container-id to use when opening the connection.
 	//
 	// A container ID will be randomly generated if this option is not used.
 	ContainerID string
 
+	// DesiredCapabilities sets the extension capabilities requested from the
+	// peer in the Open frame (e.g. "ANONYMOUS-RELAY").  A desired capability
+	// is in effect only when the peer offers it back; use
+	// [Conn.PeerOfferedCapability] to check.
+	//
+	// Default: nil.
+	DesiredCapabilities []string
+
+	// Dialer establishes the network connection to the broker,
+	// replacing the default net/tls dialing path.  Use it for custom
+	// name resolution, happy-eyeballs, or unix sockets.  For "amqps"
+	// schemes, TLS is layered over the returned connection.  When
+	// Proxy is also set, the dialer is used to reach the proxy.
+	// Applies only to [Dial]; [NewConn] takes an already established
+	// connection.
+	//
+	// Default: nil, meaning the standard library dialers are used.
+	Dialer Dialer
+
+	// DisableURLVHost disables mapping the dial URL's path segment to the
+	// hostname sent in the AMQP Open frame.  Set it when connecting to a
+	// broker that serves a path in its URL but doesn't treat it as a vhost.
+	//
+	// Default: false.
+	DisableURLVHost bool
+
+	// FrameObserver is invoked for every AMQP frame sent to or received
+	// from the peer, making frame-level behavior such as flow control
+	// traceable in production without a debug build.  The credentials
+	// carried in SASL frames are redacted before the observer sees them;
+	// empty (keep-alive) frames are not observed.
+	//
+	// The observer is invoked from the connection's read and write
+	// goroutines so it MUST NOT block; a slow observer stalls all
+	// traffic on the connection.
+	//
+	// Default: nil, meaning frames are not observed.
+	FrameObserver func(direction Direction, channel uint16, fr any)
+
+	// HandleReuseDelay is the grace period before a released session
+	// channel or link handle may be reused.  When a call to Close times
+	// out mid-handshake, frames addressed to the old channel or handle
+	// can still be in flight; the grace period keeps them from being
+	// attributed to a new session or link reusing the number.
+	//
+	// Note that while a number is quarantined it still counts against
+	// MaxSessions and the session's MaxLinks.
+	//
+	// Default: 0, meaning channels and handles are reused immediately.
+	HandleReuseDelay time.Duration
+
 	// HostName sets the hostname sent in the AMQP
 	// Open frame and TLS ServerName (if not otherwise set).
+	//
+	// Multi-tenant brokers use the Open frame's hostname to select a
+	// vhost or tenant.  When it differs from the broker's TLS identity,
+	// set TLSServerName so certificate verification isn't performed
+	// against the vhost name.
 	HostName string
 
 	// IdleTimeout specifies the maximum period between
 	// receiving frames from the peer.
 	//
+	// When violated the connection is closed with a *ConnError wrapping
+	// [IdleTimeoutError]; see IdleTolerance for grace options.
+	//
 	// Specify a value less than zero to disable idle timeout.
 	//
 	// Default: 1 minute (60000000000).
 	IdleTimeout time.Duration
 
+	// IdleTolerance controls how the peer's idle behavior is enforced once
+	// IdleTimeout elapses with no incoming traffic.
+	//
+	// Default: nil, meaning the connection is closed after one idle interval.
+	IdleTolerance *IdleToleranceOptions
+
+	// KeepAlive controls how the keepalive interval is derived from the
+	// peer's idle-timeout.
+	//
+	// Default: nil, meaning keepalives are sent at half the peer's idle-timeout.
+	KeepAlive *KeepAliveOptions
+
+	// KeyLogWriter optionally specifies a destination for TLS master secrets
+	// in NSS key log format, allowing captured traffic to be decrypted by
+	// external tools such as Wireshark.
+	//
+	// Use of KeyLogWriter compromises security and should only be used for
+	// debugging.  Ignored when TLSConfig is set; assign its KeyLogWriter
+	// field instead.
+	//
+	// Default: nil.
+	KeyLogWriter io.Writer
+
+	// Logger receives structured protocol events: connection, session,
+	// and link lifecycle at [slog.LevelInfo], and every frame sent or
+	// received at [slog.LevelDebug], each carrying identifying attributes
+	// such as channel, link name, and handle.  Unlike the debug build
+	// tag, logging is enabled at runtime and filtered by the logger's
+	// level.
+	//
+	// Default: nil, meaning no events are logged.
+	Logger *slog.Logger
+
 	// MaxFrameSize sets the maximum frame size that
 	// the connection will accept.
 	//
@@ -55,18 +175,76 @@ type ConnOptions struct {
 	// Default: 65536.
 	MaxFrameSize uint32
 
+	// MaxIncomingFrameRate caps the number of incoming frames processed
+	// per second.  When the cap is exceeded, the connection stops reading
+	// from the network until the current one-second window elapses; the
+	// kernel's receive buffer then fills, applying TCP backpressure to
+	// the peer.  Protects memory when a peer produces frames faster than
+	// the application consumes them.
+	//
+	// Default: 0, meaning no cap is applied.
+	MaxIncomingFrameRate uint32
+
 	// MaxSessions sets the maximum number of channels.
 	// The value must be greater than zero.
 	//
 	// Default: 65536.
 	MaxSessions uint16
 
+	// Metrics receives callbacks as metrics events occur on the connection
+	// and its sessions and links.
+	// The provided implementation must be safe for concurrent use.
+	//
+	// Default: nil, meaning no metrics are collected.
+	Metrics Metrics
+
+	// OfferedCapabilities sets the extension capabilities advertised to the
+	// peer in the Open frame, i.e. those this client supports if the peer
+	// desires them.
+	//
+	// Default: nil.
+	OfferedCapabilities []string
+
 	// Properties sets an entry in the connection properties map sent to the server.
 	Properties map[string]any
 
+	// Proxy tunnels the TCP connection through a proxy before the AMQP
+	// handshake.  Applies only to [Dial]; [NewConn] takes an already
+	// established connection.
+	//
+	// Default: nil, meaning the broker is dialed directly.
+	Proxy *ProxyOptions
+
+	// SASLLimits bounds SASL negotiation so a malicious or broken peer
+	// can't stall connection establishment indefinitely.  When a limit is
+	// exceeded, connecting fails with a *SASLLimitError.
+	//
+	// Default: nil, meaning no limits are applied.
+	SASLLimits *SASLLimitOptions
+
 	// SASLType contains the specified SASL authentication mechanism.
 	SASLType SASLType
 
+	// SoleConnectionForContainer requests the sole-connection-for-container
+	// capability when opening the connection, indicating that the peer should
+	// permit at most one connection with this container-id.
+	//
+	// If the peer enforces the capability and another connection with the same
+	// container-id already exists, the peer will reject this connection; use
+	// [SoleConnectionDetected] to classify the resulting error.
+	//
+	// Default: false.
+	SoleConnectionForContainer bool
+
+	// StrictValidation enforces spec MUSTs that the library tolerates by
+	// default (e.g. settle-mode rules on transfers, handles above the
+	// negotiated handle-max, link-level flow fields without a handle) and
+	// responds with the mandated errors.  Useful for conformance testing
+	// and broker development.
+	//
+	// Default: false.
+	StrictValidation bool
+
 	// TLSConfig sets the tls.Config to be used during
 	// TLS negotiation.
 	//
@@ -74,6 +252,61 @@ type ConnOptions struct {
 	// providing a URL scheme of "amqps://" is sufficient.
 	TLSConfig *tls.Config
 
+	// TLSServerName sets the server name used for SNI and certificate
+	// verification during TLS negotiation, independent of the hostname
+	// sent in the Open frame.  Required when HostName names a vhost or
+	// tenant that differs from the broker's TLS identity.
+	//
+	// Takes no effect when TLSConfig is set with a non-empty ServerName;
+	// assign its ServerName field instead.
+	//
+	// Default: "", meaning HostName is used.
+	TLSServerName string
+
+	// Tracer receives callbacks to create spans around sends (through
+	// settlement) and to recover trace context from received messages,
+	// propagated as a W3C traceparent in the message's application
+	// properties.  Adapt a tracing system such as OpenTelemetry by
+	// implementing [Tracer] over it.
+	//
+	// Default: nil, meaning no tracing is performed.
+	Tracer Tracer
+
+	// WatchdogTimeout enables a diagnostic watchdog over the connection's
+	// session and link mux goroutines when set to a value greater than zero.
+	// If a mux makes no progress for at least this period while work is
+	// queued for it, WatchdogHandler is invoked with a stack capture to aid
+	// deadlock triage.
+	//
+	// The watchdog is purely diagnostic; it takes no corrective action.
+	//
+	// Default: 0 (disabled).
+	WatchdogTimeout time.Duration
+
+	// WatchdogHandler is invoked when the watchdog detects a stalled mux.
+	// Required when WatchdogTimeout is greater than zero.
+	// Invoked from the watchdog's goroutine; implementations shouldn't block.
+	WatchdogHandler func(WatchdogEvent)
+
+	// WriteBatchDelay enables frame coalescing: instead of writing each
+	// frame to the network as it's produced, the connection buffers
+	// outgoing frames for up to this duration and flushes them in a
+	// single write.  This trades a small amount of per-frame latency
+	// for far fewer write syscalls when many small frames (e.g.
+	// dispositions and flows) are produced at high message rates.
+	// While batching, a frame's write is confirmed once it's committed
+	// to the buffer; a failed flush terminates the connection.
+	//
+	// Default: 0, meaning each frame is written immediately.
+	WriteBatchDelay time.Duration
+
+	// WriteBufferSize caps the bytes buffered while coalescing frames;
+	// reaching the cap forces an immediate flush.  Only applies when
+	// WriteBatchDelay is greater than zero.
+	//
+	// Default: 0, meaning 65536.
+	WriteBufferSize int
+
 	// WriteTimeout controls the write deadline when writing AMQP frames to the
 	// underlying net.Conn and no caller provided context.Context is available or
 	// the context contains no deadline (e.g. context.Background()).
@@ -89,6 +322,141 @@ type ConnOptions struct {
 	dialer dialer
 }
 
+// Direction indicates whether a frame observed via
+// [ConnOptions.FrameObserver] was sent or received.
+type Direction int
+
+const (
+	// DirectionReceive indicates the frame was received from the peer.
+	DirectionReceive Direction = iota
+
+	// DirectionSend indicates the frame was sent to the peer.
+	DirectionSend
+)
+
+// String implements the fmt.Stringer interface for type Direction.
+func (d Direction) String() string {
+	switch d {
+	case DirectionReceive:
+		return "receive"
+	case DirectionSend:
+		return "send"
+	default:
+		return fmt.Sprintf("unknown direction %d", int(d))
+	}
+}
+
+// IdleToleranceOptions contains the optional settings controlling how missed
+// peer heartbeats are handled once ConnOptions.IdleTimeout elapses with no
+// incoming traffic.
+type IdleToleranceOptions struct {
+	// MissedIntervals sets how many consecutive idle intervals must elapse
+	// with no incoming traffic before the connection is declared dead.
+	//
+	// Default: 0, meaning the connection is declared dead after one interval.
+	MissedIntervals uint32
+
+	// OnWarning is invoked each time an idle interval elapses with no
+	// incoming traffic, including the interval that declares the connection
+	// dead.  Invoked from the connection's read goroutine; implementations
+	// shouldn't block.
+	//
+	// Default: nil.
+	OnWarning func(IdleWarningEvent)
+
+	// Probe sends an empty (keepalive) frame to the peer each time an idle
+	// interval elapses, prompting traffic from peers that only transmit in
+	// response to activity.
+	//
+	// Default: false.
+	Probe bool
+
+	// WarnOnly suppresses closing the connection entirely; each elapsed
+	// idle interval invokes OnWarning and reading continues.
+	// OnWarning is required when WarnOnly is set.
+	//
+	// Default: false.
+	WarnOnly bool
+}
+
+// IdleWarningEvent describes an idle interval that elapsed with no
+// incoming traffic.  See [IdleToleranceOptions].
+type IdleWarningEvent struct {
+	// MissedIntervals is the number of consecutive idle intervals that
+	// have elapsed with no incoming traffic.
+	MissedIntervals uint32
+
+	// Idle is the total time the connection has gone without incoming
+	// traffic.
+	Idle time.Duration
+}
+
+// KeepAliveOptions contains the optional settings for deriving the keepalive
+// interval from the peer's idle-timeout.  Keepalives remain disabled when the
+// peer doesn't request an idle-timeout, unless Interval is set.
+//
+// Keepalives cover the outgoing direction; enforcement of the peer's
+// incoming traffic is configured via [ConnOptions.IdleTimeout] and
+// [ConnOptions.IdleTolerance], whose OnWarning callback distinguishes a
+// silently dead network from a broker-initiated close.
+type KeepAliveOptions struct {
+	// Divisor divides the peer's idle-timeout to derive the keepalive
+	// interval.  Must be 2 or greater so keepalives are sent well within
+	// the peer's idle-timeout.
+	//
+	// Default: 2.
+	Divisor int
+
+	// Interval sets a fixed cadence for sending empty (keepalive) frames,
+	// overriding derivation from the peer's idle-timeout.  Unlike the
+	// derived interval, it applies even when the peer doesn't request an
+	// idle-timeout, e.g. to hold open intermediaries that silently drop
+	// idle connections.  Divisor, MinInterval, and MaxInterval are
+	// ignored when set.
+	//
+	// Default: 0, meaning the interval is derived from the peer's
+	// idle-timeout.
+	Interval time.Duration
+
+	// MaxInterval caps the derived keepalive interval, forcing more
+	// frequent keepalives than the peer's idle-timeout requires.  Useful
+	// when an intermediary (e.g. an L4 load balancer) silently drops
+	// connections that are idle for shorter periods than the peer allows.
+	//
+	// Default: 0, meaning no cap is applied.
+	MaxInterval time.Duration
+
+	// MinInterval sets a floor on the derived keepalive interval so an
+	// aggressively small peer idle-timeout doesn't result in excessive
+	// keepalive traffic.
+	//
+	// Default: 0, meaning no floor is applied.
+	MinInterval time.Duration
+}
+
+// SASLLimitOptions contains the optional limits enforced during SASL
+// negotiation.
+type SASLLimitOptions struct {
+	// HandshakeTimeout bounds the entire SASL negotiation, from reading
+	// the mechanisms frame through the outcome frame.
+	//
+	// Default: 0, meaning negotiation is bounded only by the context
+	// passed when connecting.
+	HandshakeTimeout time.Duration
+
+	// MaxChallengeRounds caps the number of challenge frames the peer may
+	// send before negotiation concludes.
+	//
+	// Default: 0, meaning no cap is applied.
+	MaxChallengeRounds uint32
+
+	// MaxChallengeSize caps the size in bytes of any single challenge
+	// payload received from the peer.
+	//
+	// Default: 0, meaning no cap is applied.
+	MaxChallengeSize uint32
+}
+
 // Dial connects to an AMQP broker.
 //
 // If the addr includes a scheme, it must be "amqp", "amqps", or "amqp+ssl".
@@ -97,6 +465,13 @@ type ConnOptions struct {
 // If username and password information is not empty it's used as SASL PLAIN
 // credentials, equal to passing ConnSASLPlain option.
 //
+// If the addr includes a path (e.g. "amqps://host/vhost"), the path (with its
+// leading slash removed) is sent as the hostname in the AMQP Open frame,
+// selecting a vhost on multi-tenant brokers.  An explicit HostName option
+// takes precedence; set DisableURLVHost to suppress the mapping entirely.
+// The mapping doesn't affect TLS: certificates are still verified against
+// the dialed host unless TLSServerName or TLSConfig says otherwise.
+//
 // opts: pass nil to accept the default values.
 func Dial(ctx context.Context, addr string, opts *ConnOptions) (*Conn, error) {
 	c, err := dialConn(ctx, addr, opts)
@@ -135,23 +510,56 @@ type Conn struct {
 	tlsNegotiation bool        // negotiate TLS
 	tlsComplete    bool        // TLS negotiation complete
 	tlsConfig      *tls.Config // TLS config, default used if nil (ServerName set to Client.hostname)
+	tlsServerName  string      // overrides hostname as the default TLS ServerName when set
+	requireALPN    bool        // fail the connection if the peer doesn't negotiate one of ConnOptions.ALPNProtocols
 
 	// SASL
-	saslHandlers map[encoding.Symbol]stateFunc // map of supported handlers keyed by SASL mechanism, SASL not negotiated if nil
-	saslComplete bool                          // SASL negotiation complete; internal *except* for SASL auth methods
+	saslHandlers        map[encoding.Symbol]stateFunc // map of supported handlers keyed by SASL mechanism, SASL not negotiated if nil
+	saslComplete        bool                          // SASL negotiation complete; internal *except* for SASL auth methods
+	saslLimits          SASLLimitOptions              // negotiation limits; zero values mean no limits
+	saslDeadline        time.Time                     // when SASL negotiation must complete by; zero when no HandshakeTimeout is set
+	saslChallengeRounds uint32                        // number of challenge frames received from the peer
 
 	// local settings
-	maxFrameSize uint32                  // max frame size to accept
-	channelMax   uint16                  // maximum number of channels to allow
-	hostname     string                  // hostname of remote server (set explicitly or parsed from URL)
-	idleTimeout  time.Duration           // maximum period between receiving frames
-	properties   map[encoding.Symbol]any // additional properties sent upon connection open
-	containerID  string                  // set explicitly or randomly generated
+	maxIncomingFrameRate uint32                       // cap on incoming frames per second; zero means no cap
+	maxFrameSize         uint32                       // max frame size to accept
+	channelMax           uint16                       // maximum number of channels to allow
+	hostname             string                       // hostname of remote server (set explicitly or parsed from URL)
+	idleTimeout          time.Duration                // maximum period between receiving frames
+	idleTolerance        IdleToleranceOptions         // handling of elapsed idle intervals; zero values mean close after one
+	idleToleranceActive  bool                         // tolerance applies only once the connection is established; only touched by connReader
+	frameObserver        func(Direction, uint16, any) // observes every frame sent/received; see ConnOptions.FrameObserver
+	logger               *slog.Logger                 // structured protocol events; nil when logging is disabled
+	properties           map[encoding.Symbol]any      // additional properties sent upon connection open
+	containerID          string                       // set explicitly or randomly generated
+	correlationID        string                       // short randomly generated ID included in the connection's debug log lines
+	desiredCapabilities  encoding.MultiSymbol         // capabilities requested upon connection open
+	offeredCapabilities  encoding.MultiSymbol         // capabilities advertised upon connection open
+
+	// mux watchdog settings; disabled when watchdogTimeout is zero
+	watchdogTimeout time.Duration
+	watchdogHandler func(WatchdogEvent)
+
+	metrics    Metrics         // optional metrics callbacks; nil when metrics are disabled
+	extMetrics ExtendedMetrics // set when metrics also implements the extension; nil otherwise
+	tracer     Tracer          // optional tracing callbacks; nil when tracing is disabled
+
+	strictValidation bool // enforce spec MUSTs that are tolerated by default
+
+	keepAlive KeepAliveOptions // keepalive interval derivation; zero values mean the defaults
+
+	// outgoing frame coalescing; disabled when writeBatchDelay is zero
+	writeBatchDelay time.Duration
+	writeBufferSize int
+
+	bufferPool BufferPool // optional pool for incoming transfer payloads; nil means allocate per transfer
 
 	// peer settings
-	peerIdleTimeout  time.Duration  // maximum period between sending frames
-	peerMaxFrameSize uint32         // maximum frame size peer will accept
-	peerProperties   map[string]any // properties returned by the peer
+	peerIdleTimeout         time.Duration        // maximum period between sending frames
+	peerMaxFrameSize        uint32               // maximum frame size peer will accept
+	peerProperties          map[string]any       // properties returned by the peer
+	peerOfferedCapabilities encoding.MultiSymbol // capabilities offered by the peer upon connection open
+	peerDesiredCapabilities encoding.MultiSymbol // capabilities requested by the peer upon connection open
 
 	// conn state
 	done    chan struct{} // indicates the connection has terminated
@@ -163,6 +571,8 @@ type Conn struct {
 
 	// session tracking
 	channels            *bitmap.Bitmap
+	channelQuarantine   quarantine // delays channel reuse; guarded by sessionsByChannelMu
+	handleReuseDelay    time.Duration
 	sessionsByChannel   map[uint16]*Session
 	sessionsByChannelMu sync.RWMutex
 
@@ -170,15 +580,48 @@ type Conn struct {
 	abandonedSessions   []*Session
 
 	// connReader
-	rxBuf  buffer.Buffer // incoming bytes buffer
-	rxDone chan struct{} // closed when connReader exits
-	rxErr  error         // contains last error reading from c.net; DO NOT TOUCH outside of connReader until rxDone has been closed!
+	rxBuf     buffer.Buffer // incoming bytes buffer
+	rxDone    chan struct{} // closed when connReader exits
+	rxErr     error         // contains last error reading from c.net; DO NOT TOUCH outside of connReader until rxDone has been closed!
+	rxPauseMu sync.Mutex
+	rxResume  chan struct{} // non-nil while reads are paused; closed by ResumeReads
 
 	// connWriter
 	txFrame chan frameEnvelope // AMQP frames to be sent by connWriter
+	txProbe chan struct{}      // signals connWriter to send a probe keepalive frame (idle tolerance)
 	txBuf   buffer.Buffer      // buffer for marshaling frames before transmitting
 	txDone  chan struct{}      // closed when connWriter exits
 	txErr   error              // contains last error writing to c.net; DO NOT TOUCH outside of connWriter until txDone has been closed!
+
+	// last-frame timestamps in UnixNano, for idle-timeout diagnostics.
+	// lastFrameReceived is written by connReader and lastFrameSent by
+	// connWriter; each may read the other's via [IdleTimeoutError].
+	lastFrameReceived atomic.Int64
+	lastFrameSent     atomic.Int64
+}
+
+// BufferPool obtains and recycles the byte slices that hold incoming
+// transfer frame payloads.  Implementations must be safe for concurrent
+// use by multiple goroutines.
+type BufferPool interface {
+	// Get returns a slice of the specified length.  The slice may have
+	// excess capacity, e.g. when backed by a [sync.Pool] of fixed-size
+	// buffers.
+	Get(size int) []byte
+
+	// Put returns a slice obtained from Get once its payload has been
+	// consumed.  The slice may have been shortened from the length it
+	// was handed out with.  Not every buffer makes it back to the pool
+	// (e.g. payloads in flight when a link closes), so implementations
+	// must tolerate misses.
+	Put(buf []byte)
+}
+
+// Dialer establishes network connections.  Its signature matches
+// [net.Dialer.DialContext], so the standard library dialer and
+// compatible third-party dialers plug in directly.
+type Dialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
 // used to abstract the underlying dialer for testing purposes
@@ -202,6 +645,30 @@ func (defaultDialer) TLSDialWithDialer(ctx context.Context, c *Conn, host, port
 	return
 }
 
+// implements the dialer interface over a user-provided [Dialer]
+type userDialer struct {
+	dial Dialer
+}
+
+func (d userDialer) NetDialerDial(ctx context.Context, c *Conn, host, port string) (err error) {
+	c.net, err = d.dial.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	return
+}
+
+func (d userDialer) TLSDialWithDialer(ctx context.Context, c *Conn, host, port string) error {
+	conn, err := d.dial.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+	tlsConn := tls.Client(conn, c.tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return err
+	}
+	c.net = tlsConn
+	return nil
+}
+
 func dialConn(ctx context.Context, addr string, opts *ConnOptions) (*Conn, error) {
 	u, err := url.Parse(addr)
 	if err != nil {
@@ -227,7 +694,17 @@ func dialConn(ctx context.Context, addr string, opts *ConnOptions) (*Conn, error
 	}
 
 	if cp.HostName == "" {
-		cp.HostName = host
+		// a non-empty URL path names a vhost per common broker conventions
+		if vhost := strings.TrimPrefix(u.Path, "/"); vhost != "" && !cp.DisableURLVHost {
+			cp.HostName = vhost
+			// the vhost is an AMQP-level name; the certificate must still
+			// be verified against the host that was dialed
+			if cp.TLSServerName == "" {
+				cp.TLSServerName = host
+			}
+		} else {
+			cp.HostName = host
+		}
 	}
 
 	c, err := newConn(nil, &cp)
@@ -235,6 +712,13 @@ func dialConn(ctx context.Context, addr string, opts *ConnOptions) (*Conn, error
 		return nil, err
 	}
 
+	if cp.Dialer != nil {
+		c.dialer = userDialer{dial: cp.Dialer}
+	}
+	if cp.Proxy != nil {
+		c.dialer = proxyDialer{opts: cp.Proxy, netDial: cp.Dialer}
+	}
+
 	switch u.Scheme {
 	case "amqp", "":
 		err = c.dialer.NetDialerDial(ctx, c, host, port)
@@ -249,6 +733,11 @@ func dialConn(ctx context.Context, addr string, opts *ConnOptions) (*Conn, error
 	if err != nil {
 		return nil, err
 	}
+
+	if err := c.validateALPN(); err != nil {
+		c.net.Close()
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -261,10 +750,12 @@ func newConn(netConn net.Conn, opts *ConnOptions) (*Conn, error) {
 		channelMax:        defaultMaxSessions - 1, // -1 because channel-max starts at zero
 		idleTimeout:       defaultIdleTimeout,
 		containerID:       shared.RandString(40),
+		correlationID:     shared.RandString(6),
 		done:              make(chan struct{}),
 		rxtxExit:          make(chan struct{}),
 		rxDone:            make(chan struct{}),
 		txFrame:           make(chan frameEnvelope),
+		txProbe:           make(chan struct{}, 1),
 		txDone:            make(chan struct{}),
 		sessionsByChannel: map[uint16]*Session{},
 		writeTimeout:      defaultWriteTimeout,
@@ -280,9 +771,30 @@ func newConn(netConn net.Conn, opts *ConnOptions) (*Conn, error) {
 	} else if opts.WriteTimeout < 0 {
 		c.writeTimeout = 0
 	}
+	if opts.WriteBatchDelay < 0 || opts.WriteBufferSize < 0 {
+		return nil, errors.New("write batching values cannot be negative")
+	}
+	c.writeBatchDelay = opts.WriteBatchDelay
+	if c.writeBufferSize = opts.WriteBufferSize; c.writeBufferSize == 0 {
+		c.writeBufferSize = defaultWriteBatchBufferSize
+	}
+	c.bufferPool = opts.BufferPool
 	if opts.ContainerID != "" {
 		c.containerID = opts.ContainerID
 	}
+	for _, capability := range opts.DesiredCapabilities {
+		c.desiredCapabilities = append(c.desiredCapabilities, encoding.Symbol(capability))
+	}
+	c.frameObserver = opts.FrameObserver
+	if opts.Logger != nil {
+		c.logger = opts.Logger.With(slog.String("connection", c.correlationID))
+	}
+	if opts.HandleReuseDelay < 0 {
+		return nil, errors.New("HandleReuseDelay cannot be negative")
+	} else if opts.HandleReuseDelay > 0 {
+		c.handleReuseDelay = opts.HandleReuseDelay
+		c.channelQuarantine.delay = opts.HandleReuseDelay
+	}
 	if opts.HostName != "" {
 		c.hostname = opts.HostName
 	}
@@ -291,6 +803,27 @@ func newConn(netConn net.Conn, opts *ConnOptions) (*Conn, error) {
 	} else if opts.IdleTimeout < 0 {
 		c.idleTimeout = 0
 	}
+	if opts.IdleTolerance != nil {
+		if opts.IdleTolerance.WarnOnly && opts.IdleTolerance.OnWarning == nil {
+			return nil, errors.New("IdleTolerance.OnWarning is required when WarnOnly is set")
+		}
+		c.idleTolerance = *opts.IdleTolerance
+	}
+	if opts.KeepAlive != nil {
+		if opts.KeepAlive.Divisor < 0 || opts.KeepAlive.Divisor == 1 {
+			return nil, fmt.Errorf("invalid KeepAlive.Divisor value %d", opts.KeepAlive.Divisor)
+		}
+		if opts.KeepAlive.Interval < 0 || opts.KeepAlive.MinInterval < 0 || opts.KeepAlive.MaxInterval < 0 {
+			return nil, errors.New("KeepAlive intervals cannot be negative")
+		}
+		if opts.KeepAlive.MinInterval > 0 && opts.KeepAlive.MaxInterval > 0 && opts.KeepAlive.MinInterval > opts.KeepAlive.MaxInterval {
+			return nil, errors.New("KeepAlive.MinInterval cannot exceed KeepAlive.MaxInterval")
+		}
+		c.keepAlive = *opts.KeepAlive
+	}
+	if opts.MaxIncomingFrameRate > 0 {
+		c.maxIncomingFrameRate = opts.MaxIncomingFrameRate
+	}
 	if opts.MaxFrameSize > 0 && opts.MaxFrameSize < 512 {
 		return nil, fmt.Errorf("invalid MaxFrameSize value %d", opts.MaxFrameSize)
 	} else if opts.MaxFrameSize > 512 {
@@ -299,6 +832,18 @@ func newConn(netConn net.Conn, opts *ConnOptions) (*Conn, error) {
 	if opts.MaxSessions > 0 {
 		c.channelMax = opts.MaxSessions
 	}
+	for _, capability := range opts.OfferedCapabilities {
+		c.offeredCapabilities = append(c.offeredCapabilities, encoding.Symbol(capability))
+	}
+	if opts.SoleConnectionForContainer {
+		c.desiredCapabilities = append(c.desiredCapabilities, capabilitySoleConnectionForContainer)
+	}
+	if opts.SASLLimits != nil {
+		if opts.SASLLimits.HandshakeTimeout < 0 {
+			return nil, errors.New("SASLLimits.HandshakeTimeout cannot be negative")
+		}
+		c.saslLimits = *opts.SASLLimits
+	}
 	if opts.SASLType != nil {
 		if err := opts.SASLType(c); err != nil {
 			return nil, err
@@ -310,8 +855,34 @@ func newConn(netConn net.Conn, opts *ConnOptions) (*Conn, error) {
 			c.properties[encoding.Symbol(key)] = val
 		}
 	}
+	c.tlsServerName = opts.TLSServerName
 	if opts.TLSConfig != nil {
 		c.tlsConfig = opts.TLSConfig.Clone()
+	} else if opts.KeyLogWriter != nil || len(opts.ALPNProtocols) > 0 {
+		c.tlsConfig = &tls.Config{
+			KeyLogWriter: opts.KeyLogWriter,
+			NextProtos:   append([]string(nil), opts.ALPNProtocols...),
+		}
+		c.requireALPN = len(opts.ALPNProtocols) > 0
+	}
+	if opts.WatchdogTimeout > 0 {
+		if opts.WatchdogHandler == nil {
+			return nil, errors.New("WatchdogHandler is required when WatchdogTimeout is set")
+		}
+		c.watchdogTimeout = opts.WatchdogTimeout
+		c.watchdogHandler = opts.WatchdogHandler
+	}
+	if opts.Metrics != nil {
+		c.metrics = opts.Metrics
+		if em, ok := opts.Metrics.(ExtendedMetrics); ok {
+			c.extMetrics = em
+		}
+	}
+	if opts.Tracer != nil {
+		c.tracer = opts.Tracer
+	}
+	if opts.StrictValidation {
+		c.strictValidation = true
 	}
 	if opts.dialer != nil {
 		c.dialer = opts.dialer
@@ -319,6 +890,24 @@ func newConn(netConn net.Conn, opts *ConnOptions) (*Conn, error) {
 	return c, nil
 }
 
+// validateALPN verifies that ALPN negotiation selected one of the protocols
+// configured via ConnOptions.ALPNProtocols.  Called after the TLS handshake.
+func (c *Conn) validateALPN() error {
+	if !c.requireALPN {
+		return nil
+	}
+
+	tlsConn, ok := c.net.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+
+	if proto := tlsConn.ConnectionState().NegotiatedProtocol; proto == "" {
+		return fmt.Errorf("peer didn't negotiate one of the required ALPN protocols %v", c.tlsConfig.NextProtos)
+	}
+	return nil
+}
+
 func (c *Conn) initTLSConfig() {
 	// create a new config if not already set
 	if c.tlsConfig == nil {
@@ -327,7 +916,11 @@ func (c *Conn) initTLSConfig() {
 
 	// TLS config must have ServerName or InsecureSkipVerify set
 	if c.tlsConfig.ServerName == "" && !c.tlsConfig.InsecureSkipVerify {
-		c.tlsConfig.ServerName = c.hostname
+		if c.tlsServerName != "" {
+			c.tlsConfig.ServerName = c.tlsServerName
+		} else {
+			c.tlsConfig.ServerName = c.hostname
+		}
 	}
 }
 
@@ -402,6 +995,11 @@ func (c *Conn) startImpl(ctx context.Context) error {
 		}
 	}
 
+	if c.metrics != nil {
+		c.metrics.ConnectionOpened()
+	}
+	c.logEvent("connection opened", slog.String("hostname", c.hostname), slog.String("container", c.containerID))
+	expvarAdd(expvarActiveConns, 1)
 	return nil
 }
 
@@ -476,7 +1074,13 @@ func (c *Conn) close() {
 			c.doneErr = &ConnError{}
 		} else if amqpErr, ok := c.rxErr.(*Error); ok {
 			// we experienced a peer-initiated close that contained an Error.  return it
-			c.doneErr = &ConnError{RemoteErr: amqpErr}
+			if amqpErr.Condition == ErrCondConnectionForced {
+				// broker-initiated close (e.g. maintenance); surface a distinct,
+				// retryable error type
+				c.doneErr = &ConnError{RemoteErr: amqpErr, inner: &ConnectionForcedError{RemoteErr: amqpErr}}
+			} else {
+				c.doneErr = &ConnError{RemoteErr: amqpErr}
+			}
 		} else if c.txErr != nil {
 			// c.txErr is already wrapped in a ConnError
 			c.doneErr = c.txErr
@@ -485,6 +1089,13 @@ func (c *Conn) close() {
 		} else {
 			c.doneErr = &ConnError{inner: closeErr}
 		}
+
+		if c.metrics != nil {
+			c.metrics.ConnectionClosed()
+		}
+		c.logEvent("connection closed", slog.Any("error", c.doneErr))
+		expvarAdd(expvarActiveConns, -1)
+		debug.ClearLevel(c.correlationID)
 	})
 }
 
@@ -541,11 +1152,131 @@ func (c *Conn) Properties() map[string]any {
 	return c.peerProperties
 }
 
+// CorrelationID returns the short, randomly generated ID assigned to
+// this connection.  It's included in all of the connection's debug log
+// lines, allowing them to be correlated with application events.
+func (c *Conn) CorrelationID() string {
+	return c.correlationID
+}
+
+// SetLogLevel sets the debug log verbosity for this connection,
+// overriding the process-wide level set via the DEBUG_LEVEL environment
+// variable.  The greater the value, the more verbose messages will be
+// logged.  It has no effect unless the debug build tag is set.
+func (c *Conn) SetLogLevel(level int) {
+	debug.SetLevel(c.correlationID, level)
+}
+
+// TLSConnectionState returns the TLS connection state (negotiated
+// version, cipher suite, peer certificates, ALPN protocol) of the
+// underlying connection.  Returns nil if the connection doesn't use TLS.
+func (c *Conn) TLSConnectionState() *tls.ConnectionState {
+	tlsConn, ok := c.net.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tlsConn.ConnectionState()
+	return &state
+}
+
+// SessionCount returns the number of sessions currently open on the
+// connection.  Use with [ConnOptions.MaxSessions] to detect session
+// leaks before the connection's channel-max is exhausted.
+func (c *Conn) SessionCount() int {
+	c.sessionsByChannelMu.RLock()
+	defer c.sessionsByChannelMu.RUnlock()
+	return len(c.sessionsByChannel)
+}
+
+// PauseReads stops the connection from reading incoming frames, taking
+// effect before the next frame is read.  Bytes from the peer accumulate
+// in the kernel's receive buffer; once it fills, TCP flow control pushes
+// back on the peer.  This provides connection-wide backpressure during a
+// downstream outage without detaching links or ending sessions.
+//
+// While reads are paused no incoming frames are processed, including
+// keepalives, and the local idle timeout is not enforced.  The peer's
+// idle timeout is unaffected as outgoing keepalives are still sent.
+//
+// Calling PauseReads when reads are already paused is a no-op.
+func (c *Conn) PauseReads() {
+	c.rxPauseMu.Lock()
+	defer c.rxPauseMu.Unlock()
+	if c.rxResume == nil {
+		c.rxResume = make(chan struct{})
+	}
+}
+
+// ResumeReads resumes reading incoming frames after a call to [Conn.PauseReads].
+// Calling ResumeReads when reads are not paused is a no-op.
+func (c *Conn) ResumeReads() {
+	c.rxPauseMu.Lock()
+	defer c.rxPauseMu.Unlock()
+	if c.rxResume != nil {
+		close(c.rxResume)
+		c.rxResume = nil
+	}
+}
+
+// PeerOfferedCapabilities returns the extension capabilities the peer
+// offered when the connection was opened.
+// Returns nil if the peer didn't offer any capabilities.
+func (c *Conn) PeerOfferedCapabilities() []string {
+	return capabilitiesToStrings(c.peerOfferedCapabilities)
+}
+
+// PeerDesiredCapabilities returns the extension capabilities the peer
+// requested when the connection was opened.
+// Returns nil if the peer didn't request any capabilities.
+func (c *Conn) PeerDesiredCapabilities() []string {
+	return capabilitiesToStrings(c.peerDesiredCapabilities)
+}
+
+// PeerOfferedCapability reports whether the peer offered the named extension
+// capability when the connection was opened.  A capability requested via
+// [ConnOptions.DesiredCapabilities] is in effect only when this returns true
+// for it (e.g. "ANONYMOUS-RELAY").
+func (c *Conn) PeerOfferedCapability(capability string) bool {
+	for _, cap := range c.peerOfferedCapabilities {
+		if cap == encoding.Symbol(capability) {
+			return true
+		}
+	}
+	return false
+}
+
+func capabilitiesToStrings(caps encoding.MultiSymbol) []string {
+	if len(caps) == 0 {
+		return nil
+	}
+	strs := make([]string, len(caps))
+	for i, cap := range caps {
+		strs[i] = string(cap)
+	}
+	return strs
+}
+
+// capability name as defined in the AMQP SoleConn extension spec.
+// http://docs.oasis-open.org/amqp/soleconn/v1.0/cs01/soleconn-v1.0-cs01.html
+const capabilitySoleConnectionForContainer encoding.Symbol = "sole-connection-for-container"
+
+// SoleConnectionEnforced returns true if the peer offered the
+// sole-connection-for-container capability when the connection was opened,
+// indicating that it will permit at most one connection per container-id.
+func (c *Conn) SoleConnectionEnforced() bool {
+	for _, cap := range c.peerOfferedCapabilities {
+		if cap == capabilitySoleConnectionForContainer {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Conn) freeAbandonedSessions(ctx context.Context) error {
 	c.abandonedSessionsMu.Lock()
 	defer c.abandonedSessionsMu.Unlock()
 
-	debug.Log(3, "TX (Conn %p): cleaning up %d abandoned sessions", c, len(c.abandonedSessions))
+	debug.LogC(c.correlationID, 3, "TX (Conn %p): cleaning up %d abandoned sessions", c, len(c.abandonedSessions))
 
 	for _, s := range c.abandonedSessions {
 		fr := frames.PerformEnd{}
@@ -562,6 +1293,11 @@ func (c *Conn) newSession(opts *SessionOptions) (*Session, error) {
 	c.sessionsByChannelMu.Lock()
 	defer c.sessionsByChannelMu.Unlock()
 
+	// return channels whose reuse grace period has elapsed
+	for _, ch := range c.channelQuarantine.release() {
+		c.channels.Remove(ch)
+	}
+
 	// create the next session to allocate
 	// note that channel always start at 0
 	channel, ok := c.channels.Next()
@@ -582,7 +1318,9 @@ func (c *Conn) deleteSession(s *Session) {
 	defer c.sessionsByChannelMu.Unlock()
 
 	delete(c.sessionsByChannel, s.channel)
-	c.channels.Remove(uint32(s.channel))
+	if !c.channelQuarantine.hold(uint32(s.channel)) {
+		c.channels.Remove(uint32(s.channel))
+	}
 }
 
 func (c *Conn) abandonSession(s *Session) {
@@ -595,26 +1333,74 @@ func (c *Conn) abandonSession(s *Session) {
 // them here as appropriate or sends them to the session.rx channel.
 func (c *Conn) connReader() {
 	defer func() {
+		// recover from a panic so a library goroutine doesn't crash the process.
+		// the error becomes the connection's terminal error state.
+		if v := recover(); v != nil {
+			c.rxErr = &ConnError{inner: muxPanicError(v)}
+		}
 		close(c.rxDone)
 		c.close()
 	}()
 
 	var sessionsByRemoteChannel = make(map[uint16]*Session)
 	var err error
+
+	// the connection is established; elapsed idle intervals are now subject
+	// to the configured tolerance rather than failing outright
+	c.idleToleranceActive = true
+
+	// incoming frame rate limiting; disabled when maxIncomingFrameRate is zero
+	var (
+		rateWindowStart time.Time
+		rateWindowCount uint32
+	)
+
 	for {
 		if err != nil {
-			debug.Log(0, "RX (connReader %p): terminal error: %v", c, err)
+			debug.LogC(c.correlationID, 0, "RX (connReader %p): terminal error: %v", c, err)
 			c.rxErr = err
 			return
 		}
 
+		c.rxPauseMu.Lock()
+		resume := c.rxResume
+		c.rxPauseMu.Unlock()
+		if resume != nil {
+			debug.LogC(c.correlationID, 1, "RX (connReader %p): reads paused", c)
+			select {
+			case <-resume:
+				debug.LogC(c.correlationID, 1, "RX (connReader %p): reads resumed", c)
+			case <-c.rxtxExit:
+				return
+			}
+		}
+
 		var fr frames.Frame
 		fr, err = c.readFrame()
 		if err != nil {
 			continue
 		}
 
-		debug.Log(0, "RX (connReader %p): %s", c, fr)
+		if c.maxIncomingFrameRate > 0 {
+			now := time.Now()
+			if now.Sub(rateWindowStart) >= time.Second {
+				rateWindowStart, rateWindowCount = now, 0
+			}
+			rateWindowCount++
+			if rateWindowCount > c.maxIncomingFrameRate {
+				// stop reading until the window elapses; the kernel's receive
+				// buffer will fill, applying TCP backpressure to the peer
+				debug.LogC(c.correlationID, 1, "RX (connReader %p): frame rate cap %d exceeded, pausing reads", c, c.maxIncomingFrameRate)
+				select {
+				case <-time.After(time.Second - now.Sub(rateWindowStart)):
+					rateWindowStart, rateWindowCount = time.Now(), 0
+				case <-c.rxtxExit:
+					return
+				}
+			}
+		}
+
+		debug.LogC(c.correlationID, 0, "RX (connReader %p): %s", c, fr)
 
 		var (
 			session *Session
@@ -677,7 +1463,7 @@ func (c *Conn) connReader() {
 		q := session.rxQ.Acquire()
 		q.Enqueue(fr.Body)
 		session.rxQ.Release(q)
-		debug.Log(2, "RX (connReader %p): mux frame to Session (%p): %s", c, session, fr)
+		debug.LogC(c.correlationID, 2, "RX (connReader %p): mux frame to Session (%p): %s", c, session, fr)
 	}
 }
 
@@ -698,20 +1484,66 @@ func (c *Conn) readFrame() (frames.Frame, error) {
 	var (
 		currentHeader   frames.Header // keep track of the current header, for frames split across multiple TCP packets
 		frameInProgress bool          // true if in the middle of receiving data for currentHeader
+		missedIntervals uint32        // consecutive idle intervals with no incoming traffic
 	)
 
 	for {
 		// need to read more if buf doesn't contain the complete frame
 		// or there's not enough in buf to parse the header
 		if frameInProgress || c.rxBuf.Len() < frames.HeaderSize {
-			// we MUST reset the idle timeout before each read from net.Conn
+			// we MUST reset the idle timeout before each read from net.Conn,
+			// capped at the SASL handshake deadline while one is in force
+			var idleDeadline time.Time
 			if c.idleTimeout > 0 {
-				_ = c.net.SetReadDeadline(time.Now().Add(c.idleTimeout))
+				deadline := time.Now().Add(c.idleTimeout)
+				if !c.saslDeadline.IsZero() && c.saslDeadline.Before(deadline) {
+					deadline = c.saslDeadline
+				} else {
+					idleDeadline = deadline
+				}
+				_ = c.net.SetReadDeadline(deadline)
+			} else if !c.saslDeadline.IsZero() {
+				_ = c.net.SetReadDeadline(c.saslDeadline)
 			}
 			err := c.rxBuf.ReadFromOnce(c.net)
 			if err != nil {
+				// an error at or past the idle deadline means the interval
+				// elapsed with no traffic; apply the configured tolerance.
+				// a genuine error surfaces on the next read, which fails well
+				// before its own deadline.
+				if c.idleToleranceActive && !idleDeadline.IsZero() && !time.Now().Before(idleDeadline) {
+					missedIntervals++
+					debug.LogC(c.correlationID, 1, "RX (connReader %p): idle interval %d elapsed with no incoming traffic", c, missedIntervals)
+					if onWarning := c.idleTolerance.OnWarning; onWarning != nil {
+						onWarning(IdleWarningEvent{
+							MissedIntervals: missedIntervals,
+							Idle:            time.Duration(missedIntervals) * c.idleTimeout,
+						})
+					}
+					if c.idleTolerance.Probe {
+						select {
+						case c.txProbe <- struct{}{}:
+						default:
+							// a probe is already pending
+						}
+					}
+					if c.idleTolerance.WarnOnly || missedIntervals < c.idleTolerance.MissedIntervals {
+						continue
+					}
+					// the peer violated its obligation to send traffic within
+					// our advertised idle timeout; surface a typed error so
+					// recovery layers can distinguish silent network death
+					// from broker-initiated closes.
+					return frames.Frame{}, &IdleTimeoutError{
+						LastFrameReceived: time.Unix(0, c.lastFrameReceived.Load()),
+						LastFrameSent:     time.Unix(0, c.lastFrameSent.Load()),
+						Timeout:           c.idleTimeout,
+					}
+				}
 				return frames.Frame{}, err
 			}
+			missedIntervals = 0
+			c.lastFrameReceived.Store(time.Now().UnixNano())
 		}
 
 		// parse the header if a frame isn't in progress
@@ -746,7 +1578,7 @@ func (c *Conn) readFrame() (frames.Frame, error) {
 
 		// check if body is empty (keepalive)
 		if bodySize == 0 {
-			debug.Log(3, "RX (connReader %p): received keep-alive frame", c)
+			debug.LogC(c.correlationID, 3, "RX (connReader %p): received keep-alive frame", c)
 			continue
 		}
 
@@ -761,6 +1593,28 @@ func (c *Conn) readFrame() (frames.Frame, error) {
 			return frames.Frame{}, err
 		}
 
+		// a parsed transfer payload aliases rxBuf, whose storage is
+		// recycled across frames; copy it out, recycling buffers
+		// through the configured pool when there is one
+		if t, ok := parsedBody.(*frames.PerformTransfer); ok && len(t.Payload) > 0 {
+			var payload []byte
+			if c.bufferPool != nil {
+				payload = c.bufferPool.Get(len(t.Payload))[:len(t.Payload)]
+			} else {
+				payload = make([]byte, len(t.Payload))
+			}
+			copy(payload, t.Payload)
+			t.Payload = payload
+		}
+
+		if m := c.extMetrics; m != nil {
+			m.FrameReceived(int(currentHeader.Size))
+			if _, ok := parsedBody.(*frames.PerformDisposition); ok {
+				m.DispositionReceived()
+			}
+		}
+		c.observeFrame(DirectionReceive, currentHeader.Channel, parsedBody)
+		c.logFrame(DirectionReceive, currentHeader.Channel, parsedBody)
 		return frames.Frame{Channel: currentHeader.Channel, Body: parsedBody}, nil
 	}
 }
@@ -789,13 +1643,17 @@ type frameEnvelope struct {
 
 func (c *Conn) connWriter() {
 	defer func() {
+		// recover from a panic so a library goroutine doesn't crash the process.
+		// the error becomes the connection's terminal error state.
+		if v := recover(); v != nil {
+			c.txErr = &ConnError{inner: muxPanicError(v)}
+		}
 		close(c.txDone)
 		c.close()
 	}()
 
 	var (
-		// keepalives are sent at a rate of 1/2 idle timeout
-		keepaliveInterval = c.peerIdleTimeout / 2
+		keepaliveInterval = c.keepaliveInterval()
 		// 0 disables keepalives
 		keepalivesEnabled = keepaliveInterval > 0
 		// set if enable, nil if not; nil channels block forever
@@ -808,10 +1666,15 @@ func (c *Conn) connWriter() {
 		keepalive = ticker.C
 	}
 
+	if c.writeBatchDelay > 0 {
+		c.connWriterBatched(keepalive)
+		return
+	}
+
 	var err error
 	for {
 		if err != nil {
-			debug.Log(0, "TX (connWriter %p): terminal error: %v", c, err)
+			debug.LogC(c.correlationID, 0, "TX (connWriter %p): terminal error: %v", c, err)
 			c.txErr = err
 			return
 		}
@@ -821,7 +1684,7 @@ func (c *Conn) connWriter() {
 		case env := <-c.txFrame:
 			timeout, ctxErr := c.getWriteTimeout(env.FrameCtx.Ctx)
 			if ctxErr != nil {
-				debug.Log(1, "TX (connWriter %p) getWriteTimeout: %s: %s", c, ctxErr.Error(), env.Frame)
+				debug.LogC(c.correlationID, 1, "TX (connWriter %p) getWriteTimeout: %s: %s", c, ctxErr.Error(), env.Frame)
 				if env.FrameCtx.Done != nil {
 					// the error MUST be set before closing the channel
 					env.FrameCtx.Err = ctxErr
@@ -830,7 +1693,7 @@ func (c *Conn) connWriter() {
 				continue
 			}
 
-			debug.Log(0, "TX (connWriter %p) timeout %s: %s", c, timeout, env.Frame)
+			debug.LogC(c.correlationID, 0, "TX (connWriter %p) timeout %s: %s", c, timeout, env.Frame)
 			err = c.writeFrame(timeout, env.Frame)
 			if err == nil && env.FrameCtx.Done != nil {
 				close(env.FrameCtx.Done)
@@ -838,12 +1701,24 @@ func (c *Conn) connWriter() {
 			// in the event of write failure, Conn will close and a
 			// *ConnError will be propagated to all of the sessions/link.
 
+		// probe request from the connReader (see IdleToleranceOptions.Probe)
+		case <-c.txProbe:
+			debug.LogC(c.correlationID, 3, "TX (connWriter %p): sending probe keep-alive frame", c)
+			_ = c.net.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+			if _, err = c.net.Write(keepaliveFrame); err != nil {
+				err = &ConnError{inner: err}
+			} else {
+				c.lastFrameSent.Store(time.Now().UnixNano())
+			}
+
 		// keepalive timer
 		case <-keepalive:
-			debug.Log(3, "TX (connWriter %p): sending keep-alive frame", c)
+			debug.LogC(c.correlationID, 3, "TX (connWriter %p): sending keep-alive frame", c)
 			_ = c.net.SetWriteDeadline(time.Now().Add(c.writeTimeout))
 			if _, err = c.net.Write(keepaliveFrame); err != nil {
 				err = &ConnError{inner: err}
+			} else {
+				c.lastFrameSent.Store(time.Now().UnixNano())
 			}
 			// It would be slightly more efficient in terms of network
 			// resources to reset the timer each time a frame is sent.
@@ -863,7 +1738,161 @@ func (c *Conn) connWriter() {
 				Type: frames.TypeAMQP,
 				Body: &frames.PerformClose{},
 			}
-			debug.Log(1, "TX (connWriter %p): %s", c, fr)
+			debug.LogC(c.correlationID, 1, "TX (connWriter %p): %s", c, fr)
+			c.txErr = c.writeFrame(c.writeTimeout, fr)
+			return
+		}
+	}
+}
+
+// connWriterBatched is the connWriter loop used when frame coalescing is
+// enabled (ConnOptions.WriteBatchDelay).  Frames are encoded as they
+// arrive and flushed to the network in a single write once the batch
+// delay elapses, the buffer reaches ConnOptions.WriteBufferSize, or a
+// keepalive or shutdown forces the batch out.  Terminal errors are
+// recorded in c.txErr; the caller's deferred cleanup closes the conn.
+func (c *Conn) connWriterBatched(keepalive <-chan time.Time) {
+	type batchedFrame struct {
+		channel uint16
+		body    frames.FrameBody
+		size    int
+	}
+
+	var (
+		batch   buffer.Buffer
+		batched []batchedFrame // frames in the buffer; for per-frame callbacks on flush
+		scratch buffer.Buffer  // frames.Write sizes a frame relative to the start of its buffer, so frames are encoded here then appended
+		flushC  <-chan time.Time
+	)
+	flushTimer := time.NewTimer(c.writeBatchDelay)
+	if !flushTimer.Stop() {
+		<-flushTimer.C
+	}
+	defer flushTimer.Stop()
+
+	flush := func() error {
+		flushC = nil
+		if batch.Len() == 0 {
+			return nil
+		}
+		debug.LogC(c.correlationID, 0, "TX (connWriterBatched %p): flushing %d frames, %d bytes", c, len(batched), batch.Len())
+		_ = c.net.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+		if _, err := c.net.Write(batch.Bytes()); err != nil {
+			// in the event of write failure, Conn will close and a
+			// *ConnError will be propagated to all of the sessions/link.
+			return &ConnError{inner: err}
+		}
+		c.lastFrameSent.Store(time.Now().UnixNano())
+		for _, fr := range batched {
+			if m := c.extMetrics; m != nil {
+				m.FrameSent(fr.size)
+				if _, ok := fr.body.(*frames.PerformDisposition); ok {
+					m.DispositionSent()
+				}
+			}
+			c.observeFrame(DirectionSend, fr.channel, fr.body)
+			c.logFrame(DirectionSend, fr.channel, fr.body)
+		}
+		batch.Reset()
+		batched = batched[:0]
+		return nil
+	}
+
+	var err error
+	for {
+		if err != nil {
+			debug.LogC(c.correlationID, 0, "TX (connWriterBatched %p): terminal error: %v", c, err)
+			c.txErr = err
+			return
+		}
+
+		select {
+		// frame write request
+		case env := <-c.txFrame:
+			if ctxErr := env.FrameCtx.Ctx.Err(); ctxErr != nil {
+				debug.LogC(c.correlationID, 1, "TX (connWriterBatched %p) expired frame context: %s: %s", c, ctxErr.Error(), env.Frame)
+				if env.FrameCtx.Done != nil {
+					// the error MUST be set before closing the channel
+					env.FrameCtx.Err = ctxErr
+					close(env.FrameCtx.Done)
+				}
+				continue
+			}
+
+			scratch.Reset()
+			if werr := frames.Write(&scratch, env.Frame); werr != nil {
+				err = &ConnError{inner: werr}
+				continue
+			}
+
+			// validate the frame isn't exceeding peer's max frame size
+			size := scratch.Len()
+			if uint64(size) > uint64(c.peerMaxFrameSize) {
+				err = &ConnError{inner: fmt.Errorf("%T frame size %d larger than peer's max frame size %d", env.Frame, size, c.peerMaxFrameSize)}
+				continue
+			}
+
+			batch.Append(scratch.Bytes())
+			batched = append(batched, batchedFrame{channel: env.Frame.Channel, body: env.Frame.Body, size: size})
+
+			// confirm the write now that the frame is committed to the
+			// batch: it will either be flushed or the connection will
+			// terminate, failing all in-flight work anyway.  waiting
+			// until the flush would serialize callers (e.g. the session
+			// mux) that send their next frame only after confirmation,
+			// defeating the coalescing.
+			if env.FrameCtx.Done != nil {
+				close(env.FrameCtx.Done)
+			}
+
+			if batch.Len() >= c.writeBufferSize {
+				err = flush()
+			} else if flushC == nil {
+				flushTimer.Reset(c.writeBatchDelay)
+				flushC = flushTimer.C
+			}
+
+		// batch delay elapsed
+		case <-flushC:
+			err = flush()
+
+		// probe request from the connReader (see IdleToleranceOptions.Probe)
+		case <-c.txProbe:
+			debug.LogC(c.correlationID, 3, "TX (connWriterBatched %p): sending probe keep-alive frame", c)
+			if err = flush(); err != nil {
+				continue
+			}
+			_ = c.net.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+			if _, werr := c.net.Write(keepaliveFrame); werr != nil {
+				err = &ConnError{inner: werr}
+			} else {
+				c.lastFrameSent.Store(time.Now().UnixNano())
+			}
+
+		// keepalive timer
+		case <-keepalive:
+			debug.LogC(c.correlationID, 3, "TX (connWriterBatched %p): sending keep-alive frame", c)
+			if err = flush(); err != nil {
+				continue
+			}
+			_ = c.net.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+			if _, werr := c.net.Write(keepaliveFrame); werr != nil {
+				err = &ConnError{inner: werr}
+			} else {
+				c.lastFrameSent.Store(time.Now().UnixNano())
+			}
+
+		// connection complete
+		case <-c.rxtxExit:
+			if err = flush(); err != nil {
+				c.txErr = err
+				return
+			}
+			fr := frames.Frame{
+				Type: frames.TypeAMQP,
+				Body: &frames.PerformClose{},
+			}
+			debug.LogC(c.correlationID, 1, "TX (connWriterBatched %p): %s", c, fr)
 			c.txErr = c.writeFrame(c.writeTimeout, fr)
 			return
 		}
@@ -898,14 +1927,42 @@ func (c *Conn) writeFrame(timeout time.Duration, fr frames.Frame) error {
 	// write to network
 	n, err := c.net.Write(c.txBuf.Bytes())
 	if l := c.txBuf.Len(); n > 0 && n < l && err != nil {
-		debug.Log(1, "TX (writeFrame %p): wrote %d bytes less than len %d: %v", c, n, l, err)
+		debug.LogC(c.correlationID, 1, "TX (writeFrame %p): wrote %d bytes less than len %d: %v", c, n, l, err)
 	}
 	if err != nil {
 		err = &ConnError{inner: err}
+	} else {
+		c.lastFrameSent.Store(time.Now().UnixNano())
+		if m := c.extMetrics; m != nil {
+			m.FrameSent(requiredFrameSize)
+			if _, ok := fr.Body.(*frames.PerformDisposition); ok {
+				m.DispositionSent()
+			}
+		}
+		c.observeFrame(DirectionSend, fr.Channel, fr.Body)
+		c.logFrame(DirectionSend, fr.Channel, fr.Body)
 	}
 	return err
 }
 
+// observeFrame invokes the configured FrameObserver, redacting the
+// credentials carried in SASL frames.
+func (c *Conn) observeFrame(direction Direction, channel uint16, body frames.FrameBody) {
+	if c.frameObserver == nil {
+		return
+	}
+	switch tt := body.(type) {
+	case *frames.SASLInit:
+		redacted := *tt
+		redacted.InitialResponse = nil
+		c.frameObserver(direction, channel, &redacted)
+	case *frames.SASLResponse:
+		c.frameObserver(direction, channel, &frames.SASLResponse{})
+	default:
+		c.frameObserver(direction, channel, body)
+	}
+}
+
 // writeProtoHeader writes an AMQP protocol header to the
 // network
 func (c *Conn) writeProtoHeader(pID protoID) error {
@@ -913,6 +1970,33 @@ func (c *Conn) writeProtoHeader(pID protoID) error {
 	return err
 }
 
+// keepaliveInterval derives the keepalive period from the peer's idle-timeout,
+// applying the configured divisor and clamps.  by default keepalives are sent
+// at a rate of 1/2 idle timeout.  zero disables keepalives.
+func (c *Conn) keepaliveInterval() time.Duration {
+	if c.keepAlive.Interval > 0 {
+		// fixed cadence; doesn't depend on the peer requesting an idle-timeout
+		return c.keepAlive.Interval
+	}
+	if c.peerIdleTimeout == 0 {
+		return 0
+	}
+
+	divisor := time.Duration(c.keepAlive.Divisor)
+	if divisor == 0 {
+		divisor = 2
+	}
+
+	interval := c.peerIdleTimeout / divisor
+	if c.keepAlive.MinInterval > 0 && interval < c.keepAlive.MinInterval {
+		interval = c.keepAlive.MinInterval
+	}
+	if c.keepAlive.MaxInterval > 0 && interval > c.keepAlive.MaxInterval {
+		interval = c.keepAlive.MaxInterval
+	}
+	return interval
+}
+
 // keepaliveFrame is an AMQP frame with no body, used for keepalives
 var keepaliveFrame = []byte{0x00, 0x00, 0x00, 0x08, 0x02, 0x00, 0x00, 0x00}
 
@@ -920,7 +2004,7 @@ var keepaliveFrame = []byte{0x00, 0x00, 0x00, 0x08, 0x02, 0x00, 0x00, 0x00}
 func (c *Conn) sendFrame(frameEnv frameEnvelope) {
 	select {
 	case c.txFrame <- frameEnv:
-		debug.Log(2, "TX (Conn %p): mux frame to connWriter: %s", c, frameEnv.Frame)
+		debug.LogC(c.correlationID, 2, "TX (Conn %p): mux frame to connWriter: %s", c, frameEnv.Frame)
 	case <-c.done:
 		// Conn has closed
 	}
@@ -935,6 +2019,14 @@ type stateFunc func(context.Context) (stateFunc, error)
 // negotiateProto determines which proto to negotiate next.
 // used externally by SASL only.
 func (c *Conn) negotiateProto(ctx context.Context) (stateFunc, error) {
+	// SASL concluded within its deadline; restore the connection
+	// establishment deadline derived from the context
+	if c.saslComplete && !c.saslDeadline.IsZero() {
+		c.saslDeadline = time.Time{}
+		deadline, _ := ctx.Deadline()
+		_ = c.net.SetDeadline(deadline)
+	}
+
 	// in the order each must be negotiated
 	switch {
 	case c.tlsNegotiation && !c.tlsComplete:
@@ -1050,6 +2142,10 @@ func (c *Conn) startTLS(ctx context.Context) (stateFunc, error) {
 	c.net = tlsConn
 	c.tlsComplete = true
 
+	if err := c.validateALPN(); err != nil {
+		return nil, err
+	}
+
 	// go to next protocol
 	return c.negotiateProto, nil
 }
@@ -1058,19 +2154,21 @@ func (c *Conn) startTLS(ctx context.Context) (stateFunc, error) {
 func (c *Conn) openAMQP(ctx context.Context) (stateFunc, error) {
 	// send open frame
 	open := &frames.PerformOpen{
-		ContainerID:  c.containerID,
-		Hostname:     c.hostname,
-		MaxFrameSize: c.maxFrameSize,
-		ChannelMax:   c.channelMax,
-		IdleTimeout:  c.idleTimeout / 2, // per spec, advertise half our idle timeout
-		Properties:   c.properties,
+		ContainerID:         c.containerID,
+		Hostname:            c.hostname,
+		MaxFrameSize:        c.maxFrameSize,
+		ChannelMax:          c.channelMax,
+		IdleTimeout:         c.idleTimeout / 2, // per spec, advertise half our idle timeout
+		Properties:          c.properties,
+		OfferedCapabilities: c.offeredCapabilities,
+		DesiredCapabilities: c.desiredCapabilities,
 	}
 	fr := frames.Frame{
 		Type:    frames.TypeAMQP,
 		Body:    open,
 		Channel: 0,
 	}
-	debug.Log(1, "TX (openAMQP %p): %s", c, fr)
+	debug.LogC(c.correlationID, 1, "TX (openAMQP %p): %s", c, fr)
 	timeout, err := c.getWriteTimeout(ctx)
 	if err != nil {
 		return nil, err
@@ -1084,7 +2182,7 @@ func (c *Conn) openAMQP(ctx context.Context) (stateFunc, error) {
 	if err != nil {
 		return nil, err
 	}
-	debug.Log(1, "RX (openAMQP %p): %s", c, fr)
+	debug.LogC(c.correlationID, 1, "RX (openAMQP %p): %s", c, fr)
 	o, ok := fr.Body.(*frames.PerformOpen)
 	if !ok {
 		return nil, fmt.Errorf("openAMQP: unexpected frame type %T", fr.Body)
@@ -1109,19 +2207,31 @@ func (c *Conn) openAMQP(ctx context.Context) (stateFunc, error) {
 		}
 	}
 
+	c.peerOfferedCapabilities = o.OfferedCapabilities
+	c.peerDesiredCapabilities = o.DesiredCapabilities
+
 	// connection established, exit state machine
 	return nil, nil
 }
 
 // negotiateSASL returns the SASL handler for the first matched
 // mechanism specified by the server
-func (c *Conn) negotiateSASL(context.Context) (stateFunc, error) {
+func (c *Conn) negotiateSASL(ctx context.Context) (stateFunc, error) {
+	// bound the entire negotiation when a handshake timeout was configured,
+	// tightening the connection establishment deadline as required
+	if t := c.saslLimits.HandshakeTimeout; t > 0 {
+		c.saslDeadline = time.Now().Add(t)
+		if deadline, ok := ctx.Deadline(); !ok || c.saslDeadline.Before(deadline) {
+			_ = c.net.SetDeadline(c.saslDeadline)
+		}
+	}
+
 	// read mechanisms frame
-	fr, err := c.readSingleFrame()
+	fr, err := c.readSASLFrame()
 	if err != nil {
 		return nil, err
 	}
-	debug.Log(1, "RX (negotiateSASL %p): %s", c, fr)
+	debug.LogC(c.correlationID, 1, "RX (negotiateSASL %p): %s", c, fr)
 	sm, ok := fr.Body.(*frames.SASLMechanisms)
 	if !ok {
 		return nil, fmt.Errorf("negotiateSASL: unexpected frame type %T", fr.Body)
@@ -1146,11 +2256,11 @@ func (c *Conn) negotiateSASL(context.Context) (stateFunc, error) {
 // used externally by SASL only.
 func (c *Conn) saslOutcome(context.Context) (stateFunc, error) {
 	// read outcome frame
-	fr, err := c.readSingleFrame()
+	fr, err := c.readSASLFrame()
 	if err != nil {
 		return nil, err
 	}
-	debug.Log(1, "RX (saslOutcome %p): %s", c, fr)
+	debug.LogC(c.correlationID, 1, "RX (saslOutcome %p): %s", c, fr)
 	so, ok := fr.Body.(*frames.SASLOutcome)
 	if !ok {
 		return nil, fmt.Errorf("saslOutcome: unexpected frame type %T", fr.Body)
@@ -1178,6 +2288,41 @@ func (c *Conn) readSingleFrame() (frames.Frame, error) {
 	return fr, nil
 }
 
+// readSASLFrame reads a single frame during SASL negotiation, enforcing
+// any limits configured via ConnOptions.SASLLimits.
+func (c *Conn) readSASLFrame() (frames.Frame, error) {
+	fr, err := c.readSingleFrame()
+	if err != nil {
+		// a read error at or past the SASL deadline means the peer stalled
+		// negotiation beyond the configured handshake timeout
+		if !c.saslDeadline.IsZero() && !time.Now().Before(c.saslDeadline) {
+			return frames.Frame{}, &SASLLimitError{
+				Limit:   "HandshakeTimeout",
+				Message: fmt.Sprintf("sasl: negotiation didn't complete within %s", c.saslLimits.HandshakeTimeout),
+			}
+		}
+		return frames.Frame{}, err
+	}
+
+	if ch, ok := fr.Body.(*frames.SASLChallenge); ok {
+		if max := c.saslLimits.MaxChallengeSize; max > 0 && uint64(len(ch.Challenge)) > uint64(max) {
+			return frames.Frame{}, &SASLLimitError{
+				Limit:   "MaxChallengeSize",
+				Message: fmt.Sprintf("sasl: challenge of %d bytes exceeds the maximum size %d", len(ch.Challenge), max),
+			}
+		}
+		c.saslChallengeRounds++
+		if max := c.saslLimits.MaxChallengeRounds; max > 0 && c.saslChallengeRounds > max {
+			return frames.Frame{}, &SASLLimitError{
+				Limit:   "MaxChallengeRounds",
+				Message: fmt.Sprintf("sasl: peer sent more than %d challenges", max),
+			}
+		}
+	}
+
+	return fr, nil
+}
+
 // getWriteTimeout returns the timeout as calculated from the context's deadline
 // or the default write timeout if the context has no deadline.
 // if the context has timed out or was cancelled, an error is returned.
@@ -1,11 +1,18 @@
 package amqp
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
+	"net"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -67,6 +74,97 @@ func TestConnOptions(t *testing.T) {
 				}
 			},
 		},
+		{
+			label: "ConnKeyLogWriter",
+			opts: ConnOptions{
+				KeyLogWriter: &bytes.Buffer{},
+			},
+			verify: func(t *testing.T, c *Conn) {
+				if c.tlsConfig == nil || c.tlsConfig.KeyLogWriter == nil {
+					t.Error("expected KeyLogWriter plumbed into TLS config")
+				}
+			},
+		},
+		{
+			label: "ConnKeyLogWriterIgnoredWithTLSConfig",
+			opts: ConnOptions{
+				KeyLogWriter: &bytes.Buffer{},
+				TLSConfig:    &tls.Config{MinVersion: tls.VersionTLS13},
+			},
+			verify: func(t *testing.T, c *Conn) {
+				if c.tlsConfig.KeyLogWriter != nil {
+					t.Error("KeyLogWriter should be ignored when TLSConfig is set")
+				}
+			},
+		},
+		{
+			label: "ConnALPNProtocols",
+			opts: ConnOptions{
+				ALPNProtocols: []string{"amqp", "http/1.1"},
+			},
+			verify: func(t *testing.T, c *Conn) {
+				if c.tlsConfig == nil || !reflect.DeepEqual(c.tlsConfig.NextProtos, []string{"amqp", "http/1.1"}) {
+					t.Errorf("unexpected ALPN protocols %v", c.tlsConfig.NextProtos)
+				}
+				if !c.requireALPN {
+					t.Error("expected ALPN validation to be required")
+				}
+			},
+		},
+		{
+			label: "ConnALPNProtocolsIgnoredWithTLSConfig",
+			opts: ConnOptions{
+				ALPNProtocols: []string{"amqp"},
+				TLSConfig:     &tls.Config{MinVersion: tls.VersionTLS13},
+			},
+			verify: func(t *testing.T, c *Conn) {
+				if c.tlsConfig.NextProtos != nil {
+					t.Error("ALPNProtocols should be ignored when TLSConfig is set")
+				}
+				if c.requireALPN {
+					t.Error("ALPN validation shouldn't be required")
+				}
+			},
+		},
+		{
+			label: "ConnTLSServerName",
+			opts: ConnOptions{
+				HostName:      "vhost-1",
+				TLSServerName: "broker.example.com",
+			},
+			verify: func(t *testing.T, c *Conn) {
+				if c.hostname != "vhost-1" {
+					t.Errorf("unexpected hostname %s", c.hostname)
+				}
+				c.initTLSConfig()
+				if c.tlsConfig.ServerName != "broker.example.com" {
+					t.Errorf("unexpected TLS server name %s", c.tlsConfig.ServerName)
+				}
+			},
+		},
+		{
+			label: "ConnTLSServerNameDefaultsToHostName",
+			opts: ConnOptions{
+				HostName: "vhost-1",
+			},
+			verify: func(t *testing.T, c *Conn) {
+				c.initTLSConfig()
+				if c.tlsConfig.ServerName != "vhost-1" {
+					t.Errorf("unexpected TLS server name %s", c.tlsConfig.ServerName)
+				}
+			},
+		},
+		{
+			label: "ConnMaxIncomingFrameRate",
+			opts: ConnOptions{
+				MaxIncomingFrameRate: 100,
+			},
+			verify: func(t *testing.T, c *Conn) {
+				if c.maxIncomingFrameRate != 100 {
+					t.Errorf("unexpected incoming frame rate %d", c.maxIncomingFrameRate)
+				}
+			},
+		},
 		{
 			label: "ConnIdleTimeout_Valid",
 			opts: ConnOptions{
@@ -192,6 +290,29 @@ func TestDialConn(t *testing.T) {
 	if _, ok := c.saslHandlers[saslMechanismPLAIN]; !ok {
 		t.Fatal("missing SASL plain handler")
 	}
+	c, err = dialConn(context.Background(), "amqp://localhost/vhost-2", &ConnOptions{dialer: fakeDialer{}})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.Equal(t, "vhost-2", c.hostname)
+	c, err = dialConn(context.Background(), "amqp://localhost/vhost-2", &ConnOptions{dialer: fakeDialer{}, DisableURLVHost: true})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.Equal(t, "localhost", c.hostname)
+	c, err = dialConn(context.Background(), "amqp://localhost/vhost-2", &ConnOptions{dialer: fakeDialer{}, HostName: "explicit"})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.Equal(t, "explicit", c.hostname)
+	c, err = dialConn(context.Background(), "amqps://localhost/vhost-2", &ConnOptions{dialer: fakeDialer{}})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.Equal(t, "vhost-2", c.hostname)
+	// the certificate is verified against the dialed host, not the vhost
+	require.Equal(t, "localhost", c.tlsConfig.ServerName)
+	c, err = dialConn(context.Background(), "amqps://localhost/vhost-2", &ConnOptions{dialer: fakeDialer{}, TLSServerName: "broker.example.com"})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.Equal(t, "vhost-2", c.hostname)
+	require.Equal(t, "broker.example.com", c.tlsConfig.ServerName)
 	c, err = dialConn(context.Background(), "amqp://localhost", &ConnOptions{dialer: fakeDialer{fail: true}})
 	require.Error(t, err)
 	require.Nil(t, c)
@@ -430,6 +551,43 @@ func TestServerSideClose(t *testing.T) {
 	require.Equal(t, "*Error{Condition: Close, Description: mock server error, Info: map[]}", connErr.Error())
 }
 
+func TestServerSideCloseConnectionForced(t *testing.T) {
+	closeReceived := make(chan struct{})
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			close(closeReceived)
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	conn, err := newConn(netConn, nil)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, conn.start(ctx))
+	cancel()
+	fr, err := fake.PerformClose(&Error{Condition: ErrCondConnectionForced, Description: "server restarting"})
+	require.NoError(t, err)
+	netConn.SendFrame(fr)
+	<-closeReceived
+	err = conn.Close()
+	var connErr *ConnError
+	require.ErrorAs(t, err, &connErr)
+	var forcedErr *ConnectionForcedError
+	require.ErrorAs(t, err, &forcedErr)
+	require.Equal(t, ErrCondConnectionForced, forcedErr.RemoteErr.Condition)
+	require.Equal(t, "server restarting", forcedErr.RemoteErr.Description)
+	// the remote *Error remains discoverable through the chain
+	var amqpErr *Error
+	require.ErrorAs(t, err, &amqpErr)
+}
+
 func TestKeepAlives(t *testing.T) {
 	// closing conn can race with keep-alive ticks, so sometimes we get
 	// two in this test.  the test needs to receive at least one keep-alive,
@@ -471,6 +629,100 @@ func TestKeepAlives(t *testing.T) {
 	require.NoError(t, conn.Close())
 }
 
+func TestKeepAlivesClamped(t *testing.T) {
+	keepAlives := make(chan struct{}, 3)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			// an idle timeout this large means keepalives would normally be
+			// sent far too infrequently for this test to observe one
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{ContainerID: "container", IdleTimeout: time.Minute}))
+		case *fake.KeepAlive:
+			keepAlives <- struct{}{}
+			return fake.Response{}, nil
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{
+		KeepAlive: &KeepAliveOptions{
+			MaxInterval: 50 * time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, conn.start(ctx))
+	cancel()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	select {
+	case <-keepAlives:
+		// got keep-alive well within the peer's idle timeout
+	case <-ctx.Done():
+		t.Fatal("didn't receive any keepalive frames")
+	}
+	require.NoError(t, conn.Close())
+}
+
+func TestKeepAlivesInvalidOptions(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{KeepAlive: &KeepAliveOptions{Divisor: 1}})
+	require.Error(t, err)
+	require.Nil(t, conn)
+	conn, err = newConn(netConn, &ConnOptions{KeepAlive: &KeepAliveOptions{MinInterval: time.Minute, MaxInterval: time.Second}})
+	require.Error(t, err)
+	require.Nil(t, conn)
+	conn, err = newConn(netConn, &ConnOptions{KeepAlive: &KeepAliveOptions{Interval: -time.Second}})
+	require.Error(t, err)
+	require.Nil(t, conn)
+}
+
+func TestKeepAlivesFixedInterval(t *testing.T) {
+	keepAlives := make(chan struct{}, 3)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			// the peer requests no idle-timeout, so keepalives would
+			// normally be disabled entirely
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{ContainerID: "container"}))
+		case *fake.KeepAlive:
+			keepAlives <- struct{}{}
+			return fake.Response{}, nil
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{
+		KeepAlive: &KeepAliveOptions{
+			Interval: 50 * time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, conn.start(ctx))
+	cancel()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	select {
+	case <-keepAlives:
+		// got keep-alive despite the peer requesting no idle-timeout
+	case <-ctx.Done():
+		t.Fatal("didn't receive any keepalive frames")
+	}
+	require.NoError(t, conn.Close())
+}
 func TestKeepAlivesIdleTimeout(t *testing.T) {
 	start := make(chan struct{})
 	done := make(chan struct{})
@@ -520,6 +772,189 @@ func TestKeepAlivesIdleTimeout(t *testing.T) {
 	require.NoError(t, conn.Close())
 }
 
+func TestIdleToleranceMissedIntervals(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{ContainerID: "container"}))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+
+	warnings := make(chan IdleWarningEvent, 10)
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{
+		IdleTimeout: 50 * time.Millisecond,
+		IdleTolerance: &IdleToleranceOptions{
+			MissedIntervals: 3,
+			OnWarning: func(e IdleWarningEvent) {
+				warnings <- e
+			},
+		},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, conn.start(ctx))
+	cancel()
+
+	// the peer sends no traffic; the third elapsed interval kills the connection
+	select {
+	case <-conn.Done():
+		// connection was declared dead
+	case <-time.After(time.Second):
+		t.Fatal("connection didn't terminate")
+	}
+	require.Len(t, warnings, 3)
+	event := <-warnings
+	require.EqualValues(t, 1, event.MissedIntervals)
+	require.Equal(t, 50*time.Millisecond, event.Idle)
+}
+
+func TestIdleTimeoutError(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{ContainerID: "container"}))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{
+		IdleTimeout: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, conn.start(ctx))
+	cancel()
+	start := time.Now()
+
+	// the peer sends no traffic; the first elapsed interval kills the
+	// connection with the typed error
+	select {
+	case <-conn.Done():
+		// connection was declared dead
+	case <-time.After(time.Second):
+		t.Fatal("connection didn't terminate")
+	}
+
+	var connErr *ConnError
+	require.ErrorAs(t, conn.Err(), &connErr)
+	var idleErr *IdleTimeoutError
+	require.ErrorAs(t, conn.Err(), &idleErr)
+	require.Equal(t, 50*time.Millisecond, idleErr.Timeout)
+	require.False(t, idleErr.LastFrameReceived.IsZero())
+	require.False(t, idleErr.LastFrameReceived.After(start))
+	require.False(t, idleErr.LastFrameSent.IsZero())
+}
+
+func TestIdleToleranceWarnOnly(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{ContainerID: "container"}))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+
+	warnings := make(chan IdleWarningEvent, 10)
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{
+		IdleTimeout: 50 * time.Millisecond,
+		IdleTolerance: &IdleToleranceOptions{
+			OnWarning: func(e IdleWarningEvent) {
+				warnings <- e
+			},
+			WarnOnly: true,
+		},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, conn.start(ctx))
+	cancel()
+
+	// several intervals elapse yet the connection stays up
+	for i := 0; i < 3; i++ {
+		select {
+		case <-warnings:
+			// got warning
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive idle warning")
+		}
+	}
+	select {
+	case <-conn.Done():
+		t.Fatal("connection terminated in warn-only mode")
+	default:
+		// still connected
+	}
+	require.NoError(t, conn.Close())
+}
+
+func TestIdleToleranceProbe(t *testing.T) {
+	probes := make(chan struct{}, 10)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			// no idle timeout requested so regular keepalives stay disabled
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{ContainerID: "container"}))
+		case *fake.KeepAlive:
+			probes <- struct{}{}
+			return fake.Response{}, nil
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{
+		IdleTimeout: 50 * time.Millisecond,
+		IdleTolerance: &IdleToleranceOptions{
+			OnWarning: func(IdleWarningEvent) {},
+			Probe:     true,
+			WarnOnly:  true,
+		},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, conn.start(ctx))
+	cancel()
+
+	select {
+	case <-probes:
+		// got probe keep-alive
+	case <-time.After(time.Second):
+		t.Fatal("didn't receive any probe frames")
+	}
+	require.NoError(t, conn.Close())
+}
+
+func TestIdleToleranceInvalidOptions(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	conn, err := newConn(netConn, &ConnOptions{IdleTolerance: &IdleToleranceOptions{WarnOnly: true}})
+	require.Error(t, err)
+	require.Nil(t, conn)
+}
+
 func TestConnReaderError(t *testing.T) {
 	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
 	conn, err := newConn(netConn, nil)
@@ -640,55 +1075,250 @@ func TestClientDial(t *testing.T) {
 	require.Nil(t, client)
 }
 
-func TestClientClose(t *testing.T) {
+func TestClientClose(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := Dial(ctx, "amqp://localhost", &ConnOptions{dialer: mockDialer{resp: responder}})
+	cancel()
+	require.NoError(t, err)
+	require.NotNil(t, client)
+	require.NoError(t, client.Close())
+	require.NoError(t, client.Err())
+	require.NoError(t, client.Close())
+	require.NoError(t, client.Err())
+}
+
+func TestSessionOptions(t *testing.T) {
+	tests := []struct {
+		label  string
+		opt    SessionOptions
+		verify func(t *testing.T, s *Session)
+	}{
+		{
+			label: "SessionMaxLinks",
+			opt: SessionOptions{
+				MaxLinks: 4096,
+			},
+			verify: func(t *testing.T, s *Session) {
+				if s.handleMax != 4096-1 {
+					t.Errorf("unexpected max links %d", s.handleMax)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			session := newSession(nil, 0, &tt.opt)
+			tt.verify(t, session)
+		})
+	}
+}
+
+func TestConnCorrelationID(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	require.NotEmpty(t, client.CorrelationID())
+
+	netConn2 := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	client2, err := NewConn(ctx, netConn2, nil)
+	cancel()
+	require.NoError(t, err)
+	require.NotEqual(t, client.CorrelationID(), client2.CorrelationID())
+
+	// no-op unless the debug build tag is set
+	client.SetLogLevel(3)
+
+	require.NoError(t, client.Close())
+	require.NoError(t, client2.Close())
+}
+
+func TestConnFrameObserver(t *testing.T) {
+	type observed struct {
+		direction Direction
+		channel   uint16
+		frame     any
+	}
+	var (
+		mu   sync.Mutex
+		seen []observed
+	)
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{
+		FrameObserver: func(direction Direction, channel uint16, fr any) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen = append(seen, observed{direction: direction, channel: channel, frame: fr})
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	_ = session
+
+	require.NoError(t, client.Close())
+
+	find := func(direction Direction, frame any) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, o := range seen {
+			if o.direction == direction && reflect.TypeOf(o.frame) == reflect.TypeOf(frame) {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, find(DirectionSend, &frames.PerformOpen{}))
+	require.True(t, find(DirectionReceive, &frames.PerformOpen{}))
+	require.True(t, find(DirectionSend, &frames.PerformBegin{}))
+	require.True(t, find(DirectionReceive, &frames.PerformBegin{}))
+	// the peer's close ack isn't observed, as the reader can exit
+	// before it arrives
+	require.True(t, find(DirectionSend, &frames.PerformClose{}))
+}
+
+// lockedBuffer serializes writes so a slog handler can be driven from
+// the connection's goroutines while the test reads the output.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestConnLogger(t *testing.T) {
+	out := &lockedBuffer{}
+	logger := slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{Logger: logger})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+
+	output := out.String()
+	require.Contains(t, output, "connection="+client.CorrelationID())
+	require.Contains(t, output, `msg="connection opened"`)
+	require.Contains(t, output, `msg="session begun"`)
+	require.Contains(t, output, `msg="link attached"`)
+	require.Contains(t, output, "link="+snd.LinkName())
+	require.Contains(t, output, `msg="link detached"`)
+	require.Contains(t, output, `msg="connection closed"`)
+	// frame-level records carry the frame's formatted body
+	require.Contains(t, output, "msg=frame")
+	require.Contains(t, output, "direction=send")
+	require.Contains(t, output, "direction=receive")
+	require.Contains(t, output, "Begin")
+}
+
+func TestConnMaxIncomingFrameRateThrottles(t *testing.T) {
 	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
-		switch req.(type) {
-		case *fake.AMQPProto:
-			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
-		case *frames.PerformOpen:
-			return newResponse(fake.PerformOpen("container"))
-		case *frames.PerformClose:
-			return newResponse(fake.PerformClose(nil))
-		default:
-			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		if _, ok := req.(*frames.PerformDisposition); ok {
+			return fake.Response{}, nil
 		}
+		return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	client, err := Dial(ctx, "amqp://localhost", &ConnOptions{dialer: mockDialer{resp: responder}})
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{MaxIncomingFrameRate: 2})
 	cancel()
 	require.NoError(t, err)
-	require.NotNil(t, client)
-	require.NoError(t, client.Close())
-	require.NoError(t, client.Err())
-	require.NoError(t, client.Close())
-	require.NoError(t, client.Err())
-}
 
-func TestSessionOptions(t *testing.T) {
-	tests := []struct {
-		label  string
-		opt    SessionOptions
-		verify func(t *testing.T, s *Session)
-	}{
-		{
-			label: "SessionMaxLinks",
-			opt: SessionOptions{
-				MaxLinks: 4096,
-			},
-			verify: func(t *testing.T, s *Session) {
-				if s.handleMax != 4096-1 {
-					t.Errorf("unexpected max links %d", s.handleMax)
-				}
-			},
-		},
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	rcv, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	// begin and attach consumed the first window; these transfers span
+	// at least two more windows at 2 frames/sec
+	const numTransfers = 6
+	for i := uint32(0); i < numTransfers; i++ {
+		b, err := fake.PerformTransfer(0, 0, i+1, []byte("hello"))
+		require.NoError(t, err)
+		netConn.SendFrame(b)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.label, func(t *testing.T) {
-			session := newSession(nil, 0, &tt.opt)
-			tt.verify(t, session)
-		})
+	start := time.Now()
+	for i := 0; i < numTransfers; i++ {
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		msg, err := rcv.Receive(ctx, nil)
+		cancel()
+		require.NoError(t, err)
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		require.NoError(t, rcv.AcceptMessage(ctx, msg))
+		cancel()
 	}
+
+	// all frames can't arrive within a single one-second window
+	require.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+	require.NoError(t, client.Close())
+}
+
+func TestConnTLSConnectionState(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Nil(t, client.TLSConnectionState())
+	require.NoError(t, client.Close())
+
+	// a TLS-wrapped conn surfaces its connection state
+	netConn2 := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	conn, err := newConn(tls.Client(netConn2, &tls.Config{}), nil)
+	require.NoError(t, err)
+	state := conn.TLSConnectionState()
+	require.NotNil(t, state)
+	require.False(t, state.HandshakeComplete)
 }
 
 func TestClientNewSession(t *testing.T) {
@@ -779,6 +1409,103 @@ func TestClientMultipleSessions(t *testing.T) {
 	require.NoError(t, client.Close())
 }
 
+func TestClientChannelReuseDelay(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{
+		HandleReuseDelay: 100 * time.Millisecond,
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session1, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint16(0), session1.channel)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, session1.Close(ctx))
+	cancel()
+
+	// channel 0 is quarantined so the next session skips over it
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session2, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), session2.channel)
+
+	// once the grace period elapses, channel 0 becomes available again
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, session2.Close(ctx))
+	cancel()
+	time.Sleep(200 * time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session3, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint16(0), session3.channel)
+
+	require.NoError(t, client.Close())
+}
+
+func TestClientPauseReads(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// pausing twice is a no-op
+	client.PauseReads()
+	client.PauseReads()
+
+	// the begin ack isn't processed while reads are paused
+	sessionErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := client.NewSession(ctx, nil)
+		sessionErr <- err
+	}()
+
+	select {
+	case err := <-sessionErr:
+		t.Fatalf("session created while reads were paused: %v", err)
+	case <-time.After(200 * time.Millisecond):
+		// expected
+	}
+
+	client.ResumeReads()
+	select {
+	case err := <-sessionErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("session wasn't created after reads were resumed")
+	}
+
+	// resuming when not paused is a no-op
+	client.ResumeReads()
+
+	require.NoError(t, client.Close())
+}
+
+func TestClientPauseReadsClose(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// closing a connection with paused reads must not hang
+	client.PauseReads()
+	require.NoError(t, client.Close())
+}
+
 func TestClientTooManySessions(t *testing.T) {
 	channelNum := uint16(0)
 	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
@@ -1176,3 +1903,278 @@ func TestConnProperties(t *testing.T) {
 	}, client.Properties())
 	require.NoError(t, client.Close())
 }
+
+func TestConnCapabilities(t *testing.T) {
+	opened := make(chan *frames.PerformOpen, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			opened <- tt
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{
+				ChannelMax:          65535,
+				ContainerID:         "container",
+				IdleTimeout:         time.Minute,
+				MaxFrameSize:        4294967295,
+				OfferedCapabilities: encoding.MultiSymbol{"ANONYMOUS-RELAY"},
+				DesiredCapabilities: encoding.MultiSymbol{"DELAYED-DELIVERY"},
+			})
+			return newResponse(b, err)
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{
+		DesiredCapabilities: []string{"ANONYMOUS-RELAY"},
+		OfferedCapabilities: []string{"DELAYED-DELIVERY"},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	open := <-opened
+	require.Equal(t, encoding.MultiSymbol{"ANONYMOUS-RELAY"}, open.DesiredCapabilities)
+	require.Equal(t, encoding.MultiSymbol{"DELAYED-DELIVERY"}, open.OfferedCapabilities)
+
+	require.Equal(t, []string{"ANONYMOUS-RELAY"}, client.PeerOfferedCapabilities())
+	require.Equal(t, []string{"DELAYED-DELIVERY"}, client.PeerDesiredCapabilities())
+	require.True(t, client.PeerOfferedCapability("ANONYMOUS-RELAY"))
+	require.False(t, client.PeerOfferedCapability("SHARED-SUBS"))
+	require.NoError(t, client.Close())
+}
+
+// recordingDialer hands out a prepared connection, recording the
+// addresses it was asked to dial.
+type recordingDialer struct {
+	mu    sync.Mutex
+	addrs []string
+	conn  net.Conn
+}
+
+func (d *recordingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.addrs = append(d.addrs, addr)
+	return d.conn, nil
+}
+
+func TestDialCustomDialer(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *fake.KeepAlive:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	dialer := &recordingDialer{conn: fake.NewNetConn(responder, fake.NetConnOptions{})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := Dial(ctx, "amqp://broker.example.com", &ConnOptions{Dialer: dialer})
+	cancel()
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+	require.Equal(t, []string{"broker.example.com:5672"}, dialer.addrs)
+}
+
+// countingConn records the number of size-prefixed frames in each write
+// passing through it.
+type countingConn struct {
+	net.Conn
+	mu     sync.Mutex
+	writes []int
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	var count int
+	for off := 0; off+4 <= len(b); count++ {
+		size := int(binary.BigEndian.Uint32(b[off:]))
+		if size < 8 || off+size > len(b) {
+			// not a frame (e.g. the protocol header); count the write as one
+			count = 1
+			break
+		}
+		off += size
+	}
+	c.mu.Lock()
+	c.writes = append(c.writes, count)
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
+
+func (c *countingConn) maxFramesPerWrite() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var max int
+	for _, w := range c.writes {
+		if w > max {
+			max = w
+		}
+	}
+	return max
+}
+
+func TestConnWriteBatching(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	fakeConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	// bridge a real stream to the fake responder so coalesced writes can
+	// be split back into individual frames
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		server, err := l.Accept()
+		if err != nil {
+			return
+		}
+		proxyBridge(server, bufio.NewReader(server), fakeConn)
+	}()
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+
+	counting := &countingConn{Conn: client}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	conn, err := NewConn(ctx, counting, &ConnOptions{WriteBatchDelay: 20 * time.Millisecond})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	session, err := conn.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, fakeConn, 0, 100)
+
+	// concurrent unsettled sends land within the batch delay and their
+	// transfers are coalesced into a single network write
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			require.NoError(t, snd.Send(ctx, NewMessage([]byte("test")), nil))
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, conn.Close())
+	require.GreaterOrEqual(t, counting.maxFramesPerWrite(), 2)
+}
+
+func TestConnWriteBatchingInvalidOptions(t *testing.T) {
+	for _, opts := range []ConnOptions{
+		{WriteBatchDelay: -1},
+		{WriteBufferSize: -1},
+	} {
+		conn, err := newConn(nil, &opts)
+		require.Error(t, err)
+		require.Nil(t, conn)
+	}
+}
+
+type countingBufferPool struct {
+	mu   sync.Mutex
+	gets int
+	puts int
+}
+
+func (p *countingBufferPool) Get(size int) []byte {
+	p.mu.Lock()
+	p.gets++
+	p.mu.Unlock()
+	return make([]byte, size)
+}
+
+func (p *countingBufferPool) Put(buf []byte) {
+	p.mu.Lock()
+	p.puts++
+	p.mu.Unlock()
+}
+
+func (p *countingBufferPool) counts() (gets, puts int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.gets, p.puts
+}
+
+func TestConnBufferPool(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch req.(type) {
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	fakeConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	pool := &countingBufferPool{}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	conn, err := NewConn(ctx, fakeConn, &ConnOptions{BufferPool: pool})
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := conn.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	const msgCount = 3
+	for i := uint32(0); i < msgCount; i++ {
+		b, err := fake.PerformTransfer(0, 0, i, []byte("hello"))
+		require.NoError(t, err)
+		fakeConn.SendFrame(b)
+	}
+
+	for i := 0; i < msgCount; i++ {
+		ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+		msg, err := r.Receive(ctx, nil)
+		cancel()
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(msg.GetData()))
+	}
+
+	gets, puts := pool.counts()
+	require.Equal(t, msgCount, gets)
+	require.Equal(t, msgCount, puts)
+
+	require.NoError(t, conn.Close())
+}
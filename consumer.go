@@ -0,0 +1,224 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+)
+
+// ConsumerOptions contains the optional settings for configuring a Consumer.
+type ConsumerOptions struct {
+	// Conn contains the optional settings applied when dialing the
+	// underlying connection.
+	//
+	// Default: nil, meaning the [ConnOptions] defaults.
+	Conn *ConnOptions
+
+	// Receiver contains the optional settings applied when attaching the
+	// underlying receiver link.  Credit management follows
+	// [ReceiverOptions.Credit]; by default credit is automatically
+	// replenished as messages are settled.
+	//
+	// Default: nil, meaning the [ReceiverOptions] defaults.
+	Receiver *ReceiverOptions
+
+	// Retry bounds the recovery and receive attempts for a single operation.
+	//
+	// Default: nil, meaning the [RetryOptions] defaults.
+	Retry *RetryOptions
+
+	// Session contains the optional settings applied when creating the
+	// underlying session.
+	//
+	// Default: nil, meaning the [SessionOptions] defaults.
+	Session *SessionOptions
+}
+
+// Consumer is a high-level wrapper around [Conn], [Session], and [Receiver]
+// that transparently recovers from connection, session, and link failures.
+// It trades fine-grained control for a minimal interface: dial once with
+// [NewConsumer], call Receive, and whatever failed is re-established before
+// the receive is retried.
+//
+// Safe for concurrent use.
+type Consumer struct {
+	addr   string
+	source string
+	opts   ConsumerOptions
+
+	mu       sync.Mutex // guards the fields below
+	conn     *Conn
+	session  *Session
+	receiver *Receiver
+	closed   bool
+}
+
+// NewConsumer dials addr per [Dial] and attaches a receiver to source,
+// retrying per the configured RetryOptions when a recoverable error occurs.
+//   - ctx controls waiting for the connection and link to be established
+//   - addr is the broker's address (see [Dial])
+//   - source is the name of the peer's sender terminus
+//   - opts contains optional values, pass nil to accept the defaults
+func NewConsumer(ctx context.Context, addr string, source string, opts *ConsumerOptions) (*Consumer, error) {
+	c := &Consumer{addr: addr, source: source}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if err := withRetry(ctx, c.opts.Retry, func() error {
+		_, err := c.currentReceiver(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Receive returns the next message from the source, re-establishing the
+// connection, session, or link and retrying per the configured RetryOptions
+// when a recoverable error occurs.
+//
+// See [Receiver.Receive] for the semantics of a single attempt.  Messages
+// that were delivered but not settled before a recovery are redelivered by
+// the peer subject to its settlement state.
+func (c *Consumer) Receive(ctx context.Context) (*Message, error) {
+	var msg *Message
+	err := withRetry(ctx, c.opts.Retry, func() error {
+		rcv, err := c.currentReceiver(ctx)
+		if err != nil {
+			return err
+		}
+		m, err := rcv.Receive(ctx, nil)
+		if err != nil {
+			c.invalidate(rcv, err)
+			return err
+		}
+		msg = m
+		return nil
+	})
+	return msg, err
+}
+
+// AcceptMessage notifies the server that the message has been accepted and
+// doesn't require redelivery.
+//
+// Messages must be settled on the link they were received on; a message
+// delivered before a recovery can no longer be settled and attempting to do
+// so returns a *LinkError.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to accept
+func (c *Consumer) AcceptMessage(ctx context.Context, msg *Message) error {
+	return msg.rcv.messageDisposition(ctx, msg, &encoding.StateAccepted{})
+}
+
+// RejectMessage notifies the server that the message is invalid.
+// See [Consumer.AcceptMessage] for settlement semantics across recoveries.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to reject
+//   - e is an optional rejection error
+func (c *Consumer) RejectMessage(ctx context.Context, msg *Message, e *Error) error {
+	return msg.rcv.messageDisposition(ctx, msg, &encoding.StateRejected{Error: e})
+}
+
+// ReleaseMessage releases the message back to the server.  The message may
+// be redelivered to this or another consumer.
+// See [Consumer.AcceptMessage] for settlement semantics across recoveries.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to release
+func (c *Consumer) ReleaseMessage(ctx context.Context, msg *Message) error {
+	return msg.rcv.messageDisposition(ctx, msg, &encoding.StateReleased{})
+}
+
+// ModifyMessage notifies the server that the message was not acted upon and
+// should be modified.
+// See [Consumer.AcceptMessage] for settlement semantics across recoveries.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to modify
+//   - options contains the optional settings to modify; pass nil to apply
+//     [ReceiverOptions.ModifiedOutcomeDefaults], if configured
+func (c *Consumer) ModifyMessage(ctx context.Context, msg *Message, options *ModifyMessageOptions) error {
+	return msg.rcv.messageDisposition(ctx, msg, msg.rcv.modifiedOutcome(options))
+}
+
+// Close closes the underlying connection along with its session and link.
+// Subsequent calls to Receive will fail.
+func (c *Consumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	conn := c.conn
+	c.conn, c.session, c.receiver = nil, nil, nil
+	return conn.Close()
+}
+
+// currentReceiver returns the active receiver, re-establishing the
+// connection, session, and link as needed.
+func (c *Consumer) currentReceiver(ctx context.Context) (*Receiver, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errors.New("consumer is closed")
+	}
+	if c.conn == nil {
+		conn, err := Dial(ctx, c.addr, c.opts.Conn)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+	if c.session == nil {
+		session, err := c.conn.NewSession(ctx, c.opts.Session)
+		if err != nil {
+			c.invalidateLocked(err)
+			return nil, err
+		}
+		c.session = session
+	}
+	if c.receiver == nil {
+		rcv, err := c.session.NewReceiver(ctx, c.source, c.opts.Receiver)
+		if err != nil {
+			c.invalidateLocked(err)
+			return nil, err
+		}
+		c.receiver = rcv
+	}
+	return c.receiver, nil
+}
+
+// invalidate tears down the layers indicated by err so the next operation
+// re-establishes them.  rcv is the receiver the error was observed on; when
+// it's no longer the current one a concurrent operation already recovered.
+func (c *Consumer) invalidate(rcv *Receiver, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rcv != c.receiver {
+		return
+	}
+	c.invalidateLocked(err)
+}
+
+func (c *Consumer) invalidateLocked(err error) {
+	var (
+		connErr    *ConnError
+		sessionErr *SessionError
+		linkErr    *LinkError
+	)
+	switch {
+	case errors.As(err, &connErr):
+		if c.conn != nil {
+			_ = c.conn.Close()
+		}
+		c.conn, c.session, c.receiver = nil, nil, nil
+	case errors.As(err, &sessionErr):
+		c.session, c.receiver = nil, nil
+	case errors.As(err, &linkErr):
+		c.receiver = nil
+	}
+}
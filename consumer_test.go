@@ -0,0 +1,99 @@
+package amqp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// consumerResponder handles the full frame exchange for a receiver link,
+// delivering a single "test" message in response to the first flow frame.
+func consumerResponder() frameHandler {
+	sentTransfer := false
+	return func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *frames.PerformFlow:
+			if sentTransfer {
+				return fake.Response{}, nil
+			}
+			sentTransfer = true
+			return newResponse(fake.PerformTransfer(0, 0, 1, []byte("test")))
+		case *frames.PerformDisposition:
+			return fake.Response{}, nil
+		default:
+			return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+}
+
+func TestConsumerReceive(t *testing.T) {
+	dialer := newFakeNetDialer(consumerResponder)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	consumer, err := NewConsumer(ctx, "amqp://localhost", "source", &ConsumerOptions{
+		Conn: &ConnOptions{dialer: dialer},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := consumer.Receive(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("test"), msg.GetData())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, consumer.AcceptMessage(ctx, msg))
+	cancel()
+
+	require.NoError(t, consumer.Close())
+	require.Len(t, dialer.conns, 1)
+
+	// operations fail once closed
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = consumer.Receive(ctx)
+	cancel()
+	require.Error(t, err)
+	require.NoError(t, consumer.Close())
+}
+
+func TestConsumerReceiveRecoversConnection(t *testing.T) {
+	dialer := newFakeNetDialer(consumerResponder)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	consumer, err := NewConsumer(ctx, "amqp://localhost", "source", &ConsumerOptions{
+		Conn:  &ConnOptions{dialer: dialer},
+		Retry: &RetryOptions{Delay: 10 * time.Millisecond},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := consumer.Receive(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("test"), msg.GetData())
+
+	// sever the connection out from under the consumer and wait for the
+	// client to observe the failure
+	(<-dialer.conns).Close()
+	<-consumer.conn.Done()
+
+	// a message received before the recovery can no longer be settled
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = consumer.AcceptMessage(ctx, msg)
+	cancel()
+	require.Error(t, err)
+
+	// the next receive transparently redials and re-attaches
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	msg, err = consumer.Receive(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("test"), msg.GetData())
+
+	require.NoError(t, consumer.Close())
+	require.Len(t, dialer.conns, 1)
+}
@@ -0,0 +1,142 @@
+package amqp
+
+// CreditStrategy determines when a Receiver automatically issues link credit
+// and how much. Implementations are consulted by the Receiver's mux when a
+// message arrives, when messages are settled, and periodically while the
+// link is idle.
+//
+// Implementations MUST NOT block and MUST NOT call methods on the Receiver.
+type CreditStrategy interface {
+	// Credits returns the number of credits to issue for the provided
+	// snapshot of the receiver's flow-control state.
+	// Returning zero means no flow frame is sent.
+	Credits(state CreditState) uint32
+}
+
+// CreditState is a snapshot of a Receiver's flow-control state.
+// It's passed to a CreditStrategy when determining credits to issue.
+type CreditState struct {
+	// LinkCredit is the current link credit, i.e. the maximum number
+	// of messages the sender can still transmit.
+	LinkCredit uint32
+
+	// SettledCount is the number of messages settled since credit
+	// was last issued.
+	SettledCount uint32
+
+	// BufferedMessages is the number of messages in the prefetch
+	// cache awaiting retrieval via Receiver.Receive/Prefetched.
+	BufferedMessages uint32
+
+	// UnsettledCount is the number of messages received but not yet settled.
+	UnsettledCount uint32
+}
+
+// FixedWindowCreditStrategy reclaims settled credit once the number of
+// settled messages reaches the current link credit. This is the default
+// strategy used by receivers in automatic credit management mode.
+type FixedWindowCreditStrategy struct{}
+
+// Credits implements the CreditStrategy interface for FixedWindowCreditStrategy.
+func (FixedWindowCreditStrategy) Credits(state CreditState) uint32 {
+	// once pending credit is equal to or greater than the available credit, reclaim it.
+	// this, instead of issuing on every settlement, prevents flow frames from being
+	// too chatty while still ensuring credit is reclaimed in cases where the number
+	// of unsettled messages remains high.
+	if state.SettledCount > 0 && state.SettledCount >= state.LinkCredit {
+		return state.SettledCount
+	}
+	return 0
+}
+
+// AdaptiveCreditStrategy tunes the credit window between Min and Max
+// based on how the consumer is keeping up.  A window that cycles through
+// settlements with no backlog building up is doubled; a backlog of
+// prefetched messages or unsettled deliveries — the visible effect of a
+// slow consumer or high settlement latency — halves it.  The strategy
+// carries state between evaluations, so give each receiver its own
+// instance and pass it by pointer.
+type AdaptiveCreditStrategy struct {
+	// Max bounds the window's growth.
+	//
+	// Default: 0, meaning 1024.
+	Max uint32
+
+	// Min bounds the window's shrinkage.
+	//
+	// Default: 0, meaning 1.
+	Min uint32
+
+	// target is the current window size; 0 until the first evaluation,
+	// which adopts the window the link attached with
+	target uint32
+}
+
+// Credits implements the CreditStrategy interface for AdaptiveCreditStrategy.
+func (a *AdaptiveCreditStrategy) Credits(state CreditState) uint32 {
+	lower, upper := a.Min, a.Max
+	if lower == 0 {
+		lower = 1
+	}
+	if upper == 0 {
+		upper = 1024
+	}
+	if upper < lower {
+		upper = lower
+	}
+
+	// outstanding is everything counting against the window: credit the
+	// sender can still consume, prefetched messages, and deliveries
+	// awaiting settlement
+	outstanding := state.LinkCredit + state.BufferedMessages + state.UnsettledCount
+
+	if a.target == 0 {
+		a.target = outstanding
+	}
+	if a.target < lower {
+		a.target = lower
+	} else if a.target > upper {
+		a.target = upper
+	}
+
+	backlog := state.BufferedMessages + state.UnsettledCount
+	switch {
+	case backlog > a.target/2:
+		// the consumer is falling behind; shrink the window
+		if a.target /= 2; a.target < lower {
+			a.target = lower
+		}
+	case state.SettledCount > 0 && state.SettledCount >= a.target/2:
+		// at least half the window settled since credit was last issued
+		// with no backlog building up; grow it
+		if a.target >= upper/2 {
+			a.target = upper
+		} else {
+			a.target *= 2
+		}
+	}
+
+	if outstanding >= a.target {
+		// over target: let settled credit lapse instead of reclaiming it
+		return 0
+	}
+
+	// top up toward the target, but only once the deficit is a meaningful
+	// fraction of the window (or the sender is out of credit entirely) to
+	// prevent flow frames from being too chatty
+	deficit := a.target - outstanding
+	if state.LinkCredit > 0 && deficit < a.target/4 {
+		return 0
+	}
+	return deficit
+}
+
+// ManualCreditStrategy disables automatic credit management.
+// Credits are added with Receiver.IssueCredit, and the link can
+// be drained with Receiver.DrainCredit.
+type ManualCreditStrategy struct{}
+
+// Credits implements the CreditStrategy interface for ManualCreditStrategy.
+func (ManualCreditStrategy) Credits(CreditState) uint32 {
+	return 0
+}
@@ -0,0 +1,97 @@
+package amqp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedWindowCreditStrategy(t *testing.T) {
+	strategy := FixedWindowCreditStrategy{}
+
+	// nothing settled, no credit issued
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 10}))
+
+	// settled count below the window, no credit issued
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 10, SettledCount: 5}))
+
+	// settled count reaches the window, credit is reclaimed
+	require.EqualValues(t, 10, strategy.Credits(CreditState{LinkCredit: 10, SettledCount: 10}))
+
+	// settled count can exceed the current link credit
+	require.EqualValues(t, 15, strategy.Credits(CreditState{LinkCredit: 10, SettledCount: 15}))
+}
+
+func TestManualCreditStrategy(t *testing.T) {
+	strategy := ManualCreditStrategy{}
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 10, SettledCount: 10}))
+}
+
+func TestReceiverOptionsCreditStrategy(t *testing.T) {
+	// manual strategy disables automatic flow
+	r, err := newReceiver("source", newSession(nil, 0, nil), &ReceiverOptions{
+		CreditStrategy: ManualCreditStrategy{},
+	})
+	require.NoError(t, err)
+	require.False(t, r.autoSendFlow)
+	require.EqualValues(t, 0, r.l.linkCredit)
+
+	// custom strategy retains automatic flow
+	r, err = newReceiver("source", newSession(nil, 0, nil), &ReceiverOptions{
+		Credit:         10,
+		CreditStrategy: FixedWindowCreditStrategy{},
+	})
+	require.NoError(t, err)
+	require.True(t, r.autoSendFlow)
+	require.EqualValues(t, 10, r.l.linkCredit)
+}
+
+func TestAdaptiveCreditStrategy(t *testing.T) {
+	strategy := &AdaptiveCreditStrategy{Min: 2, Max: 64}
+
+	// the first evaluation adopts the window the link attached with
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 8}))
+	require.EqualValues(t, 8, strategy.target)
+
+	// the window cycled with no backlog: it doubles, and the settled
+	// credit plus the growth is issued
+	require.EqualValues(t, 12, strategy.Credits(CreditState{LinkCredit: 4, SettledCount: 4}))
+	require.EqualValues(t, 16, strategy.target)
+
+	// a small deficit with credit still on the link isn't topped up
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 14, SettledCount: 1}))
+
+	// a sender out of credit is topped up regardless of deficit size
+	require.EqualValues(t, 8, strategy.Credits(CreditState{LinkCredit: 0, BufferedMessages: 4, UnsettledCount: 4}))
+
+	// a backlog over half the window halves it, and settled credit
+	// lapses instead of being reclaimed
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 2, BufferedMessages: 10, SettledCount: 4}))
+	require.EqualValues(t, 8, strategy.target)
+
+	// growth is capped at Max
+	strategy = &AdaptiveCreditStrategy{Min: 2, Max: 10}
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 8}))
+	require.EqualValues(t, 10, strategy.Credits(CreditState{SettledCount: 8}))
+	require.EqualValues(t, 10, strategy.target)
+
+	// shrinkage is capped at Min
+	strategy = &AdaptiveCreditStrategy{Min: 4, Max: 64}
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 8}))
+	require.EqualValues(t, 0, strategy.Credits(CreditState{BufferedMessages: 8}))
+	require.EqualValues(t, 4, strategy.target)
+	require.EqualValues(t, 0, strategy.Credits(CreditState{BufferedMessages: 8}))
+	require.EqualValues(t, 4, strategy.target)
+}
+
+func TestAdaptiveCreditStrategyDefaults(t *testing.T) {
+	strategy := &AdaptiveCreditStrategy{}
+
+	// zero bounds default to 1 and 1024
+	require.EqualValues(t, 1, strategy.Credits(CreditState{}))
+	require.EqualValues(t, 1, strategy.target)
+
+	strategy = &AdaptiveCreditStrategy{}
+	require.EqualValues(t, 0, strategy.Credits(CreditState{LinkCredit: 2000}))
+	require.EqualValues(t, 1024, strategy.target)
+}
@@ -0,0 +1,107 @@
+package amqp
+
+import "sync"
+
+// DeliveryJournal records a sender's unsettled deliveries so the
+// link-resume machinery can determine which deliveries require
+// resending.  Plug a durable implementation via
+// [SenderOptions.DeliveryJournal] to preserve delivery state across
+// process restarts and achieve exactly-once delivery.
+//
+// RecordUnsettled is called before a delivery's first transfer frame is
+// written and MarkSettled once its settlement is confirmed, so a journal
+// entry without a matching MarkSettled denotes a delivery in an unknown
+// state of settlement.
+//
+// Implementations must be safe for concurrent use.
+type DeliveryJournal interface {
+	// RecordUnsettled stores the delivery's last known state.
+	// state may be nil when no outcome has been reported yet; a
+	// delivery may be recorded again as its state progresses.
+	RecordUnsettled(linkName string, deliveryTag []byte, state DeliveryState) error
+
+	// MarkSettled removes the delivery from the journal once its
+	// settlement has been confirmed.
+	MarkSettled(linkName string, deliveryTag []byte) error
+
+	// Unsettled returns the last recorded state of the link's unsettled
+	// deliveries, keyed by delivery tag.
+	Unsettled(linkName string) (map[string]DeliveryState, error)
+}
+
+// MemoryDeliveryJournal is the default [DeliveryJournal], keeping
+// delivery state in process memory.  State is lost on process restart,
+// so it cannot extend exactly-once delivery across restarts.
+type MemoryDeliveryJournal struct {
+	mu    sync.Mutex
+	links map[string]map[string]DeliveryState
+}
+
+// NewMemoryDeliveryJournal creates an empty MemoryDeliveryJournal.
+func NewMemoryDeliveryJournal() *MemoryDeliveryJournal {
+	return &MemoryDeliveryJournal{links: map[string]map[string]DeliveryState{}}
+}
+
+// RecordUnsettled implements the [DeliveryJournal] interface.
+func (j *MemoryDeliveryJournal) RecordUnsettled(linkName string, deliveryTag []byte, state DeliveryState) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	deliveries := j.links[linkName]
+	if deliveries == nil {
+		deliveries = map[string]DeliveryState{}
+		j.links[linkName] = deliveries
+	}
+	deliveries[string(deliveryTag)] = state
+	return nil
+}
+
+// MarkSettled implements the [DeliveryJournal] interface.
+func (j *MemoryDeliveryJournal) MarkSettled(linkName string, deliveryTag []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	deliveries := j.links[linkName]
+	delete(deliveries, string(deliveryTag))
+	if len(deliveries) == 0 {
+		delete(j.links, linkName)
+	}
+	return nil
+}
+
+// Unsettled implements the [DeliveryJournal] interface.
+func (j *MemoryDeliveryJournal) Unsettled(linkName string) (map[string]DeliveryState, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	unsettled := make(map[string]DeliveryState, len(j.links[linkName]))
+	for tag, state := range j.links[linkName] {
+		unsettled[tag] = state
+	}
+	return unsettled, nil
+}
+
+// DeliveryResolution records what the unsettled-map exchange performed at
+// link attach established about one previously unsettled delivery.
+// Returned by [Sender.ResumedDeliveries].
+type DeliveryResolution struct {
+	// DeliveryTag identifies the delivery.
+	DeliveryTag []byte
+
+	// State is the delivery state reported by the peer, or nil when the
+	// peer reported none.
+	State DeliveryState
+
+	// InDoubt is true when the peer has no record of the delivery.  It
+	// must be resent, with the same delivery tag, for the delivery to
+	// complete.
+	InDoubt bool
+}
+
+// isTerminalDeliveryState reports whether state is a terminal outcome,
+// i.e. the receiver's processing of the delivery is complete.
+func isTerminalDeliveryState(state DeliveryState) bool {
+	switch state.(type) {
+	case *StateAccepted, *StateRejected, *StateReleased, *StateModified:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,45 @@
+package amqp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryDeliveryJournal(t *testing.T) {
+	journal := NewMemoryDeliveryJournal()
+
+	unsettled, err := journal.Unsettled("link")
+	require.NoError(t, err)
+	require.Empty(t, unsettled)
+
+	require.NoError(t, journal.RecordUnsettled("link", []byte("tag1"), nil))
+	require.NoError(t, journal.RecordUnsettled("link", []byte("tag2"), &StateReceived{}))
+	require.NoError(t, journal.RecordUnsettled("other", []byte("tag1"), nil))
+
+	unsettled, err = journal.Unsettled("link")
+	require.NoError(t, err)
+	require.Len(t, unsettled, 2)
+	require.Contains(t, unsettled, "tag1")
+	require.IsType(t, &StateReceived{}, unsettled["tag2"])
+
+	// recording again updates the delivery's state
+	require.NoError(t, journal.RecordUnsettled("link", []byte("tag1"), &StateAccepted{}))
+	unsettled, err = journal.Unsettled("link")
+	require.NoError(t, err)
+	require.IsType(t, &StateAccepted{}, unsettled["tag1"])
+
+	require.NoError(t, journal.MarkSettled("link", []byte("tag1")))
+	require.NoError(t, journal.MarkSettled("link", []byte("tag2")))
+	unsettled, err = journal.Unsettled("link")
+	require.NoError(t, err)
+	require.Empty(t, unsettled)
+
+	// settling is scoped to the link
+	unsettled, err = journal.Unsettled("other")
+	require.NoError(t, err)
+	require.Len(t, unsettled, 1)
+
+	// settling an unknown delivery is a no-op
+	require.NoError(t, journal.MarkSettled("link", []byte("tag1")))
+}
@@ -9,6 +9,7 @@ import "github.com/Azure/go-amqp/internal/encoding"
 //   - *StateReceived
 //   - *StateRejected
 //   - *StateReleased
+//   - *TransactionalState
 type DeliveryState = encoding.DeliveryState
 
 // StateAccepted indicates that an incoming message has been successfully processed,
@@ -31,3 +32,9 @@ type StateRejected = encoding.StateRejected
 
 // StateReleased indicates that a given transfer was not and will not be acted upon.
 type StateReleased = encoding.StateReleased
+
+// TransactionalState associates a delivery's work with a transaction.
+// On a transfer it enrolls the delivery in the transaction; on a disposition
+// it carries the provisional outcome that becomes effective when the
+// transaction is discharged.
+type TransactionalState = encoding.TransactionalState
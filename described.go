@@ -0,0 +1,37 @@
+package amqp
+
+import (
+	"reflect"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+)
+
+// DescribedType is an AMQP described value: an arbitrary value paired
+// with the descriptor giving it meaning.  Described values whose
+// descriptor hasn't been registered via [RegisterDescribedType] are
+// surfaced as DescribedType when received, and a DescribedType can be
+// placed in a message body to send one directly.
+type DescribedType = encoding.DescribedType
+
+// RegisterDescribedType registers a custom described type for use in
+// amqp-value message bodies, including nested inside maps and lists.
+// Values of type T marshal as a described value carrying descriptor,
+// and received described values carrying descriptor unmarshal back
+// into a T.
+//
+//   - descriptor identifies the type on the wire: a string (symbol) or uint64 (code)
+//   - encode converts a T into a value this package can marshal
+//   - decode converts the unmarshaled value back into a T
+//
+// T must be the concrete type placed in message bodies.  Registration is
+// process-wide and expected during program initialization; it returns an
+// error when the descriptor or type is already registered.
+func RegisterDescribedType[T any](descriptor any, encode func(T) (any, error), decode func(any) (T, error)) error {
+	return encoding.RegisterDescribedType(descriptor, reflect.TypeOf((*T)(nil)).Elem(),
+		func(v any) (any, error) {
+			return encode(v.(T))
+		},
+		func(v any) (any, error) {
+			return decode(v)
+		})
+}
@@ -0,0 +1,78 @@
+package amqp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPoint struct {
+	X, Y int64
+}
+
+func TestDescribedTypeRegistered(t *testing.T) {
+	require.NoError(t, RegisterDescribedType[testPoint]("test:point",
+		func(p testPoint) (any, error) {
+			return []any{p.X, p.Y}, nil
+		},
+		func(v any) (testPoint, error) {
+			fields, ok := v.([]any)
+			if !ok || len(fields) != 2 {
+				return testPoint{}, fmt.Errorf("unexpected point encoding %v", v)
+			}
+			return testPoint{X: fields[0].(int64), Y: fields[1].(int64)}, nil
+		}))
+
+	// a registered type round-trips through an amqp-value body
+	msg := &Message{Value: testPoint{X: 3, Y: -7}}
+	encoded, err := msg.MarshalBinary()
+	require.NoError(t, err)
+	decoded := &Message{}
+	require.NoError(t, decoded.UnmarshalBinary(encoded))
+	require.Equal(t, testPoint{X: 3, Y: -7}, decoded.Value)
+
+	// including nested inside lists...
+	msg = &Message{Value: []any{"origin", testPoint{X: 1, Y: 2}}}
+	encoded, err = msg.MarshalBinary()
+	require.NoError(t, err)
+	decoded = &Message{}
+	require.NoError(t, decoded.UnmarshalBinary(encoded))
+	require.Equal(t, []any{"origin", testPoint{X: 1, Y: 2}}, decoded.Value)
+
+	// ...and maps
+	msg = &Message{Value: map[string]any{"pos": testPoint{X: 4, Y: 5}}}
+	encoded, err = msg.MarshalBinary()
+	require.NoError(t, err)
+	decoded = &Message{}
+	require.NoError(t, decoded.UnmarshalBinary(encoded))
+	require.Equal(t, map[string]any{"pos": testPoint{X: 4, Y: 5}}, decoded.Value)
+
+	// the descriptor and type can only be registered once
+	require.ErrorContains(t, RegisterDescribedType[testPoint]("test:point2",
+		func(p testPoint) (any, error) { return nil, nil },
+		func(v any) (testPoint, error) { return testPoint{}, nil }),
+		"already registered")
+	require.ErrorContains(t, RegisterDescribedType[int32]("test:point",
+		func(p int32) (any, error) { return nil, nil },
+		func(v any) (int32, error) { return 0, nil }),
+		"already registered")
+}
+
+func TestDescribedTypeUnregistered(t *testing.T) {
+	// an unregistered described value is surfaced as DescribedType
+	dt := DescribedType{Descriptor: "test:unregistered", Value: "opaque"}
+	msg := &Message{Value: dt}
+	encoded, err := msg.MarshalBinary()
+	require.NoError(t, err)
+	decoded := &Message{}
+	require.NoError(t, decoded.UnmarshalBinary(encoded))
+	require.Equal(t, dt, decoded.Value)
+}
+
+func TestDescribedTypeInvalidDescriptor(t *testing.T) {
+	require.ErrorContains(t, RegisterDescribedType[testPoint](3.14,
+		func(p testPoint) (any, error) { return nil, nil },
+		func(v any) (testPoint, error) { return testPoint{}, nil }),
+		"invalid descriptor type")
+}
@@ -6,5 +6,20 @@ the most common AMQP protocols in use today.
 
 The example below shows how to use this package to connect
 to a Microsoft Azure Service Bus queue.
+
+# Encoding semantics
+
+Message sections and frame fields follow consistent rules for absent and
+empty values:
+
+  - nil pointers and nil maps encode as AMQP null
+  - empty (non-nil) maps encode as empty maps; to force-encode an empty
+    section such as [Message.ApplicationProperties], set it to a non-nil
+    empty map
+  - nil slices encode as empty arrays or binary, preserving the
+    empty-vs-absent distinction of list-like fields
+  - zero-valued optional fields are omitted on the wire; to force-encode
+    one, point the field at a zero value, e.g. new(uint32)
+  - [Null] in [Message.Value] sends an explicit null body
 */
 package amqp // import "github.com/Azure/go-amqp"
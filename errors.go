@@ -1,6 +1,11 @@
 package amqp
 
 import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
 	"github.com/Azure/go-amqp/internal/encoding"
 )
 
@@ -47,6 +52,34 @@ const (
 // Error is an AMQP error.
 type Error = encoding.Error
 
+// SoleConnectionDetected returns true if err indicates that the peer rejected
+// the connection because another connection with the same container-id was
+// already open and the sole-connection-for-container capability is in force.
+// When provided by the peer, the offending container-id from the error info
+// is also returned.
+//
+// Per the AMQP SoleConn extension spec, detection is signaled with the
+// amqp:invalid-field condition and an error info entry of
+// "invalid-field": "container-id".
+func SoleConnectionDetected(err error) (string, bool) {
+	var connErr *ConnError
+	if !errors.As(err, &connErr) || connErr.RemoteErr == nil {
+		return "", false
+	}
+
+	remoteErr := connErr.RemoteErr
+	if remoteErr.Condition != ErrCondInvalidField {
+		return "", false
+	}
+
+	if field, ok := remoteErr.Info["invalid-field"]; !ok || field != "container-id" {
+		return "", false
+	}
+
+	containerID, _ := remoteErr.Info["container-id"].(string)
+	return containerID, true
+}
+
 // LinkError is returned by methods on Sender/Receiver when the link has closed.
 type LinkError struct {
 	// RemoteErr contains any error information provided by the peer if the peer detached the link.
@@ -65,8 +98,11 @@ func (e *LinkError) Error() string {
 	return e.inner.Error()
 }
 
-// Unwrap returns the RemoteErr, if any.
+// Unwrap returns the inner error if set, else the RemoteErr, if any.
 func (e *LinkError) Unwrap() error {
+	if e.inner != nil {
+		return e.inner
+	}
 	if e.RemoteErr == nil {
 		return nil
 	}
@@ -74,6 +110,58 @@ func (e *LinkError) Unwrap() error {
 	return e.RemoteErr
 }
 
+// LinkSuspendedError indicates the link was detached without being closed
+// (a non-closing detach), suspending it while both termini retain their
+// unsettled delivery state.  Resume the link by attaching a new sender or
+// receiver with the same link name, e.g. via [Sender.Refresh] or
+// [Receiver.Refresh].
+//
+// It is carried as the inner error of the resulting *LinkError, so detect
+// it with [errors.As].
+type LinkSuspendedError struct {
+	// RemoteErr contains any error information provided by the peer if the
+	// peer initiated the detach.
+	RemoteErr *Error
+}
+
+// Error implements the error interface for LinkSuspendedError.
+func (e *LinkSuspendedError) Error() string {
+	if e.RemoteErr == nil {
+		return "amqp: link suspended"
+	}
+	return e.RemoteErr.Error()
+}
+
+// Unwrap returns the RemoteErr, if any.
+func (e *LinkSuspendedError) Unwrap() error {
+	if e.RemoteErr == nil {
+		return nil
+	}
+
+	return e.RemoteErr
+}
+
+// ConnectionForcedError indicates the peer closed the connection with the
+// amqp:connection:forced condition, e.g. a broker shutting down for maintenance.
+//
+// The condition is transient; recovery layers can treat it as immediately
+// retryable and re-establish the connection.
+type ConnectionForcedError struct {
+	// RemoteErr contains the error information provided by the peer,
+	// including any description (e.g. "server restarting") and info map.
+	RemoteErr *Error
+}
+
+// Error implements the error interface for ConnectionForcedError.
+func (e *ConnectionForcedError) Error() string {
+	return e.RemoteErr.Error()
+}
+
+// Unwrap returns the RemoteErr.
+func (e *ConnectionForcedError) Unwrap() error {
+	return e.RemoteErr
+}
+
 // ConnError is returned by methods on Conn and propagated to Session and Senders/Receivers
 // when the connection has been closed.
 type ConnError struct {
@@ -93,8 +181,11 @@ func (e *ConnError) Error() string {
 	return e.inner.Error()
 }
 
-// Unwrap returns the RemoteErr, if any.
+// Unwrap returns the inner error if set, else the RemoteErr, if any.
 func (e *ConnError) Unwrap() error {
+	if e.inner != nil {
+		return e.inner
+	}
 	if e.RemoteErr == nil {
 		return nil
 	}
@@ -102,6 +193,48 @@ func (e *ConnError) Unwrap() error {
 	return e.RemoteErr
 }
 
+// IdleTimeoutError indicates the connection was closed because the peer
+// sent no traffic within the locally advertised idle timeout — silent
+// network death rather than a broker-initiated close.  It is carried as
+// the inner error of the resulting *ConnError, so detect it with
+// [errors.As].
+type IdleTimeoutError struct {
+	// LastFrameReceived is when the last frame, including keepalives,
+	// was received from the peer.
+	LastFrameReceived time.Time
+
+	// LastFrameSent is when the last frame, including keepalives, was
+	// written to the peer.
+	LastFrameSent time.Time
+
+	// Timeout is the advertised idle timeout the peer violated.  When
+	// [IdleToleranceOptions.MissedIntervals] is set the peer was idle
+	// for that many multiples of Timeout.
+	Timeout time.Duration
+}
+
+// Error implements the error interface for IdleTimeoutError.
+func (e *IdleTimeoutError) Error() string {
+	return fmt.Sprintf("amqp: idle timeout %s exceeded: last frame received at %s, last frame sent at %s",
+		e.Timeout, e.LastFrameReceived.Format(time.RFC3339), e.LastFrameSent.Format(time.RFC3339))
+}
+
+// SASLLimitError is returned when connecting fails because the peer
+// exceeded one of the limits configured via [SASLLimitOptions].
+type SASLLimitError struct {
+	// Limit names the exceeded limit: "HandshakeTimeout",
+	// "MaxChallengeRounds", or "MaxChallengeSize".
+	Limit string
+
+	// Message describes the violation.
+	Message string
+}
+
+// Error implements the error interface for SASLLimitError.
+func (e *SASLLimitError) Error() string {
+	return e.Message
+}
+
 // SessionError is returned by methods on Session and propagated to Senders/Receivers
 // when the session has been closed.
 type SessionError struct {
@@ -129,3 +262,11 @@ func (e *SessionError) Unwrap() error {
 
 	return e.RemoteErr
 }
+
+// muxPanicError converts a value recovered from a panicking mux goroutine
+// into an error that includes the panicking goroutine's stack.
+func muxPanicError(v any) error {
+	buf := make([]byte, 16*1024)
+	n := runtime.Stack(buf, false)
+	return fmt.Errorf("amqp: panic in mux: %v\n%s", v, buf[:n])
+}
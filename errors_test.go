@@ -42,3 +42,31 @@ func TestErrorUnwrap(t *testing.T) {
 		require.ErrorAs(t, se, &amqpErr)
 	})
 }
+
+func TestSoleConnectionDetected(t *testing.T) {
+	containerID, ok := amqp.SoleConnectionDetected(errors.New("unrelated"))
+	require.False(t, ok)
+	require.Empty(t, containerID)
+
+	containerID, ok = amqp.SoleConnectionDetected(&amqp.ConnError{})
+	require.False(t, ok)
+	require.Empty(t, containerID)
+
+	containerID, ok = amqp.SoleConnectionDetected(&amqp.ConnError{
+		RemoteErr: &amqp.Error{Condition: amqp.ErrCondConnectionForced},
+	})
+	require.False(t, ok)
+	require.Empty(t, containerID)
+
+	containerID, ok = amqp.SoleConnectionDetected(&amqp.ConnError{
+		RemoteErr: &amqp.Error{
+			Condition: amqp.ErrCondInvalidField,
+			Info: map[string]any{
+				"invalid-field": "container-id",
+				"container-id":  "my-container",
+			},
+		},
+	})
+	require.True(t, ok)
+	require.Equal(t, "my-container", containerID)
+}
@@ -0,0 +1,42 @@
+package amqp
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// expvar counter names published under the "go-amqp" map.
+const (
+	expvarActiveConns      = "active_connections"
+	expvarActiveSessions   = "active_sessions"
+	expvarActiveLinks      = "active_links"
+	expvarMessagesSent     = "messages_sent"
+	expvarMessagesReceived = "messages_received"
+)
+
+var (
+	expvarsOnce sync.Once
+	expvarsOn   uint32
+	expvarMap   = new(expvar.Map).Init()
+)
+
+// PublishExpvars publishes the library's key runtime counters
+// (active connections/sessions/links, messages sent/received) under
+// the "go-amqp" expvar map for inspection on debug endpoints.
+//
+// Publishing is opt-in and process-wide; once enabled, all connections
+// contribute to the counters.  Subsequent calls are no-ops.
+func PublishExpvars() {
+	expvarsOnce.Do(func() {
+		expvar.Publish("go-amqp", expvarMap)
+		atomic.StoreUint32(&expvarsOn, 1)
+	})
+}
+
+// expvarAdd adds delta to the named counter when expvars are published.
+func expvarAdd(name string, delta int64) {
+	if atomic.LoadUint32(&expvarsOn) == 1 {
+		expvarMap.Add(name, delta)
+	}
+}
@@ -0,0 +1,37 @@
+package amqp
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvars(t *testing.T) {
+	PublishExpvars()
+	// subsequent calls are no-ops
+	PublishExpvars()
+	require.NotNil(t, expvar.Get("go-amqp"))
+
+	get := func(name string) int64 {
+		v := expvarMap.Get(name)
+		if v == nil {
+			return 0
+		}
+		return v.(*expvar.Int).Value()
+	}
+	before := get(expvarActiveConns)
+
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	conn, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, before+1, get(expvarActiveConns))
+
+	require.NoError(t, conn.Close())
+	require.Equal(t, before, get(expvarActiveConns))
+}
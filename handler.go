@@ -0,0 +1,115 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+)
+
+// MessageHandler processes a single received message.
+// Returning nil settles the delivery with the accepted outcome; returning
+// an error (or panicking) settles it according to [HandleOptions.FailureOutcome].
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+// FailureOutcome selects the outcome applied to a delivery when its
+// handler returns an error or panics.
+type FailureOutcome int
+
+const (
+	// FailureOutcomeModify modifies the delivery with delivery-failed set,
+	// incrementing the delivery count, and merges in any configured
+	// annotations.  The message remains eligible for redelivery.
+	FailureOutcomeModify FailureOutcome = 0
+
+	// FailureOutcomeRelease releases the delivery back to the server
+	// without incrementing the delivery count.
+	FailureOutcomeRelease FailureOutcome = 1
+
+	// FailureOutcomeReject rejects the delivery as invalid.  The handler's
+	// error is conveyed to the server in the rejection.
+	FailureOutcomeReject FailureOutcome = 2
+)
+
+// HandleOptions contains any optional values for the Receiver.Handle method.
+type HandleOptions struct {
+	// FailureOutcome is the outcome applied to a delivery when the handler
+	// returns an error or panics.
+	//
+	// Default: FailureOutcomeModify.
+	FailureOutcome FailureOutcome
+
+	// Annotations is an optional annotation map merged with the message's
+	// annotations when a delivery fails and FailureOutcome is
+	// FailureOutcomeModify, e.g. dead-letter diagnostics.
+	Annotations Annotations
+
+	// UndeliverableHere indicates that failed deliveries must not be
+	// redelivered to this link.  Only applies when FailureOutcome is
+	// FailureOutcomeModify.
+	//
+	// Default: false.
+	UndeliverableHere bool
+}
+
+// Handle receives messages and dispatches them to handler until ctx is
+// cancelled or the link fails; the terminal error is returned.
+//
+// Deliveries are settled as each handler invocation completes, so a
+// handler that panics or returns an error never leaves its delivery
+// unsettled.  Panics are recovered and treated as handler errors.
+//
+//   - ctx controls the lifetime of the loop and each disposition
+//   - handler is invoked for each received message
+//   - opts contains optional values, pass nil to accept the defaults
+func (r *Receiver) Handle(ctx context.Context, handler MessageHandler, opts *HandleOptions) error {
+	if opts == nil {
+		opts = &HandleOptions{}
+	}
+	if fo := opts.FailureOutcome; fo > FailureOutcomeReject {
+		return fmt.Errorf("invalid FailureOutcome %d", fo)
+	}
+
+	for {
+		msg, err := r.Receive(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := r.dispatchMessage(ctx, handler, msg, opts); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatchMessage invokes handler for msg and settles the delivery
+// based on the outcome.  The returned error is terminal for the
+// handler loop; handler failures are conveyed via dispositions.
+func (r *Receiver) dispatchMessage(ctx context.Context, handler MessageHandler, msg *Message, opts *HandleOptions) error {
+	handlerErr := func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("amqp: message handler panic: %v", v)
+			}
+		}()
+		return handler(ctx, msg)
+	}()
+
+	if handlerErr == nil {
+		return r.AcceptMessage(ctx, msg)
+	}
+
+	switch opts.FailureOutcome {
+	case FailureOutcomeRelease:
+		return r.ReleaseMessage(ctx, msg)
+	case FailureOutcomeReject:
+		return r.RejectMessage(ctx, msg, &Error{
+			Condition:   ErrCondInternalError,
+			Description: handlerErr.Error(),
+		})
+	default:
+		return r.ModifyMessage(ctx, msg, &ModifyMessageOptions{
+			DeliveryFailed:    true,
+			UndeliverableHere: opts.UndeliverableHere,
+			Annotations:       opts.Annotations,
+		})
+	}
+}
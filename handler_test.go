@@ -0,0 +1,130 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// runHandlerTest receives a single message via Handle with the specified
+// handler and options, returning the disposition states sent to the server.
+func runHandlerTest(t *testing.T, handler MessageHandler, opts *HandleOptions) []encoding.DeliveryState {
+	const linkHandle = 0
+	deliveryID := uint32(1)
+	states := make(chan encoding.DeliveryState, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch ff := req.(type) {
+		case *frames.PerformFlow:
+			if *ff.NextIncomingID == deliveryID {
+				// this is the first flow frame, send our payload
+				return newResponse(fake.PerformTransfer(0, linkHandle, deliveryID, []byte("hello")))
+			}
+			// ignore future flow frames as we have no response
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			states <- ff.State
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, client.Close()) })
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		SettlementMode: ReceiverSettleModeFirst.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	// the loop terminates once the context expires waiting for a second message
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	err = r.Handle(ctx, handler, opts)
+	cancel()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case state := <-states:
+		return []encoding.DeliveryState{state}
+	default:
+		return nil
+	}
+}
+
+func TestReceiverHandleAccept(t *testing.T) {
+	var received *Message
+	states := runHandlerTest(t, func(ctx context.Context, msg *Message) error {
+		received = msg
+		return nil
+	}, nil)
+	require.NotNil(t, received)
+	require.Equal(t, []byte("hello"), received.GetData())
+	require.Len(t, states, 1)
+	require.IsType(t, &encoding.StateAccepted{}, states[0])
+}
+
+func TestReceiverHandleErrorModify(t *testing.T) {
+	states := runHandlerTest(t, func(ctx context.Context, msg *Message) error {
+		return errors.New("can't process this")
+	}, &HandleOptions{
+		Annotations:       Annotations{"failure": "handler"},
+		UndeliverableHere: true,
+	})
+	require.Len(t, states, 1)
+	mod, ok := states[0].(*encoding.StateModified)
+	require.True(t, ok)
+	require.True(t, mod.DeliveryFailed)
+	require.True(t, mod.UndeliverableHere)
+	require.Equal(t, "handler", mod.MessageAnnotations["failure"])
+}
+
+func TestReceiverHandlePanicRelease(t *testing.T) {
+	states := runHandlerTest(t, func(ctx context.Context, msg *Message) error {
+		panic("boom")
+	}, &HandleOptions{
+		FailureOutcome: FailureOutcomeRelease,
+	})
+	require.Len(t, states, 1)
+	require.IsType(t, &encoding.StateReleased{}, states[0])
+}
+
+func TestReceiverHandleErrorReject(t *testing.T) {
+	states := runHandlerTest(t, func(ctx context.Context, msg *Message) error {
+		return errors.New("invalid message")
+	}, &HandleOptions{
+		FailureOutcome: FailureOutcomeReject,
+	})
+	require.Len(t, states, 1)
+	rej, ok := states[0].(*encoding.StateRejected)
+	require.True(t, ok)
+	require.NotNil(t, rej.Error)
+	require.Equal(t, ErrCondInternalError, rej.Error.Condition)
+	require.Equal(t, "invalid message", rej.Error.Description)
+}
+
+func TestReceiverHandleInvalidOptions(t *testing.T) {
+	r := newTestLink(t)
+	err := r.Handle(context.Background(), func(ctx context.Context, msg *Message) error { return nil }, &HandleOptions{
+		FailureOutcome: 42,
+	})
+	require.EqualError(t, err, "invalid FailureOutcome 42")
+}
@@ -0,0 +1,45 @@
+package amqp
+
+// IBM MQ's AMQP 1.0 channel is topic-only and stricter than most brokers
+// about terminus configuration: addresses use the topic:// scheme, the
+// broker-side terminus must carry the "topic" capability, and receiver
+// settlement is limited to mode first.  The helpers below produce
+// source/target configurations the channel accepts; attaching with
+// incompatible ones is rejected with an amqp:precondition-failed error.
+
+// capability IBM MQ's AMQP channel requires on the broker-side terminus.
+const capabilityIBMMQTopic = "topic"
+
+// IBMMQTopicAddress returns the address of a topic in the form IBM MQ's
+// AMQP channel expects, e.g. "topic://sports/results".
+func IBMMQTopicAddress(topic string) string {
+	return "topic://" + topic
+}
+
+// IBMMQSenderOptions returns sender options valid for publishing to a
+// topic on an IBM MQ AMQP channel: the target carries the required
+// "topic" capability, deliveries are sent unsettled, and the peer is
+// asked to settle first.  Pass the returned options, with any further
+// adjustments, to [Session.NewSender] along with a target address from
+// [IBMMQTopicAddress].
+func IBMMQSenderOptions() *SenderOptions {
+	return &SenderOptions{
+		RequestedReceiverSettleMode: ReceiverSettleModeFirst.Ptr(),
+		// the channel doesn't support mode mixed, this library's default
+		SettlementMode:     SenderSettleModeUnsettled.Ptr(),
+		TargetCapabilities: []string{capabilityIBMMQTopic},
+	}
+}
+
+// IBMMQReceiverOptions returns receiver options valid for subscribing to
+// a topic on an IBM MQ AMQP channel: the source carries the required
+// "topic" capability and deliveries are settled in mode first, the only
+// receiver settlement mode the channel supports.  Pass the returned
+// options, with any further adjustments, to [Session.NewReceiver] along
+// with a source address from [IBMMQTopicAddress].
+func IBMMQReceiverOptions() *ReceiverOptions {
+	return &ReceiverOptions{
+		SettlementMode:     ReceiverSettleModeFirst.Ptr(),
+		SourceCapabilities: []string{capabilityIBMMQTopic},
+	}
+}
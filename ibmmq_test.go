@@ -0,0 +1,81 @@
+package amqp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIBMMQTopicAddress(t *testing.T) {
+	require.Equal(t, "topic://sports/results", IBMMQTopicAddress("sports/results"))
+}
+
+func TestIBMMQSenderOptions(t *testing.T) {
+	attaches := make(chan *frames.PerformAttach, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if pa, ok := req.(*frames.PerformAttach); ok {
+			attaches <- pa
+		}
+		return senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewSender(ctx, IBMMQTopicAddress("sports/results"), IBMMQSenderOptions())
+	cancel()
+	require.NoError(t, err)
+
+	attach := <-attaches
+	require.Equal(t, "topic://sports/results", attach.Target.Address)
+	require.Contains(t, attach.Target.Capabilities, encoding.Symbol("topic"))
+	require.Equal(t, encoding.SenderSettleModeUnsettled, *attach.SenderSettleMode)
+	require.Equal(t, encoding.ReceiverSettleModeFirst, *attach.ReceiverSettleMode)
+
+	require.NoError(t, client.Close())
+}
+
+func TestIBMMQReceiverOptions(t *testing.T) {
+	attaches := make(chan *frames.PerformAttach, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if pa, ok := req.(*frames.PerformAttach); ok {
+			attaches <- pa
+		}
+		return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewReceiver(ctx, IBMMQTopicAddress("sports/results"), IBMMQReceiverOptions())
+	cancel()
+	require.NoError(t, err)
+
+	attach := <-attaches
+	require.Equal(t, "topic://sports/results", attach.Source.Address)
+	require.Contains(t, attach.Source.Capabilities, encoding.Symbol("topic"))
+	require.Equal(t, encoding.ReceiverSettleModeFirst, *attach.ReceiverSettleMode)
+
+	require.NoError(t, client.Close())
+}
@@ -10,6 +10,17 @@ package debug
 // The greater the value, the more verbose messages will be logged.
 func Log(_ int, _ string, _ ...any) {}
 
+// LogC is like Log but tags the line with the provided correlation ID
+// and honors any per-ID verbosity override set via SetLevel.
+func LogC(_ string, _ int, _ string, _ ...any) {}
+
+// SetLevel overrides the verbosity for log lines tagged with the
+// provided correlation ID.
+func SetLevel(_ string, _ int) {}
+
+// ClearLevel removes any verbosity override for the provided correlation ID.
+func ClearLevel(_ string) {}
+
 // Assert panics if the specified condition is false.
 func Assert(bool) {}
 
@@ -8,11 +8,15 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"sync"
 )
 
 var (
 	debugLevel = 1
 	logger     = log.New(os.Stderr, "", log.Lmicroseconds)
+
+	levelMu sync.RWMutex
+	levels  = map[string]int{} // per-correlation-ID verbosity overrides
 )
 
 func init() {
@@ -33,6 +37,36 @@ func Log(level int, format string, v ...any) {
 	}
 }
 
+// LogC is like Log but tags the line with the provided correlation ID
+// and honors any per-ID verbosity override set via SetLevel.
+func LogC(id string, level int, format string, v ...any) {
+	levelMu.RLock()
+	max, ok := levels[id]
+	levelMu.RUnlock()
+	if !ok {
+		max = debugLevel
+	}
+
+	if level <= max {
+		logger.Printf("["+id+"] "+format, v...)
+	}
+}
+
+// SetLevel overrides the verbosity for log lines tagged with the
+// provided correlation ID.
+func SetLevel(id string, level int) {
+	levelMu.Lock()
+	levels[id] = level
+	levelMu.Unlock()
+}
+
+// ClearLevel removes any verbosity override for the provided correlation ID.
+func ClearLevel(id string) {
+	levelMu.Lock()
+	delete(levels, id)
+	levelMu.Unlock()
+}
+
 // Assert panics if the specified condition is false.
 func Assert(condition bool) {
 	if !condition {
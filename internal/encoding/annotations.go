@@ -0,0 +1,100 @@
+package encoding
+
+// Annotation keys are encoded in a handful of forms: symbols and strings
+// for vendor-prefixed names (e.g. "x-opt-*"), and the various integer
+// widths for ulong keys.  Decoders differ in which Go type they produce,
+// making direct map indexing a recurring source of interop bugs.  The
+// helpers below compare keys by value rather than by Go type.
+
+// Get returns the value for key, matching symbol and string key forms
+// as well as integral keys of any width transparently.
+func (a Annotations) Get(key any) (any, bool) {
+	if v, ok := a[key]; ok {
+		return v, true
+	}
+	for k, v := range a {
+		if annotationKeysEqual(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Set stores value under key, replacing any existing entry whose key
+// matches key per Get's rules.
+func (a Annotations) Set(key, value any) {
+	for k := range a {
+		if annotationKeysEqual(k, key) {
+			delete(a, k)
+		}
+	}
+	a[key] = value
+}
+
+// Delete removes the entries whose keys match key per Get's rules.
+func (a Annotations) Delete(key any) {
+	for k := range a {
+		if annotationKeysEqual(k, key) {
+			delete(a, k)
+		}
+	}
+}
+
+// annotationKeysEqual reports whether two annotation keys refer to the
+// same entry regardless of their Go types.
+func annotationKeysEqual(k1, k2 any) bool {
+	if s1, ok := annotationKeyString(k1); ok {
+		s2, ok := annotationKeyString(k2)
+		return ok && s1 == s2
+	}
+	if m1, neg1, ok := annotationKeyInt(k1); ok {
+		m2, neg2, ok := annotationKeyInt(k2)
+		return ok && m1 == m2 && neg1 == neg2
+	}
+	return k1 == k2
+}
+
+// annotationKeyString returns the string form of a symbol or string key.
+func annotationKeyString(k any) (string, bool) {
+	switch k := k.(type) {
+	case string:
+		return k, true
+	case Symbol:
+		return string(k), true
+	default:
+		return "", false
+	}
+}
+
+// annotationKeyInt returns the magnitude and sign of an integral key.
+func annotationKeyInt(k any) (magnitude uint64, negative bool, ok bool) {
+	var i int64
+	switch k := k.(type) {
+	case int:
+		i = int64(k)
+	case int8:
+		i = int64(k)
+	case int16:
+		i = int64(k)
+	case int32:
+		i = int64(k)
+	case int64:
+		i = k
+	case uint:
+		return uint64(k), false, true
+	case uint8:
+		return uint64(k), false, true
+	case uint16:
+		return uint64(k), false, true
+	case uint32:
+		return uint64(k), false, true
+	case uint64:
+		return k, false, true
+	default:
+		return 0, false, false
+	}
+	if i < 0 {
+		return uint64(-i), true, true
+	}
+	return uint64(i), false, true
+}
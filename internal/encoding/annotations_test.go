@@ -0,0 +1,69 @@
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationsGet(t *testing.T) {
+	a := Annotations{
+		Symbol("x-opt-partition-key"): "key",
+		uint64(0x13):                  "ulong",
+		"plain":                       "string",
+	}
+
+	// symbol and string key forms match either way
+	v, ok := a.Get("x-opt-partition-key")
+	require.True(t, ok)
+	require.Equal(t, "key", v)
+	v, ok = a.Get(Symbol("x-opt-partition-key"))
+	require.True(t, ok)
+	require.Equal(t, "key", v)
+	v, ok = a.Get(Symbol("plain"))
+	require.True(t, ok)
+	require.Equal(t, "string", v)
+
+	// integral keys match across widths
+	for _, key := range []any{int(0x13), int32(0x13), int64(0x13), uint32(0x13), uint64(0x13)} {
+		v, ok = a.Get(key)
+		require.True(t, ok, "key %T", key)
+		require.Equal(t, "ulong", v)
+	}
+
+	_, ok = a.Get("x-opt-missing")
+	require.False(t, ok)
+	_, ok = a.Get(int64(-0x13))
+	require.False(t, ok)
+}
+
+func TestAnnotationsSet(t *testing.T) {
+	a := Annotations{
+		Symbol("x-opt-offset"): int64(1),
+	}
+
+	// setting via the string form replaces the symbol-keyed entry
+	a.Set("x-opt-offset", int64(2))
+	require.Len(t, a, 1)
+	v, ok := a.Get(Symbol("x-opt-offset"))
+	require.True(t, ok)
+	require.Equal(t, int64(2), v)
+
+	a.Set(uint64(5), "ulong")
+	a.Set(int32(5), "replaced")
+	require.Len(t, a, 2)
+	v, ok = a.Get(uint64(5))
+	require.True(t, ok)
+	require.Equal(t, "replaced", v)
+}
+
+func TestAnnotationsDelete(t *testing.T) {
+	a := Annotations{
+		Symbol("x-opt-offset"): int64(1),
+		uint64(5):              "ulong",
+	}
+
+	a.Delete("x-opt-offset")
+	a.Delete(int(5))
+	require.Empty(t, a)
+}
@@ -0,0 +1,19 @@
+package encoding
+
+// Marshaler allows a Go type to provide its own AMQP encoding.
+// The codec consults it for types it has no native encoding for,
+// marshaling the returned value in the type's place.
+type Marshaler interface {
+	// MarshalAMQP returns a value the codec natively encodes,
+	// e.g. a map, list, or primitive.
+	MarshalAMQP() (any, error)
+}
+
+// Unmarshaler allows a Go type to populate itself from a decoded AMQP
+// value.  The codec consults it when decoding into destination types it
+// has no native decoding for.
+type Unmarshaler interface {
+	// UnmarshalAMQP populates the type from v, the decoded form of an
+	// AMQP value, e.g. a map, list, or primitive.
+	UnmarshalAMQP(v any) error
+}
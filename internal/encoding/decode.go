@@ -196,6 +196,10 @@ func Unmarshal(r *buffer.Buffer, i any) error {
 			*t = new(StateRejected)
 		case TypeCodeStateReleased:
 			*t = new(StateReleased)
+		case TypeCodeDeclared:
+			*t = new(Declared)
+		case TypeCodeTransactionalState:
+			*t = new(TransactionalState)
 		default:
 			return fmt.Errorf("unexpected type %d for deliveryState", type_)
 		}
@@ -210,6 +214,12 @@ func Unmarshal(r *buffer.Buffer, i any) error {
 
 	case unmarshaler:
 		return t.Unmarshal(r)
+	case Unmarshaler:
+		v, err := ReadAny(r)
+		if err != nil {
+			return err
+		}
+		return t.UnmarshalAMQP(v)
 	default:
 		// handle **T
 		v := reflect.Indirect(reflect.ValueOf(i))
@@ -744,8 +754,10 @@ func readComposite(r *buffer.Buffer) (any, error) {
 	if compositeType > math.MaxUint8 {
 		// try as described type
 		var dt DescribedType
-		err := dt.Unmarshal(r)
-		return dt, err
+		if err := dt.Unmarshal(r); err != nil {
+			return nil, err
+		}
+		return unmarshalDescribed(dt)
 	}
 
 	switch AMQPType(compositeType) {
@@ -794,6 +806,28 @@ func readComposite(r *buffer.Buffer) (any, error) {
 		t := new(StateReleased)
 		err := t.Unmarshal(r)
 		return t, err
+	case TypeCodeTransactionalState:
+		t := new(TransactionalState)
+		err := t.Unmarshal(r)
+		return t, err
+
+	// Transactions
+	case TypeCodeCoordinator:
+		t := new(Coordinator)
+		err := t.Unmarshal(r)
+		return t, err
+	case TypeCodeDeclare:
+		t := new(Declare)
+		err := t.Unmarshal(r)
+		return t, err
+	case TypeCodeDischarge:
+		t := new(Discharge)
+		err := t.Unmarshal(r)
+		return t, err
+	case TypeCodeDeclared:
+		t := new(Declared)
+		err := t.Unmarshal(r)
+		return t, err
 
 	case TypeCodeOpen,
 		TypeCodeBegin,
@@ -825,8 +859,10 @@ func readComposite(r *buffer.Buffer) (any, error) {
 	default:
 		// try as described type
 		var dt DescribedType
-		err := dt.Unmarshal(r)
-		return dt, err
+		if err := dt.Unmarshal(r); err != nil {
+			return nil, err
+		}
+		return unmarshalDescribed(dt)
 	}
 }
 
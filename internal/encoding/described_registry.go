@@ -0,0 +1,93 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Azure/go-amqp/internal/buffer"
+)
+
+// registry of described types, mapped in both directions: descriptor to
+// decoder for inbound values and Go type to encoder for outbound ones.
+// registration is expected at program start-up while lookups occur on
+// hot paths, hence the RWMutex.
+var (
+	describedMu       sync.RWMutex
+	describedDecoders = map[any]func(any) (any, error){}
+	describedEncoders = map[reflect.Type]describedEncoder{}
+)
+
+type describedEncoder struct {
+	descriptor any
+	encode     func(any) (any, error)
+}
+
+// descriptorKey normalizes a descriptor for use as a registry key,
+// accepting the symbolic and numeric forms the wire can carry.
+func descriptorKey(descriptor any) (any, error) {
+	switch d := descriptor.(type) {
+	case string:
+		return d, nil
+	case Symbol:
+		return string(d), nil
+	case uint64:
+		return d, nil
+	default:
+		return nil, fmt.Errorf("invalid descriptor type %T", descriptor)
+	}
+}
+
+// RegisterDescribedType registers typ as a described type: values of typ
+// marshal as descriptor plus the result of encode, and described values
+// carrying descriptor unmarshal via decode.
+func RegisterDescribedType(descriptor any, typ reflect.Type, encode func(any) (any, error), decode func(any) (any, error)) error {
+	key, err := descriptorKey(descriptor)
+	if err != nil {
+		return err
+	}
+	describedMu.Lock()
+	defer describedMu.Unlock()
+	if _, ok := describedDecoders[key]; ok {
+		return fmt.Errorf("descriptor %v is already registered", descriptor)
+	}
+	if _, ok := describedEncoders[typ]; ok {
+		return fmt.Errorf("type %s is already registered", typ)
+	}
+	describedDecoders[key] = decode
+	describedEncoders[typ] = describedEncoder{descriptor: descriptor, encode: encode}
+	return nil
+}
+
+// marshalDescribed encodes i as a described value when its type has been
+// registered.  ok is false when it hasn't.
+func marshalDescribed(wr *buffer.Buffer, i any) (ok bool, err error) {
+	describedMu.RLock()
+	enc, ok := describedEncoders[reflect.TypeOf(i)]
+	describedMu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	value, err := enc.encode(i)
+	if err != nil {
+		return true, err
+	}
+	return true, DescribedType{Descriptor: enc.descriptor, Value: value}.Marshal(wr)
+}
+
+// unmarshalDescribed converts dt into its registered Go type, returning
+// dt unchanged when its descriptor hasn't been registered.
+func unmarshalDescribed(dt DescribedType) (any, error) {
+	key, err := descriptorKey(dt.Descriptor)
+	if err != nil {
+		// a descriptor form we don't key on; surface the raw value
+		return dt, nil
+	}
+	describedMu.RLock()
+	decode, ok := describedDecoders[key]
+	describedMu.RUnlock()
+	if !ok {
+		return dt, nil
+	}
+	return decode(dt.Value)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
 	"time"
 	"unicode/utf8"
 
@@ -16,6 +17,14 @@ type marshaler interface {
 }
 
 func Marshal(wr *buffer.Buffer, i any) error {
+	// nil pointers and nil maps encode as null, matching the untyped nil
+	// case below; an empty (non-nil) map encodes as an empty map.  this
+	// keeps the dereferences in the typed cases safe.
+	if isNilForEncoding(i) {
+		wr.AppendByte(byte(TypeCodeNull))
+		return nil
+	}
+
 	switch t := i.(type) {
 	case nil:
 		wr.AppendByte(byte(TypeCodeNull))
@@ -190,11 +199,37 @@ func Marshal(wr *buffer.Buffer, i any) error {
 	case marshaler:
 		return t.Marshal(wr)
 	default:
+		if ok, err := marshalDescribed(wr, i); ok {
+			return err
+		}
+		if m, ok := i.(Marshaler); ok {
+			v, err := m.MarshalAMQP()
+			if err != nil {
+				return err
+			}
+			return Marshal(wr, v)
+		}
 		return fmt.Errorf("marshal not implemented for %T", i)
 	}
 	return nil
 }
 
+// isNilForEncoding reports whether i is a typed nil that encodes as null:
+// a nil pointer or a nil map.  Nil slices are excluded as they encode as
+// empty arrays/binary, preserving the empty-vs-absent distinction peers
+// rely on for list-like fields.
+func isNilForEncoding(i any) bool {
+	if i == nil {
+		return false // the type switch's nil case handles this
+	}
+	switch v := reflect.ValueOf(i); v.Kind() {
+	case reflect.Ptr, reflect.Map:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
 func writeInt32(wr *buffer.Buffer, n int32) {
 	if n < 128 && n >= -128 {
 		wr.Append([]byte{
@@ -454,7 +454,6 @@ func (t DescribedType) String() string {
 
 // DeliveryState encapsulates the various concrete delivery states.
 // http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-messaging-v1.0-os.html#section-delivery-state
-// TODO: http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-transactions-v1.0-os.html#type-declared
 type DeliveryState interface {
 	deliveryState() // marker method
 }
@@ -669,3 +668,202 @@ func (sm *StateModified) Unmarshal(r *buffer.Buffer) error {
 func (sm *StateModified) String() string {
 	return fmt.Sprintf("StateModified{DeliveryFailed: %t, UndeliverableHere: %t, MessageAnnotations: %v}", sm.DeliveryFailed, sm.UndeliverableHere, sm.MessageAnnotations)
 }
+
+/*
+<type name="transactional-state" class="composite" source="list" provides="delivery-state">
+    <descriptor name="amqp:transactional-state:list" code="0x00000000:0x00000034"/>
+    <field name="txn-id" type="*" mandatory="true" requires="txn-id"/>
+    <field name="outcome" type="*" requires="outcome"/>
+</type>
+*/
+
+// TransactionalState associates a delivery's work with a transaction.
+// On a transfer it enrolls the delivery in the transaction; on a disposition
+// it carries the provisional outcome that becomes effective when the
+// transaction is discharged.
+// http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-transactions-v1.0-os.html#type-transactional-state
+type TransactionalState struct {
+	// TxnID identifies the transaction with which the work is associated.
+	TxnID []byte
+
+	// Outcome is the provisional outcome to be applied on a successful
+	// discharge; nil on transfers, where only enrollment is conveyed.
+	Outcome DeliveryState
+}
+
+func (ts *TransactionalState) deliveryState() {}
+
+// Marshal encodes this type into a buffer. It is not intended for public use.
+func (ts *TransactionalState) Marshal(wr *buffer.Buffer) error {
+	return MarshalComposite(wr, TypeCodeTransactionalState, []MarshalField{
+		{Value: &ts.TxnID, Omit: false},
+		{Value: ts.Outcome, Omit: ts.Outcome == nil},
+	})
+}
+
+// Unmarshal decodes a buffer into this type. It is not intended for public use.
+func (ts *TransactionalState) Unmarshal(r *buffer.Buffer) error {
+	return UnmarshalComposite(r, TypeCodeTransactionalState, []UnmarshalField{
+		{Field: &ts.TxnID, HandleNull: func() error { return errors.New("TransactionalState.TxnID is required") }},
+		{Field: &ts.Outcome},
+	}...)
+}
+
+// String implements the [fmt.Stringer] interface.
+// Note that the values are for diagnostic purposes and may change over time.
+func (ts *TransactionalState) String() string {
+	return fmt.Sprintf("TransactionalState{TxnID: %v, Outcome: %v}", ts.TxnID, ts.Outcome)
+}
+
+/*
+<type name="coordinator" class="composite" source="list" provides="target">
+    <descriptor name="amqp:coordinator:list" code="0x00000000:0x00000030"/>
+    <field name="capabilities" type="symbol" multiple="true"/>
+</type>
+*/
+
+// Coordinator is the target of a transaction control link; it takes the
+// place of the target in the attach frame.
+// http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-transactions-v1.0-os.html#type-coordinator
+type Coordinator struct {
+	// Capabilities are the transactional capabilities
+	// (e.g. "amqp:local-transactions") desired or, in the reply of the
+	// coordinator, supported.
+	Capabilities MultiSymbol
+}
+
+// Marshal encodes this type into a buffer. It is not intended for public use.
+func (c *Coordinator) Marshal(wr *buffer.Buffer) error {
+	return MarshalComposite(wr, TypeCodeCoordinator, []MarshalField{
+		{Value: &c.Capabilities, Omit: len(c.Capabilities) == 0},
+	})
+}
+
+// Unmarshal decodes a buffer into this type. It is not intended for public use.
+func (c *Coordinator) Unmarshal(r *buffer.Buffer) error {
+	return UnmarshalComposite(r, TypeCodeCoordinator,
+		UnmarshalField{Field: &c.Capabilities},
+	)
+}
+
+// String implements the [fmt.Stringer] interface.
+// Note that the values are for diagnostic purposes and may change over time.
+func (c *Coordinator) String() string {
+	return fmt.Sprintf("Coordinator{Capabilities: %v}", c.Capabilities)
+}
+
+/*
+<type name="declare" class="composite" source="list" provides="message-body">
+    <descriptor name="amqp:declare:list" code="0x00000000:0x00000031"/>
+    <field name="global-id" type="*" requires="global-tx-id"/>
+</type>
+*/
+
+// Declare is the message body sent on a control link to declare a
+// transaction with the coordinator.
+// http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-transactions-v1.0-os.html#type-declare
+type Declare struct {
+	// GlobalID identifies a global transaction; nil for local transactions,
+	// which are the only kind the specification defines.
+	GlobalID any
+}
+
+// Marshal encodes this type into a buffer. It is not intended for public use.
+func (d *Declare) Marshal(wr *buffer.Buffer) error {
+	return MarshalComposite(wr, TypeCodeDeclare, []MarshalField{
+		{Value: d.GlobalID, Omit: d.GlobalID == nil},
+	})
+}
+
+// Unmarshal decodes a buffer into this type. It is not intended for public use.
+func (d *Declare) Unmarshal(r *buffer.Buffer) error {
+	return UnmarshalComposite(r, TypeCodeDeclare,
+		UnmarshalField{Field: &d.GlobalID},
+	)
+}
+
+// String implements the [fmt.Stringer] interface.
+// Note that the values are for diagnostic purposes and may change over time.
+func (d *Declare) String() string {
+	return fmt.Sprintf("Declare{GlobalID: %v}", d.GlobalID)
+}
+
+/*
+<type name="discharge" class="composite" source="list" provides="message-body">
+    <descriptor name="amqp:discharge:list" code="0x00000000:0x00000032"/>
+    <field name="txn-id" type="*" mandatory="true" requires="txn-id"/>
+    <field name="fail" type="boolean"/>
+</type>
+*/
+
+// Discharge is the message body sent on a control link to complete a
+// transaction: committing its work when Fail is false, discarding it
+// when Fail is true.
+// http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-transactions-v1.0-os.html#type-discharge
+type Discharge struct {
+	// TxnID identifies the transaction to discharge.
+	TxnID []byte
+
+	// Fail indicates the work associated with the transaction is to be
+	// rolled back rather than committed.
+	Fail bool
+}
+
+// Marshal encodes this type into a buffer. It is not intended for public use.
+func (d *Discharge) Marshal(wr *buffer.Buffer) error {
+	return MarshalComposite(wr, TypeCodeDischarge, []MarshalField{
+		{Value: &d.TxnID, Omit: false},
+		{Value: &d.Fail, Omit: !d.Fail},
+	})
+}
+
+// Unmarshal decodes a buffer into this type. It is not intended for public use.
+func (d *Discharge) Unmarshal(r *buffer.Buffer) error {
+	return UnmarshalComposite(r, TypeCodeDischarge, []UnmarshalField{
+		{Field: &d.TxnID, HandleNull: func() error { return errors.New("Discharge.TxnID is required") }},
+		{Field: &d.Fail},
+	}...)
+}
+
+// String implements the [fmt.Stringer] interface.
+// Note that the values are for diagnostic purposes and may change over time.
+func (d *Discharge) String() string {
+	return fmt.Sprintf("Discharge{TxnID: %v, Fail: %t}", d.TxnID, d.Fail)
+}
+
+/*
+<type name="declared" class="composite" source="list" provides="delivery-state, outcome">
+    <descriptor name="amqp:declared:list" code="0x00000000:0x00000033"/>
+    <field name="txn-id" type="*" mandatory="true" requires="txn-id"/>
+</type>
+*/
+
+// Declared is the outcome of a successful declare, carrying the ID the
+// coordinator allocated for the transaction.
+// http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-transactions-v1.0-os.html#type-declared
+type Declared struct {
+	// TxnID identifies the declared transaction.
+	TxnID []byte
+}
+
+func (d *Declared) deliveryState() {}
+
+// Marshal encodes this type into a buffer. It is not intended for public use.
+func (d *Declared) Marshal(wr *buffer.Buffer) error {
+	return MarshalComposite(wr, TypeCodeDeclared, []MarshalField{
+		{Value: &d.TxnID, Omit: false},
+	})
+}
+
+// Unmarshal decodes a buffer into this type. It is not intended for public use.
+func (d *Declared) Unmarshal(r *buffer.Buffer) error {
+	return UnmarshalComposite(r, TypeCodeDeclared,
+		UnmarshalField{Field: &d.TxnID, HandleNull: func() error { return errors.New("Declared.TxnID is required") }},
+	)
+}
+
+// String implements the [fmt.Stringer] interface.
+// Note that the values are for diagnostic purposes and may change over time.
+func (d *Declared) String() string {
+	return fmt.Sprintf("Declared{TxnID: %v}", d.TxnID)
+}
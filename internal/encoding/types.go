@@ -101,6 +101,12 @@ const (
 	TypeCodeStateReleased AMQPType = 0x26
 	TypeCodeStateModified AMQPType = 0x27
 
+	TypeCodeCoordinator        AMQPType = 0x30
+	TypeCodeDeclare            AMQPType = 0x31
+	TypeCodeDischarge          AMQPType = 0x32
+	TypeCodeDeclared           AMQPType = 0x33
+	TypeCodeTransactionalState AMQPType = 0x34
+
 	TypeCodeSASLMechanism AMQPType = 0x40
 	TypeCodeSASLInit      AMQPType = 0x41
 	TypeCodeSASLChallenge AMQPType = 0x42
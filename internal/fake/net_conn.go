@@ -258,7 +258,12 @@ func (n *NetConn) SetReadDeadline(t time.Time) error {
 	// called by conn.connReader before calling Read
 	// stop the last timer if available
 	if n.readDL != nil && !n.readDL.Stop() {
-		<-n.readDL.C()
+		select {
+		case <-n.readDL.C():
+			// drained the expired value
+		default:
+			// the expired value was already consumed by Read
+		}
 	}
 	n.readDL = timer{t: time.NewTimer(time.Until(t))}
 	return nil
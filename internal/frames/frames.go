@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/Azure/go-amqp/internal/buffer"
@@ -639,6 +640,13 @@ type PerformAttach struct {
 	// attached to the link. A link with no target will never permit incoming messages.
 	Target *Target
 
+	// the transaction coordinator
+	//
+	// On a transaction control link the coordinator takes the place of the
+	// target; when set, it's encoded in the attach frame's target field and
+	// Target must be nil.
+	Coordinator *encoding.Coordinator
+
 	// unsettled delivery state
 	//
 	// This is used to indicate any unsettled delivery states when a suspended link is
@@ -731,7 +739,7 @@ func (a *PerformAttach) Marshal(wr *buffer.Buffer) error {
 		{Value: a.SenderSettleMode, Omit: a.SenderSettleMode == nil},
 		{Value: a.ReceiverSettleMode, Omit: a.ReceiverSettleMode == nil},
 		{Value: a.Source, Omit: a.Source == nil},
-		{Value: a.Target, Omit: a.Target == nil},
+		{Value: a.targetValue(), Omit: a.Target == nil && a.Coordinator == nil},
 		{Value: a.Unsettled, Omit: len(a.Unsettled) == 0},
 		{Value: &a.IncompleteUnsettled, Omit: !a.IncompleteUnsettled},
 		{Value: &a.InitialDeliveryCount, Omit: a.Role == encoding.RoleReceiver},
@@ -742,6 +750,31 @@ func (a *PerformAttach) Marshal(wr *buffer.Buffer) error {
 	})
 }
 
+// targetValue returns the value for the attach frame's target field: the
+// coordinator on a transaction control link, else the target.
+func (a *PerformAttach) targetValue() any {
+	if a.Coordinator != nil {
+		return a.Coordinator
+	}
+	return a.Target
+}
+
+// attachTarget decodes the attach frame's target field, which carries
+// either a target or, on a transaction control link, a coordinator.
+type attachTarget PerformAttach
+
+func (t *attachTarget) Unmarshal(r *buffer.Buffer) error {
+	type_, _, err := encoding.PeekMessageType(r.Bytes())
+	if err != nil {
+		return err
+	}
+	if encoding.AMQPType(type_) == encoding.TypeCodeCoordinator {
+		t.Coordinator = new(encoding.Coordinator)
+		return t.Coordinator.Unmarshal(r)
+	}
+	return encoding.Unmarshal(r, &t.Target)
+}
+
 func (a *PerformAttach) Unmarshal(r *buffer.Buffer) error {
 	return encoding.UnmarshalComposite(r, encoding.TypeCodeAttach, []encoding.UnmarshalField{
 		{Field: &a.Name, HandleNull: func() error { return errors.New("Attach.Name is required") }},
@@ -750,7 +783,7 @@ func (a *PerformAttach) Unmarshal(r *buffer.Buffer) error {
 		{Field: &a.SenderSettleMode},
 		{Field: &a.ReceiverSettleMode},
 		{Field: &a.Source},
-		{Field: &a.Target},
+		{Field: (*attachTarget)(a)},
 		{Field: &a.Unsettled},
 		{Field: &a.IncompleteUnsettled},
 		{Field: &a.InitialDeliveryCount},
@@ -1124,8 +1157,70 @@ func (t PerformTransfer) String() string {
 	)
 }
 
+// transferTemplates caches the encoded performative for continuation
+// transfers, keyed by handle.  Every non-first frame of a multi-frame
+// delivery encodes to the same bytes for a given handle, so the hot
+// path appends the cached prefix instead of re-marshaling it.  Handles
+// are reused starting from the lowest available value, keeping the
+// cache bounded by a link's peak handle count.
+var (
+	transferTemplatesMu sync.RWMutex
+	transferTemplates   = map[uint32][]byte{}
+)
+
+// isContinuation reports whether t carries only the handle and more
+// flag, i.e. it's a non-first frame of a multi-frame delivery.
+func (t *PerformTransfer) isContinuation() bool {
+	return t.More && t.DeliveryID == nil && len(t.DeliveryTag) == 0 &&
+		t.MessageFormat == nil && !t.Settled && t.ReceiverSettleMode == nil &&
+		t.State == nil && !t.Resume && !t.Aborted && !t.Batchable
+}
+
+// continuationTemplate returns the cached performative bytes for a
+// continuation transfer on handle, encoding them on first use.
+func continuationTemplate(handle uint32) ([]byte, error) {
+	transferTemplatesMu.RLock()
+	template := transferTemplates[handle]
+	transferTemplatesMu.RUnlock()
+	if template != nil {
+		return template, nil
+	}
+
+	buf := &buffer.Buffer{}
+	t := PerformTransfer{Handle: handle, More: true}
+	if err := t.marshalFields(buf); err != nil {
+		return nil, err
+	}
+	template = buf.Detach()
+
+	transferTemplatesMu.Lock()
+	transferTemplates[handle] = template
+	transferTemplatesMu.Unlock()
+	return template, nil
+}
+
 func (t *PerformTransfer) Marshal(wr *buffer.Buffer) error {
-	err := encoding.MarshalComposite(wr, encoding.TypeCodeTransfer, []encoding.MarshalField{
+	if t.isContinuation() {
+		template, err := continuationTemplate(t.Handle)
+		if err != nil {
+			return err
+		}
+		wr.Append(template)
+		wr.Append(t.Payload)
+		return nil
+	}
+
+	err := t.marshalFields(wr)
+	if err != nil {
+		return err
+	}
+
+	wr.Append(t.Payload)
+	return nil
+}
+
+func (t *PerformTransfer) marshalFields(wr *buffer.Buffer) error {
+	return encoding.MarshalComposite(wr, encoding.TypeCodeTransfer, []encoding.MarshalField{
 		{Value: &t.Handle},
 		{Value: t.DeliveryID, Omit: t.DeliveryID == nil},
 		{Value: &t.DeliveryTag, Omit: len(t.DeliveryTag) == 0},
@@ -1138,12 +1233,6 @@ func (t *PerformTransfer) Marshal(wr *buffer.Buffer) error {
 		{Value: &t.Aborted, Omit: !t.Aborted},
 		{Value: &t.Batchable, Omit: !t.Batchable},
 	})
-	if err != nil {
-		return err
-	}
-
-	wr.Append(t.Payload)
-	return nil
 }
 
 func (t *PerformTransfer) Unmarshal(r *buffer.Buffer) error {
@@ -1164,7 +1253,9 @@ func (t *PerformTransfer) Unmarshal(r *buffer.Buffer) error {
 		return err
 	}
 
-	t.Payload = append([]byte(nil), r.Bytes()...)
+	// Payload aliases r's storage; callers that retain the frame beyond
+	// the life of the buffer must copy it.
+	t.Payload = r.Bytes()
 
 	return err
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/Azure/go-amqp/internal/buffer"
 	"github.com/Azure/go-amqp/internal/encoding"
@@ -128,16 +129,45 @@ func ParseBody(r *buffer.Buffer) (FrameBody, error) {
 	}
 }
 
+// headerTemplates caches the encoded frame header per (type, channel)
+// pair.  Everything but the size is invariant per pair, so the hot
+// transfer path reuses the same eight bytes instead of re-encoding
+// them for every frame.  Bounded by the negotiated channel count.
+var (
+	headerTemplatesMu sync.RWMutex
+	headerTemplates   = map[uint32][]byte{}
+)
+
+// headerTemplate returns the cached header bytes for the (typ, channel)
+// pair, encoding them on first use.  The size field is zero; Write
+// overwrites it once the body length is known.
+func headerTemplate(typ Type, channel uint16) []byte {
+	key := uint32(typ)<<16 | uint32(channel)
+	headerTemplatesMu.RLock()
+	template := headerTemplates[key]
+	headerTemplatesMu.RUnlock()
+	if template != nil {
+		return template
+	}
+
+	template = []byte{
+		0, 0, 0, 0, // size, overwritten by Write
+		2,                                 // doff, see frameHeader.DataOffset comment
+		uint8(typ),                        // frame type
+		byte(channel >> 8), byte(channel), // channel
+	}
+
+	headerTemplatesMu.Lock()
+	headerTemplates[key] = template
+	headerTemplatesMu.Unlock()
+	return template
+}
+
 // Write encodes fr into buf.
 // split out from conn.WriteFrame for testing purposes.
 func Write(buf *buffer.Buffer, fr Frame) error {
 	// write header
-	buf.Append([]byte{
-		0, 0, 0, 0, // size, overwrite later
-		2,              // doff, see frameHeader.DataOffset comment
-		uint8(fr.Type), // frame type
-	})
-	buf.AppendUint16(fr.Channel) // channel
+	buf.Append(headerTemplate(fr.Type, fr.Channel))
 
 	// write AMQP frame body
 	err := encoding.Marshal(buf, fr.Body)
@@ -74,19 +74,46 @@ type Queue[T any] struct {
 	head *ring.Ring
 	tail *ring.Ring
 	size int
+
+	// drained segments retained for reuse; bounded by maxIdle
+	idle [][]*T
+
+	// maximum count of drained segments to retain.
+	// negative means drained segments are never unlinked from the ring.
+	maxIdle int
+}
+
+// Options contains the optional values for NewWithOptions.
+type Options struct {
+	// SegmentSize is the number of item slots in each Queue segment.
+	SegmentSize int
+
+	// MaxIdleSegments is the maximum number of drained segments retained
+	// for reuse; drained segments beyond the count are released to the
+	// garbage collector.  Set to a negative value to never release
+	// drained segments.
+	MaxIdleSegments int
 }
 
 // New creates a new instance of Queue[T].
+// Drained segments are retained indefinitely for reuse; use
+// NewWithOptions to bound retention.
 //   - size is the size of each Queue segment
 func New[T any](size int) *Queue[T] {
+	return NewWithOptions[T](Options{SegmentSize: size, MaxIdleSegments: -1})
+}
+
+// NewWithOptions creates a new instance of Queue[T] configured per opts.
+func NewWithOptions[T any](opts Options) *Queue[T] {
 	r := &ring.Ring{
 		Value: &segment[T]{
-			items: make([]*T, size),
+			items: make([]*T, opts.SegmentSize),
 		},
 	}
 	return &Queue[T]{
-		head: r,
-		tail: r,
+		head:    r,
+		tail:    r,
+		maxIdle: opts.MaxIdleSegments,
 	}
 }
 
@@ -110,10 +137,16 @@ func (q *Queue[T]) Enqueue(item T) {
 			continue
 		}
 
-		// no, add a new ring
+		// no, add a new ring, reusing a retained segment if available
+		items := make([]*T, len(seg.items))
+		if n := len(q.idle); n > 0 {
+			items = q.idle[n-1]
+			q.idle[n-1] = nil
+			q.idle = q.idle[:n-1]
+		}
 		r.Link(&ring.Ring{
 			Value: &segment[T]{
-				items: make([]*T, len(seg.items)),
+				items: items,
 			},
 		})
 
@@ -144,6 +177,15 @@ func (q *Queue[T]) Dequeue() *T {
 		// if we're not at the last ring, advance head to the next one
 		if q.head != q.tail {
 			q.head = r.Next()
+
+			if q.maxIdle >= 0 {
+				// unlink the drained segment so bursts don't pin memory,
+				// retaining up to maxIdle item slices for reuse
+				r.Prev().Unlink(1)
+				if len(q.idle) < q.maxIdle {
+					q.idle = append(q.idle, seg.items)
+				}
+			}
 		}
 	}
 
@@ -317,3 +317,54 @@ func TestChasingRingGrowth(t *testing.T) {
 	require.Same(t, q.head, q.tail)
 	require.Zero(t, q.Len())
 }
+
+func TestRecycleDrainedSegments(t *testing.T) {
+	const segSize = 10
+	q := NewWithOptions[int](Options{SegmentSize: segSize, MaxIdleSegments: 1})
+
+	for i := 0; i < segSize*3; i++ {
+		q.Enqueue(i)
+	}
+	require.EqualValues(t, 3, q.head.Len())
+
+	// draining a segment unlinks it from the ring and retains it for reuse
+	for i := 0; i < segSize; i++ {
+		v := q.Dequeue()
+		require.NotNil(t, v)
+		require.EqualValues(t, i, *v)
+	}
+	require.EqualValues(t, 2, q.head.Len())
+	require.Len(t, q.idle, 1)
+
+	// the next drained segment exceeds MaxIdleSegments and is released
+	for i := 0; i < segSize; i++ {
+		v := q.Dequeue()
+		require.NotNil(t, v)
+		require.EqualValues(t, segSize+i, *v)
+	}
+	require.EqualValues(t, 1, q.head.Len())
+	require.Len(t, q.idle, 1)
+
+	// the last segment is never unlinked
+	for i := 0; i < segSize; i++ {
+		v := q.Dequeue()
+		require.NotNil(t, v)
+		require.EqualValues(t, segSize*2+i, *v)
+	}
+	require.EqualValues(t, 1, q.head.Len())
+	require.Zero(t, q.Len())
+	require.Same(t, q.head, q.tail)
+
+	// growing again reuses the retained segment before allocating
+	for i := 0; i < segSize+1; i++ {
+		q.Enqueue(i)
+	}
+	require.EqualValues(t, 2, q.head.Len())
+	require.Empty(t, q.idle)
+	for i := 0; i < segSize+1; i++ {
+		v := q.Dequeue()
+		require.NotNil(t, v)
+		require.EqualValues(t, i, *v)
+	}
+	require.Zero(t, q.Len())
+}
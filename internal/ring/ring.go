@@ -0,0 +1,136 @@
+// Package ring provides a bounded, multi-producer/single-consumer ring
+// buffer used to hand frames from links to their session's mux with
+// explicit backpressure.
+package ring
+
+import "sync"
+
+// Enqueued is returned by [Buffer.Enqueue] when the item was added to the buffer.
+const Enqueued = -1
+
+// Buffer is a bounded MPSC ring buffer.
+// Producers block while the buffer is full, providing backpressure;
+// the single consumer is signaled as items become available.
+type Buffer[T any] struct {
+	mu    sync.Mutex
+	items []T // fixed-size backing store
+	head  int // index of the oldest item
+	count int // number of items in the buffer
+
+	notEmpty chan struct{} // cap 1; signaled when an item is available to the consumer
+	notFull  chan struct{} // cap 1; signaled when space becomes available to a producer
+}
+
+// New creates a new Buffer with the specified capacity.
+func New[T any](capacity int) *Buffer[T] {
+	return &Buffer[T]{
+		items:    make([]T, capacity),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+	}
+}
+
+// Enqueue adds item to the buffer, blocking while the buffer is full.
+// Returns [Enqueued] on success, else the index of the cancel channel
+// that was closed while waiting for space.
+func (b *Buffer[T]) Enqueue(item T, cancel ...<-chan struct{}) int {
+	for {
+		b.mu.Lock()
+		if b.count < len(b.items) {
+			b.items[(b.head+b.count)%len(b.items)] = item
+			b.count++
+			spare := b.count < len(b.items)
+			b.mu.Unlock()
+			signal(b.notEmpty)
+			if spare {
+				// pass the wakeup on to any other producer waiting for
+				// space; the cap-1 channel coalesces signals so a single
+				// dequeue can't wake all waiters
+				signal(b.notFull)
+			}
+			return Enqueued
+		}
+		b.mu.Unlock()
+
+		// buffer is full, wait for the consumer to make space.
+		// note that the signal is buffered so it's not lost if it's
+		// sent between the unlock above and the select below.
+		if i := waitFull(b.notFull, cancel); i != Enqueued {
+			return i
+		}
+	}
+}
+
+// Dequeue removes and returns the oldest item in the buffer.
+// Returns false if the buffer is empty.
+func (b *Buffer[T]) Dequeue() (T, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var zero T
+	if b.count == 0 {
+		return zero, false
+	}
+
+	item := b.items[b.head]
+	b.items[b.head] = zero // don't retain the item
+	b.head = (b.head + 1) % len(b.items)
+	b.count--
+
+	if b.count > 0 {
+		// re-arm the consumer signal for the remaining items
+		signal(b.notEmpty)
+	}
+	signal(b.notFull)
+	return item, true
+}
+
+// Wait returns the channel signaled when an item is available.
+// After receiving from the channel, call [Buffer.Dequeue] to claim the item.
+func (b *Buffer[T]) Wait() <-chan struct{} {
+	return b.notEmpty
+}
+
+// Len returns the number of items in the buffer.
+func (b *Buffer[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// signal performs a non-blocking send on ch; the channel retains
+// at most one pending signal.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// waitFull blocks until ch or one of the cancel channels is signaled.
+// Returns [Enqueued] when ch fired, else the index of the cancel channel.
+func waitFull(ch <-chan struct{}, cancel []<-chan struct{}) int {
+	switch len(cancel) {
+	case 0:
+		<-ch
+		return Enqueued
+	case 1:
+		select {
+		case <-ch:
+			return Enqueued
+		case <-cancel[0]:
+			return 0
+		}
+	case 2:
+		select {
+		case <-ch:
+			return Enqueued
+		case <-cancel[0]:
+			return 0
+		case <-cancel[1]:
+			return 1
+		}
+	default:
+		panic("ring: unsupported cancel channel count")
+	}
+}
@@ -0,0 +1,134 @@
+package ring
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnqueueDequeue(t *testing.T) {
+	b := New[int](4)
+	require.Zero(t, b.Len())
+
+	_, ok := b.Dequeue()
+	require.False(t, ok)
+
+	for i := 0; i < 4; i++ {
+		require.Equal(t, Enqueued, b.Enqueue(i))
+	}
+	require.Equal(t, 4, b.Len())
+
+	for i := 0; i < 4; i++ {
+		v, ok := b.Dequeue()
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	require.Zero(t, b.Len())
+}
+
+func TestEnqueueBlocksWhenFull(t *testing.T) {
+	b := New[int](1)
+	require.Equal(t, Enqueued, b.Enqueue(1))
+
+	enqueued := make(chan struct{})
+	go func() {
+		b.Enqueue(2)
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("enqueue didn't block on a full buffer")
+	case <-time.After(100 * time.Millisecond):
+		// expected
+	}
+
+	v, ok := b.Dequeue()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	select {
+	case <-enqueued:
+		// backpressure released
+	case <-time.After(time.Second):
+		t.Fatal("enqueue didn't unblock after dequeue")
+	}
+}
+
+func TestEnqueueCancelled(t *testing.T) {
+	b := New[int](1)
+	require.Equal(t, Enqueued, b.Enqueue(1))
+
+	cancel1 := make(chan struct{})
+	cancel2 := make(chan struct{})
+	close(cancel2)
+	require.Equal(t, 1, b.Enqueue(2, cancel1, cancel2))
+	require.Equal(t, 1, b.Len())
+}
+
+func TestWaitSignalsPerItem(t *testing.T) {
+	b := New[int](4)
+	require.Equal(t, Enqueued, b.Enqueue(1))
+	require.Equal(t, Enqueued, b.Enqueue(2))
+
+	// the signal is coalesced but re-armed on dequeue while items remain
+	for i := 1; i <= 2; i++ {
+		select {
+		case <-b.Wait():
+			v, ok := b.Dequeue()
+			require.True(t, ok)
+			require.Equal(t, i, v)
+		case <-time.After(time.Second):
+			t.Fatalf("missing signal for item %d", i)
+		}
+	}
+
+	select {
+	case <-b.Wait():
+		t.Fatal("unexpected signal on empty buffer")
+	case <-time.After(100 * time.Millisecond):
+		// expected
+	}
+}
+
+func TestConcurrentProducers(t *testing.T) {
+	const producers = 8
+	const perProducer = 100
+
+	b := New[int](4)
+	var wg sync.WaitGroup
+	wg.Add(producers)
+	for i := 0; i < producers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perProducer; j++ {
+				require.Equal(t, Enqueued, b.Enqueue(j))
+			}
+		}()
+	}
+
+	received := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for received < producers*perProducer {
+			<-b.Wait()
+			for {
+				if _, ok := b.Dequeue(); !ok {
+					break
+				}
+				received++
+			}
+		}
+	}()
+
+	wg.Wait()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("consumer stalled, received %d items", received)
+	}
+	require.Equal(t, producers*perProducer, received)
+}
@@ -0,0 +1,11 @@
+// Package serial provides RFC 1982 serial number arithmetic with
+// SERIAL_BITS of 32, matching the AMQP sequence-no type used for
+// delivery-id, transfer-id, and delivery-count.
+package serial
+
+// Less reports whether a precedes b in serial number order.
+// Note that when a and b differ by exactly 1 << 31 the comparison
+// is undefined by RFC 1982; this implementation reports false.
+func Less(a, b uint32) bool {
+	return a != b && b-a < 1<<31
+}
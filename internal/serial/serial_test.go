@@ -0,0 +1,28 @@
+package serial
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLess(t *testing.T) {
+	tests := []struct {
+		a, b uint32
+		less bool
+	}{
+		{a: 0, b: 0, less: false},
+		{a: 0, b: 1, less: true},
+		{a: 1, b: 0, less: false},
+		{a: 0, b: math.MaxUint32, less: false},
+		{a: math.MaxUint32, b: 0, less: true},     // wraparound
+		{a: math.MaxUint32 - 1, b: 5, less: true}, // wraparound
+		{a: 5, b: math.MaxUint32 - 1, less: false},
+		{a: 0, b: 1 << 31, less: false}, // undefined by RFC 1982
+		{a: 1 << 31, b: 0, less: false}, // undefined by RFC 1982
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.less, Less(tt.a, tt.b), "Less(%d, %d)", tt.a, tt.b)
+	}
+}
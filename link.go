@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Azure/go-amqp/internal/debug"
 	"github.com/Azure/go-amqp/internal/encoding"
 	"github.com/Azure/go-amqp/internal/frames"
 	"github.com/Azure/go-amqp/internal/queue"
+	"github.com/Azure/go-amqp/internal/ring"
 	"github.com/Azure/go-amqp/internal/shared"
 )
 
@@ -69,23 +72,40 @@ type link struct {
 	// properties returned by the peer
 	peerProperties map[string]any
 
+	// capabilities declared by the peer on its termini upon attach
+	peerSourceCapabilities []string
+	peerTargetCapabilities []string
+
 	senderSettleMode   *SenderSettleMode
 	receiverSettleMode *ReceiverSettleMode
 	maxMessageSize     uint64
 
 	closeInProgress bool // indicates that the detach performative has been sent
+	detachRequested bool // the pending detach is non-closing (link suspension); written before close fires, read by the mux
 	dynamicAddr     bool // request a dynamic link address from the server
 
 	desiredCapabilities encoding.MultiSymbol // maps to the ATTACH frame's desired-capabilities field
+
+	// idle link detection; disabled when idleTimeout is zero
+	idleTimeout  time.Duration
+	onIdle       func() bool
+	lastActivity int64 // unix nanos of the most recent transfer or settlement; MUST be atomically accessed
+
+	// terminus address re-issued by the peer; holds nil until the peer
+	// changes the address (see muxReissuedAttach).
+	// a pointer as the enclosing link is copied by value at construction.
+	updatedAddr     *atomic.Pointer[string]
+	onAddressChange func(newAddr string)
 }
 
 func newLink(s *Session, r encoding.Role) link {
 	l := link{
-		key:       linkKey{shared.RandString(40), r},
-		session:   s,
-		close:     make(chan struct{}),
-		closeOnce: &sync.Once{},
-		done:      make(chan struct{}),
+		key:         linkKey{shared.RandString(40), r},
+		session:     s,
+		close:       make(chan struct{}),
+		closeOnce:   &sync.Once{},
+		done:        make(chan struct{}),
+		updatedAddr: &atomic.Pointer[string]{},
 	}
 
 	// set the segment size relative to respective window
@@ -159,7 +179,7 @@ func (l *link) attach(ctx context.Context, beforeAttach func(*frames.PerformAtta
 
 	resp, ok := fr.(*frames.PerformAttach)
 	if !ok {
-		debug.Log(1, "RX (link %p): unexpected attach response frame %T", l, fr)
+		debug.LogC(l.session.conn.correlationID, 1, "RX (link %p): unexpected attach response frame %T", l, fr)
 		if err := l.session.conn.Close(); err != nil {
 			return err
 		}
@@ -175,7 +195,7 @@ func (l *link) attach(ctx context.Context, beforeAttach func(*frames.PerformAtta
 	//   session endpoint MUST immediately detach the newly created link endpoint.
 	//
 	// http://docs.oasis-open.org/amqp/core/v1.0/csprd01/amqp-core-transport-v1.0-csprd01.html#doc-idp386144
-	if resp.Source == nil && resp.Target == nil {
+	if resp.Source == nil && resp.Target == nil && resp.Coordinator == nil {
 		// wait for detach
 		fr, err := l.waitForFrame(ctx)
 		if err != nil {
@@ -234,6 +254,26 @@ func (l *link) attach(ctx context.Context, beforeAttach func(*frames.PerformAtta
 		}
 	}
 
+	// capture the capabilities the peer declared on its termini; brokers
+	// echo (or amend) the requested capabilities to describe the node the
+	// link actually attached to
+	if resp.Source != nil {
+		for _, c := range resp.Source.Capabilities {
+			l.peerSourceCapabilities = append(l.peerSourceCapabilities, string(c))
+		}
+	}
+	if resp.Target != nil {
+		for _, c := range resp.Target.Capabilities {
+			l.peerTargetCapabilities = append(l.peerTargetCapabilities, string(c))
+		}
+	}
+
+	if m := l.session.conn.metrics; m != nil {
+		m.LinkAttached()
+	}
+	l.session.conn.logEvent("link attached", l.logAttrs()...)
+	expvarAdd(expvarActiveLinks, 1)
+
 	return nil
 }
 
@@ -263,13 +303,73 @@ func (l *link) setSettleModes(resp *frames.PerformAttach) error {
 	return nil
 }
 
+// currentAddr returns the link's terminus address, preferring an address
+// re-issued by the peer over the one negotiated at attach time.
+func (l *link) currentAddr() string {
+	if addr := l.updatedAddr.Load(); addr != nil {
+		return *addr
+	}
+	if l.key.role == encoding.RoleReceiver {
+		if l.source == nil {
+			return ""
+		}
+		return l.source.Address
+	}
+	if l.target == nil {
+		return ""
+	}
+	return l.target.Address
+}
+
+// muxReissuedAttach processes an attach frame received on an established
+// link.  Brokers re-issue terminus information after link recovery; if
+// the address differs (e.g. a re-assigned dynamic address), record the
+// new one so Address() doesn't go stale and notify the application.
+func (l *link) muxReissuedAttach(fr *frames.PerformAttach) {
+	var addr string
+	switch {
+	case l.key.role == encoding.RoleReceiver && fr.Source != nil:
+		addr = fr.Source.Address
+	case l.key.role == encoding.RoleSender && fr.Target != nil:
+		addr = fr.Target.Address
+	}
+	if addr == "" || addr == l.currentAddr() {
+		return
+	}
+
+	debug.LogC(l.session.conn.correlationID, 1, "RX (link %p): terminus address changed from %q to %q", l, l.currentAddr(), addr)
+	l.updatedAddr.Store(&addr)
+	if l.onAddressChange != nil {
+		l.onAddressChange(addr)
+	}
+}
+
 // muxHandleFrame processes fr based on type.
 func (l *link) muxHandleFrame(fr frames.FrameBody) error {
 	switch fr := fr.(type) {
+	case *frames.PerformAttach:
+		l.muxReissuedAttach(fr)
+		return nil
+
 	case *frames.PerformDetach:
 		if !fr.Closed {
-			l.closeWithError(ErrCondNotImplemented, fmt.Sprintf("non-closing detach not supported: %+v", fr))
-			return nil
+			// a non-closing detach suspends the link: the handle is
+			// released but both termini retain their unsettled delivery
+			// state for resumption on re-attach.
+			if !l.closeInProgress {
+				// the peer initiated the suspension so we must ack
+				dr := &frames.PerformDetach{
+					Handle: l.outputHandle,
+				}
+				l.txFrame(&frameContext{Ctx: context.Background()}, dr)
+			}
+
+			// this is either the ack to a client-side Detach() or the ack
+			// we just sent; either way the link is now suspended
+			if l.doneErr != nil {
+				return l.doneErr
+			}
+			return &LinkError{RemoteErr: fr.Error, inner: &LinkSuspendedError{RemoteErr: fr.Error}}
 		}
 
 		// there are two possibilities:
@@ -294,7 +394,7 @@ func (l *link) muxHandleFrame(fr frames.FrameBody) error {
 		return &LinkError{RemoteErr: fr.Error}
 
 	default:
-		debug.Log(1, "RX (link %p): unexpected frame: %s", l, fr)
+		debug.LogC(l.session.conn.correlationID, 1, "RX (link %p): unexpected frame: %s", l, fr)
 		l.closeWithError(ErrCondInternalError, fmt.Sprintf("link received unexpected frame %T", fr))
 		return nil
 	}
@@ -318,7 +418,7 @@ func (l *link) closeLink(ctx context.Context) error {
 
 			// record that the close timed out/was cancelled.
 			// subsequent calls to closeLink() will return this
-			debug.Log(1, "TX (link %p) closing %s: %v", l, l.key.name, ctxErr)
+			debug.LogC(l.session.conn.correlationID, 1, "TX (link %p) closing %s: %v", l, l.key.name, ctxErr)
 			l.closeErr = &LinkError{inner: ctxErr}
 		}
 	})
@@ -335,6 +435,91 @@ func (l *link) closeLink(ctx context.Context) error {
 	return l.closeErr
 }
 
+// detachLink initiates a non-closing detach, suspending the link.
+func (l *link) detachLink(ctx context.Context) error {
+	var ctxErr error
+	l.closeOnce.Do(func() {
+		// closing l.close publishes the flag to the mux
+		l.detachRequested = true
+		close(l.close)
+
+		// once the mux has received the ack'ing detach performative, the mux will
+		// exit which deletes the link and closes l.done.
+		select {
+		case <-l.done:
+			l.closeErr = l.doneErr
+		case <-ctx.Done():
+			// notify the caller that the detach timed out/was cancelled.
+			// the mux will remain running and once the ack is received it will terminate.
+			ctxErr = ctx.Err()
+
+			// record that the detach timed out/was cancelled.
+			// subsequent calls to closeLink()/detachLink() will return this
+			debug.LogC(l.session.conn.correlationID, 1, "TX (link %p) detaching %s: %v", l, l.key.name, ctxErr)
+			l.closeErr = &LinkError{inner: ctxErr}
+		}
+	})
+
+	if ctxErr != nil {
+		return ctxErr
+	}
+
+	var linkErr *LinkError
+	if errors.As(l.closeErr, &linkErr) && linkErr.RemoteErr == nil && linkErr.inner == nil {
+		// an empty LinkError means the peer escalated to a closing detach
+		// and the link was cleanly closed instead of suspended
+		return nil
+	}
+	var suspendedErr *LinkSuspendedError
+	if errors.As(l.closeErr, &suspendedErr) && suspendedErr.RemoteErr == nil {
+		// the peer ack'd the detach without error; the link is suspended
+		return nil
+	}
+	return l.closeErr
+}
+
+// markActive records link activity for idle detection.
+func (l *link) markActive() {
+	if l.idleTimeout > 0 {
+		atomic.StoreInt64(&l.lastActivity, time.Now().UnixNano())
+	}
+}
+
+// monitorIdle periodically checks the link for activity, invoking onIdle
+// when no transfers or settlements have occurred for at least idleTimeout.
+// if onIdle returns true the link is closed.  the monitor exits when the
+// link terminates.
+func (l *link) monitorIdle() {
+	timer := time.NewTimer(l.idleTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-timer.C:
+			// fall through to the idle check
+		}
+
+		idle := time.Since(time.Unix(0, atomic.LoadInt64(&l.lastActivity)))
+		if idle < l.idleTimeout {
+			timer.Reset(l.idleTimeout - idle)
+			continue
+		}
+
+		debug.LogC(l.session.conn.correlationID, 1, "link %s idle for %s", l.key.name, idle)
+		if l.onIdle() {
+			// initiate a graceful close; the mux sends the detach and
+			// terminates once the peer acks it
+			l.closeOnce.Do(func() { close(l.close) })
+			return
+		}
+
+		// the link remains open; start a fresh idle period
+		timer.Reset(l.idleTimeout)
+	}
+}
+
 // closeWithError initiates closing the link with the specified AMQP error.
 // the mux must continue to run until the ack'ing detach is received.
 // l.doneErr is populated with a &LinkError{} containing an inner error constructed from the specified values
@@ -343,7 +528,7 @@ func (l *link) closeLink(ctx context.Context) error {
 func (l *link) closeWithError(cnd ErrCond, desc string) {
 	amqpErr := &Error{Condition: cnd, Description: desc}
 	if l.closeInProgress {
-		debug.Log(3, "TX (link %p) close error already pending, discarding %v", l, amqpErr)
+		debug.LogC(l.session.conn.correlationID, 3, "TX (link %p) close error already pending, discarding %v", l, amqpErr)
 		return
 	}
 
@@ -363,14 +548,14 @@ func (l *link) closeWithError(cnd ErrCond, desc string) {
 func (l *link) txFrame(frameCtx *frameContext, fr frames.FrameBody) {
 	// NOTE: there is no need to select on l.done as this is either
 	// called from a link's mux or before the mux has even started.
-	select {
-	case <-l.session.done:
+	switch l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: frameCtx, FrameBody: fr}, l.session.done, l.session.endSent) {
+	case ring.Enqueued:
+		debug.LogC(l.session.conn.correlationID, 2, "TX (link %p): mux frame to Session (%p): %s", l, l.session, fr)
+	case 0:
 		// the link's session has terminated, let that propagate to the link's mux
-	case <-l.session.endSent:
-		// we swallow this to prevent the link's mux from terminating.
+	case 1:
+		// endSent: we swallow this to prevent the link's mux from terminating.
 		// l.session.done will soon close so this is temporary.
-	case l.session.tx <- frameBodyEnvelope{FrameCtx: frameCtx, FrameBody: fr}:
-		debug.Log(2, "TX (link %p): mux frame to Session (%p): %s", l, l.session, fr)
 	}
 }
 
@@ -386,15 +571,15 @@ func (l *link) txFrameAndWait(ctx context.Context, fr frames.FrameBody) error {
 	// NOTE: there is no need to select on l.done as this is either
 	// called from a link's mux or before the mux has even started.
 
-	select {
-	case <-l.session.done:
+	switch l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: &frameCtx, FrameBody: fr}, l.session.done, l.session.endSent) {
+	case ring.Enqueued:
+		debug.LogC(l.session.conn.correlationID, 2, "TX (link %p): mux frame to Session (%p): %s", l, l.session, fr)
+	case 0:
 		return l.session.doneErr
-	case <-l.session.endSent:
-		// we swallow this to prevent the link's mux from terminating.
+	case 1:
+		// endSent: we swallow this to prevent the link's mux from terminating.
 		// l.session.done will soon close so this is temporary.
 		return nil
-	case l.session.tx <- frameBodyEnvelope{FrameCtx: &frameCtx, FrameBody: fr}:
-		debug.Log(2, "TX (link %p): mux frame to Session (%p): %s", l, l.session, fr)
 	}
 
 	select {
@@ -404,3 +589,82 @@ func (l *link) txFrameAndWait(ctx context.Context, fr frames.FrameBody) error {
 		return l.session.doneErr
 	}
 }
+
+const (
+	defaultAttachRetryAttempts = 3
+	defaultAttachRetryDelay    = 250 * time.Millisecond
+	defaultAttachRetryMaxDelay = 30 * time.Second
+)
+
+// attachWithRetry repeatedly creates and attaches a link until attach
+// succeeds, a non-transient error occurs, or the configured attempts are
+// exhausted.  A new link must be created for each attempt as a failed
+// attach consumes the link's state.
+func attachWithRetry[T interface{ attach(context.Context) error }](ctx context.Context, opts *AttachRetryOptions, newLink func() (T, error)) (T, error) {
+	var zero T
+
+	maxAttempts := 1
+	var delay, maxDelay time.Duration
+	var conditions []ErrCond
+	if opts != nil {
+		maxAttempts = opts.MaxAttempts
+		if maxAttempts < 1 {
+			maxAttempts = defaultAttachRetryAttempts
+		}
+		delay = opts.Delay
+		if delay <= 0 {
+			delay = defaultAttachRetryDelay
+		}
+		maxDelay = opts.MaxDelay
+		if maxDelay <= 0 {
+			maxDelay = defaultAttachRetryMaxDelay
+		}
+		conditions = opts.Conditions
+		if len(conditions) == 0 {
+			conditions = []ErrCond{ErrCondResourceLocked}
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		l, err := newLink()
+		if err != nil {
+			return zero, err
+		}
+
+		err = l.attach(ctx)
+		if err == nil {
+			return l, nil
+		}
+
+		if attempt >= maxAttempts || !isTransientAttachError(err, conditions) {
+			return zero, err
+		}
+
+		select {
+		case <-time.After(delay):
+			// ready for the next attempt
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// isTransientAttachError returns true if err carries one of the
+// specified error conditions.
+func isTransientAttachError(err error, conditions []ErrCond) bool {
+	var amqpErr *Error
+	if !errors.As(err, &amqpErr) || amqpErr == nil {
+		return false
+	}
+	for _, cond := range conditions {
+		if amqpErr.Condition == cond {
+			return true
+		}
+	}
+	return false
+}
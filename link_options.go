@@ -1,13 +1,145 @@
 package amqp
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/Azure/go-amqp/internal/encoding"
 )
 
+// AttachRetryOptions configures automatic retries of link attachment when
+// the peer detaches with a transient error condition, e.g. the entity is
+// locked by another link or is still activating.
+type AttachRetryOptions struct {
+	// MaxAttempts is the maximum number of attach attempts.
+	//
+	// Default: 3.
+	MaxAttempts int
+
+	// Delay is the time to wait before the second attach attempt.
+	// The delay is doubled after each subsequent failed attempt,
+	// up to MaxDelay.
+	//
+	// Default: 250ms.
+	Delay time.Duration
+
+	// MaxDelay caps the delay between attach attempts.
+	//
+	// Default: 30s.
+	MaxDelay time.Duration
+
+	// Conditions is the set of error conditions considered transient.
+	//
+	// Default: ErrCondResourceLocked.
+	Conditions []ErrCond
+}
+
+// CreditStarvationOptions configures detection of a sender that has
+// messages queued for sending but no link credit to send them.
+type CreditStarvationOptions struct {
+	// OnStarvation is invoked each time the sender has been starved for
+	// another Threshold interval.
+	//
+	// The callback is invoked from the sender's event loop and MUST NOT
+	// block or call methods on the Sender.
+	//
+	// Default: nil, meaning only [Metrics.CreditStarved] is invoked.
+	OnStarvation func(CreditStarvationEvent)
+
+	// Threshold is how long the sender must have queued messages and zero
+	// link credit before starvation is reported.  Reporting repeats every
+	// Threshold until credit arrives.
+	// Required.
+	Threshold time.Duration
+}
+
+// CreditStarvationEvent describes a sender whose queued messages can't
+// be sent for lack of link credit.
+type CreditStarvationEvent struct {
+	// LinkName is the name of the starved sender's link.
+	LinkName string
+
+	// Starved is how long the sender has had queued messages
+	// but zero link credit.
+	Starved time.Duration
+}
+
+// FragmentationEvent describes a sender whose messages are routinely
+// fragmented across multiple transfer frames.
+type FragmentationEvent struct {
+	// LinkName is the name of the sender's link.
+	LinkName string
+
+	// Stats is a snapshot of the sender's fragmentation statistics.
+	Stats FragmentationStats
+
+	// SingleFrameSize is the connection max frame size that would have
+	// carried every message sent so far in a single transfer frame.
+	// Compare against the negotiated size when tuning
+	// [ConnOptions.MaxFrameSize].
+	SingleFrameSize uint64
+}
+
+// RXQueueOptions contains the optional settings for a link's incoming
+// frame queue.
+type RXQueueOptions struct {
+	// MaxIdleSegments is the maximum number of drained queue segments
+	// retained for reuse; the rest are released to the garbage
+	// collector, reducing steady-state memory on connections with many
+	// links.  Set to a negative value to retain all drained segments.
+	//
+	// Default: 0, meaning one drained segment is retained.
+	MaxIdleSegments int
+
+	// SegmentSize is the number of entries in each queue segment.
+	//
+	// Default: 0, meaning the segment size is derived from the
+	// session's window.
+	SegmentSize int
+}
+
+// values returns the validated segment size and idle segment cap,
+// applying defaults.
+func (o *RXQueueOptions) values(defaultSegmentSize int) (segmentSize, maxIdleSegments int, err error) {
+	segmentSize = defaultSegmentSize
+	if o.SegmentSize < 0 {
+		return 0, 0, errors.New("RXQueue.SegmentSize must not be negative")
+	} else if o.SegmentSize > 0 {
+		segmentSize = o.SegmentSize
+	}
+	maxIdleSegments = o.MaxIdleSegments
+	if maxIdleSegments == 0 {
+		maxIdleSegments = 1
+	}
+	return segmentSize, maxIdleSegments, nil
+}
+
 type SenderOptions struct {
+	// AttachRetry configures automatic retries of link attachment for
+	// transient failures.
+	//
+	// Default: nil, meaning failed attaches aren't retried.
+	AttachRetry *AttachRetryOptions
+
 	// Capabilities is the list of extension capabilities the sender supports.
 	Capabilities []string
 
+	// CreditStarvation enables reporting when messages are queued for
+	// sending but the sender has had zero link credit for longer than a
+	// threshold, the most common cause of sends appearing to hang.
+	//
+	// Default: nil, meaning starvation detection is disabled.
+	CreditStarvation *CreditStarvationOptions
+
+	// DeliveryJournal records the sender's unsettled deliveries for the
+	// link-resume machinery.  Plug a durable implementation to preserve
+	// delivery state across process restarts; see [DeliveryJournal].
+	//
+	// Default: a process-local [MemoryDeliveryJournal].
+	DeliveryJournal DeliveryJournal
+
 	// Durability indicates what state of the sender will be retained durably.
 	//
 	// Default: DurabilityNone.
@@ -34,6 +166,13 @@ type SenderOptions struct {
 	// Default: 0.
 	ExpiryTimeout uint32
 
+	// IdleTimeout enables idle link detection.  If no transfers or
+	// settlements occur on the link for this duration, OnIdle is invoked.
+	// Required when OnIdle is set.
+	//
+	// Default: 0, meaning idle detection is disabled.
+	IdleTimeout time.Duration
+
 	// Name sets the name of the link.
 	//
 	// Link names must be unique per-connection and direction.
@@ -41,6 +180,54 @@ type SenderOptions struct {
 	// Default: randomly generated.
 	Name string
 
+	// OnAddressChange is called when the peer re-issues the link's
+	// terminus information with a different target address, e.g. when a
+	// dynamic address is re-assigned after link recovery.  It receives
+	// the new address, which also becomes the value returned by
+	// [Sender.Address].
+	//
+	// The callback is invoked from the sender's event loop and MUST NOT
+	// block or call methods on the Sender.
+	//
+	// Default: nil, meaning address changes are applied silently.
+	OnAddressChange func(newAddr string)
+
+	// OnDrain is called when the peer requests that the link be drained
+	// and the sender has advanced its delivery-count to consume the
+	// remaining link credit.  It receives the amount of credit consumed.
+	//
+	// The callback is invoked from the sender's event loop and MUST NOT
+	// block or call methods on the Sender.
+	//
+	// Default: nil.
+	OnDrain func(credit uint32)
+
+	// OnFragmentation is called, at most once for the life of the sender,
+	// when the majority of the sender's messages have been fragmented
+	// across multiple transfer frames.  The event carries guidance for
+	// sizing [ConnOptions.MaxFrameSize] to avoid fragmentation.  The
+	// callback is invoked from the goroutine calling Send and MUST NOT
+	// block.
+	//
+	// Default: nil, meaning fragmentation is only tracked in
+	// [Sender.FragmentationStats].
+	OnFragmentation func(FragmentationEvent)
+
+	// OnIdle is invoked from a background goroutine when the link has had
+	// no activity for IdleTimeout.  Return true to close the link; blocked
+	// and subsequent calls on it will receive a *LinkError.  Return false
+	// to leave the link open, in which case idle monitoring continues.
+	// Required when IdleTimeout is greater than zero.
+	//
+	// Default: nil.
+	OnIdle func() bool
+
+	// ProducerID identifies this producer in messages stamped via
+	// SequenceStamping, and is required when stamping is enabled.
+	// It can be at most 11 bytes long so that stamped delivery tags
+	// stay within the 32-byte limit.
+	ProducerID string
+
 	// Properties sets an entry in the link properties map sent to the server.
 	Properties map[string]any
 
@@ -52,6 +239,45 @@ type SenderOptions struct {
 	// Default: Accept the settlement mode set by the server, commonly ModeFirst.
 	RequestedReceiverSettleMode *ReceiverSettleMode
 
+	// RXQueue configures the link's incoming frame queue.
+	//
+	// Default: nil, meaning the queue's segment size is derived from the
+	// session's outgoing window and one drained segment is retained.
+	RXQueue *RXQueueOptions
+
+	// SendTransforms is a chain of functions applied, in order, to each
+	// message sent via Send, SendBatch, or SendWithReceipt after all
+	// transport metadata (e.g. sequence stamping annotations) has been
+	// applied and immediately before the message is encoded.  Use it to
+	// enrich delivery annotations, compute footer checksums, or sign
+	// messages at the transport layer instead of in every caller.  A
+	// transform returning an error fails the send with that error.
+	// Transforms are invoked from the goroutine calling the send method
+	// and MUST NOT block.
+	//
+	// Messages sent via SendRaw are not transformed, as their payload is
+	// already encoded.
+	//
+	// Default: nil.
+	SendTransforms []func(*Message) error
+
+	// SequenceStamping stamps each message sent via Send or
+	// SendWithReceipt with a monotonically increasing sequence number:
+	// ProducerID and the sequence number are written to the message's
+	// annotations under the [AnnotationProducerID] and
+	// [AnnotationProducerSequence] keys, and form the delivery tag
+	// unless the message carries an explicit one.  Dedupe-capable
+	// brokers and consumers can use the stamp to detect duplicates,
+	// enabling idempotent production across retries; a message that
+	// already carries a sequence number keeps it, so resending a
+	// message reuses the original stamp.
+	//
+	// Messages sent via SendRaw are not stamped, as their payload is
+	// already encoded.
+	//
+	// Default: false.
+	SequenceStamping bool
+
 	// SettlementMode sets the settlement mode in use by this sender.
 	//
 	// Default: ModeMixed.
@@ -82,6 +308,12 @@ type SenderOptions struct {
 }
 
 type ReceiverOptions struct {
+	// AttachRetry configures automatic retries of link attachment for
+	// transient failures.
+	//
+	// Default: nil, meaning failed attaches aren't retried.
+	AttachRetry *AttachRetryOptions
+
 	// Capabilities is the list of extension capabilities the receiver supports.
 	Capabilities []string
 
@@ -100,9 +332,36 @@ type ReceiverOptions struct {
 	// Default: 1.
 	Credit int32
 
+	// CreditStrategy determines when the receiver automatically issues
+	// link credit and how much.
+	//
+	// Specify ManualCreditStrategy for complete control of the link's
+	// flow control; this is equivalent to setting Credit to -1.
+	// Specify an *AdaptiveCreditStrategy to have the window tuned
+	// between bounds automatically instead of holding Credit fixed.
+	//
+	// Default: FixedWindowCreditStrategy.
+	CreditStrategy CreditStrategy
+
 	// DesiredCapabilities maps to the desired-capabilities of an ATTACH frame.
 	DesiredCapabilities []string
 
+	// DiscardExpired, when true, checks each message's expiry on arrival
+	// — the properties' absolute-expiry-time or, failing that, the
+	// creation time plus the header's ttl — and automatically settles
+	// expired messages with DiscardExpiredOutcome instead of returning
+	// them, matching JMS-style expiry semantics.
+	//
+	// Default: false.
+	DiscardExpired bool
+
+	// DiscardExpiredOutcome is the delivery state applied to expired
+	// messages.
+	//
+	// Default: nil, meaning the message is accepted, removing it from
+	// the queue.
+	DiscardExpiredOutcome DeliveryState
+
 	// Durability indicates what state of the receiver will be retained durably.
 	//
 	// Default: DurabilityNone.
@@ -130,6 +389,38 @@ type ReceiverOptions struct {
 	// If the peer cannot fulfill the filters the link will be detached.
 	Filters []LinkFilter
 
+	// IdleTimeout enables idle link detection.  If no transfers or
+	// settlements occur on the link for this duration, OnIdle is invoked.
+	// Required when OnIdle is set.
+	//
+	// Default: 0, meaning idle detection is disabled.
+	IdleTimeout time.Duration
+
+	// InlineReceive runs frame processing and message assembly on the
+	// goroutine calling [Receiver.Receive] instead of a dedicated mux
+	// goroutine, removing a wakeup handoff from the hot path for latency
+	// sensitive request/response workloads.  Note that credit, settlement
+	// acknowledgments, and link state updates are only processed during
+	// calls into the Receiver.  Cannot be combined with a session-wide
+	// link mux (see [SessionOptions.SharedLinkMux]).
+	//
+	// Default: false.
+	InlineReceive bool
+
+	// MaxDeliveryAttempts, when greater than zero, causes Receive to
+	// automatically settle any message whose header reports that many
+	// or more prior delivery attempts instead of returning it.  The
+	// message is settled with MaxDeliveryAttemptsOutcome.
+	//
+	// Default: 0, meaning no limit is enforced.
+	MaxDeliveryAttempts uint32
+
+	// MaxDeliveryAttemptsOutcome is the delivery state applied to
+	// messages that have reached MaxDeliveryAttempts.
+	//
+	// Default: nil, meaning the message is rejected.
+	MaxDeliveryAttemptsOutcome DeliveryState
+
 	// MaxMessageSize sets the maximum message size that can
 	// be received on the link.
 	//
@@ -138,6 +429,50 @@ type ReceiverOptions struct {
 	// Default: 0.
 	MaxMessageSize uint64
 
+	// MaxUnsettled, when greater than zero, caps the number of locally
+	// unsettled deliveries.  Once the cap is reached no further credit
+	// is issued until deliveries are settled, so consumers that fall
+	// behind on settling apply backpressure to the sender instead of
+	// accumulating unsettled deliveries without bound.
+	//
+	// Credit already issued to the sender counts against the cap, so
+	// Credit cannot exceed MaxUnsettled.  Only applies to receiver
+	// links using automatic credit management.
+	//
+	// Default: 0, meaning the number of unsettled deliveries is unbounded.
+	MaxUnsettled uint32
+
+	// MessageFilter, when non-nil, is evaluated against each message
+	// before Receive returns it.  Messages for which it returns false
+	// are automatically settled with MessageFilterOutcome and never
+	// surfaced to the application.  Useful when the broker doesn't
+	// support selectors but the consumer only wants a subset of the
+	// messages on the source.
+	//
+	// The predicate is invoked from the goroutine calling Receive and
+	// MUST NOT call methods on the Receiver.
+	//
+	// Default: nil, meaning all messages are returned.
+	MessageFilter func(*Message) bool
+
+	// MessageFilterOutcome is the delivery state applied to messages
+	// for which MessageFilter returns false.
+	//
+	// Default: nil, meaning the message is released.
+	MessageFilterOutcome DeliveryState
+
+	// ModifiedOutcomeDefaults provides the delivery-failed and
+	// undeliverable-here flags and the annotations used when the
+	// receiver settles a delivery with the modified outcome and no
+	// explicit values were given: ModifyMessage calls with nil options,
+	// and automatic settlements (MaxDeliveryAttemptsOutcome,
+	// MessageFilterOutcome) configured with an empty [StateModified].
+	// Brokers route redeliveries based on these fields.
+	//
+	// Default: nil, meaning the flags are false and no annotations are
+	// injected.
+	ModifiedOutcomeDefaults *ModifyMessageOptions
+
 	// Name sets the name of the link.
 	//
 	// Link names must be unique per-connection and direction.
@@ -145,9 +480,48 @@ type ReceiverOptions struct {
 	// Default: randomly generated.
 	Name string
 
+	// OnAddressChange is called when the peer re-issues the link's
+	// terminus information with a different source address, e.g. when a
+	// dynamic address is re-assigned after link recovery.  It receives
+	// the new address, which also becomes the value returned by
+	// [Receiver.Address].
+	//
+	// The callback is invoked from the receiver's event loop and MUST NOT
+	// block or call methods on the Receiver.
+	//
+	// Default: nil, meaning address changes are applied silently.
+	OnAddressChange func(newAddr string)
+
+	// OnIdle is invoked from a background goroutine when the link has had
+	// no activity for IdleTimeout.  Return true to close the link; blocked
+	// and subsequent calls on it will receive a *LinkError.  Return false
+	// to leave the link open, in which case idle monitoring continues.
+	// Required when IdleTimeout is greater than zero.
+	//
+	// Default: nil.
+	OnIdle func() bool
+
+	// OrderedSettlement guarantees that dispositions are transmitted in
+	// delivery order.  Settling a message while older deliveries remain
+	// unsettled buffers its disposition until those deliveries have been
+	// settled.  Some brokers require this for correct ranged disposition
+	// handling.
+	//
+	// Default: false.
+	OrderedSettlement bool
+
 	// Properties sets an entry in the link properties map sent to the server.
 	Properties map[string]any
 
+	// RawTransfers configures the receiver to skip decoding of message
+	// sections. The assembled payload bytes of each delivery are surfaced
+	// via [Message.RawPayload] along with the transfer metadata (delivery
+	// tag, message format, settlement state). Intended for proxies and
+	// archivers that never need decoded sections.
+	//
+	// Default: false.
+	RawTransfers bool
+
 	// RequestedSenderSettleMode sets the requested sender settlement mode.
 	//
 	// If a settlement mode is explicitly set and the server does not
@@ -156,6 +530,13 @@ type ReceiverOptions struct {
 	// Default: Accept the settlement mode set by the server, commonly ModeMixed.
 	RequestedSenderSettleMode *SenderSettleMode
 
+	// RXQueue configures the link's incoming frame queue and the queue of
+	// prefetched messages.
+	//
+	// Default: nil, meaning the queues' segment size is derived from the
+	// session's incoming window and one drained segment is retained.
+	RXQueue *RXQueueOptions
+
 	// SettlementMode sets the settlement mode in use by this receiver.
 	//
 	// Default: ModeFirst.
@@ -238,6 +619,80 @@ func NewSelectorFilter(filter string) LinkFilter {
 	return NewLinkFilter(selectorFilter, selectorFilterCode, filter)
 }
 
+// NewCompositeSelectorFilter creates a selector filter
+// (apache.org:selector-filter:string) that matches only when all the
+// provided selector expressions match.  Each expression is parenthesized
+// and the results are joined with AND, preserving the operator precedence
+// of the individual expressions.  Expressions are validated with
+// [ValidateSelector] before being combined.
+// Any preexisting selector filter will be updated with the combined value.
+func NewCompositeSelectorFilter(exprs ...string) (LinkFilter, error) {
+	if len(exprs) == 0 {
+		return nil, errors.New("at least one selector expression is required")
+	}
+	for _, expr := range exprs {
+		if err := ValidateSelector(expr); err != nil {
+			return nil, err
+		}
+	}
+	if len(exprs) == 1 {
+		return NewSelectorFilter(exprs[0]), nil
+	}
+
+	combined := strings.Builder{}
+	for i, expr := range exprs {
+		if i > 0 {
+			combined.WriteString(" AND ")
+		}
+		combined.WriteString("(")
+		combined.WriteString(expr)
+		combined.WriteString(")")
+	}
+	return NewSelectorFilter(combined.String()), nil
+}
+
+// ValidateSelector performs client-side checks on a selector expression:
+// it must be non-empty with balanced parentheses and terminated
+// single-quoted string literals.  A nil return doesn't guarantee the peer
+// will accept the expression; full syntax validation is left to the peer,
+// which is the authority on its selector dialect.
+func ValidateSelector(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return errors.New("selector expression is empty")
+	}
+
+	var depth int
+	var inLiteral bool
+	for _, r := range expr {
+		if inLiteral {
+			// a quote inside a literal is escaped by doubling it, which
+			// toggles inLiteral twice and so doesn't affect balance
+			if r == '\'' {
+				inLiteral = false
+			}
+			continue
+		}
+		switch r {
+		case '\'':
+			inLiteral = true
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("selector %q has unbalanced parentheses", expr)
+			}
+		}
+	}
+	if inLiteral {
+		return fmt.Errorf("selector %q has an unterminated string literal", expr)
+	}
+	if depth != 0 {
+		return fmt.Errorf("selector %q has unbalanced parentheses", expr)
+	}
+	return nil
+}
+
 const (
 	selectorFilter     = "apache.org:selector-filter:string"
 	selectorFilterCode = uint64(0x0000468C00000004)
@@ -11,6 +11,7 @@ import (
 	"github.com/Azure/go-amqp/internal/fake"
 	"github.com/Azure/go-amqp/internal/frames"
 	"github.com/Azure/go-amqp/internal/queue"
+	"github.com/Azure/go-amqp/internal/ring"
 	"github.com/Azure/go-amqp/internal/test"
 	"github.com/stretchr/testify/require"
 )
@@ -47,7 +48,9 @@ func TestLinkFlowThatNeedsToReplenishCredits(t *testing.T) {
 			// to avoid the race, we continue to receive frames until we get the flow frame
 			// with the correct value, a wrong frame, or the context expires.
 			select {
-			case txFrame := <-l.l.session.tx:
+			case <-l.l.session.tx.Wait():
+				txFrame, ok := l.l.session.tx.Dequeue()
+				require.True(t, ok)
 				switch frame := txFrame.FrameBody.(type) {
 				case *frames.PerformFlow:
 					require.False(t, frame.Drain)
@@ -116,7 +119,9 @@ func TestLinkFlowWithManualCreditor(t *testing.T) {
 	require.NoError(t, l.IssueCredit(100))
 
 	// flow happens immmediately in 'mux'
-	txFrame := <-l.l.session.tx
+	<-l.l.session.tx.Wait()
+	txFrame, ok := l.l.session.tx.Dequeue()
+	require.True(t, ok)
 
 	switch frame := txFrame.FrameBody.(type) {
 	case *frames.PerformFlow:
@@ -199,7 +204,8 @@ func TestLinkFlowWithManualCreditorAndNoFlowNeeded(t *testing.T) {
 
 	// flow happens immmediately in 'mux'
 	select {
-	case fr := <-l.l.session.tx: // there won't be a flow this time.
+	case <-l.l.session.tx.Wait(): // there won't be a flow this time.
+		fr, _ := l.l.session.tx.Dequeue()
 		require.Failf(t, "No flow frame would be needed since no link credits were added and drain was not requested", "Frame was %+v", fr)
 	case <-time.After(time.Second * 2):
 		// this is the expected case since no frame will be sent.
@@ -221,6 +227,27 @@ func TestMuxFlowHandlesDrainProperly(t *testing.T) {
 	require.EqualValues(t, 501, l.l.linkCredit, "credits are untouched when draining")
 }
 
+func TestLinkMuxPanicRecovery(t *testing.T) {
+	l := newTestLink(t)
+
+	go l.mux(receiverTestHooks{
+		MuxSelect: func() {
+			panic("boom")
+		},
+	})
+
+	select {
+	case <-l.l.done:
+		// mux terminated instead of crashing the process
+	case <-time.After(time.Second):
+		t.Fatal("expected mux to terminate")
+	}
+
+	var linkErr *LinkError
+	require.ErrorAs(t, l.l.doneErr, &linkErr)
+	require.Contains(t, l.l.doneErr.Error(), "boom")
+}
+
 func newTestLink(t *testing.T) *Receiver {
 	fakeConn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -238,7 +265,7 @@ func newTestLink(t *testing.T) *Receiver {
 			// debug(1, "FLOW Link Mux half: source: %s, inflight: %d, credit: %d, deliveryCount: %d, messages: %d, unsettled: %d, maxCredit : %d, settleMode: %s", l.source.Address, l.receiver.inFlight.len(), l.l.linkCredit, l.deliveryCount, len(l.messages), l.countUnsettled(), l.receiver.maxCredit, l.receiverSettleMode.String())
 			done: make(chan struct{}),
 			session: &Session{
-				tx:            make(chan frameBodyEnvelope, 100),
+				tx:            ring.New[frameBodyEnvelope](100),
 				done:          make(chan struct{}),
 				conn:          conn,
 				outputHandles: bitmap.New(32),
@@ -246,9 +273,10 @@ func newTestLink(t *testing.T) *Receiver {
 			rxQ:   queue.NewHolder(queue.New[frames.FrameBody](100)),
 			close: make(chan struct{}),
 		},
-		autoSendFlow:  true,
-		inFlight:      inFlight{},
-		receiverReady: make(chan struct{}, 1),
+		autoSendFlow:   true,
+		creditStrategy: FixedWindowCreditStrategy{},
+		inFlight:       inFlight{},
+		receiverReady:  make(chan struct{}, 1),
 	}
 
 	l.messagesQ = queue.NewHolder(queue.New[Message](100))
@@ -438,6 +466,68 @@ func TestNewReceivingLink(t *testing.T) {
 	}
 }
 
+func TestNewCompositeSelectorFilter(t *testing.T) {
+	t.Run("MultipleExpressions", func(t *testing.T) {
+		filter, err := NewCompositeSelectorFilter("priority > 5", "color = 'red' OR color = 'blue'")
+		require.NoError(t, err)
+		f := encoding.Filter{}
+		filter(f)
+		require.Equal(t, &encoding.DescribedType{
+			Descriptor: selectorFilterCode,
+			Value:      "(priority > 5) AND (color = 'red' OR color = 'blue')",
+		}, f[selectorFilter])
+	})
+	t.Run("SingleExpression", func(t *testing.T) {
+		filter, err := NewCompositeSelectorFilter("priority > 5")
+		require.NoError(t, err)
+		f := encoding.Filter{}
+		filter(f)
+		require.Equal(t, &encoding.DescribedType{
+			Descriptor: selectorFilterCode,
+			Value:      "priority > 5",
+		}, f[selectorFilter])
+	})
+	t.Run("NoExpressions", func(t *testing.T) {
+		filter, err := NewCompositeSelectorFilter()
+		require.Error(t, err)
+		require.Nil(t, filter)
+	})
+	t.Run("InvalidExpression", func(t *testing.T) {
+		filter, err := NewCompositeSelectorFilter("priority > 5", "color = 'red")
+		require.Error(t, err)
+		require.Nil(t, filter)
+	})
+}
+
+func TestValidateSelector(t *testing.T) {
+	tests := []struct {
+		label   string
+		expr    string
+		wantErr bool
+	}{
+		{label: "simple comparison", expr: "priority > 5"},
+		{label: "parenthesized", expr: "(a = 1 OR b = 2) AND c = 3"},
+		{label: "escaped quote in literal", expr: "name = 'it''s'"},
+		{label: "parenthesis inside literal", expr: "name = '(unbalanced'"},
+		{label: "empty", expr: "", wantErr: true},
+		{label: "whitespace only", expr: "   ", wantErr: true},
+		{label: "unterminated literal", expr: "name = 'oops", wantErr: true},
+		{label: "missing close paren", expr: "(a = 1", wantErr: true},
+		{label: "missing open paren", expr: "a = 1)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			err := ValidateSelector(tt.expr)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestSessionFlowDisablesTransfer(t *testing.T) {
 	t.Skip("TODO: finish for link testing")
 	nextIncomingID := uint32(0)
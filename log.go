@@ -0,0 +1,41 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Azure/go-amqp/internal/frames"
+)
+
+// logEvent writes a lifecycle event to the connection's logger at
+// [slog.LevelInfo].  No-op when no logger is configured.
+func (c *Conn) logEvent(msg string, attrs ...slog.Attr) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(context.Background(), slog.LevelInfo, msg, attrs...)
+}
+
+// logFrame writes a frame sent to or received from the peer to the
+// connection's logger at [slog.LevelDebug].  The frame is formatted via
+// its String method, which elides the credentials in SASL frames.
+func (c *Conn) logFrame(direction Direction, channel uint16, body frames.FrameBody) {
+	if c.logger == nil || !c.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	c.logger.LogAttrs(context.Background(), slog.LevelDebug, "frame",
+		slog.String("direction", direction.String()),
+		slog.Int("channel", int(channel)),
+		slog.String("body", fmt.Sprintf("%s", body)),
+	)
+}
+
+// logAttrs returns the attributes identifying l in log events.
+func (l *link) logAttrs() []slog.Attr {
+	return []slog.Attr{
+		slog.Int("channel", int(l.session.channel)),
+		slog.String("link", l.key.name),
+		slog.Int("handle", int(l.outputHandle)),
+	}
+}
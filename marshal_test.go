@@ -42,6 +42,18 @@ var exampleFrames = []struct {
 			},
 		},
 	},
+	{
+		label: "transfer continuation",
+		frame: frames.Frame{
+			Type:    frames.TypeAMQP,
+			Channel: 10,
+			Body: &frames.PerformTransfer{
+				Handle:  34983,
+				More:    true,
+				Payload: []byte("remainder of a very important payload"),
+			},
+		},
+	},
 }
 
 func TestFrameMarshalUnmarshal(t *testing.T) {
@@ -261,6 +273,50 @@ func TestReadAny(t *testing.T) {
 	}
 }
 
+func TestMarshalNilAndEmptySemantics(t *testing.T) {
+	const null = byte(0x40)
+
+	// nil pointers and nil maps encode as null
+	for _, type_ := range []any{(*uint32)(nil), (*string)(nil), (*bool)(nil), map[string]any(nil), map[any]any(nil)} {
+		t.Run(fmt.Sprintf("%T", type_), func(t *testing.T) {
+			var buf buffer.Buffer
+			if err := encoding.Marshal(&buf, type_); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !reflect.DeepEqual(buf.Bytes(), []byte{null}) {
+				t.Errorf("expected null, got %#v", buf.Bytes())
+			}
+		})
+	}
+
+	// an empty (non-nil) map encodes as an empty map, not null
+	var buf buffer.Buffer
+	if err := encoding.Marshal(&buf, map[string]any{}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if buf.Bytes()[0] == null {
+		t.Error("empty map encoded as null")
+	}
+
+	// a nil slice encodes as empty binary, not null
+	buf.Reset()
+	if err := encoding.Marshal(&buf, []byte(nil)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if buf.Bytes()[0] == null {
+		t.Error("nil slice encoded as null")
+	}
+
+	// force-encoding a zero value via a pointer
+	buf.Reset()
+	if err := encoding.Marshal(&buf, new(uint32)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if buf.Bytes()[0] == null {
+		t.Error("zero uint32 pointer encoded as null")
+	}
+}
+
 var (
 	allTypes = append(protoTypes, generalTypes...)
 
@@ -0,0 +1,16 @@
+package amqp
+
+import "github.com/Azure/go-amqp/internal/encoding"
+
+// Marshaler allows a domain type to provide its own AMQP encoding.
+// A type implementing Marshaler can be placed directly in an amqp-value
+// message body, including nested inside maps and lists, instead of being
+// pre-converted to a map or list by hand.
+//
+// For types that should also carry their identity on the wire, register
+// a described type via [RegisterDescribedType] instead.
+type Marshaler = encoding.Marshaler
+
+// Unmarshaler allows a domain type to populate itself from a decoded
+// AMQP value when the codec decodes into it.
+type Unmarshaler = encoding.Unmarshaler
@@ -0,0 +1,50 @@
+package amqp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Azure/go-amqp/internal/buffer"
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+type testTemperature struct {
+	Celsius int64
+}
+
+func (t testTemperature) MarshalAMQP() (any, error) {
+	return map[string]any{"celsius": t.Celsius}, nil
+}
+
+func (t *testTemperature) UnmarshalAMQP(v any) error {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("unexpected temperature encoding %v", v)
+	}
+	celsius, ok := m["celsius"].(int64)
+	if !ok {
+		return fmt.Errorf("unexpected temperature encoding %v", v)
+	}
+	t.Celsius = celsius
+	return nil
+}
+
+func TestMarshalerValueBody(t *testing.T) {
+	// a Marshaler serializes itself, including nested inside lists
+	msg := &Message{Value: []any{"reading", testTemperature{Celsius: 21}}}
+	encoded, err := msg.MarshalBinary()
+	require.NoError(t, err)
+	decoded := &Message{}
+	require.NoError(t, decoded.UnmarshalBinary(encoded))
+	require.Equal(t, []any{"reading", map[string]any{"celsius": int64(21)}}, decoded.Value)
+}
+
+func TestUnmarshalerDestination(t *testing.T) {
+	buf := &buffer.Buffer{}
+	require.NoError(t, encoding.Marshal(buf, testTemperature{Celsius: -4}))
+
+	var temp testTemperature
+	require.NoError(t, encoding.Unmarshal(buf, &temp))
+	require.Equal(t, testTemperature{Celsius: -4}, temp)
+}
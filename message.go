@@ -2,6 +2,8 @@ package amqp
 
 import (
 	"fmt"
+	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/go-amqp/internal/buffer"
@@ -102,6 +104,12 @@ type Message struct {
 	// encryption details).
 	Footer Annotations
 
+	// RawPayload contains the message's assembled, undecoded payload bytes.
+	// It's only populated by receivers configured with
+	// [ReceiverOptions.RawTransfers]; in that mode no other sections are
+	// decoded.
+	RawPayload []byte
+
 	deliveryID uint32    // used when sending disposition
 	settled    bool      // whether transfer was settled by sender
 	rcv        *Receiver // used to settle message on the corresponding Receiver (nil if settled == true)
@@ -121,6 +129,95 @@ func NewMessage(data []byte) *Message {
 	}
 }
 
+// WithDurable sets the header's durable field, allocating the header if
+// necessary, and returns m to allow chaining.
+func (m *Message) WithDurable(durable bool) *Message {
+	m.ensureHeader().Durable = durable
+	return m
+}
+
+// WithPriority sets the header's priority field, allocating the header if
+// necessary, and returns m to allow chaining.
+func (m *Message) WithPriority(priority uint8) *Message {
+	m.ensureHeader().Priority = priority
+	return m
+}
+
+// WithTTL sets the header's ttl field, allocating the header if necessary,
+// and returns m to allow chaining.
+// Call [MessageHeader.Validate] to verify the value survives encoding.
+func (m *Message) WithTTL(ttl time.Duration) *Message {
+	m.ensureHeader().TTL = ttl
+	return m
+}
+
+// WithGroupID sets the properties' group-id field, allocating the properties
+// if necessary, and returns m to allow chaining.
+func (m *Message) WithGroupID(id string) *Message {
+	m.ensureProperties().GroupID = &id
+	return m
+}
+
+// WithGroupSequence sets the properties' group-sequence field, allocating the
+// properties if necessary, and returns m to allow chaining.
+// Use [MessageGroup] to stamp sequence numbers automatically.
+func (m *Message) WithGroupSequence(seq uint32) *Message {
+	m.ensureProperties().GroupSequence = &seq
+	return m
+}
+
+// WithReplyToGroupID sets the properties' reply-to-group-id field, allocating
+// the properties if necessary, and returns m to allow chaining.
+func (m *Message) WithReplyToGroupID(id string) *Message {
+	m.ensureProperties().ReplyToGroupID = &id
+	return m
+}
+
+func (m *Message) ensureHeader() *MessageHeader {
+	if m.Header == nil {
+		m.Header = &MessageHeader{Priority: 4}
+	}
+	return m.Header
+}
+
+func (m *Message) ensureProperties() *MessageProperties {
+	if m.Properties == nil {
+		m.Properties = &MessageProperties{}
+	}
+	return m.Properties
+}
+
+// MessageGroup assigns messages to an AMQP message group, stamping each with
+// the group's id and the next group-sequence.  Sequence numbers are RFC-1982
+// serial numbers and wrap around on overflow.
+// Safe for concurrent use.
+type MessageGroup struct {
+	id  string
+	seq uint32 // next sequence number to assign; MUST be atomically accessed
+}
+
+// NewMessageGroup returns a MessageGroup whose first stamped message carries
+// a group-sequence of zero.
+func NewMessageGroup(id string) *MessageGroup {
+	return &MessageGroup{id: id}
+}
+
+// ID returns the group's id.
+func (g *MessageGroup) ID() string {
+	return g.id
+}
+
+// Stamp sets m's group-id to the group's id and its group-sequence to the
+// next sequence number, allocating the properties if necessary, and returns
+// m to allow chaining.
+func (g *MessageGroup) Stamp(m *Message) *Message {
+	seq := atomic.AddUint32(&g.seq, 1) - 1
+	p := m.ensureProperties()
+	p.GroupID = &g.id
+	p.GroupSequence = &seq
+	return m
+}
+
 // Null is an AMQP null.
 // Typically used in [Message.Value] to send a null.
 //
@@ -141,6 +238,72 @@ func (m *Message) GetData() []byte {
 	return m.Data[0]
 }
 
+// DeliveryCount returns the number of prior unsuccessful delivery attempts
+// as reported in the message's header, or zero if the header is absent.
+func (m *Message) DeliveryCount() uint32 {
+	if m.Header == nil {
+		return 0
+	}
+	return m.Header.DeliveryCount
+}
+
+// ExpiryTime returns the time at which the message expires, derived from
+// the properties' absolute-expiry-time or, failing that, the creation
+// time plus the header's ttl.  Returns the zero time if the message
+// doesn't expire.
+func (m *Message) ExpiryTime() time.Time {
+	if m.Properties != nil && m.Properties.AbsoluteExpiryTime != nil {
+		return *m.Properties.AbsoluteExpiryTime
+	}
+	if m.Header != nil && m.Header.TTL > 0 && m.Properties != nil && m.Properties.CreationTime != nil {
+		return m.Properties.CreationTime.Add(m.Header.TTL)
+	}
+	return time.Time{}
+}
+
+// FirstAcquirer reports whether this message's recipient might be its
+// first acquirer, as reported in the message's header.  Returns false
+// if the header is absent.
+func (m *Message) FirstAcquirer() bool {
+	if m.Header == nil {
+		return false
+	}
+	return m.Header.FirstAcquirer
+}
+
+// IsRedelivered reports whether at least one prior, unsuccessful delivery
+// attempt was made, i.e. the header's delivery-count is greater than zero.
+func (m *Message) IsRedelivered() bool {
+	return m.DeliveryCount() > 0
+}
+
+// GroupID returns the group-id from the message's properties, or the empty
+// string if it's absent.
+func (m *Message) GroupID() string {
+	if m.Properties == nil || m.Properties.GroupID == nil {
+		return ""
+	}
+	return *m.Properties.GroupID
+}
+
+// GroupSequence returns the group-sequence from the message's properties,
+// or zero if it's absent.
+func (m *Message) GroupSequence() uint32 {
+	if m.Properties == nil || m.Properties.GroupSequence == nil {
+		return 0
+	}
+	return *m.Properties.GroupSequence
+}
+
+// ReplyToGroupID returns the reply-to-group-id from the message's properties,
+// or the empty string if it's absent.
+func (m *Message) ReplyToGroupID() string {
+	if m.Properties == nil || m.Properties.ReplyToGroupID == nil {
+		return ""
+	}
+	return *m.Properties.ReplyToGroupID
+}
+
 // MarshalBinary encodes the message into binary form.
 func (m *Message) MarshalBinary() ([]byte, error) {
 	buf := &buffer.Buffer{}
@@ -340,7 +503,7 @@ type MessageHeader struct {
 
 func (h *MessageHeader) Marshal(wr *buffer.Buffer) error {
 	return encoding.MarshalComposite(wr, encoding.TypeCodeMessageHeader, []encoding.MarshalField{
-		{Value: &h.Durable},
+		{Value: &h.Durable, Omit: !h.Durable},
 		{Value: &h.Priority, Omit: h.Priority == 4},
 		{Value: (*encoding.Milliseconds)(&h.TTL), Omit: h.TTL == 0},
 		{Value: &h.FirstAcquirer, Omit: !h.FirstAcquirer},
@@ -348,6 +511,22 @@ func (h *MessageHeader) Marshal(wr *buffer.Buffer) error {
 	})
 }
 
+// Validate checks the header's fields against the ranges the AMQP encoding
+// can represent.  Durable and first-acquirer are booleans and priority is
+// bounded by its type; only ttl, which is encoded as an unsigned number of
+// milliseconds, can hold values that don't survive encoding.
+func (h *MessageHeader) Validate() error {
+	switch {
+	case h.TTL < 0:
+		return fmt.Errorf("ttl (%s) must not be negative", h.TTL)
+	case h.TTL%time.Millisecond != 0:
+		return fmt.Errorf("ttl (%s) must be a whole number of milliseconds", h.TTL)
+	case h.TTL/time.Millisecond > math.MaxUint32:
+		return fmt.Errorf("ttl (%s) exceeds the maximum of %d milliseconds", h.TTL, uint32(math.MaxUint32))
+	}
+	return nil
+}
+
 func (h *MessageHeader) Unmarshal(r *buffer.Buffer) error {
 	return encoding.UnmarshalComposite(r, encoding.TypeCodeMessageHeader, []encoding.UnmarshalField{
 		{Field: &h.Durable},
@@ -506,6 +685,11 @@ func (p *MessageProperties) Unmarshal(r *buffer.Buffer) error {
 // Annotations keys must be of type string, int, or int64.
 //
 // String keys are encoded as AMQP Symbols.
+//
+// Because decoders differ in the key types they produce (symbol vs
+// string, and the various integer widths), prefer the Get, Set, and
+// Delete methods over direct map indexing; they match keys across their
+// encoded forms.
 type Annotations = encoding.Annotations
 
 // UUID is a 128 bit identifier as defined in RFC 4122.
@@ -0,0 +1,109 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBatchRoundTrip(t *testing.T) {
+	batch, err := NewMessageBatch([]*Message{
+		NewMessage([]byte("one")),
+		{
+			Properties: &MessageProperties{MessageID: "two"},
+			Value:      "second",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, MessageFormatBatch, batch.Format)
+	require.Len(t, batch.Data, 2)
+
+	// simulate the envelope crossing the wire
+	payload, err := batch.MarshalBinary()
+	require.NoError(t, err)
+	received := &Message{Format: batch.Format}
+	require.NoError(t, received.UnmarshalBinary(payload))
+
+	msgs, err := received.Unbatch()
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, []byte("one"), msgs[0].GetData())
+	require.Equal(t, "two", msgs[1].Properties.MessageID)
+	require.Equal(t, "second", msgs[1].Value)
+}
+
+func TestMessageBatchEmpty(t *testing.T) {
+	batch, err := NewMessageBatch(nil)
+	require.Error(t, err)
+	require.Nil(t, batch)
+}
+
+func TestMessageUnbatchWrongFormat(t *testing.T) {
+	msgs, err := NewMessage([]byte("test")).Unbatch()
+	require.ErrorContains(t, err, "not a batch envelope")
+	require.Nil(t, msgs)
+}
+
+func TestSenderSendMessageBatch(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			if tt.MessageFormat == nil || *tt.MessageFormat != MessageFormatBatch {
+				return fake.Response{}, fmt.Errorf("unexpected message format %v", tt.MessageFormat)
+			}
+			envelope := &Message{Format: *tt.MessageFormat}
+			if err := envelope.UnmarshalBinary(tt.Payload); err != nil {
+				return fake.Response{}, err
+			}
+			msgs, err := envelope.Unbatch()
+			if err != nil {
+				return fake.Response{}, err
+			}
+			if len(msgs) != 2 {
+				return fake.Response{}, fmt.Errorf("unexpected batch size %d", len(msgs))
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	batch, err := NewMessageBatch([]*Message{
+		NewMessage([]byte("one")),
+		NewMessage([]byte("two")),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, batch, nil))
+	cancel()
+
+	require.NoError(t, client.Close())
+}
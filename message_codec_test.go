@@ -0,0 +1,58 @@
+package amqp
+
+import (
+	"testing"
+
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+type testCodec struct{}
+
+func (testCodec) Encode(msg *Message) ([]byte, error) {
+	return msg.GetData(), nil
+}
+
+func (testCodec) Decode(payload []byte, msg *Message) error {
+	msg.Value = string(payload)
+	return nil
+}
+
+func TestRegisterMessageCodec(t *testing.T) {
+	// the standard format cannot be overridden
+	require.Error(t, RegisterMessageCodec(0, testCodec{}))
+	require.Nil(t, lookupMessageCodec(0))
+
+	const format = uint32(0x80010000)
+	require.NoError(t, RegisterMessageCodec(format, testCodec{}))
+	require.NotNil(t, lookupMessageCodec(format))
+
+	// registering nil removes the codec
+	require.NoError(t, RegisterMessageCodec(format, nil))
+	require.Nil(t, lookupMessageCodec(format))
+}
+
+func TestMessageCodecReceive(t *testing.T) {
+	const format = uint32(0x80010001)
+	require.NoError(t, RegisterMessageCodec(format, testCodec{}))
+	defer func() {
+		require.NoError(t, RegisterMessageCodec(format, nil))
+	}()
+
+	r := newTestLink(t)
+
+	deliveryID := uint32(1)
+	msgFormat := format
+	r.muxReceive(frames.PerformTransfer{
+		DeliveryID:    &deliveryID,
+		DeliveryTag:   []byte("tag"),
+		MessageFormat: &msgFormat,
+		Payload:       []byte("hello"),
+		Settled:       true,
+	})
+
+	msg := r.Prefetched()
+	require.NotNil(t, msg)
+	require.Equal(t, format, msg.Format)
+	require.Equal(t, "hello", msg.Value)
+}
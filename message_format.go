@@ -0,0 +1,160 @@
+package amqp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// redactedValue replaces redacted application property values in
+// formatted output.
+const redactedValue = "<redacted>"
+
+// MessageFormatOptions contains the optional settings for [FormatMessage].
+type MessageFormatOptions struct {
+	// MaxPayload is the maximum number of body bytes included in the
+	// output; the remainder is elided.  Set to -1 to include the full
+	// payload.
+	//
+	// Default: 64.
+	MaxPayload int
+
+	// RedactProperty reports whether the named application property's
+	// value must be replaced with "<redacted>" in the output, keeping
+	// secrets out of logs while preserving the property's presence.
+	//
+	// Default: nil, meaning no redaction.
+	RedactProperty func(key string) bool
+}
+
+// String formats the message per [FormatMessage] with the default options.
+func (m *Message) String() string {
+	return FormatMessage(m, nil)
+}
+
+// FormatMessage renders all of the message's sections in a readable
+// single-line layout, truncating the payload and redacting application
+// properties per opts, making it safe for use in production logs.
+//   - m is the message to format
+//   - opts contains optional values, pass nil to accept the defaults
+func FormatMessage(m *Message, opts *MessageFormatOptions) string {
+	maxPayload := 64
+	var redact func(string) bool
+	if opts != nil {
+		if opts.MaxPayload != 0 {
+			maxPayload = opts.MaxPayload
+		}
+		redact = opts.RedactProperty
+	}
+
+	var sections []string
+	if h := m.Header; h != nil {
+		sections = append(sections, fmt.Sprintf("Header{Durable: %t, Priority: %d, TTL: %s, FirstAcquirer: %t, DeliveryCount: %d}",
+			h.Durable, h.Priority, h.TTL, h.FirstAcquirer, h.DeliveryCount))
+	}
+	if m.DeliveryAnnotations != nil {
+		sections = append(sections, fmt.Sprintf("DeliveryAnnotations[size: %d]", len(m.DeliveryAnnotations)))
+	}
+	if m.Annotations != nil {
+		sections = append(sections, fmt.Sprintf("Annotations[size: %d]", len(m.Annotations)))
+	}
+	if p := m.Properties; p != nil {
+		sections = append(sections, formatMessageProperties(p))
+	}
+	if m.ApplicationProperties != nil {
+		sections = append(sections, formatApplicationProperties(m.ApplicationProperties, redact))
+	}
+	if len(m.Data) > 0 {
+		var size int
+		for _, d := range m.Data {
+			size += len(d)
+		}
+		sections = append(sections, fmt.Sprintf("Data[chunks: %d, size: %d]: %s", len(m.Data), size, truncateForFormat(string(m.Data[0]), maxPayload)))
+	}
+	if m.Value != nil {
+		sections = append(sections, fmt.Sprintf("Value: %s", truncateForFormat(fmt.Sprintf("%v", m.Value), maxPayload)))
+	}
+	if m.Sequence != nil {
+		sections = append(sections, fmt.Sprintf("Sequence[sections: %d]", len(m.Sequence)))
+	}
+	if m.Footer != nil {
+		sections = append(sections, fmt.Sprintf("Footer[size: %d]", len(m.Footer)))
+	}
+	return "Message{" + strings.Join(sections, ", ") + "}"
+}
+
+// formatMessageProperties renders the properties section, listing only
+// the fields that are set.
+func formatMessageProperties(p *MessageProperties) string {
+	var fields []string
+	add := func(name string, value any) {
+		fields = append(fields, fmt.Sprintf("%s: %v", name, value))
+	}
+	if p.MessageID != nil {
+		add("MessageID", p.MessageID)
+	}
+	if p.UserID != nil {
+		add("UserID", fmt.Sprintf("%X", p.UserID))
+	}
+	if p.To != nil {
+		add("To", *p.To)
+	}
+	if p.Subject != nil {
+		add("Subject", *p.Subject)
+	}
+	if p.ReplyTo != nil {
+		add("ReplyTo", *p.ReplyTo)
+	}
+	if p.CorrelationID != nil {
+		add("CorrelationID", p.CorrelationID)
+	}
+	if p.ContentType != nil {
+		add("ContentType", *p.ContentType)
+	}
+	if p.ContentEncoding != nil {
+		add("ContentEncoding", *p.ContentEncoding)
+	}
+	if p.AbsoluteExpiryTime != nil {
+		add("AbsoluteExpiryTime", *p.AbsoluteExpiryTime)
+	}
+	if p.CreationTime != nil {
+		add("CreationTime", *p.CreationTime)
+	}
+	if p.GroupID != nil {
+		add("GroupID", *p.GroupID)
+	}
+	if p.GroupSequence != nil {
+		add("GroupSequence", *p.GroupSequence)
+	}
+	if p.ReplyToGroupID != nil {
+		add("ReplyToGroupID", *p.ReplyToGroupID)
+	}
+	return "Properties{" + strings.Join(fields, ", ") + "}"
+}
+
+// formatApplicationProperties renders the application-properties section
+// with keys in sorted order and redacted values replaced.
+func formatApplicationProperties(props map[string]any, redact func(string) bool) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if redact != nil && redact(k) {
+			fields = append(fields, fmt.Sprintf("%s: %s", k, redactedValue))
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s: %v", k, props[k]))
+	}
+	return "ApplicationProperties{" + strings.Join(fields, ", ") + "}"
+}
+
+// truncateForFormat quotes s, eliding everything past max bytes.
+func truncateForFormat(s string, max int) string {
+	if max < 0 || len(s) <= max {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%q... (%d more bytes)", s[:max], len(s)-max)
+}
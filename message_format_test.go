@@ -0,0 +1,43 @@
+package amqp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMessage(t *testing.T) {
+	msg := NewMessage([]byte("hello world")).WithDurable(true).WithTTL(5 * time.Second)
+	msg.ApplicationProperties = map[string]any{
+		"password": "hunter2",
+		"user":     "bob",
+	}
+	to := "queue-1"
+	msg.Properties = &MessageProperties{To: &to}
+
+	out := FormatMessage(msg, &MessageFormatOptions{
+		RedactProperty: func(key string) bool { return key == "password" },
+	})
+	require.Contains(t, out, "Header{Durable: true, Priority: 4, TTL: 5s")
+	require.Contains(t, out, "Properties{To: queue-1}")
+	require.Contains(t, out, `ApplicationProperties{password: <redacted>, user: bob}`)
+	require.Contains(t, out, `Data[chunks: 1, size: 11]: "hello world"`)
+	require.NotContains(t, out, "hunter2")
+}
+
+func TestFormatMessageTruncation(t *testing.T) {
+	msg := NewMessage([]byte(strings.Repeat("x", 100)))
+
+	// the default elides past 64 bytes
+	out := msg.String()
+	require.Contains(t, out, `... (36 more bytes)`)
+
+	// MaxPayload -1 includes the full payload
+	out = FormatMessage(msg, &MessageFormatOptions{MaxPayload: -1})
+	require.Contains(t, out, strings.Repeat("x", 100))
+
+	// an empty message formats cleanly
+	require.Equal(t, "Message{}", (&Message{}).String())
+}
@@ -1,7 +1,9 @@
 package amqp
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/Azure/go-amqp/internal/buffer"
 	"github.com/google/go-cmp/cmp"
@@ -100,6 +102,92 @@ func TestMessageHeaderMarshal(t *testing.T) {
 	require.NoError(t, err)
 	b := buf.Detach()
 	require.NotNil(t, b)
-	// 0x42 is false for the Durable field
-	require.Equal(t, []byte{0x0, 0x53, 0x70, 0xd0, 0x0, 0x0, 0x0, 0x7, 0x0, 0x0, 0x0, 0x2, 0x42, 0x50, 0x0}, b)
+	// 0x40 is null for the omitted (default false) Durable field,
+	// followed by the explicit zero Priority
+	require.Equal(t, []byte{0x0, 0x53, 0x70, 0xd0, 0x0, 0x0, 0x0, 0x7, 0x0, 0x0, 0x0, 0x2, 0x40, 0x50, 0x0}, b)
+}
+
+func TestMessageHeaderMarshalDefaults(t *testing.T) {
+	// a header holding only default values encodes as an empty list
+	header := MessageHeader{Priority: 4}
+	buf := &buffer.Buffer{}
+	err := header.Marshal(buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x0, 0x53, 0x70, 0x45}, buf.Bytes())
+
+	var got MessageHeader
+	require.NoError(t, got.Unmarshal(buf))
+	require.Equal(t, header, got)
+}
+
+func TestMessageHeaderBuilder(t *testing.T) {
+	msg := NewMessage([]byte("hello")).WithDurable(true).WithPriority(9).WithTTL(5 * time.Second)
+	require.NoError(t, msg.Header.Validate())
+
+	b, err := msg.MarshalBinary()
+	require.NoError(t, err)
+
+	var got Message
+	require.NoError(t, got.UnmarshalBinary(b))
+	require.Equal(t, &MessageHeader{Durable: true, Priority: 9, TTL: 5 * time.Second}, got.Header)
+
+	// an untouched field retains its spec default
+	msg = NewMessage(nil).WithDurable(true)
+	require.Equal(t, uint8(4), msg.Header.Priority)
+}
+
+func TestMessageGroupHelpers(t *testing.T) {
+	var empty Message
+	require.Empty(t, empty.GroupID())
+	require.Zero(t, empty.GroupSequence())
+	require.Empty(t, empty.ReplyToGroupID())
+
+	msg := NewMessage([]byte("hello")).WithGroupID("session-1").WithGroupSequence(3).WithReplyToGroupID("replies-1")
+	require.Equal(t, "session-1", msg.GroupID())
+	require.EqualValues(t, 3, msg.GroupSequence())
+	require.Equal(t, "replies-1", msg.ReplyToGroupID())
+
+	b, err := msg.MarshalBinary()
+	require.NoError(t, err)
+	var got Message
+	require.NoError(t, got.UnmarshalBinary(b))
+	require.Equal(t, "session-1", got.GroupID())
+	require.EqualValues(t, 3, got.GroupSequence())
+	require.Equal(t, "replies-1", got.ReplyToGroupID())
+}
+
+func TestMessageGroupStamp(t *testing.T) {
+	group := NewMessageGroup("session-2")
+	require.Equal(t, "session-2", group.ID())
+	for i := 0; i < 3; i++ {
+		msg := group.Stamp(NewMessage(nil))
+		require.Equal(t, "session-2", msg.GroupID())
+		require.EqualValues(t, i, msg.GroupSequence())
+	}
+}
+
+func TestMessageHeaderValidate(t *testing.T) {
+	tests := []struct {
+		label   string
+		ttl     time.Duration
+		wantErr bool
+	}{
+		{label: "zero", ttl: 0},
+		{label: "whole milliseconds", ttl: 1500 * time.Millisecond},
+		{label: "maximum", ttl: math.MaxUint32 * time.Millisecond},
+		{label: "negative", ttl: -time.Second, wantErr: true},
+		{label: "sub-millisecond", ttl: 500 * time.Microsecond, wantErr: true},
+		{label: "fractional milliseconds", ttl: time.Second + time.Nanosecond, wantErr: true},
+		{label: "overflows uint32", ttl: (math.MaxUint32 + 1) * time.Millisecond, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			err := (&MessageHeader{TTL: tt.ttl}).Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
 }
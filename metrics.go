@@ -0,0 +1,73 @@
+package amqp
+
+import "time"
+
+// Metrics receives callbacks as metrics events occur on a connection
+// and its sessions and links.  Set an implementation on a connection
+// via [ConnOptions.Metrics].
+//
+// Implementations must be safe for concurrent use and shouldn't block;
+// callbacks are invoked from the connection's and links' mux goroutines.
+type Metrics interface {
+	// ConnectionOpened is called when the connection has been established.
+	ConnectionOpened()
+
+	// ConnectionClosed is called when the connection has been closed.
+	ConnectionClosed()
+
+	// LinkAttached is called when a sender or receiver link has been attached.
+	LinkAttached()
+
+	// LinkDetached is called when a sender or receiver link has terminated.
+	LinkDetached()
+
+	// TransferSent is called for each transfer frame written to the network.
+	// bytes is the size of the frame's payload.
+	TransferSent(bytes int)
+
+	// TransferReceived is called for each transfer frame received from the peer.
+	// bytes is the size of the frame's payload.
+	TransferReceived(bytes int)
+
+	// CreditsIssued is called when a receiver issues link credit to the peer.
+	CreditsIssued(credits uint32)
+
+	// CreditStarved is called when a sender has had queued messages but zero
+	// link credit for the threshold in [SenderOptions.CreditStarvation], and
+	// again each threshold interval while the starvation persists.
+	// starved is how long the sender has been starved.
+	CreditStarved(starved time.Duration)
+}
+
+// ExtendedMetrics is an optional extension of [Metrics].  A Metrics
+// implementation set via [ConnOptions.Metrics] that also implements
+// ExtendedMetrics additionally receives frame, disposition, and
+// settlement-latency events.
+//
+// The extension is separate from Metrics so existing implementations
+// keep compiling as events are added.
+type ExtendedMetrics interface {
+	Metrics
+
+	// FrameSent is called for each AMQP frame written to the network.
+	// bytes is the encoded size of the frame, including its header.
+	FrameSent(bytes int)
+
+	// FrameReceived is called for each AMQP frame received from the peer.
+	// bytes is the encoded size of the frame, including its header.
+	// Empty (keep-alive) frames are not counted.
+	FrameReceived(bytes int)
+
+	// DispositionSent is called for each disposition frame sent to the peer.
+	DispositionSent()
+
+	// DispositionReceived is called for each disposition frame received
+	// from the peer.
+	DispositionReceived()
+
+	// SettlementLatency is called when the settlement of an unsettled
+	// delivery is confirmed, with the time elapsed between the delivery's
+	// last transfer frame being written and its settlement being observed
+	// by the waiting sender.
+	SettlementLatency(latency time.Duration)
+}
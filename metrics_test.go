@@ -0,0 +1,93 @@
+package amqp
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// countingMetrics records every ExtendedMetrics event with atomic counters.
+type countingMetrics struct {
+	connectionsOpened    atomic.Int64
+	connectionsClosed    atomic.Int64
+	linksAttached        atomic.Int64
+	linksDetached        atomic.Int64
+	transfersSent        atomic.Int64
+	transfersReceived    atomic.Int64
+	creditsIssued        atomic.Int64
+	creditStarved        atomic.Int64
+	framesSent           atomic.Int64
+	framesReceived       atomic.Int64
+	dispositionsSent     atomic.Int64
+	dispositionsReceived atomic.Int64
+	settlements          atomic.Int64
+}
+
+func (m *countingMetrics) ConnectionOpened()                 { m.connectionsOpened.Add(1) }
+func (m *countingMetrics) ConnectionClosed()                 { m.connectionsClosed.Add(1) }
+func (m *countingMetrics) LinkAttached()                     { m.linksAttached.Add(1) }
+func (m *countingMetrics) LinkDetached()                     { m.linksDetached.Add(1) }
+func (m *countingMetrics) TransferSent(bytes int)            { m.transfersSent.Add(1) }
+func (m *countingMetrics) TransferReceived(bytes int)        { m.transfersReceived.Add(1) }
+func (m *countingMetrics) CreditsIssued(credits uint32)      { m.creditsIssued.Add(1) }
+func (m *countingMetrics) CreditStarved(time.Duration)       { m.creditStarved.Add(1) }
+func (m *countingMetrics) FrameSent(bytes int)               { m.framesSent.Add(1) }
+func (m *countingMetrics) FrameReceived(bytes int)           { m.framesReceived.Add(1) }
+func (m *countingMetrics) DispositionSent()                  { m.dispositionsSent.Add(1) }
+func (m *countingMetrics) DispositionReceived()              { m.dispositionsReceived.Add(1) }
+func (m *countingMetrics) SettlementLatency(t time.Duration) { m.settlements.Add(1) }
+
+var _ ExtendedMetrics = (*countingMetrics)(nil)
+
+func TestExtendedMetrics(t *testing.T) {
+	metrics := &countingMetrics{}
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, nil
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{Metrics: metrics})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, NewMessage([]byte("test")), nil))
+	cancel()
+
+	require.NoError(t, client.Close())
+
+	require.EqualValues(t, 1, metrics.connectionsOpened.Load())
+	require.EqualValues(t, 1, metrics.connectionsClosed.Load())
+	require.EqualValues(t, 1, metrics.transfersSent.Load())
+	// at minimum the open, begin, attach, and transfer frames were sent
+	require.GreaterOrEqual(t, metrics.framesSent.Load(), int64(4))
+	require.GreaterOrEqual(t, metrics.framesReceived.Load(), int64(3))
+	require.EqualValues(t, 1, metrics.dispositionsReceived.Load())
+	require.EqualValues(t, 1, metrics.settlements.Load())
+}
@@ -0,0 +1,263 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Default retry values shared by Producer and Consumer.
+const (
+	defaultRetryAttempts = 3
+	defaultRetryDelay    = 250 * time.Millisecond
+	defaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryOptions contains the optional settings bounding the recovery and
+// operation retries performed by [Producer] and [Consumer].
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts for a single
+	// operation, including the first.
+	//
+	// Default: 3.
+	MaxAttempts int
+
+	// Delay is the time to wait before the second attempt.
+	// The delay is doubled after each subsequent failed attempt,
+	// up to MaxDelay.
+	//
+	// Default: 250ms.
+	Delay time.Duration
+
+	// MaxDelay caps the delay between attempts.
+	//
+	// Default: 30s.
+	MaxDelay time.Duration
+}
+
+// withRetry invokes op until it succeeds, returns a non-recoverable error,
+// or the configured attempts are exhausted.
+func withRetry(ctx context.Context, opts *RetryOptions, op func() error) error {
+	maxAttempts := defaultRetryAttempts
+	delay := defaultRetryDelay
+	maxDelay := defaultRetryMaxDelay
+	if opts != nil {
+		if opts.MaxAttempts > 0 {
+			maxAttempts = opts.MaxAttempts
+		}
+		if opts.Delay > 0 {
+			delay = opts.Delay
+		}
+		if opts.MaxDelay > 0 {
+			maxDelay = opts.MaxDelay
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= maxAttempts || !isRecoverableError(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+			// ready for the next attempt
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// isRecoverableError reports whether err indicates a failure of the
+// connection, session, or link rather than of the operation itself.
+func isRecoverableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var (
+		connErr    *ConnError
+		sessionErr *SessionError
+		linkErr    *LinkError
+		netErr     net.Error
+	)
+	return errors.As(err, &connErr) || errors.As(err, &sessionErr) || errors.As(err, &linkErr) || errors.As(err, &netErr)
+}
+
+// ProducerOptions contains the optional settings for configuring a Producer.
+type ProducerOptions struct {
+	// Conn contains the optional settings applied when dialing the
+	// underlying connection.
+	//
+	// Default: nil, meaning the [ConnOptions] defaults.
+	Conn *ConnOptions
+
+	// Retry bounds the recovery and send attempts for a single operation.
+	//
+	// Default: nil, meaning the [RetryOptions] defaults.
+	Retry *RetryOptions
+
+	// Sender contains the optional settings applied when attaching the
+	// underlying sender link.
+	//
+	// Default: nil, meaning the [SenderOptions] defaults.
+	Sender *SenderOptions
+
+	// Session contains the optional settings applied when creating the
+	// underlying session.
+	//
+	// Default: nil, meaning the [SessionOptions] defaults.
+	Session *SessionOptions
+}
+
+// Producer is a high-level wrapper around [Conn], [Session], and [Sender]
+// that transparently recovers from connection, session, and link failures.
+// It trades fine-grained control for a minimal interface: dial once with
+// [NewProducer], call Send, and whatever failed is re-established before
+// the send is retried.
+//
+// Safe for concurrent use.
+type Producer struct {
+	addr   string
+	target string
+	opts   ProducerOptions
+
+	mu      sync.Mutex // guards the fields below
+	conn    *Conn
+	session *Session
+	sender  *Sender
+	closed  bool
+}
+
+// NewProducer dials addr per [Dial] and attaches a sender to target,
+// retrying per the configured RetryOptions when a recoverable error occurs.
+//   - ctx controls waiting for the connection and link to be established
+//   - addr is the broker's address (see [Dial])
+//   - target is the name of the peer's receiver terminus
+//   - opts contains optional values, pass nil to accept the defaults
+func NewProducer(ctx context.Context, addr string, target string, opts *ProducerOptions) (*Producer, error) {
+	p := &Producer{addr: addr, target: target}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if err := withRetry(ctx, p.opts.Retry, func() error {
+		_, err := p.currentSender(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Send sends msg on the underlying sender, re-establishing the connection,
+// session, or link and retrying per the configured RetryOptions when a
+// recoverable error occurs.
+//
+// See [Sender.Send] for the semantics of a single attempt.  Note that a
+// retried send can result in duplicate delivery when the original attempt
+// was received but its acknowledgment was lost.
+func (p *Producer) Send(ctx context.Context, msg *Message, opts *SendOptions) error {
+	return withRetry(ctx, p.opts.Retry, func() error {
+		snd, err := p.currentSender(ctx)
+		if err != nil {
+			return err
+		}
+		if err = snd.Send(ctx, msg, opts); err != nil {
+			p.invalidate(snd, err)
+			return err
+		}
+		return nil
+	})
+}
+
+// Close closes the underlying connection along with its session and link.
+// Subsequent calls to Send will fail.
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if p.conn == nil {
+		return nil
+	}
+	conn := p.conn
+	p.conn, p.session, p.sender = nil, nil, nil
+	return conn.Close()
+}
+
+// currentSender returns the active sender, re-establishing the connection,
+// session, and link as needed.
+func (p *Producer) currentSender(ctx context.Context) (*Sender, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errors.New("producer is closed")
+	}
+	if p.conn == nil {
+		conn, err := Dial(ctx, p.addr, p.opts.Conn)
+		if err != nil {
+			return nil, err
+		}
+		p.conn = conn
+	}
+	if p.session == nil {
+		session, err := p.conn.NewSession(ctx, p.opts.Session)
+		if err != nil {
+			p.invalidateLocked(err)
+			return nil, err
+		}
+		p.session = session
+	}
+	if p.sender == nil {
+		snd, err := p.session.NewSender(ctx, p.target, p.opts.Sender)
+		if err != nil {
+			p.invalidateLocked(err)
+			return nil, err
+		}
+		p.sender = snd
+	}
+	return p.sender, nil
+}
+
+// invalidate tears down the layers indicated by err so the next operation
+// re-establishes them.  snd is the sender the error was observed on; when
+// it's no longer the current one a concurrent operation already recovered.
+func (p *Producer) invalidate(snd *Sender, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if snd != p.sender {
+		return
+	}
+	p.invalidateLocked(err)
+}
+
+func (p *Producer) invalidateLocked(err error) {
+	var (
+		connErr    *ConnError
+		sessionErr *SessionError
+		linkErr    *LinkError
+	)
+	switch {
+	case errors.As(err, &connErr):
+		if p.conn != nil {
+			_ = p.conn.Close()
+		}
+		p.conn, p.session, p.sender = nil, nil, nil
+	case errors.As(err, &sessionErr):
+		p.session, p.sender = nil, nil
+	case errors.As(err, &linkErr):
+		p.sender = nil
+	}
+}
@@ -0,0 +1,120 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNetDialer installs a fresh fake.NetConn for every dial, allowing
+// Producer/Consumer recovery to be exercised without a real network.
+type fakeNetDialer struct {
+	responder func() frameHandler
+	conns     chan *fake.NetConn
+}
+
+func newFakeNetDialer(responder func() frameHandler) *fakeNetDialer {
+	return &fakeNetDialer{responder: responder, conns: make(chan *fake.NetConn, 10)}
+}
+
+func (d *fakeNetDialer) NetDialerDial(ctx context.Context, c *Conn, host, port string) error {
+	conn := fake.NewNetConn(d.responder(), fake.NetConnOptions{})
+	d.conns <- conn
+	c.net = conn
+	return nil
+}
+
+func (d *fakeNetDialer) TLSDialWithDialer(ctx context.Context, c *Conn, host, port string) error {
+	return errors.New("not implemented")
+}
+
+// producerResponder handles the full frame exchange for a sender link,
+// granting credit on attach and accepting every transfer.
+func producerResponder() frameHandler {
+	return func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformAttach:
+			attach, err := fake.SenderAttach(0, tt.Name, 0, SenderSettleModeUnsettled)
+			if err != nil {
+				return fake.Response{}, err
+			}
+			nextIncoming, count, available, handle, credit := uint32(0), uint32(0), uint32(0), uint32(0), uint32(100)
+			flow, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+				NextIncomingID: &nextIncoming,
+				IncomingWindow: 1000000,
+				OutgoingWindow: 1000000,
+				NextOutgoingID: nextIncoming + 1,
+				Handle:         &handle,
+				DeliveryCount:  &count,
+				LinkCredit:     &credit,
+				Available:      &available,
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: append(attach, flow...)}, nil
+		case *frames.PerformTransfer:
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		}
+	}
+}
+
+func TestProducerSend(t *testing.T) {
+	dialer := newFakeNetDialer(producerResponder)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	producer, err := NewProducer(ctx, "amqp://localhost", "target", &ProducerOptions{
+		Conn: &ConnOptions{dialer: dialer},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, producer.Send(ctx, NewMessage([]byte("test")), nil))
+	cancel()
+
+	require.NoError(t, producer.Close())
+	require.Len(t, dialer.conns, 1)
+
+	// operations fail once closed
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = producer.Send(ctx, NewMessage([]byte("test")), nil)
+	cancel()
+	require.Error(t, err)
+	require.NoError(t, producer.Close())
+}
+
+func TestProducerSendRecoversConnection(t *testing.T) {
+	dialer := newFakeNetDialer(producerResponder)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	producer, err := NewProducer(ctx, "amqp://localhost", "target", &ProducerOptions{
+		Conn:  &ConnOptions{dialer: dialer},
+		Retry: &RetryOptions{Delay: 10 * time.Millisecond},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, producer.Send(ctx, NewMessage([]byte("test")), nil))
+	cancel()
+
+	// sever the connection out from under the producer and wait for the
+	// client to observe the failure
+	(<-dialer.conns).Close()
+	<-producer.conn.Done()
+
+	// the next send transparently redials and re-attaches
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	require.NoError(t, producer.Send(ctx, NewMessage([]byte("test")), nil))
+	cancel()
+
+	require.NoError(t, producer.Close())
+	require.Len(t, dialer.conns, 1)
+}
@@ -0,0 +1,195 @@
+// Package prometheus provides a ready-made Prometheus collector for
+// go-amqp metrics.
+//
+// The collector implements [amqp.Metrics] and [net/http.Handler], so a
+// single instance can be shared between connections and exposed on a
+// debug endpoint:
+//
+//	collector := prometheus.NewCollector()
+//	conn, err := amqp.Dial(ctx, addr, &amqp.ConnOptions{Metrics: collector})
+//	http.Handle("/metrics", collector)
+//
+// The collector also implements [amqp.ExtendedMetrics], adding frame and
+// disposition counters and a settlement latency histogram.
+//
+// The collector has no dependency on the Prometheus client library; it
+// serves the text exposition format directly.
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/Azure/go-amqp"
+)
+
+// Collector accumulates connection, link, credit, and throughput metrics
+// and exposes them in the Prometheus text exposition format.
+//
+// Collector is safe for concurrent use.
+type Collector struct {
+	connectionsOpened uint64
+	connectionsClosed uint64
+	linksAttached     uint64
+	linksDetached     uint64
+	transfersSent     uint64
+	transfersReceived uint64
+	bytesSent         uint64
+	bytesReceived     uint64
+	creditsIssued     uint64
+	creditStarvations uint64
+
+	framesSent           uint64
+	framesReceived       uint64
+	frameBytesSent       uint64
+	frameBytesReceived   uint64
+	dispositionsSent     uint64
+	dispositionsReceived uint64
+
+	// settlement latency histogram; per-bucket counts are cumulated when served
+	settlementCount   uint64
+	settlementSumNs   uint64
+	settlementBuckets [len(settlementUpperBounds)]uint64
+}
+
+// settlementUpperBounds are the upper bounds, in seconds, of the
+// settlement latency histogram's buckets.
+var settlementUpperBounds = [...]float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewCollector creates a new Collector with all metrics at zero.
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// ConnectionOpened implements the [amqp.Metrics] interface.
+func (c *Collector) ConnectionOpened() {
+	atomic.AddUint64(&c.connectionsOpened, 1)
+}
+
+// ConnectionClosed implements the [amqp.Metrics] interface.
+func (c *Collector) ConnectionClosed() {
+	atomic.AddUint64(&c.connectionsClosed, 1)
+}
+
+// LinkAttached implements the [amqp.Metrics] interface.
+func (c *Collector) LinkAttached() {
+	atomic.AddUint64(&c.linksAttached, 1)
+}
+
+// LinkDetached implements the [amqp.Metrics] interface.
+func (c *Collector) LinkDetached() {
+	atomic.AddUint64(&c.linksDetached, 1)
+}
+
+// TransferSent implements the [amqp.Metrics] interface.
+func (c *Collector) TransferSent(bytes int) {
+	atomic.AddUint64(&c.transfersSent, 1)
+	atomic.AddUint64(&c.bytesSent, uint64(bytes))
+}
+
+// TransferReceived implements the [amqp.Metrics] interface.
+func (c *Collector) TransferReceived(bytes int) {
+	atomic.AddUint64(&c.transfersReceived, 1)
+	atomic.AddUint64(&c.bytesReceived, uint64(bytes))
+}
+
+// CreditsIssued implements the [amqp.Metrics] interface.
+func (c *Collector) CreditsIssued(credits uint32) {
+	atomic.AddUint64(&c.creditsIssued, uint64(credits))
+}
+
+// CreditStarved implements the [amqp.Metrics] interface.
+func (c *Collector) CreditStarved(starved time.Duration) {
+	atomic.AddUint64(&c.creditStarvations, 1)
+}
+
+// FrameSent implements the [amqp.ExtendedMetrics] interface.
+func (c *Collector) FrameSent(bytes int) {
+	atomic.AddUint64(&c.framesSent, 1)
+	atomic.AddUint64(&c.frameBytesSent, uint64(bytes))
+}
+
+// FrameReceived implements the [amqp.ExtendedMetrics] interface.
+func (c *Collector) FrameReceived(bytes int) {
+	atomic.AddUint64(&c.framesReceived, 1)
+	atomic.AddUint64(&c.frameBytesReceived, uint64(bytes))
+}
+
+// DispositionSent implements the [amqp.ExtendedMetrics] interface.
+func (c *Collector) DispositionSent() {
+	atomic.AddUint64(&c.dispositionsSent, 1)
+}
+
+// DispositionReceived implements the [amqp.ExtendedMetrics] interface.
+func (c *Collector) DispositionReceived() {
+	atomic.AddUint64(&c.dispositionsReceived, 1)
+}
+
+// SettlementLatency implements the [amqp.ExtendedMetrics] interface.
+func (c *Collector) SettlementLatency(latency time.Duration) {
+	atomic.AddUint64(&c.settlementCount, 1)
+	atomic.AddUint64(&c.settlementSumNs, uint64(latency))
+	for i, ub := range settlementUpperBounds {
+		if latency.Seconds() <= ub {
+			atomic.AddUint64(&c.settlementBuckets[i], 1)
+			return
+		}
+	}
+	// above the last bound; counted only in +Inf via settlementCount
+}
+
+// ServeHTTP implements the [net/http.Handler] interface.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	opened := atomic.LoadUint64(&c.connectionsOpened)
+	closed := atomic.LoadUint64(&c.connectionsClosed)
+	attached := atomic.LoadUint64(&c.linksAttached)
+	detached := atomic.LoadUint64(&c.linksDetached)
+
+	for _, m := range []struct {
+		name  string
+		kind  string
+		help  string
+		value uint64
+	}{
+		{"amqp_connections_opened_total", "counter", "Total number of connections established.", opened},
+		{"amqp_connections_closed_total", "counter", "Total number of connections closed.", closed},
+		{"amqp_connections_open", "gauge", "Number of currently open connections.", opened - closed},
+		{"amqp_links_attached_total", "counter", "Total number of links attached.", attached},
+		{"amqp_links_detached_total", "counter", "Total number of links detached.", detached},
+		{"amqp_links_open", "gauge", "Number of currently attached links.", attached - detached},
+		{"amqp_transfers_sent_total", "counter", "Total number of transfer frames sent.", atomic.LoadUint64(&c.transfersSent)},
+		{"amqp_transfers_received_total", "counter", "Total number of transfer frames received.", atomic.LoadUint64(&c.transfersReceived)},
+		{"amqp_payload_bytes_sent_total", "counter", "Total payload bytes sent in transfer frames.", atomic.LoadUint64(&c.bytesSent)},
+		{"amqp_payload_bytes_received_total", "counter", "Total payload bytes received in transfer frames.", atomic.LoadUint64(&c.bytesReceived)},
+		{"amqp_link_credits_issued_total", "counter", "Total link credits issued by receivers.", atomic.LoadUint64(&c.creditsIssued)},
+		{"amqp_credit_starvation_events_total", "counter", "Total credit starvation events reported by senders.", atomic.LoadUint64(&c.creditStarvations)},
+		{"amqp_frames_sent_total", "counter", "Total number of AMQP frames sent.", atomic.LoadUint64(&c.framesSent)},
+		{"amqp_frames_received_total", "counter", "Total number of AMQP frames received.", atomic.LoadUint64(&c.framesReceived)},
+		{"amqp_frame_bytes_sent_total", "counter", "Total encoded bytes of AMQP frames sent.", atomic.LoadUint64(&c.frameBytesSent)},
+		{"amqp_frame_bytes_received_total", "counter", "Total encoded bytes of AMQP frames received.", atomic.LoadUint64(&c.frameBytesReceived)},
+		{"amqp_dispositions_sent_total", "counter", "Total number of disposition frames sent.", atomic.LoadUint64(&c.dispositionsSent)},
+		{"amqp_dispositions_received_total", "counter", "Total number of disposition frames received.", atomic.LoadUint64(&c.dispositionsReceived)},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+	}
+
+	const histogram = "amqp_settlement_latency_seconds"
+	fmt.Fprintf(w, "# HELP %s Time between sending an unsettled delivery and observing its settlement.\n# TYPE %s histogram\n", histogram, histogram)
+	var cumulative uint64
+	for i, ub := range settlementUpperBounds {
+		cumulative += atomic.LoadUint64(&c.settlementBuckets[i])
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", histogram, strconv.FormatFloat(ub, 'g', -1, 64), cumulative)
+	}
+	count := atomic.LoadUint64(&c.settlementCount)
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", histogram, count)
+	fmt.Fprintf(w, "%s_sum %g\n", histogram, time.Duration(atomic.LoadUint64(&c.settlementSumNs)).Seconds())
+	fmt.Fprintf(w, "%s_count %d\n", histogram, count)
+}
+
+var _ amqp.ExtendedMetrics = (*Collector)(nil)
+var _ http.Handler = (*Collector)(nil)
@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	c.ConnectionOpened()
+	c.ConnectionOpened()
+	c.ConnectionClosed()
+	c.LinkAttached()
+	c.LinkDetached()
+	c.TransferSent(10)
+	c.TransferReceived(20)
+	c.TransferReceived(5)
+	c.CreditsIssued(100)
+	c.CreditStarved(time.Second)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	require.Contains(t, body, "amqp_connections_opened_total 2\n")
+	require.Contains(t, body, "amqp_connections_closed_total 1\n")
+	require.Contains(t, body, "amqp_connections_open 1\n")
+	require.Contains(t, body, "amqp_links_attached_total 1\n")
+	require.Contains(t, body, "amqp_links_detached_total 1\n")
+	require.Contains(t, body, "amqp_links_open 0\n")
+	require.Contains(t, body, "amqp_transfers_sent_total 1\n")
+	require.Contains(t, body, "amqp_transfers_received_total 2\n")
+	require.Contains(t, body, "amqp_payload_bytes_sent_total 10\n")
+	require.Contains(t, body, "amqp_payload_bytes_received_total 25\n")
+	require.Contains(t, body, "amqp_link_credits_issued_total 100\n")
+	require.Contains(t, body, "amqp_credit_starvation_events_total 1\n")
+}
+
+func TestCollectorExtendedMetrics(t *testing.T) {
+	c := NewCollector()
+	c.FrameSent(100)
+	c.FrameSent(50)
+	c.FrameReceived(25)
+	c.DispositionSent()
+	c.DispositionReceived()
+	c.DispositionReceived()
+	c.SettlementLatency(2 * time.Millisecond)
+	c.SettlementLatency(40 * time.Millisecond)
+	c.SettlementLatency(time.Minute) // above the last bucket bound
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	require.Contains(t, body, "amqp_frames_sent_total 2\n")
+	require.Contains(t, body, "amqp_frames_received_total 1\n")
+	require.Contains(t, body, "amqp_frame_bytes_sent_total 150\n")
+	require.Contains(t, body, "amqp_frame_bytes_received_total 25\n")
+	require.Contains(t, body, "amqp_dispositions_sent_total 1\n")
+	require.Contains(t, body, "amqp_dispositions_received_total 2\n")
+	require.Contains(t, body, "# TYPE amqp_settlement_latency_seconds histogram\n")
+	require.Contains(t, body, `amqp_settlement_latency_seconds_bucket{le="0.001"} 0`)
+	require.Contains(t, body, `amqp_settlement_latency_seconds_bucket{le="0.005"} 1`)
+	require.Contains(t, body, `amqp_settlement_latency_seconds_bucket{le="0.05"} 2`)
+	require.Contains(t, body, `amqp_settlement_latency_seconds_bucket{le="10"} 2`)
+	require.Contains(t, body, `amqp_settlement_latency_seconds_bucket{le="+Inf"} 3`)
+	require.Contains(t, body, "amqp_settlement_latency_seconds_sum 60.042\n")
+	require.Contains(t, body, "amqp_settlement_latency_seconds_count 3\n")
+}
@@ -0,0 +1,298 @@
+package amqp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProxyDialer establishes the tunneled connection to the broker's
+// address.  Its signature matches golang.org/x/net/proxy's
+// ContextDialer, so dialers from that package plug in directly.
+type ProxyDialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// ProxyOptions contains the settings for tunneling through a proxy.
+// One of Dialer or URL must be set.
+type ProxyOptions struct {
+	// Dialer establishes the tunneled connection, for proxy protocols
+	// this package doesn't implement.  When set, URL is ignored.
+	//
+	// Default: nil, meaning URL selects a built-in proxy protocol.
+	Dialer ProxyDialer
+
+	// URL is the address of the proxy.  The scheme selects the proxy
+	// protocol: "http" and "https" tunnel through the HTTP CONNECT
+	// method (the latter speaking TLS to the proxy itself), "socks5"
+	// through the SOCKS version 5 CONNECT command.  Credentials in the
+	// URL are sent as Proxy-Authorization (Basic) or SOCKS
+	// username/password authentication respectively.  When the URL
+	// omits the port, the scheme's default (80, 443, or 1080) is used.
+	URL string
+}
+
+// implements the dialer interface by tunneling through a proxy
+type proxyDialer struct {
+	opts    *ProxyOptions
+	netDial Dialer // reaches the proxy itself; nil means net.Dialer
+}
+
+func (d proxyDialer) NetDialerDial(ctx context.Context, c *Conn, host, port string) error {
+	conn, err := proxyDial(ctx, d, net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+	c.net = conn
+	return nil
+}
+
+func (d proxyDialer) TLSDialWithDialer(ctx context.Context, c *Conn, host, port string) error {
+	conn, err := proxyDial(ctx, d, net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+	tlsConn := tls.Client(conn, c.tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return err
+	}
+	c.net = tlsConn
+	return nil
+}
+
+// proxyDial connects to the proxy and negotiates a tunnel to targetAddr.
+func proxyDial(ctx context.Context, d proxyDialer, targetAddr string) (net.Conn, error) {
+	opts := d.opts
+	if opts.Dialer != nil {
+		return opts.Dialer.DialContext(ctx, "tcp", targetAddr)
+	}
+	if opts.URL == "" {
+		return nil, errors.New("proxy options must specify a URL or a dialer")
+	}
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	host, port := u.Hostname(), u.Port()
+	var proxyTLS bool
+	switch u.Scheme {
+	case "http":
+		if port == "" {
+			port = "80"
+		}
+	case "https":
+		if port == "" {
+			port = "443"
+		}
+		proxyTLS = true
+	case "socks5":
+		if port == "" {
+			port = "1080"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+
+	netDial := d.netDial
+	if netDial == nil {
+		netDial = &net.Dialer{}
+	}
+	conn, err := netDial.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	if proxyTLS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	// bound the tunnel negotiation by the dialing context
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+
+	var tunneled net.Conn
+	if u.Scheme == "socks5" {
+		tunneled, err = socks5Connect(conn, u.User, targetAddr)
+	} else {
+		tunneled, err = httpConnect(conn, u.User, targetAddr)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = tunneled.SetDeadline(time.Time{})
+	return tunneled, nil
+}
+
+// httpConnect issues an HTTP CONNECT request for targetAddr over conn.
+func httpConnect(conn net.Conn, user *url.Userinfo, targetAddr string) (net.Conn, error) {
+	var req strings.Builder
+	fmt.Fprintf(&req, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n", targetAddr, targetAddr)
+	if user != nil {
+		pass, _ := user.Password()
+		cred := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + pass))
+		fmt.Fprintf(&req, "Proxy-Authorization: Basic %s\r\n", cred)
+	}
+	req.WriteString("\r\n")
+	if _, err := conn.Write([]byte(req.String())); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy refused connection: %s", resp.Status)
+	}
+	// any bytes the reader buffered past the response belong to the broker
+	return &bufferedConn{Conn: conn, r: br}, nil
+}
+
+// bufferedConn joins bytes buffered during the tunnel negotiation with
+// the rest of the stream.
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+// textual forms of the SOCKS5 reply codes, per RFC 1928
+var socks5Replies = []string{
+	"succeeded",
+	"general SOCKS server failure",
+	"connection not allowed by ruleset",
+	"network unreachable",
+	"host unreachable",
+	"connection refused",
+	"TTL expired",
+	"command not supported",
+	"address type not supported",
+}
+
+// socks5Connect negotiates a SOCKS5 tunnel to targetAddr over conn.
+func socks5Connect(conn net.Conn, user *url.Userinfo, targetAddr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(targetAddr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// method selection: no authentication, plus username/password when
+	// the URL carries credentials
+	methods := []byte{0x00}
+	if user != nil {
+		methods = append(methods, 0x02)
+	}
+	if _, err := conn.Write(append([]byte{0x05, byte(len(methods))}, methods...)); err != nil {
+		return nil, err
+	}
+	var sel [2]byte
+	if _, err := io.ReadFull(conn, sel[:]); err != nil {
+		return nil, err
+	}
+	if sel[0] != 0x05 {
+		return nil, errors.New("proxy isn't a SOCKS5 server")
+	}
+	switch sel[1] {
+	case 0x00:
+		// no authentication required
+	case 0x02:
+		name := user.Username()
+		pass, _ := user.Password()
+		auth := append([]byte{0x01, byte(len(name))}, name...)
+		auth = append(auth, byte(len(pass)))
+		auth = append(auth, pass...)
+		if _, err := conn.Write(auth); err != nil {
+			return nil, err
+		}
+		var status [2]byte
+		if _, err := io.ReadFull(conn, status[:]); err != nil {
+			return nil, err
+		}
+		if status[1] != 0x00 {
+			return nil, errors.New("proxy rejected the credentials")
+		}
+	default:
+		return nil, errors.New("proxy offered no acceptable authentication method")
+	}
+
+	// CONNECT request
+	req := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("hostname %q is too long for SOCKS5", host)
+		}
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, 0x01)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, 0x04)
+		req = append(req, ip.To16()...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	var reply [4]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return nil, err
+	}
+	if reply[1] != 0x00 {
+		msg := "unknown failure"
+		if int(reply[1]) < len(socks5Replies) {
+			msg = socks5Replies[reply[1]]
+		}
+		return nil, fmt.Errorf("proxy refused connection: %s", msg)
+	}
+
+	// skip the bound address and port
+	var skip int
+	switch reply[3] {
+	case 0x01:
+		skip = 4
+	case 0x04:
+		skip = 16
+	case 0x03:
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return nil, err
+		}
+		skip = int(l[0])
+	default:
+		return nil, errors.New("malformed SOCKS5 reply")
+	}
+	if _, err := io.CopyN(io.Discard, conn, int64(skip+2)); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
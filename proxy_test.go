@@ -0,0 +1,241 @@
+package amqp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyBridge shuttles the tunneled AMQP stream between conn and a fake
+// net.Conn, re-aligning on frame boundaries so the standard responders
+// receive one frame per write.
+func proxyBridge(conn net.Conn, br *bufio.Reader, fakeConn *fake.NetConn) {
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := fakeConn.Read(buf)
+			if err != nil {
+				return
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+	defer fakeConn.Close()
+
+	// the protocol header is the only unsized exchange
+	hdr := make([]byte, 8)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return
+	}
+	if _, err := fakeConn.Write(hdr); err != nil {
+		return
+	}
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+		copy(frame, size[:])
+		if _, err := io.ReadFull(br, frame[4:]); err != nil {
+			return
+		}
+		if _, err := fakeConn.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+func newProxyResponder() func(uint16, frames.FrameBody) (fake.Response, error) {
+	return func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *fake.KeepAlive:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+}
+
+func TestDialProxyHTTPConnect(t *testing.T) {
+	fakeConn := fake.NewNetConn(newProxyResponder(), fake.NetConnOptions{})
+
+	type connect struct {
+		target string
+		auth   string
+	}
+	connects := make(chan connect, 1)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		connects <- connect{target: req.Host, auth: req.Header.Get("Proxy-Authorization")}
+		if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+			return
+		}
+		proxyBridge(conn, br, fakeConn)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := Dial(ctx, "amqp://broker.example.com", &ConnOptions{
+		Proxy: &ProxyOptions{URL: "http://user:pass@" + l.Addr().String()},
+	})
+	cancel()
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+
+	c := <-connects
+	require.Equal(t, "broker.example.com:5672", c.target)
+	require.Equal(t, "Basic dXNlcjpwYXNz", c.auth)
+}
+
+func TestDialProxyHTTPConnectRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		br := bufio.NewReader(conn)
+		if _, err := http.ReadRequest(br); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err = Dial(ctx, "amqp://broker.example.com", &ConnOptions{
+		Proxy: &ProxyOptions{URL: "http://" + l.Addr().String()},
+	})
+	require.ErrorContains(t, err, "proxy refused connection")
+}
+
+func TestDialProxySOCKS5(t *testing.T) {
+	fakeConn := fake.NewNetConn(newProxyResponder(), fake.NetConnOptions{})
+
+	type connect struct {
+		target string
+		user   string
+		pass   string
+	}
+	connects := make(chan connect, 1)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		br := bufio.NewReader(conn)
+
+		// greeting: select username/password authentication
+		hdr := make([]byte, 2)
+		if _, err := io.ReadFull(br, hdr); err != nil || hdr[0] != 0x05 {
+			return
+		}
+		if _, err := io.CopyN(io.Discard, br, int64(hdr[1])); err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte{0x05, 0x02}); err != nil {
+			return
+		}
+
+		// RFC 1929 username/password subnegotiation
+		if _, err := io.ReadFull(br, hdr); err != nil || hdr[0] != 0x01 {
+			return
+		}
+		user := make([]byte, hdr[1])
+		if _, err := io.ReadFull(br, user); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(br, hdr[:1]); err != nil {
+			return
+		}
+		pass := make([]byte, hdr[0])
+		if _, err := io.ReadFull(br, pass); err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte{0x01, 0x00}); err != nil {
+			return
+		}
+
+		// CONNECT request with a domain name target
+		req := make([]byte, 5)
+		if _, err := io.ReadFull(br, req); err != nil || req[1] != 0x01 || req[3] != 0x03 {
+			return
+		}
+		host := make([]byte, req[4])
+		if _, err := io.ReadFull(br, host); err != nil {
+			return
+		}
+		var port [2]byte
+		if _, err := io.ReadFull(br, port[:]); err != nil {
+			return
+		}
+		connects <- connect{
+			target: net.JoinHostPort(string(host), fmt.Sprint(binary.BigEndian.Uint16(port[:]))),
+			user:   string(user),
+			pass:   string(pass),
+		}
+		if _, err := conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+			return
+		}
+		proxyBridge(conn, br, fakeConn)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := Dial(ctx, "amqp://broker.example.com", &ConnOptions{
+		Proxy: &ProxyOptions{URL: "socks5://user:pass@" + l.Addr().String()},
+	})
+	cancel()
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+
+	c := <-connects
+	require.Equal(t, "broker.example.com:5672", c.target)
+	require.Equal(t, "user", c.user)
+	require.Equal(t, "pass", c.pass)
+}
+
+func TestDialProxyMissingConfig(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := Dial(ctx, "amqp://broker.example.com", &ConnOptions{Proxy: &ProxyOptions{}})
+	require.ErrorContains(t, err, "proxy options")
+}
@@ -0,0 +1,63 @@
+package amqp
+
+import (
+	"sync"
+	"time"
+)
+
+// quarantine delays the reuse of released numbers (link handles, session
+// channels).  a frame addressed to a closed link or ended session can still
+// be in flight when the number is released, e.g. when the context passed to
+// Close expires mid-handshake; holding the number for a grace period keeps
+// such frames from being attributed to a new owner of the same number.
+type quarantine struct {
+	// the grace period before a held number may be reused.
+	// zero or negative disables quarantining.
+	delay time.Duration
+
+	mu sync.Mutex
+	// held numbers in release order, so expired entries are always a prefix
+	entries []quarantineEntry
+}
+
+type quarantineEntry struct {
+	n        uint32
+	released time.Time
+}
+
+// hold records n as released at the current time.  it returns false when
+// quarantining is disabled, in which case n may be reused immediately.
+func (q *quarantine) hold(n uint32) bool {
+	if q.delay <= 0 {
+		return false
+	}
+	q.mu.Lock()
+	q.entries = append(q.entries, quarantineEntry{n: n, released: time.Now()})
+	q.mu.Unlock()
+	return true
+}
+
+// release removes and returns the held numbers whose grace period has elapsed.
+func (q *quarantine) release() []uint32 {
+	if q.delay <= 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cutoff := time.Now().Add(-q.delay)
+	count := 0
+	for count < len(q.entries) && !q.entries[count].released.After(cutoff) {
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+
+	released := make([]uint32, count)
+	for i := 0; i < count; i++ {
+		released[i] = q.entries[i].n
+	}
+	q.entries = append(q.entries[:0], q.entries[count:]...)
+	return released
+}
@@ -5,14 +5,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Azure/go-amqp/internal/buffer"
 	"github.com/Azure/go-amqp/internal/debug"
 	"github.com/Azure/go-amqp/internal/encoding"
 	"github.com/Azure/go-amqp/internal/frames"
 	"github.com/Azure/go-amqp/internal/queue"
+	"github.com/Azure/go-amqp/internal/ring"
+	"github.com/Azure/go-amqp/internal/serial"
 )
 
 // Default link options
@@ -20,6 +26,9 @@ const (
 	defaultLinkCredit = 1
 )
 
+// period between evaluations of a custom CreditStrategy on an otherwise idle link
+const creditStrategyTickInterval = 1 * time.Second
+
 // Receiver receives messages on a single AMQP link.
 type Receiver struct {
 	l link
@@ -33,6 +42,18 @@ type Receiver struct {
 	// at present, this is only used for debug tracing purposes so it's safe to change it to a count.
 	unsettledMessages int32 // count of unsettled messages for this receiver; MUST be atomically accessed
 
+	peerAvailable uint32 // most recent "available" value from the peer's flow frames; MUST be atomically accessed
+
+	// link-state queries; stateQueryMu guards both fields
+	stateQueryMu      sync.Mutex
+	stateQueryPending bool             // true when a query flow frame with echo set needs to be sent
+	stateQueries      []chan LinkState // callers waiting on the peer's responding flow frame
+
+	// pending credit-window resize; see Receiver.SetCredit
+	creditResizeMu      sync.Mutex
+	creditResizePending bool
+	creditResizeTo      uint32
+
 	msgBuf buffer.Buffer // buffered bytes for current message
 	more   bool          // if true, buf contains a partial message
 	msg    Message       // current message being decoded
@@ -40,9 +61,49 @@ type Receiver struct {
 	settlementCount   uint32     // the count of settled messages
 	settlementCountMu sync.Mutex // must be held when accessing settlementCount
 
-	autoSendFlow bool     // automatically send flow frames as credit becomes available
-	inFlight     inFlight // used to track message disposition when rcv-settle-mode == second
-	creditor     creditor // manages credits via calls to IssueCredit/DrainCredit
+	autoSendFlow bool // automatically send flow frames as credit becomes available
+	rawTransfers bool // deliver assembled payload bytes without decoding sections
+
+	// streaming receive; see Receiver.ReceiveStream.
+	// streamWaiterMu guards streamWaiter; stream is mux-only state.
+	streamWaiterMu sync.Mutex
+	streamWaiter   chan *MessageStream
+	stream         *MessageStream // delivery currently being streamed, nil otherwise
+
+	// inline receive mode; see ReceiverOptions.InlineReceive.
+	// pumpMu serializes frame processing between callers' goroutines and
+	// the close-time mux; closeMuxOnce starts that mux exactly once.
+	inlineReceive bool
+	pumpMu        sync.Mutex
+	closeMuxOnce  sync.Once
+
+	maxDeliveryAttempts uint32                 // auto-settle messages with this many prior delivery attempts; zero means disabled
+	maxDeliveryOutcome  encoding.DeliveryState // outcome applied to auto-settled messages
+
+	messageFilter        func(*Message) bool    // auto-settle messages this predicate rejects; zero means disabled
+	messageFilterOutcome encoding.DeliveryState // outcome applied to filtered messages
+
+	maxUnsettled uint32 // cap on locally unsettled deliveries; zero means no cap
+
+	modifiedDefaults *ModifyMessageOptions // default fields for modified outcomes; see ReceiverOptions.ModifiedOutcomeDefaults
+
+	discardExpired        bool                   // auto-settle messages that have expired in transit
+	discardExpiredOutcome encoding.DeliveryState // outcome applied to expired messages
+
+	creditStrategy CreditStrategy // determines when and how much credit is automatically issued
+	creditTicker   *time.Ticker   // periodically wakes the mux for custom credit strategies; nil for the default strategy
+	inFlight       inFlight       // used to track message disposition when rcv-settle-mode == second
+	creditor       creditor       // manages credits via calls to IssueCredit/DrainCredit
+
+	// ordered settlement; dispOrder and dispPending are nil unless orderedSettlement is true
+	orderedSettlement bool                              // transmit dispositions in delivery order
+	dispOrderMu       sync.Mutex                        // guards dispOrder and dispPending
+	dispOrder         []uint32                          // delivery IDs of unsettled messages in arrival order
+	dispPending       map[uint32]encoding.DeliveryState // dispositions buffered until prior deliveries settle
+
+	// captured at construction so Refresh can re-attach with identical configuration
+	refreshAddr string
+	refreshOpts ReceiverOptions
 }
 
 // IssueCredit adds credits to be requested in the next flow request.
@@ -71,6 +132,35 @@ type DrainCreditOptions struct {
 	// for future expansion
 }
 
+// SetCredit changes the receiver's credit window to n at runtime.  The
+// new window takes effect on the next flow frame: growing the window
+// grants the sender additional credit, while shrinking it revokes credit
+// the sender hasn't yet consumed.  Messages already in the prefetch cache
+// count against the new window, so consumers can shrink prefetch under
+// memory pressure without re-attaching the link.
+//
+// SetCredit can only be used with receivers in automatic credit
+// management mode; for manual mode use [Receiver.IssueCredit] and
+// [Receiver.DrainCredit].
+func (r *Receiver) SetCredit(n uint32) error {
+	if !r.autoSendFlow {
+		return errors.New("setCredit can only be used with receiver links using automatic credit management")
+	}
+
+	r.creditResizeMu.Lock()
+	r.creditResizePending = true
+	r.creditResizeTo = n
+	r.creditResizeMu.Unlock()
+
+	// cause mux() to check our flow conditions.
+	select {
+	case r.receiverReady <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
 // DrainCredit sets the drain flag on the next outbound FLOW frame and blocks until
 // the corresponding FLOW frame is received. While a drain is in progress, messages
 // can continue to arrive. After a drain completes, the Receiver will have
@@ -94,6 +184,57 @@ func (r *Receiver) DrainCredit(ctx context.Context, _ *DrainCreditOptions) error
 	return r.creditor.Drain(ctx, r)
 }
 
+// LinkState is the sender's view of a link's flow-control state, as
+// reported in a flow frame.
+type LinkState struct {
+	// Available is the number of messages the sender could send if it
+	// had sufficient credit, i.e. its backlog for this link.
+	Available uint32
+
+	// DeliveryCount is the sender's delivery-count.
+	DeliveryCount uint32
+
+	// Drain indicates the sender is draining the link's credit.
+	Drain bool
+
+	// LinkCredit is the sender's view of the available link credit.
+	LinkCredit uint32
+}
+
+// QueryLinkState requests the sender's current view of the link by sending
+// a flow frame with the echo flag set and waiting for the responding flow
+// frame.  Useful for monitoring sender-side backlog on idle links.
+//   - ctx controls waiting for the peer's response
+//
+// If the context's deadline expires or is cancelled before the peer
+// responds, an error is returned.
+//
+// NOTE: responding to an echo request is optional, as per the AMQP spec.
+// Check with your individual broker's documentation for implementation
+// details.
+func (r *Receiver) QueryLinkState(ctx context.Context) (LinkState, error) {
+	ch := make(chan LinkState, 1)
+	r.stateQueryMu.Lock()
+	r.stateQueryPending = true
+	r.stateQueries = append(r.stateQueries, ch)
+	r.stateQueryMu.Unlock()
+
+	// wake the mux so the query flow frame is sent
+	select {
+	case r.receiverReady <- struct{}{}:
+	default:
+	}
+
+	select {
+	case state := <-ch:
+		return state, nil
+	case <-r.l.done:
+		return LinkState{}, r.l.doneErr
+	case <-ctx.Done():
+		return LinkState{}, ctx.Err()
+	}
+}
+
 // Prefetched returns the next message that is stored in the Receiver's
 // prefetch cache. It does NOT wait for the remote sender to send messages
 // and returns immediately if the prefetch cache is empty. To receive from the
@@ -118,7 +259,7 @@ func (r *Receiver) Prefetched() *Message {
 		return nil
 	}
 
-	debug.Log(3, "RX (Receiver %p): prefetched delivery ID %d", r, msg.deliveryID)
+	debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p): prefetched delivery ID %d", r, msg.deliveryID)
 
 	if msg.settled {
 		r.onSettlement(1)
@@ -127,6 +268,46 @@ func (r *Receiver) Prefetched() *Message {
 	return msg
 }
 
+// DrainPrefetch releases the messages in the Receiver's prefetch cache
+// back to the server, so a consumer that stops consuming doesn't strand
+// already-buffered messages until the link closes.  Messages the sender
+// pre-settled have no disposition to send and are simply discarded.
+// Returns the number of messages removed from the prefetch cache.
+//   - ctx controls waiting for the peer to acknowledge the dispositions
+//
+// DrainPrefetch doesn't alter the link's credit, so further messages can
+// arrive while it runs; only those buffered when it was called are
+// released.  To stop further deliveries first, shrink the window with
+// [Receiver.SetCredit] (automatic credit management) or drain it with
+// [Receiver.DrainCredit] (manual credit management).
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, the remaining messages stay in the prefetch cache and the
+// disposition of the message being released is in an unknown state.
+func (r *Receiver) DrainPrefetch(ctx context.Context) (int, error) {
+	count := r.messagesQ.Len()
+	for drained := 0; drained < count; drained++ {
+		msg := r.Prefetched()
+		if msg == nil {
+			return drained, nil
+		}
+		if err := r.ReleaseMessage(ctx, msg); err != nil {
+			return drained, err
+		}
+	}
+	return count, nil
+}
+
+// Available returns the number of messages the peer most recently reported
+// as available for this link, i.e. its backlog of messages that could be
+// sent given sufficient credit.  The value is updated from incoming flow
+// frames and can inform credit and prefetch decisions.  Note that peers
+// aren't required to send a flow frame when their backlog changes, so the
+// value may be stale.
+func (r *Receiver) Available() uint32 {
+	return atomic.LoadUint32(&r.peerAvailable)
+}
+
 // ReceiveOptions contains any optional values for the Receiver.Receive method.
 type ReceiveOptions struct {
 	// for future expansion
@@ -139,6 +320,118 @@ type ReceiveOptions struct {
 // than SenderSettleModeSettled, you *must* take an action on the message by calling
 // one of the following: AcceptMessage, RejectMessage, ReleaseMessage, ModifyMessage.
 func (r *Receiver) Receive(ctx context.Context, opts *ReceiveOptions) (*Message, error) {
+	for {
+		msg, err := r.receiveMessage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.discardExpired {
+			if expiry := msg.ExpiryTime(); !expiry.IsZero() && !time.Now().Before(expiry) {
+				// the message expired in transit; settle it with the
+				// configured outcome and wait for the next message
+				debug.LogC(r.l.session.conn.correlationID, 1, "RX (Receiver %p): delivery ID %d expired at %s", r, msg.deliveryID, expiry)
+				if err := r.messageDisposition(ctx, msg, r.discardExpiredOutcome); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		if r.maxDeliveryAttempts > 0 && msg.DeliveryCount() >= r.maxDeliveryAttempts {
+			// the message has exhausted its delivery attempts; settle it with
+			// the configured outcome and wait for the next message
+			debug.LogC(r.l.session.conn.correlationID, 1, "RX (Receiver %p): delivery ID %d exceeded %d delivery attempts", r, msg.deliveryID, r.maxDeliveryAttempts)
+			if err := r.messageDisposition(ctx, msg, r.maxDeliveryOutcome); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if r.messageFilter != nil && !r.messageFilter(msg) {
+			// the message was rejected by the filter predicate; settle it
+			// with the configured outcome and wait for the next message
+			debug.LogC(r.l.session.conn.correlationID, 1, "RX (Receiver %p): delivery ID %d rejected by message filter", r, msg.deliveryID)
+			if err := r.messageDisposition(ctx, msg, r.messageFilterOutcome); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if tr := r.l.session.conn.tracer; tr != nil {
+			tr.MessageReceived(extractTraceParent(msg), r.Address(), msg)
+		}
+
+		return msg, nil
+	}
+}
+
+// ReceiveBatch returns up to maxCount messages from the sender.  It
+// blocks until the first message arrives or ctx completes; once a batch
+// has started, it's returned when maxCount messages have accumulated or
+// maxWait has elapsed, whichever comes first.  A maxWait of zero or less
+// doesn't wait at all: the batch is filled from the prefetch cache and
+// returned immediately.
+//
+// Batches are filled from the prefetch cache, so throughput is governed
+// by the credit window; size [ReceiverOptions.Credit] (or call
+// [Receiver.SetCredit]) to at least maxCount so a full batch can be in
+// flight at once.
+//
+// If an error occurs after the first message has been received, the
+// messages accumulated so far are returned along with the error, as any
+// unsettled messages among them must still be settled.
+//
+// As with [Receiver.Receive], received messages from senders not
+// configured in SenderSettleModeSettled must be settled by calling one
+// of AcceptMessage, RejectMessage, ReleaseMessage, or ModifyMessage.
+func (r *Receiver) ReceiveBatch(ctx context.Context, maxCount int, maxWait time.Duration) ([]*Message, error) {
+	if maxCount < 1 {
+		return nil, errors.New("maxCount must be greater than zero")
+	}
+
+	msg, err := r.Receive(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	batch := make([]*Message, 1, maxCount)
+	batch[0] = msg
+	if maxCount == 1 {
+		return batch, nil
+	}
+
+	// bound the remainder of the batch by maxWait; when there's no wait,
+	// an already-cancelled context drains the prefetch cache and nothing
+	// more
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if maxWait > 0 {
+		timer := time.AfterFunc(maxWait, cancel)
+		defer timer.Stop()
+	} else {
+		cancel()
+	}
+
+	for len(batch) < maxCount {
+		msg, err := r.Receive(batchCtx, nil)
+		if err != nil {
+			if batchCtx.Err() != nil && ctx.Err() == nil {
+				// maxWait elapsed; the batch is as full as it's getting
+				return batch, nil
+			}
+			return batch, err
+		}
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+// receiveMessage returns the next prefetched or incoming message.
+func (r *Receiver) receiveMessage(ctx context.Context) (*Message, error) {
+	if r.inlineReceive {
+		return r.receiveMessageInline(ctx)
+	}
+
 	if msg := r.Prefetched(); msg != nil {
 		return msg, nil
 	}
@@ -148,7 +441,7 @@ func (r *Receiver) Receive(ctx context.Context, opts *ReceiveOptions) (*Message,
 	case q := <-r.messagesQ.Wait():
 		msg := q.Dequeue()
 		debug.Assert(msg != nil)
-		debug.Log(3, "RX (Receiver %p): received delivery ID %d", r, msg.deliveryID)
+		debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p): received delivery ID %d", r, msg.deliveryID)
 		r.messagesQ.Release(q)
 		if msg.settled {
 			r.onSettlement(1)
@@ -168,6 +461,174 @@ func (r *Receiver) Receive(ctx context.Context, opts *ReceiveOptions) (*Message,
 	}
 }
 
+// receiveMessageInline returns the next prefetched or incoming message,
+// processing incoming frames on the caller's goroutine.
+func (r *Receiver) receiveMessageInline(ctx context.Context) (*Message, error) {
+	for {
+		if msg := r.Prefetched(); msg != nil {
+			return msg, nil
+		}
+
+		if err := r.pump(ctx, true); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// inlineInit performs mux initialization for inline receive mode on the
+// caller's goroutine.
+func (r *Receiver) inlineInit() error {
+	if !r.muxInit() {
+		r.muxDone()
+		return r.l.doneErr
+	}
+	return nil
+}
+
+// pump runs one iteration of mux processing on the caller's goroutine.
+// it returns a terminal error once the link is done.
+//   - returnOnMessage returns early when a message has been assembled
+func (r *Receiver) pump(ctx context.Context, returnOnMessage bool) (err error) {
+	r.pumpMu.Lock()
+	defer r.pumpMu.Unlock()
+
+	defer func() {
+		// as in the mux, a panic leaves the link and its session in an
+		// undefined state, so close the owning connection
+		if v := recover(); v != nil {
+			r.l.doneErr = &LinkError{inner: muxPanicError(v)}
+			r.l.session.conn.close()
+			err = r.l.doneErr
+		}
+	}()
+
+	select {
+	case <-r.l.done:
+		return r.l.doneErr
+	default:
+	}
+
+	// a concurrent pump may have already assembled a message
+	if returnOnMessage && r.messagesQ.Len() > 0 {
+		return nil
+	}
+
+	// drain any pending wakeup rather than selecting on receiverReady;
+	// every pump re-evaluates the credit strategy in muxPrepare, which
+	// is all the wakeup asks for, and Prefetched signals it on each
+	// call so selecting on it would spin
+	select {
+	case <-r.receiverReady:
+	default:
+	}
+
+	// dispositions bypass the mux in inline mode, so txDisposition is
+	// never signaled
+	_, closed, exit := r.muxPrepare()
+	if exit {
+		r.muxDone()
+		return r.l.doneErr
+	}
+
+	var creditTick <-chan time.Time
+	if r.creditTicker != nil {
+		creditTick = r.creditTicker.C
+	}
+
+	select {
+	case q := <-r.l.rxQ.Wait():
+		if !r.muxRecvFrame(q) {
+			r.muxDone()
+			return r.l.doneErr
+		}
+
+	case <-creditTick:
+		// wake so the credit strategy is re-evaluated
+
+	case <-closed:
+		r.muxClientClose()
+
+	case <-r.l.session.done:
+		r.l.doneErr = r.l.session.doneErr
+		r.muxDone()
+		return r.l.doneErr
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// closeMux drives mux processing during link close in inline receive
+// mode so the detach handshake completes without callers pumping.
+func (r *Receiver) closeMux() {
+	r.pumpMu.Lock()
+	defer r.pumpMu.Unlock()
+
+	select {
+	case <-r.l.done:
+		// a pump already terminated the link
+		return
+	default:
+	}
+
+	defer r.muxDone()
+
+	defer func() {
+		if v := recover(); v != nil {
+			r.l.doneErr = &LinkError{inner: muxPanicError(v)}
+			r.l.session.conn.close()
+		}
+	}()
+
+	for {
+		txDisposition, closed, exit := r.muxPrepare()
+		if exit {
+			return
+		}
+
+		select {
+		case q := <-r.l.rxQ.Wait():
+			if !r.muxRecvFrame(q) {
+				return
+			}
+
+		case env := <-txDisposition:
+			r.l.txFrame(env.FrameCtx, env.FrameBody)
+
+		case <-r.receiverReady:
+			continue
+
+		case <-closed:
+			r.muxClientClose()
+
+		case <-r.l.session.done:
+			r.l.doneErr = r.l.session.doneErr
+			return
+		}
+	}
+}
+
+// DispositionOptions contains the optional parameters for message dispositions.
+type DispositionOptions struct {
+	// TransactionID associates the outcome with the transaction it
+	// identifies by sending it inside a transactional-state delivery state.
+	// The outcome is provisional until the transaction is discharged.
+	//
+	// Default: nil, meaning the disposition is non-transactional.
+	TransactionID []byte
+}
+
+// transactionalState wraps state in a TransactionalState when txnID is
+// non-nil, associating the outcome with the transaction.
+func transactionalState(txnID []byte, state encoding.DeliveryState) encoding.DeliveryState {
+	if txnID == nil {
+		return state
+	}
+	return &encoding.TransactionalState{TxnID: txnID, Outcome: state}
+}
+
 // Accept notifies the server that the message has been accepted and does not require redelivery.
 //   - ctx controls waiting for the peer to acknowledge the disposition
 //   - msg is the message to accept
@@ -175,7 +636,22 @@ func (r *Receiver) Receive(ctx context.Context, opts *ReceiveOptions) (*Message,
 // If the context's deadline expires or is cancelled before the operation
 // completes, the message's disposition is in an unknown state.
 func (r *Receiver) AcceptMessage(ctx context.Context, msg *Message) error {
-	return msg.rcv.messageDisposition(ctx, msg, &encoding.StateAccepted{})
+	return r.AcceptMessageWithOptions(ctx, msg, nil)
+}
+
+// AcceptMessageWithOptions notifies the server that the message has been accepted and does not require redelivery.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to accept
+//   - opts contains optional values, pass nil to accept the defaults
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, the message's disposition is in an unknown state.
+func (r *Receiver) AcceptMessageWithOptions(ctx context.Context, msg *Message, opts *DispositionOptions) error {
+	var txnID []byte
+	if opts != nil {
+		txnID = opts.TransactionID
+	}
+	return msg.rcv.messageDisposition(ctx, msg, transactionalState(txnID, &encoding.StateAccepted{}))
 }
 
 // Reject notifies the server that the message is invalid.
@@ -186,7 +662,23 @@ func (r *Receiver) AcceptMessage(ctx context.Context, msg *Message) error {
 // If the context's deadline expires or is cancelled before the operation
 // completes, the message's disposition is in an unknown state.
 func (r *Receiver) RejectMessage(ctx context.Context, msg *Message, e *Error) error {
-	return msg.rcv.messageDisposition(ctx, msg, &encoding.StateRejected{Error: e})
+	return r.RejectMessageWithOptions(ctx, msg, e, nil)
+}
+
+// RejectMessageWithOptions notifies the server that the message is invalid.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to reject
+//   - e is an optional rejection error
+//   - opts contains optional values, pass nil to accept the defaults
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, the message's disposition is in an unknown state.
+func (r *Receiver) RejectMessageWithOptions(ctx context.Context, msg *Message, e *Error, opts *DispositionOptions) error {
+	var txnID []byte
+	if opts != nil {
+		txnID = opts.TransactionID
+	}
+	return msg.rcv.messageDisposition(ctx, msg, transactionalState(txnID, &encoding.StateRejected{Error: e}))
 }
 
 // Release releases the message back to the server. The message may be redelivered to this or another consumer.
@@ -196,26 +688,67 @@ func (r *Receiver) RejectMessage(ctx context.Context, msg *Message, e *Error) er
 // If the context's deadline expires or is cancelled before the operation
 // completes, the message's disposition is in an unknown state.
 func (r *Receiver) ReleaseMessage(ctx context.Context, msg *Message) error {
-	return msg.rcv.messageDisposition(ctx, msg, &encoding.StateReleased{})
+	return r.ReleaseMessageWithOptions(ctx, msg, nil)
+}
+
+// ReleaseMessageWithOptions releases the message back to the server. The message may be redelivered to this or another consumer.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to release
+//   - opts contains optional values, pass nil to accept the defaults
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, the message's disposition is in an unknown state.
+func (r *Receiver) ReleaseMessageWithOptions(ctx context.Context, msg *Message, opts *DispositionOptions) error {
+	var txnID []byte
+	if opts != nil {
+		txnID = opts.TransactionID
+	}
+	return msg.rcv.messageDisposition(ctx, msg, transactionalState(txnID, &encoding.StateReleased{}))
 }
 
 // Modify notifies the server that the message was not acted upon and should be modifed.
 //   - ctx controls waiting for the peer to acknowledge the disposition
 //   - msg is the message to modify
-//   - options contains the optional settings to modify
+//   - options contains the optional settings to modify; pass nil to apply
+//     [ReceiverOptions.ModifiedOutcomeDefaults], if configured
 //
 // If the context's deadline expires or is cancelled before the operation
 // completes, the message's disposition is in an unknown state.
 func (r *Receiver) ModifyMessage(ctx context.Context, msg *Message, options *ModifyMessageOptions) error {
+	var txnID []byte
+	if options != nil {
+		txnID = options.TransactionID
+	}
+	return msg.rcv.messageDisposition(ctx, msg, transactionalState(txnID, msg.rcv.modifiedOutcome(options)))
+}
+
+// modifiedOutcome builds the modified delivery state for options, falling
+// back to the receiver's configured defaults when options is nil.
+func (r *Receiver) modifiedOutcome(options *ModifyMessageOptions) *encoding.StateModified {
 	if options == nil {
-		options = &ModifyMessageOptions{}
+		if options = r.modifiedDefaults; options == nil {
+			options = &ModifyMessageOptions{}
+		}
+	}
+	return &encoding.StateModified{
+		DeliveryFailed:     options.DeliveryFailed,
+		UndeliverableHere:  options.UndeliverableHere,
+		MessageAnnotations: options.Annotations,
+	}
+}
+
+// withModifiedDefaults replaces an empty modified outcome with one
+// carrying the configured default fields.
+func withModifiedDefaults(state encoding.DeliveryState, defaults *ModifyMessageOptions) encoding.DeliveryState {
+	mod, ok := state.(*encoding.StateModified)
+	if !ok || mod.DeliveryFailed || mod.UndeliverableHere || len(mod.MessageAnnotations) > 0 {
+		return state
+	}
+	return &encoding.StateModified{
+		DeliveryFailed:     defaults.DeliveryFailed,
+		UndeliverableHere:  defaults.UndeliverableHere,
+		MessageAnnotations: defaults.Annotations,
 	}
-	return msg.rcv.messageDisposition(ctx,
-		msg, &encoding.StateModified{
-			DeliveryFailed:     options.DeliveryFailed,
-			UndeliverableHere:  options.UndeliverableHere,
-			MessageAnnotations: options.Annotations,
-		})
 }
 
 // ModifyMessageOptions contains the optional parameters to ModifyMessage.
@@ -232,14 +765,16 @@ type ModifyMessageOptions struct {
 	// with the existing message annotations, overwriting existing keys
 	// if necessary.
 	Annotations Annotations
+
+	// TransactionID associates the outcome with the transaction it
+	// identifies, as described in [DispositionOptions.TransactionID].
+	// It's ignored when set via [ReceiverOptions.ModifiedOutcomeDefaults].
+	TransactionID []byte
 }
 
 // Address returns the link's address.
 func (r *Receiver) Address() string {
-	if r.l.source == nil {
-		return ""
-	}
-	return r.l.source.Address
+	return r.l.currentAddr()
 }
 
 // LinkName returns associated link name or an empty string if link is not defined.
@@ -247,6 +782,13 @@ func (r *Receiver) LinkName() string {
 	return r.l.key.name
 }
 
+// LinkHandle returns the output handle assigned to this link.
+// Handles are allocated lowest-available as recommended by the spec,
+// so a closed link's handle is reused by subsequently created links.
+func (r *Receiver) LinkHandle() uint32 {
+	return r.l.outputHandle
+}
+
 // LinkSourceFilterValue retrieves the specified link source filter value or nil if it doesn't exist.
 func (r *Receiver) LinkSourceFilterValue(name string) any {
 	if r.l.source == nil {
@@ -265,6 +807,16 @@ func (r *Receiver) Properties() map[string]any {
 	return r.l.peerProperties
 }
 
+// PeerSourceCapabilities returns the capabilities the peer declared on its
+// source terminus when the link was attached (e.g. "queue" or "topic").
+// These can differ from the capabilities requested via
+// [ReceiverOptions.SourceCapabilities], depending on the node the broker
+// created or resolved.
+// Returns nil if the peer didn't declare any capabilities.
+func (r *Receiver) PeerSourceCapabilities() []string {
+	return r.l.peerSourceCapabilities
+}
+
 // Close closes the Receiver and AMQP link.
 //   - ctx controls waiting for the peer to acknowledge the close
 //
@@ -273,11 +825,106 @@ func (r *Receiver) Properties() map[string]any {
 // execute in the background. Subsequent calls will return a *LinkError
 // that contains the context's error message.
 func (r *Receiver) Close(ctx context.Context) error {
+	if r.inlineReceive {
+		// hand frame processing to a dedicated goroutine for the detach
+		// handshake; callers are no longer pumping
+		r.closeMuxOnce.Do(func() { go r.closeMux() })
+	}
 	return r.l.closeLink(ctx)
 }
 
-// sendDisposition sends a disposition frame to the peer
+// Detach detaches the Receiver from the session without closing the AMQP
+// link, suspending it.  Both termini retain their unsettled delivery
+// state, as durable subscriptions require, so the link can later be
+// resumed by attaching a new receiver with the same [ReceiverOptions.Name],
+// e.g. via [Receiver.Refresh].
+//
+// The Receiver is terminal after a detach; methods on it return a
+// *LinkError whose inner error is a *LinkSuspendedError.
+//   - ctx controls waiting for the peer to acknowledge the detach
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, an error is returned.  However, the operation will continue to
+// execute in the background.
+func (r *Receiver) Detach(ctx context.Context) error {
+	if r.inlineReceive {
+		// hand frame processing to a dedicated goroutine for the detach
+		// handshake; callers are no longer pumping
+		r.closeMuxOnce.Do(func() { go r.closeMux() })
+	}
+	return r.l.detachLink(ctx)
+}
+
+// Refresh closes the Receiver and attaches a replacement link with the same
+// name and configuration on the same session, returning the new Receiver
+// with freshly negotiated values.  Useful when a broker requires a re-attach
+// to pick up changed authorization or quotas.  Unsettled messages are
+// released by the detach; a source with a dynamic address is assigned a new
+// address by the peer.
+//
+// The original Receiver is closed even when Refresh returns an error and
+// must no longer be used.
+//   - ctx controls waiting for the peer to acknowledge the detach and re-attach
+func (r *Receiver) Refresh(ctx context.Context) (*Receiver, error) {
+	opts := r.refreshOpts
+	opts.Name = r.l.key.name
+	if err := r.Close(ctx); err != nil {
+		// a *LinkError means the link was already detached (e.g. by the
+		// peer); attaching the replacement link is still valid
+		var linkErr *LinkError
+		if !errors.As(err, &linkErr) {
+			return nil, err
+		}
+	}
+	return newReceiverForSession(ctx, r.l.session, r.refreshAddr, &opts, receiverTestHooks{})
+}
+
+// sendDisposition sends a disposition frame to the peer.
+// when ordered settlement is enabled, out-of-order dispositions are
+// buffered and transmitted once all prior deliveries have been settled.
 func (r *Receiver) sendDisposition(ctx context.Context, first uint32, last *uint32, state encoding.DeliveryState) error {
+	if r.orderedSettlement && last == nil {
+		return r.sendOrderedDispositions(ctx, first, state)
+	}
+	return r.sendDispositionFrame(ctx, first, last, state)
+}
+
+// sendOrderedDispositions buffers the disposition for deliveryID and
+// transmits all buffered dispositions that are now in delivery order.
+func (r *Receiver) sendOrderedDispositions(ctx context.Context, deliveryID uint32, state encoding.DeliveryState) error {
+	type disposition struct {
+		deliveryID uint32
+		state      encoding.DeliveryState
+	}
+
+	r.dispOrderMu.Lock()
+	r.dispPending[deliveryID] = state
+
+	// flush the run of buffered dispositions at the head of the delivery order
+	var flush []disposition
+	for len(r.dispOrder) > 0 {
+		next := r.dispOrder[0]
+		st, ok := r.dispPending[next]
+		if !ok {
+			// the oldest unsettled delivery hasn't been settled yet
+			break
+		}
+		delete(r.dispPending, next)
+		r.dispOrder = r.dispOrder[1:]
+		flush = append(flush, disposition{deliveryID: next, state: st})
+	}
+	r.dispOrderMu.Unlock()
+
+	for _, d := range flush {
+		if err := r.sendDispositionFrame(ctx, d.deliveryID, nil, d.state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDispositionFrame sends a disposition frame to the peer
+func (r *Receiver) sendDispositionFrame(ctx context.Context, first uint32, last *uint32, state encoding.DeliveryState) error {
 	fr := &frames.PerformDisposition{
 		Role:    encoding.RoleReceiver,
 		First:   first,
@@ -291,11 +938,18 @@ func (r *Receiver) sendDisposition(ctx context.Context, first uint32, last *uint
 		Done: make(chan struct{}),
 	}
 
-	select {
-	case r.txDisposition <- frameBodyEnvelope{FrameCtx: &frameCtx, FrameBody: fr}:
-		debug.Log(2, "TX (Receiver %p): mux txDisposition %s", r, fr)
-	case <-r.l.done:
-		return r.l.doneErr
+	if r.inlineReceive {
+		// there's no mux goroutine to forward the disposition and the
+		// caller might not pump again before expecting the settlement,
+		// so write it to the session directly
+		r.l.txFrame(&frameCtx, fr)
+	} else {
+		select {
+		case r.txDisposition <- frameBodyEnvelope{FrameCtx: &frameCtx, FrameBody: fr}:
+			debug.LogC(r.l.session.conn.correlationID, 2, "TX (Receiver %p): mux txDisposition %s", r, fr)
+		case <-r.l.done:
+			return r.l.doneErr
+		}
 	}
 
 	select {
@@ -324,7 +978,7 @@ func (r *Receiver) messageDisposition(ctx context.Context, msg *Message, state e
 
 	var wait chan error
 	if r.l.receiverSettleMode != nil && *r.l.receiverSettleMode == ReceiverSettleModeSecond {
-		debug.Log(3, "TX (Receiver %p): delivery ID %d is in flight", r, msg.deliveryID)
+		debug.LogC(r.l.session.conn.correlationID, 3, "TX (Receiver %p): delivery ID %d is in flight", r, msg.deliveryID)
 		wait = r.inFlight.add(msg)
 	}
 
@@ -340,22 +994,33 @@ func (r *Receiver) messageDisposition(ctx context.Context, msg *Message, state e
 		return nil
 	}
 
-	select {
-	case err := <-wait:
-		// err has three possibilities
-		//   - nil, meaning the peer acknowledged the settlement
-		//   - an *Error, meaning the peer rejected the message with a provided error
-		//   - a non-AMQP error. this comes from calls to inFlight.clear() during mux unwind.
-		// only for the first two cases is the message considered settled
-
-		if amqpErr := (&Error{}); err == nil || errors.As(err, &amqpErr) {
-			debug.Log(3, "RX (Receiver %p): delivery ID %d has been settled", r, msg.deliveryID)
-			// we've received confirmation of disposition
-			return err
+	if r.inlineReceive {
+		// drive frame processing on this goroutine until the
+		// acknowledging disposition arrives
+		for {
+			select {
+			case err := <-wait:
+				return r.settlementResult(msg, err)
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			if err := r.pump(ctx, false); err != nil {
+				// wait may have been signaled during mux unwind
+				select {
+				case werr := <-wait:
+					return r.settlementResult(msg, werr)
+				default:
+				}
+				return err
+			}
 		}
+	}
 
-		debug.Log(3, "RX (Receiver %p): error settling delivery ID %d: %v", r, msg.deliveryID, err)
-		return err
+	select {
+	case err := <-wait:
+		return r.settlementResult(msg, err)
 
 	case <-ctx.Done():
 		// didn't receive the ack in the time allotted, leave message as unsettled
@@ -364,9 +1029,30 @@ func (r *Receiver) messageDisposition(ctx context.Context, msg *Message, state e
 	}
 }
 
+// settlementResult interprets the value received on a delivery's in-flight
+// settlement channel.
+func (r *Receiver) settlementResult(msg *Message, err error) error {
+	// err has three possibilities
+	//   - nil, meaning the peer acknowledged the settlement
+	//   - an *Error, meaning the peer rejected the message with a provided error
+	//   - a non-AMQP error. this comes from calls to inFlight.clear() during mux unwind.
+	// only for the first two cases is the message considered settled
+
+	if amqpErr := (&Error{}); err == nil || errors.As(err, &amqpErr) {
+		debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p): delivery ID %d has been settled", r, msg.deliveryID)
+		// we've received confirmation of disposition
+		return err
+	}
+
+	debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p): error settling delivery ID %d: %v", r, msg.deliveryID, err)
+	return err
+}
+
 // onSettlement is to be called after message settlement.
 //   - count is the number of messages that were settled
 func (r *Receiver) onSettlement(count uint32) {
+	r.l.markActive()
+
 	if !r.autoSendFlow {
 		return
 	}
@@ -408,10 +1094,11 @@ func newReceiver(source string, session *Session, opts *ReceiverOptions) (*Recei
 	l.target = new(frames.Target)
 	l.linkCredit = defaultLinkCredit
 	r := &Receiver{
-		l:             l,
-		autoSendFlow:  true,
-		receiverReady: make(chan struct{}, 1),
-		txDisposition: make(chan frameBodyEnvelope),
+		l:              l,
+		autoSendFlow:   true,
+		creditStrategy: FixedWindowCreditStrategy{},
+		receiverReady:  make(chan struct{}, 1),
+		txDisposition:  make(chan frameBodyEnvelope),
 	}
 
 	r.messagesQ = queue.NewHolder(queue.New[Message](int(session.incomingWindow)))
@@ -429,6 +1116,13 @@ func newReceiver(source string, session *Session, opts *ReceiverOptions) (*Recei
 		r.l.linkCredit = 0
 		r.autoSendFlow = false
 	}
+	if opts.CreditStrategy != nil {
+		r.creditStrategy = opts.CreditStrategy
+		if _, ok := opts.CreditStrategy.(ManualCreditStrategy); ok {
+			r.l.linkCredit = 0
+			r.autoSendFlow = false
+		}
+	}
 
 	if opts.DesiredCapabilities != nil {
 		r.l.desiredCapabilities = make([]encoding.Symbol, 0, len(opts.DesiredCapabilities))
@@ -438,6 +1132,14 @@ func newReceiver(source string, session *Session, opts *ReceiverOptions) (*Recei
 		}
 	}
 
+	if opts.DiscardExpired {
+		r.discardExpired = true
+		r.discardExpiredOutcome = opts.DiscardExpiredOutcome
+		if r.discardExpiredOutcome == nil {
+			r.discardExpiredOutcome = &encoding.StateAccepted{}
+		}
+	}
+
 	if opts.Durability > DurabilityUnsettledState {
 		return nil, fmt.Errorf("invalid Durability %d", opts.Durability)
 	}
@@ -459,12 +1161,62 @@ func newReceiver(source string, session *Session, opts *ReceiverOptions) (*Recei
 			f(r.l.source.Filter)
 		}
 	}
+	if opts.IdleTimeout > 0 {
+		if opts.OnIdle == nil {
+			return nil, errors.New("OnIdle is required when IdleTimeout is set")
+		}
+		r.l.idleTimeout = opts.IdleTimeout
+		r.l.onIdle = opts.OnIdle
+	} else if opts.OnIdle != nil {
+		return nil, errors.New("IdleTimeout is required when OnIdle is set")
+	}
+	if opts.InlineReceive {
+		if session.linkMux != nil {
+			return nil, errors.New("InlineReceive cannot be combined with SharedLinkMux")
+		}
+		r.inlineReceive = true
+	}
+	if opts.MaxDeliveryAttempts > 0 {
+		r.maxDeliveryAttempts = opts.MaxDeliveryAttempts
+		r.maxDeliveryOutcome = opts.MaxDeliveryAttemptsOutcome
+		if r.maxDeliveryOutcome == nil {
+			r.maxDeliveryOutcome = &encoding.StateRejected{}
+		}
+	}
 	if opts.MaxMessageSize > 0 {
 		r.l.maxMessageSize = opts.MaxMessageSize
 	}
+	if opts.MaxUnsettled > 0 {
+		if !r.autoSendFlow {
+			return nil, errors.New("MaxUnsettled requires automatic credit management")
+		}
+		if r.l.linkCredit > opts.MaxUnsettled {
+			return nil, errors.New("Credit cannot exceed MaxUnsettled")
+		}
+		r.maxUnsettled = opts.MaxUnsettled
+	}
+	if opts.MessageFilter != nil {
+		r.messageFilter = opts.MessageFilter
+		r.messageFilterOutcome = opts.MessageFilterOutcome
+		if r.messageFilterOutcome == nil {
+			r.messageFilterOutcome = &encoding.StateReleased{}
+		}
+	}
+	if opts.ModifiedOutcomeDefaults != nil {
+		r.modifiedDefaults = opts.ModifiedOutcomeDefaults
+		// fill in automatic outcomes configured as a bare modified state
+		r.discardExpiredOutcome = withModifiedDefaults(r.discardExpiredOutcome, r.modifiedDefaults)
+		r.maxDeliveryOutcome = withModifiedDefaults(r.maxDeliveryOutcome, r.modifiedDefaults)
+		r.messageFilterOutcome = withModifiedDefaults(r.messageFilterOutcome, r.modifiedDefaults)
+	}
 	if opts.Name != "" {
 		r.l.key.name = opts.Name
 	}
+	r.l.onAddressChange = opts.OnAddressChange
+	if opts.OrderedSettlement {
+		r.orderedSettlement = true
+		r.dispPending = map[uint32]encoding.DeliveryState{}
+	}
 	if opts.Properties != nil {
 		r.l.properties = make(map[encoding.Symbol]any)
 		for k, v := range opts.Properties {
@@ -474,12 +1226,25 @@ func newReceiver(source string, session *Session, opts *ReceiverOptions) (*Recei
 			r.l.properties[encoding.Symbol(k)] = v
 		}
 	}
+	r.rawTransfers = opts.RawTransfers
 	if opts.RequestedSenderSettleMode != nil {
 		if rsm := *opts.RequestedSenderSettleMode; rsm > SenderSettleModeMixed {
 			return nil, fmt.Errorf("invalid RequestedSenderSettleMode %d", rsm)
 		}
 		r.l.senderSettleMode = opts.RequestedSenderSettleMode
 	}
+	if opts.RXQueue != nil {
+		segmentSize, maxIdle, err := opts.RXQueue.values(int(session.incomingWindow))
+		if err != nil {
+			return nil, err
+		}
+		qOpts := queue.Options{
+			SegmentSize:     segmentSize,
+			MaxIdleSegments: maxIdle,
+		}
+		r.l.rxQ = queue.NewHolder(queue.NewWithOptions[frames.FrameBody](qOpts))
+		r.messagesQ = queue.NewHolder(queue.NewWithOptions[Message](qOpts))
+	}
 	if opts.SettlementMode != nil {
 		if rsm := *opts.SettlementMode; rsm > ReceiverSettleModeSecond {
 			return nil, fmt.Errorf("invalid SettlementMode %d", rsm)
@@ -547,93 +1312,48 @@ func (r *Receiver) mux(hooks receiverTestHooks) {
 		hooks.MuxStart = nopHook
 	}
 
-	defer func() {
-		// unblock any in flight message dispositions
-		r.inFlight.clear(r.l.doneErr)
+	defer r.muxDone()
 
-		if !r.autoSendFlow {
-			// unblock any pending drain requests
-			r.creditor.EndDrain()
+	defer func() {
+		// NOTE: this must be declared after the defer above so that it runs
+		// first, converting the panic into the mux's terminal error state.
+		// a panic leaves the link and its session in an undefined state, so
+		// close the owning connection rather than crashing the process.
+		if v := recover(); v != nil {
+			r.l.doneErr = &LinkError{inner: muxPanicError(v)}
+			r.l.session.conn.close()
 		}
-
-		close(r.l.done)
 	}()
 
 	hooks.MuxStart()
 
-	if r.autoSendFlow {
-		r.l.doneErr = r.muxFlow(r.l.linkCredit, false)
+	var muxProgress uint64
+	if wt := r.l.session.conn.watchdogTimeout; wt > 0 {
+		go watchMux(fmt.Sprintf("receiver link %s", r.l.key.name), wt, r.l.session.conn.watchdogHandler, &muxProgress, r.l.rxQ.Len, r.l.done)
 	}
 
-	for {
-		msgLen := r.messagesQ.Len()
-
-		r.settlementCountMu.Lock()
-		// counter that accumulates the settled delivery count.
-		// once the threshold has been reached, the counter is
-		// reset and a flow frame is sent.
-		previousSettlementCount := r.settlementCount
-		if previousSettlementCount >= r.l.linkCredit {
-			r.settlementCount = 0
-		}
-		r.settlementCountMu.Unlock()
-
-		// once we have pending credit equal to or greater than our available credit, reclaim it.
-		// we do this instead of settlementCount > 0 to prevent flow frames from being too chatty.
-		// NOTE: we compare the settlementCount against the current link credit instead of some
-		// fixed threshold to ensure credit is reclaimed in cases where the number of unsettled
-		// messages remains high for whatever reason.
-		if r.autoSendFlow && previousSettlementCount > 0 && previousSettlementCount >= r.l.linkCredit {
-			debug.Log(1, "RX (Receiver %p) (auto): source: %q, inflight: %d, linkCredit: %d, deliveryCount: %d, messages: %d, unsettled: %d, settlementCount: %d, settleMode: %s",
-				r, r.l.source.Address, r.inFlight.len(), r.l.linkCredit, r.l.deliveryCount, msgLen, r.countUnsettled(), previousSettlementCount, r.l.receiverSettleMode.String())
-			r.l.doneErr = r.creditor.IssueCredit(previousSettlementCount)
-		} else if r.l.linkCredit == 0 {
-			debug.Log(1, "RX (Receiver %p) (pause): source: %q, inflight: %d, linkCredit: %d, deliveryCount: %d, messages: %d, unsettled: %d, settlementCount: %d, settleMode: %s",
-				r, r.l.source.Address, r.inFlight.len(), r.l.linkCredit, r.l.deliveryCount, msgLen, r.countUnsettled(), previousSettlementCount, r.l.receiverSettleMode.String())
-		}
-
-		if r.l.doneErr != nil {
-			return
-		}
-
-		drain, credits := r.creditor.FlowBits(r.l.linkCredit)
-		if drain || credits > 0 {
-			debug.Log(1, "RX (Receiver %p) (flow): source: %q, inflight: %d, curLinkCredit: %d, newLinkCredit: %d, drain: %v, deliveryCount: %d, messages: %d, unsettled: %d, settlementCount: %d, settleMode: %s",
-				r, r.l.source.Address, r.inFlight.len(), r.l.linkCredit, credits, drain, r.l.deliveryCount, msgLen, r.countUnsettled(), previousSettlementCount, r.l.receiverSettleMode.String())
+	if !r.muxInit() {
+		return
+	}
 
-			// send a flow frame.
-			r.l.doneErr = r.muxFlow(credits, drain)
-		}
+	for {
+		atomic.AddUint64(&muxProgress, 1)
 
-		if r.l.doneErr != nil {
+		txDisposition, closed, exit := r.muxPrepare()
+		if exit {
 			return
 		}
 
-		txDisposition := r.txDisposition
-		closed := r.l.close
-		if r.l.closeInProgress {
-			// swap out channel so it no longer triggers
-			closed = nil
-
-			// disable sending of disposition frames once closing is in progress.
-			// this is to prevent races between mux shutdown and clearing of
-			// any in-flight dispositions.
-			txDisposition = nil
+		var creditTick <-chan time.Time
+		if r.creditTicker != nil {
+			creditTick = r.creditTicker.C
 		}
 
 		hooks.MuxSelect()
 
 		select {
 		case q := <-r.l.rxQ.Wait():
-			// populated queue
-			fr := *q.Dequeue()
-			r.l.rxQ.Release(q)
-
-			// if muxHandleFrame returns an error it means the mux must terminate.
-			// note that in the case of a client-side close due to an error, nil
-			// is returned in order to keep the mux running to ack the detach frame.
-			if err := r.muxHandleFrame(fr); err != nil {
-				r.l.doneErr = err
+			if !r.muxRecvFrame(q) {
 				return
 			}
 
@@ -643,19 +1363,12 @@ func (r *Receiver) mux(hooks receiverTestHooks) {
 		case <-r.receiverReady:
 			continue
 
-		case <-closed:
-			if r.l.closeInProgress {
-				// a client-side close due to protocol error is in progress
-				continue
-			}
+		case <-creditTick:
+			// wake the mux so the credit strategy is re-evaluated
+			continue
 
-			// receiver is being closed by the client
-			r.l.closeInProgress = true
-			fr := &frames.PerformDetach{
-				Handle: r.l.outputHandle,
-				Closed: true,
-			}
-			r.l.txFrame(&frameContext{Ctx: context.Background()}, fr)
+		case <-closed:
+			r.muxClientClose()
 
 		case <-r.l.session.done:
 			r.l.doneErr = r.l.session.doneErr
@@ -664,6 +1377,281 @@ func (r *Receiver) mux(hooks receiverTestHooks) {
 	}
 }
 
+// muxInit performs one-time mux initialization, sending the initial flow
+// frame and starting the credit strategy ticker as required.
+// it returns false if the mux must terminate.
+func (r *Receiver) muxInit() bool {
+	if r.autoSendFlow {
+		if r.l.doneErr = r.muxFlow(r.l.linkCredit, false); r.l.doneErr != nil {
+			return false
+		}
+	}
+
+	// custom strategies are also evaluated periodically so they can react
+	// to the passage of time (e.g. refresh the window on idle links)
+	if _, isDefault := r.creditStrategy.(FixedWindowCreditStrategy); !isDefault && r.autoSendFlow {
+		r.creditTicker = time.NewTicker(creditStrategyTickInterval)
+	}
+
+	return true
+}
+
+// muxPrepare runs the per-wakeup bookkeeping: consulting the credit strategy,
+// sending any required flow frame, and gating channels while a close is in
+// progress.  exit is true if the mux must terminate.
+func (r *Receiver) muxPrepare() (txDisposition chan frameBodyEnvelope, closed chan struct{}, exit bool) {
+	msgLen := r.messagesQ.Len()
+
+	// apply any pending credit-window resize before consulting the strategy
+	r.creditResizeMu.Lock()
+	resize, resizeTo := r.creditResizePending, r.creditResizeTo
+	r.creditResizePending = false
+	r.creditResizeMu.Unlock()
+	if resize {
+		// messages already prefetched count against the new window
+		var newCredit uint32
+		if buffered := uint32(msgLen); resizeTo > buffered {
+			newCredit = resizeTo - buffered
+		}
+		r.settlementCountMu.Lock()
+		// drop settled counts accrued under the old window so the
+		// strategy doesn't immediately grow the window back
+		r.settlementCount = 0
+		r.settlementCountMu.Unlock()
+		debug.LogC(r.l.session.conn.correlationID, 1, "RX (Receiver %p) (resize): source: %q, curLinkCredit: %d, newLinkCredit: %d, window: %d, messages: %d",
+			r, r.l.source.Address, r.l.linkCredit, newCredit, resizeTo, msgLen)
+		if r.l.doneErr = r.muxFlow(newCredit, false); r.l.doneErr != nil {
+			return nil, nil, true
+		}
+	}
+
+	r.settlementCountMu.Lock()
+	// counter that accumulates the settled delivery count.
+	// once the strategy decides to issue credit, the counter is
+	// decremented and a flow frame is sent.
+	previousSettlementCount := r.settlementCount
+	r.settlementCountMu.Unlock()
+
+	// consult the credit strategy to determine if settled credit should be reclaimed.
+	// the default FixedWindowCreditStrategy waits until pending credit is equal to or
+	// greater than our available credit to prevent flow frames from being too chatty.
+	var autoCredits uint32
+	if r.autoSendFlow {
+		autoCredits = r.creditStrategy.Credits(CreditState{
+			LinkCredit:       r.l.linkCredit,
+			SettledCount:     previousSettlementCount,
+			BufferedMessages: uint32(msgLen),
+			UnsettledCount:   uint32(r.countUnsettled()),
+		})
+	}
+
+	// cap outstanding deliveries at maxUnsettled: unsettled messages plus
+	// credit already on the link count against the cap.  settling a
+	// delivery wakes the mux, releasing the credit it was holding.
+	if r.maxUnsettled > 0 && autoCredits > 0 {
+		outstanding := uint32(r.countUnsettled()) + r.l.linkCredit
+		if outstanding >= r.maxUnsettled {
+			autoCredits = 0
+		} else if available := r.maxUnsettled - outstanding; autoCredits > available {
+			autoCredits = available
+		}
+	}
+
+	if autoCredits > 0 {
+		debug.LogC(r.l.session.conn.correlationID, 1, "RX (Receiver %p) (auto): source: %q, inflight: %d, linkCredit: %d, deliveryCount: %d, messages: %d, unsettled: %d, settlementCount: %d, settleMode: %s",
+			r, r.l.source.Address, r.inFlight.len(), r.l.linkCredit, r.l.deliveryCount, msgLen, r.countUnsettled(), previousSettlementCount, r.l.receiverSettleMode.String())
+		r.settlementCountMu.Lock()
+		// consume the settled count that backed this issuance.
+		// subtract instead of resetting to zero so concurrent
+		// settlements aren't lost.
+		if previousSettlementCount > r.settlementCount {
+			r.settlementCount = 0
+		} else {
+			r.settlementCount -= previousSettlementCount
+		}
+		r.settlementCountMu.Unlock()
+		r.l.doneErr = r.creditor.IssueCredit(autoCredits)
+	} else if r.l.linkCredit == 0 {
+		debug.LogC(r.l.session.conn.correlationID, 1, "RX (Receiver %p) (pause): source: %q, inflight: %d, linkCredit: %d, deliveryCount: %d, messages: %d, unsettled: %d, settlementCount: %d, settleMode: %s",
+			r, r.l.source.Address, r.inFlight.len(), r.l.linkCredit, r.l.deliveryCount, msgLen, r.countUnsettled(), previousSettlementCount, r.l.receiverSettleMode.String())
+	}
+
+	if r.l.doneErr != nil {
+		return nil, nil, true
+	}
+
+	drain, credits := r.creditor.FlowBits(r.l.linkCredit)
+	if drain || credits > 0 {
+		debug.LogC(r.l.session.conn.correlationID, 1, "RX (Receiver %p) (flow): source: %q, inflight: %d, curLinkCredit: %d, newLinkCredit: %d, drain: %v, deliveryCount: %d, messages: %d, unsettled: %d, settlementCount: %d, settleMode: %s",
+			r, r.l.source.Address, r.inFlight.len(), r.l.linkCredit, credits, drain, r.l.deliveryCount, msgLen, r.countUnsettled(), previousSettlementCount, r.l.receiverSettleMode.String())
+
+		// send a flow frame.
+		r.l.doneErr = r.muxFlow(credits, drain)
+	}
+
+	if r.l.doneErr != nil {
+		return nil, nil, true
+	}
+
+	// send a flow frame with the echo flag set for any pending link-state queries
+	r.stateQueryMu.Lock()
+	sendQuery := r.stateQueryPending
+	r.stateQueryPending = false
+	r.stateQueryMu.Unlock()
+	if sendQuery {
+		if r.l.doneErr = r.muxQueryLinkState(); r.l.doneErr != nil {
+			return nil, nil, true
+		}
+	}
+
+	txDisposition = r.txDisposition
+	closed = r.l.close
+	if r.l.closeInProgress {
+		// swap out channel so it no longer triggers
+		closed = nil
+
+		// disable sending of disposition frames once closing is in progress.
+		// this is to prevent races between mux shutdown and clearing of
+		// any in-flight dispositions.
+		txDisposition = nil
+	}
+
+	return txDisposition, closed, false
+}
+
+// muxRecvFrame processes an incoming frame from the session mux.
+// it returns false if the mux must terminate.
+func (r *Receiver) muxRecvFrame(q *queue.Queue[frames.FrameBody]) bool {
+	// populated queue
+	fr := *q.Dequeue()
+	r.l.rxQ.Release(q)
+
+	// if muxHandleFrame returns an error it means the mux must terminate.
+	// note that in the case of a client-side close due to an error, nil
+	// is returned in order to keep the mux running to ack the detach frame.
+	if err := r.muxHandleFrame(fr); err != nil {
+		r.l.doneErr = err
+		return false
+	}
+
+	return true
+}
+
+// muxClientClose initiates a client-side graceful close by sending a detach.
+func (r *Receiver) muxClientClose() {
+	if r.l.closeInProgress {
+		// a client-side close due to protocol error is in progress
+		return
+	}
+
+	// receiver is being closed by the client
+	r.l.closeInProgress = true
+	fr := &frames.PerformDetach{
+		Handle: r.l.outputHandle,
+		// a non-closing detach suspends the link instead of closing it
+		Closed: !r.l.detachRequested,
+	}
+	r.l.txFrame(&frameContext{Ctx: context.Background()}, fr)
+}
+
+// muxDone releases the mux's resources.
+// it's called exactly once when the mux terminates.
+func (r *Receiver) muxDone() {
+	// unblock any in flight message dispositions
+	r.inFlight.clear(r.l.doneErr)
+
+	if !r.autoSendFlow {
+		// unblock any pending drain requests
+		r.creditor.EndDrain()
+	}
+
+	if r.creditTicker != nil {
+		r.creditTicker.Stop()
+	}
+
+	close(r.l.done)
+
+	if m := r.l.session.conn.metrics; m != nil {
+		m.LinkDetached()
+	}
+	r.l.session.conn.logEvent("link detached", append(r.l.logAttrs(), slog.Any("error", r.l.doneErr))...)
+	expvarAdd(expvarActiveLinks, -1)
+}
+
+// muxPoll implements sharedMuxLink.
+// the order of the returned cases MUST match the indices in muxEvent.
+func (r *Receiver) muxPoll() ([]reflect.SelectCase, bool) {
+	txDisposition, closed, exit := r.muxPrepare()
+	if exit {
+		return nil, true
+	}
+
+	var creditTick <-chan time.Time
+	if r.creditTicker != nil {
+		creditTick = r.creditTicker.C
+	}
+
+	return []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.l.rxQ.Wait())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(txDisposition)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.receiverReady)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(creditTick)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(closed)},
+	}, false
+}
+
+// muxEvent implements sharedMuxLink.
+func (r *Receiver) muxEvent(i int, v reflect.Value) bool {
+	switch i {
+	case 0: // incoming frame
+		return !r.muxRecvFrame(v.Interface().(*queue.Queue[frames.FrameBody]))
+	case 1: // disposition to send
+		env := v.Interface().(frameBodyEnvelope)
+		r.l.txFrame(env.FrameCtx, env.FrameBody)
+	case 2, 3: // receiverReady/credit tick; wake so the credit strategy is re-evaluated
+	case 4: // client-side close
+		r.muxClientClose()
+	}
+	return false
+}
+
+// muxPending implements sharedMuxLink.
+func (r *Receiver) muxPending() int {
+	return r.l.rxQ.Len()
+}
+
+// muxFatal implements sharedMuxLink.
+func (r *Receiver) muxFatal(err error) {
+	r.l.doneErr = err
+}
+
+// muxQueryLinkState sends a flow frame restating the link's current state
+// with the echo flag set, soliciting a responding flow frame from the peer.
+func (r *Receiver) muxQueryLinkState() error {
+	var (
+		// copy because sent by pointer below; prevent race
+		deliveryCount = r.l.deliveryCount
+		linkCredit    = r.l.linkCredit
+	)
+
+	fr := &frames.PerformFlow{
+		Handle:        &r.l.outputHandle,
+		DeliveryCount: &deliveryCount,
+		LinkCredit:    &linkCredit,
+		Echo:          true,
+	}
+
+	switch r.l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: fr}, r.l.close, r.l.session.done) {
+	case ring.Enqueued:
+		debug.LogC(r.l.session.conn.correlationID, 2, "TX (Receiver %p): mux frame to Session (%p): %d, %s", r, r.l.session, r.l.session.channel, fr)
+		return nil
+	case 0:
+		return nil
+	default:
+		return r.l.session.doneErr
+	}
+}
+
 // muxFlow sends tr to the session mux.
 // l.linkCredit will also be updated to `linkCredit`
 func (r *Receiver) muxFlow(linkCredit uint32, drain bool) error {
@@ -689,20 +1677,23 @@ func (r *Receiver) muxFlow(linkCredit uint32, drain bool) error {
 		r.l.linkCredit = linkCredit
 	}
 
-	select {
-	case r.l.session.tx <- frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: fr}:
-		debug.Log(2, "TX (Receiver %p): mux frame to Session (%p): %d, %s", r, r.l.session, r.l.session.channel, fr)
+	switch r.l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: fr}, r.l.close, r.l.session.done) {
+	case ring.Enqueued:
+		debug.LogC(r.l.session.conn.correlationID, 2, "TX (Receiver %p): mux frame to Session (%p): %d, %s", r, r.l.session, r.l.session.channel, fr)
+		if m := r.l.session.conn.metrics; m != nil && !drain {
+			m.CreditsIssued(linkCredit)
+		}
 		return nil
-	case <-r.l.close:
+	case 0:
 		return nil
-	case <-r.l.session.done:
+	default:
 		return r.l.session.doneErr
 	}
 }
 
 // muxHandleFrame processes fr based on type.
 func (r *Receiver) muxHandleFrame(fr frames.FrameBody) error {
-	debug.Log(2, "RX (Receiver %p): %s", r, fr)
+	debug.LogC(r.l.session.conn.correlationID, 2, "RX (Receiver %p): %s", r, fr)
 	switch fr := fr.(type) {
 	// message frame
 	case *frames.PerformTransfer:
@@ -710,7 +1701,32 @@ func (r *Receiver) muxHandleFrame(fr frames.FrameBody) error {
 
 	// flow control frame
 	case *frames.PerformFlow:
+		if fr.Available != nil {
+			atomic.StoreUint32(&r.peerAvailable, *fr.Available)
+		}
+
 		if !fr.Echo {
+			// a flow frame from the sender carrying its link state satisfies
+			// any outstanding link-state queries
+			r.stateQueryMu.Lock()
+			if len(r.stateQueries) > 0 {
+				state := LinkState{Drain: fr.Drain}
+				if fr.Available != nil {
+					state.Available = *fr.Available
+				}
+				if fr.DeliveryCount != nil {
+					state.DeliveryCount = *fr.DeliveryCount
+				}
+				if fr.LinkCredit != nil {
+					state.LinkCredit = *fr.LinkCredit
+				}
+				for _, ch := range r.stateQueries {
+					ch <- state
+				}
+				r.stateQueries = nil
+			}
+			r.stateQueryMu.Unlock()
+
 			// if the 'drain' flag has been set in the frame sent to the _receiver_ then
 			// we signal whomever is waiting (the service has seen and acknowledged our drain)
 			if fr.Drain && !r.autoSendFlow {
@@ -733,12 +1749,12 @@ func (r *Receiver) muxHandleFrame(fr frames.FrameBody) error {
 			LinkCredit:    &linkCredit, // max number of messages
 		}
 
-		select {
-		case r.l.session.tx <- frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: resp}:
-			debug.Log(2, "TX (Receiver %p): mux frame to Session (%p): %d, %s", r, r.l.session, r.l.session.channel, resp)
-		case <-r.l.close:
+		switch r.l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: resp}, r.l.close, r.l.session.done) {
+		case ring.Enqueued:
+			debug.LogC(r.l.session.conn.correlationID, 2, "TX (Receiver %p): mux frame to Session (%p): %d, %s", r, r.l.session, r.l.session.channel, resp)
+		case 0:
 			return nil
-		case <-r.l.session.done:
+		default:
 			return r.l.session.doneErr
 		}
 
@@ -768,6 +1784,12 @@ func (r *Receiver) muxHandleFrame(fr frames.FrameBody) error {
 }
 
 func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
+	r.l.markActive()
+
+	if m := r.l.session.conn.metrics; m != nil {
+		m.TransferReceived(len(fr.Payload))
+	}
+
 	if !r.more {
 		// this is the first transfer of a message,
 		// record the delivery ID, message format,
@@ -793,6 +1815,20 @@ func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
 			r.l.closeWithError(ErrCondNotAllowed, "received message without a delivery-tag")
 			return
 		}
+
+		// "If the negotiated value for snd-settle-mode at attachment is unsettled,
+		// then this field MUST be false (or unset) on every transfer frame for a
+		// delivery" (and the inverse for settled).
+		if r.l.session.conn.strictValidation && r.l.senderSettleMode != nil {
+			switch mode := *r.l.senderSettleMode; {
+			case mode == SenderSettleModeUnsettled && fr.Settled:
+				r.l.closeWithError(ErrCondNotAllowed, "received settled transfer on link with snd-settle-mode unsettled")
+				return
+			case mode == SenderSettleModeSettled && !fr.Settled:
+				r.l.closeWithError(ErrCondNotAllowed, "received unsettled transfer on link with snd-settle-mode settled")
+				return
+			}
+		}
 	} else {
 		// this is a continuation of a multipart message
 		// some fields may be omitted on continuation transfers,
@@ -825,8 +1861,21 @@ func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
 		}
 	}
 
+	// a caller waiting on ReceiveStream takes this delivery incrementally
+	if !r.more && r.stream == nil && !fr.Aborted {
+		if waiter := r.takeStreamWaiter(); waiter != nil {
+			r.stream = newMessageStream(r)
+			waiter <- r.stream
+		}
+	}
+	if r.stream != nil {
+		r.muxReceiveStream(fr)
+		return
+	}
+
 	// discard message if it's been aborted
 	if fr.Aborted {
+		r.recyclePayload(fr.Payload)
 		r.msgBuf.Reset()
 		r.msg = Message{}
 		r.more = false
@@ -841,6 +1890,7 @@ func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
 
 	// add the payload the the buffer
 	r.msgBuf.Append(fr.Payload)
+	r.recyclePayload(fr.Payload)
 
 	// mark as settled if at least one frame is settled
 	r.msg.settled = r.msg.settled || fr.Settled
@@ -853,8 +1903,34 @@ func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
 	}
 
 	// last frame in message
-	err := r.msg.Unmarshal(&r.msgBuf)
-	if err != nil {
+
+	// a waiter that registered mid-delivery takes the assembled payload
+	// undecoded, matching a delivery streamed from its first frame
+	if waiter := r.takeStreamWaiter(); waiter != nil {
+		ms := newMessageStream(r)
+		ms.chunks <- streamChunk{payload: append([]byte(nil), r.msgBuf.Bytes()...)}
+		r.finishStream(ms)
+		waiter <- ms
+
+		// reset progress
+		r.msgBuf.Reset()
+		r.msg = Message{}
+
+		// decrement link-credit after entire message received
+		r.l.deliveryCount++
+		r.l.linkCredit--
+		return
+	}
+
+	if r.rawTransfers {
+		// surface the assembled payload as-is; metadata was recorded on first transfer
+		r.msg.RawPayload = append([]byte(nil), r.msgBuf.Bytes()...)
+	} else if codec := lookupMessageCodec(r.msg.Format); codec != nil {
+		if err := codec.Decode(r.msgBuf.Bytes(), &r.msg); err != nil {
+			r.l.closeWithError(ErrCondDecodeError, err.Error())
+			return
+		}
+	} else if err := r.msg.Unmarshal(&r.msgBuf); err != nil {
 		r.l.closeWithError(ErrCondInternalError, err.Error())
 		return
 	}
@@ -863,7 +1939,12 @@ func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
 	if !r.msg.settled {
 		r.addUnsettled()
 		r.msg.rcv = r
-		debug.Log(3, "RX (Receiver %p): add unsettled delivery ID %d", r, r.msg.deliveryID)
+		if r.orderedSettlement {
+			r.dispOrderMu.Lock()
+			r.dispOrder = append(r.dispOrder, r.msg.deliveryID)
+			r.dispOrderMu.Unlock()
+		}
+		debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p): add unsettled delivery ID %d", r, r.msg.deliveryID)
 	}
 
 	q := r.messagesQ.Acquire()
@@ -871,6 +1952,8 @@ func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
 	msgLen := q.Len()
 	r.messagesQ.Release(q)
 
+	expvarAdd(expvarMessagesReceived, 1)
+
 	// reset progress
 	r.msgBuf.Reset()
 	r.msg = Message{}
@@ -878,7 +1961,99 @@ func (r *Receiver) muxReceive(fr frames.PerformTransfer) {
 	// decrement link-credit after entire message received
 	r.l.deliveryCount++
 	r.l.linkCredit--
-	debug.Log(3, "RX (Receiver %p) link %s - deliveryCount: %d, linkCredit: %d, len(messages): %d", r, r.l.key.name, r.l.deliveryCount, r.l.linkCredit, msgLen)
+	debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p) link %s - deliveryCount: %d, linkCredit: %d, len(messages): %d", r, r.l.key.name, r.l.deliveryCount, r.l.linkCredit, msgLen)
+}
+
+// muxReceiveStream routes a transfer frame for the delivery held by
+// r.stream.  Payload slices are handed to the stream's reader as-is, so
+// they are never recycled through the connection's buffer pool.
+func (r *Receiver) muxReceiveStream(fr frames.PerformTransfer) {
+	ms := r.stream
+
+	// discard the delivery if it's been aborted
+	if fr.Aborted {
+		r.streamSend(ms, streamChunk{err: errors.New("the sender aborted the delivery")})
+		r.stream = nil
+		r.msg = Message{}
+		r.more = false
+		return
+	}
+
+	// ensure maxMessageSize will not be exceeded
+	if r.l.maxMessageSize != 0 && uint64(ms.received)+uint64(len(fr.Payload)) > r.l.maxMessageSize {
+		r.l.closeWithError(ErrCondMessageSizeExceeded, fmt.Sprintf("received message larger than max size of %d", r.l.maxMessageSize))
+		return
+	}
+	ms.received += int64(len(fr.Payload))
+
+	// mark as settled if at least one frame is settled
+	ms.msg.settled = ms.msg.settled || fr.Settled
+
+	// save in-progress status
+	r.more = fr.More
+
+	if len(fr.Payload) > 0 {
+		if !r.streamSend(ms, streamChunk{payload: fr.Payload}) {
+			return
+		}
+	}
+
+	if fr.More {
+		return
+	}
+
+	// last frame in message
+	r.finishStream(ms)
+	r.stream = nil
+	r.msg = Message{}
+
+	// decrement link-credit after entire message received
+	r.l.deliveryCount++
+	r.l.linkCredit--
+	debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p) link %s - streamed delivery ID %d complete, deliveryCount: %d, linkCredit: %d", r, r.l.key.name, ms.msg.deliveryID, r.l.deliveryCount, r.l.linkCredit)
+}
+
+// finishStream records the delivery's completion bookkeeping and signals
+// the stream's reader.  called only from the receiver's mux.
+func (r *Receiver) finishStream(ms *MessageStream) {
+	ms.complete.Store(true)
+	if !ms.msg.settled {
+		ms.msg.rcv = r
+		r.addUnsettled()
+		if r.orderedSettlement {
+			r.dispOrderMu.Lock()
+			r.dispOrder = append(r.dispOrder, ms.msg.deliveryID)
+			r.dispOrderMu.Unlock()
+		}
+		debug.LogC(r.l.session.conn.correlationID, 3, "RX (Receiver %p): add unsettled delivery ID %d", r, ms.msg.deliveryID)
+	} else {
+		// settled accounting normally happens when a queued message is
+		// dequeued; a streamed delivery is handed out directly
+		r.onSettlement(1)
+	}
+	r.streamSend(ms, streamChunk{err: io.EOF})
+	expvarAdd(expvarMessagesReceived, 1)
+}
+
+// streamSend blocks until the stream's reader has room for the chunk,
+// applying backpressure to the link, or the link or session ends.
+func (r *Receiver) streamSend(ms *MessageStream, ck streamChunk) bool {
+	select {
+	case ms.chunks <- ck:
+		return true
+	case <-r.l.close:
+		return false
+	case <-r.l.session.done:
+		return false
+	}
+}
+
+// recyclePayload returns a consumed transfer payload to the connection's
+// buffer pool, if one was configured.
+func (r *Receiver) recyclePayload(payload []byte) {
+	if pool := r.l.session.conn.bufferPool; pool != nil && len(payload) > 0 {
+		pool.Put(payload)
+	}
 }
 
 // inFlight tracks in-flight message dispositions allowing receivers
@@ -917,12 +2092,15 @@ func (f *inFlight) remove(first uint32, last *uint32, err error, handler func(*M
 	}
 
 	ll := first
-	if last != nil {
+	if last != nil && !serial.Less(*last, first) {
 		ll = *last
 	}
 
 	count := uint32(0)
-	for i := first; i <= ll; i++ {
+	// delivery IDs are serial numbers, so iterate by count to
+	// remain correct when the range wraps past the max uint32
+	for n := uint64(0); n <= uint64(ll-first); n++ {
+		i := first + uint32(n)
 		info, ok := f.m[i]
 		if ok {
 			handler(info.msg)
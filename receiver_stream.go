@@ -0,0 +1,274 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+
+	"github.com/Azure/go-amqp/internal/buffer"
+	"github.com/Azure/go-amqp/internal/encoding"
+)
+
+// streamChunkDepth is the count of transfer payloads a [MessageStream]
+// buffers before the receiver's mux blocks feeding it, applying
+// backpressure to a slow reader.
+const streamChunkDepth = 8
+
+// streamChunk carries one transfer frame's payload, or the delivery's
+// terminal status, from the receiver's mux to a [MessageStream] reader.
+type streamChunk struct {
+	payload []byte
+	err     error // io.EOF when the delivery completed, else the failure
+}
+
+// MessageStream is a single delivery surfaced incrementally as its
+// transfer frames arrive, so large messages can be consumed without
+// buffering the entire payload first.  Created by [Receiver.ReceiveStream].
+type MessageStream struct {
+	// DeliveryTag is the delivery-tag from the first transfer frame.
+	DeliveryTag []byte
+
+	// Format is the delivery's message-format code.
+	Format uint32
+
+	r        *Receiver
+	msg      *Message         // skeleton used for dispositions
+	chunks   chan streamChunk // fed by the receiver's mux
+	cur      []byte           // remainder of the chunk being read
+	readErr  error            // terminal result; sticky once set
+	received int64            // cumulative payload bytes; mux only
+	complete atomic.Bool      // set once the final transfer has arrived
+}
+
+// newMessageStream creates a stream for the delivery currently held in
+// r.msg.  Called only from the receiver's mux.
+func newMessageStream(r *Receiver) *MessageStream {
+	return &MessageStream{
+		DeliveryTag: append([]byte(nil), r.msg.DeliveryTag...),
+		Format:      r.msg.Format,
+		r:           r,
+		msg: &Message{
+			deliveryID:  r.msg.deliveryID,
+			DeliveryTag: r.msg.DeliveryTag,
+			Format:      r.msg.Format,
+			settled:     r.msg.settled,
+		},
+		chunks: make(chan streamChunk, streamChunkDepth),
+	}
+}
+
+// Read returns the message's raw encoded bytes as they arrive, as with
+// [ReceiverOptions.RawTransfers].  It returns [io.EOF] once the final
+// transfer frame has been consumed, and an error if the sender aborts
+// the delivery or the link fails mid-delivery.
+func (ms *MessageStream) Read(p []byte) (int, error) {
+	for len(ms.cur) == 0 {
+		if ms.readErr != nil {
+			return 0, ms.readErr
+		}
+		select {
+		case ck := <-ms.chunks:
+			if ck.err != nil {
+				ms.readErr = ck.err
+				return 0, ck.err
+			}
+			ms.cur = ck.payload
+		case <-ms.r.l.done:
+			// drain payload that arrived ahead of the link failure
+			select {
+			case ck := <-ms.chunks:
+				if ck.err != nil {
+					ms.readErr = ck.err
+					return 0, ck.err
+				}
+				ms.cur = ck.payload
+			default:
+				ms.readErr = ms.r.l.doneErr
+				return 0, ms.readErr
+			}
+		}
+	}
+	n := copy(p, ms.cur)
+	ms.cur = ms.cur[n:]
+	return n, nil
+}
+
+// Complete reports whether the delivery's final transfer frame has
+// arrived.  The disposition methods require a complete delivery.
+func (ms *MessageStream) Complete() bool {
+	return ms.complete.Load()
+}
+
+// Accept notifies the server that the delivery has been accepted and does
+// not require redelivery.  The delivery must be complete.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+func (ms *MessageStream) Accept(ctx context.Context) error {
+	if err := ms.settleable(); err != nil {
+		return err
+	}
+	return ms.r.messageDisposition(ctx, ms.msg, &encoding.StateAccepted{})
+}
+
+// Reject notifies the server that the delivery is invalid.  The delivery
+// must be complete.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - e is an optional rejection error
+func (ms *MessageStream) Reject(ctx context.Context, e *Error) error {
+	if err := ms.settleable(); err != nil {
+		return err
+	}
+	return ms.r.messageDisposition(ctx, ms.msg, &encoding.StateRejected{Error: e})
+}
+
+// Release releases the delivery back to the server.  The delivery may be
+// redelivered to this or another consumer.  The delivery must be complete.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+func (ms *MessageStream) Release(ctx context.Context) error {
+	if err := ms.settleable(); err != nil {
+		return err
+	}
+	return ms.r.messageDisposition(ctx, ms.msg, &encoding.StateReleased{})
+}
+
+// Modify notifies the server that the delivery was not acted upon and
+// should be modified.  The delivery must be complete.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - options contains the optional settings to modify; pass nil to apply
+//     [ReceiverOptions.ModifiedOutcomeDefaults], if configured
+func (ms *MessageStream) Modify(ctx context.Context, options *ModifyMessageOptions) error {
+	if err := ms.settleable(); err != nil {
+		return err
+	}
+	return ms.r.messageDisposition(ctx, ms.msg, ms.r.modifiedOutcome(options))
+}
+
+func (ms *MessageStream) settleable() error {
+	if !ms.complete.Load() {
+		return errors.New("the delivery is not yet complete")
+	}
+	return nil
+}
+
+// discard consumes an abandoned stream on behalf of a caller whose
+// context expired as the stream was being committed, releasing the
+// delivery once it completes so the server can redeliver it.
+func (ms *MessageStream) discard() {
+	for {
+		select {
+		case ck := <-ms.chunks:
+			if ck.err == nil {
+				continue
+			}
+			if ck.err == io.EOF {
+				_ = ms.r.messageDisposition(context.Background(), ms.msg, &encoding.StateReleased{})
+			}
+			return
+		case <-ms.r.l.done:
+			return
+		}
+	}
+}
+
+// ReceiveStream returns the next delivery from the sender as a stream
+// that's readable while its transfer frames are still arriving, so huge
+// messages don't have to be fully buffered before the application sees
+// any bytes.  The stream carries the message's raw encoded bytes; they
+// are not decoded into a [Message].
+//
+// Blocks until a delivery is available, ctx completes, or an error occurs.
+//
+// Once the delivery is complete, and if the sender is configured in any
+// mode other than SenderSettleModeSettled, you *must* take an action on
+// it by calling one of the stream's Accept, Reject, Release, or Modify
+// methods.
+//
+// Only one ReceiveStream call may be in flight at a time, and it must not
+// be mixed with concurrent calls to [Receiver.Receive].
+func (r *Receiver) ReceiveStream(ctx context.Context) (*MessageStream, error) {
+	waiter := make(chan *MessageStream, 1)
+	if err := r.registerStreamWaiter(waiter); err != nil {
+		return nil, err
+	}
+
+	// a delivery that completed before the waiter was registered is
+	// already queued; surface it through the stream to preserve order
+	if msg := r.Prefetched(); msg != nil {
+		r.unregisterStreamWaiter(waiter)
+		return wrapMessageStream(r, msg)
+	}
+
+	select {
+	case ms := <-waiter:
+		return ms, nil
+	case <-r.l.done:
+		r.unregisterStreamWaiter(waiter)
+		return nil, r.l.doneErr
+	case <-ctx.Done():
+		if !r.unregisterStreamWaiter(waiter) {
+			// the mux committed a delivery to the waiter as the context
+			// expired; disown it so the link isn't stalled
+			go (<-waiter).discard()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// registerStreamWaiter installs waiter as the recipient of the next
+// delivery.  It fails if another ReceiveStream call is in flight.
+func (r *Receiver) registerStreamWaiter(waiter chan *MessageStream) error {
+	r.streamWaiterMu.Lock()
+	defer r.streamWaiterMu.Unlock()
+	if r.streamWaiter != nil {
+		return errors.New("a ReceiveStream call is already in flight")
+	}
+	r.streamWaiter = waiter
+	return nil
+}
+
+// unregisterStreamWaiter removes waiter, reporting false if the mux
+// already took it.
+func (r *Receiver) unregisterStreamWaiter(waiter chan *MessageStream) bool {
+	r.streamWaiterMu.Lock()
+	defer r.streamWaiterMu.Unlock()
+	if r.streamWaiter != waiter {
+		return false
+	}
+	r.streamWaiter = nil
+	return true
+}
+
+// takeStreamWaiter claims the registered waiter, if any.
+// called only from the receiver's mux.
+func (r *Receiver) takeStreamWaiter() chan *MessageStream {
+	r.streamWaiterMu.Lock()
+	defer r.streamWaiterMu.Unlock()
+	waiter := r.streamWaiter
+	r.streamWaiter = nil
+	return waiter
+}
+
+// wrapMessageStream adapts a fully received message into a stream.  The
+// message was decoded on receipt, so its encoded form is reconstructed;
+// in [ReceiverOptions.RawTransfers] mode the original bytes are reused.
+func wrapMessageStream(r *Receiver, msg *Message) (*MessageStream, error) {
+	payload := msg.RawPayload
+	if payload == nil {
+		var buf buffer.Buffer
+		if err := msg.Marshal(&buf); err != nil {
+			return nil, err
+		}
+		payload = buf.Detach()
+	}
+	ms := &MessageStream{
+		DeliveryTag: msg.DeliveryTag,
+		Format:      msg.Format,
+		r:           r,
+		msg:         msg,
+		chunks:      make(chan streamChunk, 2),
+	}
+	ms.complete.Store(true)
+	ms.chunks <- streamChunk{payload: payload}
+	ms.chunks <- streamChunk{err: io.EOF}
+	return ms, nil
+}
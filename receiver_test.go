@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -131,6 +133,255 @@ func TestReceiverLinkSourceFilter(t *testing.T) {
 	cancel()
 }
 
+func TestSessionNewTemporaryReceiver(t *testing.T) {
+	attachFrames := make(chan *frames.PerformAttach, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if tt, ok := req.(*frames.PerformAttach); ok {
+			attachFrames <- tt
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+				Name:   tt.Name,
+				Handle: 0,
+				Role:   encoding.RoleSender,
+				Source: &frames.Source{
+					Address:      "temp-queue-1234",
+					Dynamic:      true,
+					ExpiryPolicy: encoding.ExpiryLinkDetach,
+				},
+				ReceiverSettleMode: ReceiverSettleModeFirst.Ptr(),
+				MaxMessageSize:     math.MaxUint32,
+			})
+			return newResponse(b, err)
+		}
+		return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewTemporaryReceiver(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// the attach must request a dynamic, delete-on-close source
+	attach := <-attachFrames
+	require.True(t, attach.Source.Dynamic)
+	require.Empty(t, attach.Source.Address)
+	require.Equal(t, encoding.ExpiryLinkDetach, attach.Source.ExpiryPolicy)
+
+	// the peer-assigned address is surfaced for use as reply-to
+	require.Equal(t, "temp-queue-1234", r.Address())
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	require.NoError(t, r.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverAvailable(t *testing.T) {
+	conn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Zero(t, r.Available())
+
+	// peer reports its backlog in the "available" field of a flow frame
+	nextIncomingID := uint32(1)
+	available := uint32(42)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+		NextIncomingID: &nextIncomingID,
+		IncomingWindow: 1000,
+		NextOutgoingID: 1,
+		OutgoingWindow: 1000,
+		Handle:         &r.l.outputHandle,
+		Available:      &available,
+	})
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	require.Eventually(t, func() bool {
+		return r.Available() == available
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	require.NoError(t, r.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverQueryLinkState(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if ff, ok := req.(*frames.PerformFlow); ok && ff.Echo {
+			nextIncomingID := uint32(1)
+			deliveryCount := uint32(7)
+			available := uint32(42)
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+				NextIncomingID: &nextIncomingID,
+				IncomingWindow: 1000,
+				NextOutgoingID: 1,
+				OutgoingWindow: 1000,
+				Handle:         ff.Handle,
+				DeliveryCount:  &deliveryCount,
+				LinkCredit:     ff.LinkCredit,
+				Available:      &available,
+			})
+			return newResponse(b, err)
+		}
+		return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	state, err := r.QueryLinkState(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, LinkState{
+		Available:     42,
+		DeliveryCount: 7,
+		LinkCredit:    10,
+	}, state)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	require.NoError(t, r.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverIdleTimeout(t *testing.T) {
+	conn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	idle := make(chan struct{})
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		IdleTimeout: 100 * time.Millisecond,
+		OnIdle: func() bool {
+			close(idle)
+			return true
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	select {
+	case <-idle:
+		// idle callback was invoked
+	case <-time.After(time.Second):
+		t.Fatal("idle callback wasn't invoked")
+	}
+
+	// returning true from OnIdle closes the link
+	var linkErr *LinkError
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = r.Receive(ctx, nil)
+	cancel()
+	require.ErrorAs(t, err, &linkErr)
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverIdleTimeoutRequiresOnIdle(t *testing.T) {
+	conn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewReceiver(ctx, "source", &ReceiverOptions{IdleTimeout: time.Minute})
+	cancel()
+	require.Error(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewReceiver(ctx, "source", &ReceiverOptions{OnIdle: func() bool { return false }})
+	cancel()
+	require.Error(t, err)
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverRefresh(t *testing.T) {
+	attaches := make(chan *frames.PerformAttach, 2)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if attach, ok := req.(*frames.PerformAttach); ok {
+			attaches <- attach
+		}
+		return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "thesource", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	refreshed, err := r.Refresh(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.NotSame(t, r, refreshed)
+	require.Equal(t, "thesource", refreshed.Address())
+
+	// the original link is closed and unusable
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = r.Receive(ctx, nil)
+	cancel()
+	var linkErr *LinkError
+	require.ErrorAs(t, err, &linkErr)
+
+	// both attaches carry the same name and configuration
+	first, second := <-attaches, <-attaches
+	require.Equal(t, first.Name, second.Name)
+	require.Equal(t, first.Source.Address, second.Source.Address)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, refreshed.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
 func TestReceiverOnClosed(t *testing.T) {
 	conn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -323,6 +574,111 @@ func TestReceiverCloseTimeout(t *testing.T) {
 	require.NoError(t, client.Close())
 }
 
+func TestReceiverAcceptMessageTransactional(t *testing.T) {
+	const linkHandle = 0
+	deliveryID := uint32(1)
+	stateCh := make(chan encoding.DeliveryState, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformFlow:
+			if tt.NextIncomingID != nil && *tt.NextIncomingID == deliveryID {
+				// this is the first flow frame, send our payload
+				return newResponse(fake.PerformTransfer(0, linkHandle, deliveryID, []byte("hello")))
+			}
+			// ignore future flow frames as we have no response
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			stateCh <- tt.State
+			return fake.Response{}, nil
+		default:
+			return receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = r.AcceptMessageWithOptions(ctx, msg, &DispositionOptions{TransactionID: []byte("txn-1")})
+	cancel()
+	require.NoError(t, err)
+
+	// the disposition carries the outcome inside transactional-state
+	txnState, ok := (<-stateCh).(*encoding.TransactionalState)
+	require.True(t, ok)
+	require.Equal(t, []byte("txn-1"), txnState.TxnID)
+	require.IsType(t, &encoding.StateAccepted{}, txnState.Outcome)
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverDetachByPeer(t *testing.T) {
+	detachAck := make(chan *frames.PerformDetach, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformFlow, *fake.KeepAlive:
+			return fake.Response{}, nil
+		case *frames.PerformDetach:
+			detachAck <- tt
+			return fake.Response{}, nil
+		default:
+			return receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", nil)
+	cancel()
+	require.NoError(t, err)
+
+	// the peer suspends the link with a non-closing detach
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformDetach{Handle: 0})
+	require.NoError(t, err)
+	netConn.SendFrame(b)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := r.Receive(ctx, nil)
+	cancel()
+	require.Nil(t, msg)
+	var linkErr *LinkError
+	require.ErrorAs(t, err, &linkErr)
+	var suspendedErr *LinkSuspendedError
+	require.ErrorAs(t, err, &suspendedErr)
+
+	// we must ack the suspension with a non-closing detach of our own
+	select {
+	case ack := <-detachAck:
+		require.False(t, ack.Closed)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for detach ack")
+	}
+	require.NoError(t, client.Close())
+}
+
 func TestReceiveInvalidMessage(t *testing.T) {
 	const linkHandle = 0
 	deliveryID := uint32(1)
@@ -442,11 +798,8 @@ func TestReceiveInvalidMessage(t *testing.T) {
 	require.NoError(t, client.Close())
 }
 
-func TestReceiveSuccessReceiverSettleModeFirst(t *testing.T) {
-	muxSem := test.NewMuxSemaphore(2)
-
-	const linkHandle = 0
-	deliveryID := uint32(1)
+func TestReceiverSetCredit(t *testing.T) {
+	flows := make(chan uint32, 10)
 	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
 		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
 		if resp.Payload != nil || err != nil {
@@ -454,13 +807,7 @@ func TestReceiveSuccessReceiverSettleModeFirst(t *testing.T) {
 		}
 		switch ff := req.(type) {
 		case *frames.PerformFlow:
-			if *ff.NextIncomingID == deliveryID {
-				// this is the first flow frame, send our payload
-				return newResponse(fake.PerformTransfer(0, linkHandle, deliveryID, []byte("hello")))
-			}
-			// ignore future flow frames as we have no response
-			return fake.Response{}, nil
-		case *frames.PerformDisposition:
+			flows <- *ff.LinkCredit
 			return fake.Response{}, nil
 		default:
 			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
@@ -476,9 +823,137 @@ func TestReceiveSuccessReceiverSettleModeFirst(t *testing.T) {
 	cancel()
 	require.NoError(t, err)
 	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
-	r, err := newReceiverForSession(ctx, session, "source", &ReceiverOptions{
-		SettlementMode: ReceiverSettleModeFirst.Ptr(),
-	}, receiverTestHooks{MuxSelect: muxSem.OnLoop})
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	waitFlow := func() uint32 {
+		select {
+		case credit := <-flows:
+			return credit
+		case <-time.After(1 * time.Second):
+			t.Fatal("timed out waiting for flow frame")
+			return 0
+		}
+	}
+
+	// the initial flow carries the configured credit window
+	require.Equal(t, uint32(10), waitFlow())
+
+	// shrinking the window revokes credit via a flow frame
+	require.NoError(t, r.SetCredit(3))
+	require.Equal(t, uint32(3), waitFlow())
+
+	// growing the window grants the difference
+	require.NoError(t, r.SetCredit(20))
+	require.Equal(t, uint32(20), waitFlow())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverSetCreditManualMode(t *testing.T) {
+	conn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: -1})
+	cancel()
+	require.NoError(t, err)
+
+	require.Error(t, r.SetCredit(5))
+
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverAddressChange(t *testing.T) {
+	conn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	addrChanged := make(chan string, 1)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		OnAddressChange: func(newAddr string) {
+			addrChanged <- newAddr
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, "source", r.Address())
+
+	// the peer re-issues the terminus with a different address
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+		Name:   r.LinkName(),
+		Handle: 0,
+		Role:   encoding.RoleSender,
+		Source: &frames.Source{Address: "test2"},
+	})
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	select {
+	case newAddr := <-addrChanged:
+		require.Equal(t, "test2", newAddr)
+	case <-time.After(1 * time.Second):
+		t.Fatal("timed out waiting for address change")
+	}
+	require.Equal(t, "test2", r.Address())
+
+	require.NoError(t, client.Close())
+}
+
+func TestReceiveSuccessReceiverSettleModeFirst(t *testing.T) {
+	muxSem := test.NewMuxSemaphore(2)
+
+	const linkHandle = 0
+	deliveryID := uint32(1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch ff := req.(type) {
+		case *frames.PerformFlow:
+			if *ff.NextIncomingID == deliveryID {
+				// this is the first flow frame, send our payload
+				return newResponse(fake.PerformTransfer(0, linkHandle, deliveryID, []byte("hello")))
+			}
+			// ignore future flow frames as we have no response
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := newReceiverForSession(ctx, session, "source", &ReceiverOptions{
+		SettlementMode: ReceiverSettleModeFirst.Ptr(),
+	}, receiverTestHooks{MuxSelect: muxSem.OnLoop})
 	cancel()
 	require.NoError(t, err)
 	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
@@ -1528,6 +2003,61 @@ func TestReceiverProperties(t *testing.T) {
 	require.NoError(t, conn.Close())
 }
 
+func TestReceiverPeerSourceCapabilities(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch ff := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("test"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformAttach:
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+				Name:   ff.Name,
+				Handle: 0,
+				Role:   encoding.RoleSender,
+				Source: &frames.Source{
+					Address:      "test",
+					Durable:      encoding.DurabilityNone,
+					ExpiryPolicy: encoding.ExpirySessionEnd,
+					Capabilities: encoding.MultiSymbol{"topic", "global"},
+				},
+				ReceiverSettleMode: ReceiverSettleModeFirst.Ptr(),
+				MaxMessageSize:     math.MaxUint32,
+			})
+			return newResponse(b, err)
+		case *frames.PerformFlow, *fake.KeepAlive:
+			return fake.Response{}, nil
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, ff.Handle, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "thesource", &ReceiverOptions{
+		SourceCapabilities: []string{"topic"},
+	})
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []string{"topic", "global"}, r.PeerSourceCapabilities())
+	require.NoError(t, conn.Close())
+}
+
 func TestReceiverAttachDesiredCapabilities(t *testing.T) {
 	t.Run("NilDesiredCaps", func(t *testing.T) {
 		require.Nil(t, runToAttachWithOptions(t, ReceiverOptions{
@@ -1550,3 +2080,985 @@ func TestReceiverAttachDesiredCapabilities(t *testing.T) {
 }
 
 // TODO: add unit tests for manual credit management
+
+func TestReceiverRawTransfers(t *testing.T) {
+	r := newTestLink(t)
+	r.rawTransfers = true
+
+	deliveryID := uint32(1)
+	msgFormat := uint32(0)
+	r.muxReceive(frames.PerformTransfer{
+		DeliveryID:    &deliveryID,
+		DeliveryTag:   []byte("tag"),
+		MessageFormat: &msgFormat,
+		Payload:       []byte{0, 0x53, 0x77, 0xa1, 5, 'h', 'e', 'l', 'l', 'o'},
+		Settled:       true,
+	})
+
+	msg := r.Prefetched()
+	require.NotNil(t, msg)
+	require.Equal(t, []byte("tag"), msg.DeliveryTag)
+	require.Equal(t, []byte{0, 0x53, 0x77, 0xa1, 5, 'h', 'e', 'l', 'l', 'o'}, msg.RawPayload)
+	// sections are not decoded in raw mode
+	require.Nil(t, msg.Value)
+}
+
+func TestReceiverOrderedSettlement(t *testing.T) {
+	firstIDs := make(chan uint32, 2)
+	var netConn *fake.NetConn
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch ff := req.(type) {
+		case *frames.PerformFlow:
+			if *ff.NextIncomingID == 1 {
+				// this is the first flow frame, send both messages
+				for deliveryID := uint32(1); deliveryID <= 2; deliveryID++ {
+					b, err := fake.PerformTransfer(0, 0, deliveryID, []byte("hello"))
+					require.NoError(t, err)
+					netConn.SendFrame(b)
+				}
+			}
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			firstIDs <- ff.First
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn = fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		Credit:            2,
+		OrderedSettlement: true,
+		SettlementMode:    ReceiverSettleModeFirst.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg1, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg2, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// settling out of order buffers the disposition
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.AcceptMessage(ctx, msg2))
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	require.Empty(t, firstIDs)
+
+	// settling the older delivery flushes both dispositions in order
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.AcceptMessage(ctx, msg1))
+	cancel()
+	require.Equal(t, uint32(1), <-firstIDs)
+	require.Equal(t, uint32(2), <-firstIDs)
+
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverMaxDeliveryAttempts(t *testing.T) {
+	states := make(chan encoding.DeliveryState, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformDisposition:
+			states <- tt.State
+			return fake.Response{}, nil
+		default:
+			return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		Credit:                     2,
+		MaxDeliveryAttempts:        3,
+		MaxDeliveryAttemptsOutcome: &StateModified{DeliveryFailed: true, UndeliverableHere: true},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	// first message has exhausted its delivery attempts
+	poison := &Message{
+		Header: &MessageHeader{DeliveryCount: 3},
+		Data:   [][]byte{[]byte("poison")},
+	}
+	payload, err := poison.MarshalBinary()
+	require.NoError(t, err)
+	deliveryID := uint32(1)
+	format := uint32(0)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{
+		Handle:        0,
+		DeliveryID:    &deliveryID,
+		DeliveryTag:   []byte("poison"),
+		MessageFormat: &format,
+		Payload:       payload,
+	})
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	b, err = fake.PerformTransfer(0, 0, 2, []byte("good"))
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	// the poison message is auto-settled; only the good one is returned
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("good"), msg.GetData())
+	require.False(t, msg.IsRedelivered())
+
+	select {
+	case state := <-states:
+		require.Equal(t, &StateModified{DeliveryFailed: true, UndeliverableHere: true}, state)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for disposition of the poison message")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.AcceptMessage(ctx, msg))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverMessageFilter(t *testing.T) {
+	states := make(chan encoding.DeliveryState, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformDisposition:
+			states <- tt.State
+			return fake.Response{}, nil
+		default:
+			return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		Credit: 2,
+		MessageFilter: func(msg *Message) bool {
+			return string(msg.GetData()) == "keep"
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	b, err := fake.PerformTransfer(0, 0, 1, []byte("drop"))
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	b, err = fake.PerformTransfer(0, 0, 2, []byte("keep"))
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	// the non-matching message is auto-settled; only the matching one is returned
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("keep"), msg.GetData())
+
+	select {
+	case state := <-states:
+		require.Equal(t, &encoding.StateReleased{}, state)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for disposition of the filtered message")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.AcceptMessage(ctx, msg))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+// greedyCreditStrategy requests a large top-up on every consultation,
+// exercising the MaxUnsettled clamp.
+type greedyCreditStrategy struct{}
+
+func (greedyCreditStrategy) Credits(CreditState) uint32 {
+	return 100
+}
+
+func TestReceiverMaxUnsettled(t *testing.T) {
+	flows := make(chan uint32, 10)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch ff := req.(type) {
+		case *frames.PerformFlow:
+			flows <- *ff.LinkCredit
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		Credit:         2,
+		CreditStrategy: greedyCreditStrategy{},
+		MaxUnsettled:   2,
+	})
+	cancel()
+	require.NoError(t, err)
+
+	waitFlow := func() uint32 {
+		select {
+		case credit := <-flows:
+			return credit
+		case <-time.After(1 * time.Second):
+			t.Fatal("timed out waiting for flow frame")
+			return 0
+		}
+	}
+
+	// the initial flow carries the configured credit window
+	require.Equal(t, uint32(2), waitFlow())
+
+	b, err := fake.PerformTransfer(0, 0, 1, []byte("one"))
+	require.NoError(t, err)
+	conn.SendFrame(b)
+	b, err = fake.PerformTransfer(0, 0, 2, []byte("two"))
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg1, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg2, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// the cap is reached; no credit is issued despite the greedy strategy
+	select {
+	case credit := <-flows:
+		t.Fatalf("unexpected flow frame with credit %d while at the unsettled cap", credit)
+	case <-time.After(200 * time.Millisecond):
+		// expected
+	}
+
+	// each settlement releases the credit its delivery was holding
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.AcceptMessage(ctx, msg1))
+	cancel()
+	require.Equal(t, uint32(1), waitFlow())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.AcceptMessage(ctx, msg2))
+	cancel()
+	require.Equal(t, uint32(2), waitFlow())
+
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverMaxUnsettledValidation(t *testing.T) {
+	// the credit window cannot exceed the unsettled cap
+	_, err := newReceiver("source", newSession(nil, 0, nil), &ReceiverOptions{
+		Credit:       10,
+		MaxUnsettled: 5,
+	})
+	require.Error(t, err)
+
+	// manual credit management is incompatible with the cap
+	_, err = newReceiver("source", newSession(nil, 0, nil), &ReceiverOptions{
+		Credit:       -1,
+		MaxUnsettled: 5,
+	})
+	require.Error(t, err)
+}
+
+func TestReceiverModifiedOutcomeDefaults(t *testing.T) {
+	states := make(chan encoding.DeliveryState, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformDisposition:
+			states <- tt.State
+			return fake.Response{}, nil
+		default:
+			return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		Credit:                     3,
+		MaxDeliveryAttempts:        3,
+		MaxDeliveryAttemptsOutcome: &StateModified{},
+		ModifiedOutcomeDefaults: &ModifyMessageOptions{
+			DeliveryFailed:    true,
+			UndeliverableHere: true,
+			Annotations:       Annotations{"x-opt-reason": "policy"},
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	wantState := &StateModified{
+		DeliveryFailed:     true,
+		UndeliverableHere:  true,
+		MessageAnnotations: Annotations{"x-opt-reason": "policy"},
+	}
+	waitState := func() encoding.DeliveryState {
+		select {
+		case state := <-states:
+			return state
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for disposition frame")
+			return nil
+		}
+	}
+
+	// the empty modified outcome for exhausted deliveries picks up the defaults
+	poison := &Message{
+		Header: &MessageHeader{DeliveryCount: 3},
+		Data:   [][]byte{[]byte("poison")},
+	}
+	payload, err := poison.MarshalBinary()
+	require.NoError(t, err)
+	deliveryID := uint32(1)
+	format := uint32(0)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{
+		Handle:        0,
+		DeliveryID:    &deliveryID,
+		DeliveryTag:   []byte("poison"),
+		MessageFormat: &format,
+		Payload:       payload,
+	})
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	b, err = fake.PerformTransfer(0, 0, 2, []byte("good-1"))
+	require.NoError(t, err)
+	conn.SendFrame(b)
+	b, err = fake.PerformTransfer(0, 0, 3, []byte("good-2"))
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg1, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, wantState, waitState())
+
+	// ModifyMessage with nil options applies the defaults too
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.ModifyMessage(ctx, msg1, nil))
+	cancel()
+	require.Equal(t, wantState, waitState())
+
+	// explicit options still win over the defaults
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg2, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.ModifyMessage(ctx, msg2, &ModifyMessageOptions{}))
+	cancel()
+	require.Equal(t, &StateModified{}, waitState())
+
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverDiscardExpired(t *testing.T) {
+	states := make(chan encoding.DeliveryState, 2)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformDisposition:
+			states <- tt.State
+			return fake.Response{}, nil
+		default:
+			return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		Credit:         3,
+		DiscardExpired: true,
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendMessage := func(deliveryID uint32, msg *Message) {
+		payload, err := msg.MarshalBinary()
+		require.NoError(t, err)
+		format := uint32(0)
+		b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{
+			Handle:        0,
+			DeliveryID:    &deliveryID,
+			DeliveryTag:   []byte{byte(deliveryID)},
+			MessageFormat: &format,
+			Payload:       payload,
+		})
+		require.NoError(t, err)
+		conn.SendFrame(b)
+	}
+
+	// expired via absolute-expiry-time
+	expiry := time.Now().Add(-time.Minute)
+	sendMessage(1, &Message{
+		Properties: &MessageProperties{AbsoluteExpiryTime: &expiry},
+		Data:       [][]byte{[]byte("stale")},
+	})
+	// expired via creation-time plus ttl
+	created := time.Now().Add(-time.Minute)
+	sendMessage(2, &Message{
+		Header:     &MessageHeader{TTL: time.Second},
+		Properties: &MessageProperties{CreationTime: &created},
+		Data:       [][]byte{[]byte("stale")},
+	})
+	// no expiry
+	sendMessage(3, &Message{Data: [][]byte{[]byte("fresh")}})
+
+	// the expired messages are accepted without being surfaced
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("fresh"), msg.GetData())
+	for i := 0; i < 2; i++ {
+		select {
+		case state := <-states:
+			require.Equal(t, &StateAccepted{}, state)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for disposition of an expired message")
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, r.AcceptMessage(ctx, msg))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverReceiveBatch(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch req.(type) {
+		case *frames.PerformFlow, *frames.PerformDisposition:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		Credit: 10,
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendMessage := func(deliveryID uint32, data string) {
+		b, err := fake.PerformTransfer(0, 0, deliveryID, []byte(data))
+		require.NoError(t, err)
+		conn.SendFrame(b)
+	}
+
+	// a full batch returns as soon as maxCount messages have arrived
+	sendMessage(1, "msg-1")
+	sendMessage(2, "msg-2")
+	sendMessage(3, "msg-3")
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	batch, err := r.ReceiveBatch(ctx, 3, time.Minute)
+	cancel()
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+
+	// a partial batch returns once maxWait elapses
+	sendMessage(4, "msg-4")
+	sendMessage(5, "msg-5")
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	batch, err = r.ReceiveBatch(ctx, 5, 200*time.Millisecond)
+	cancel()
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	// without a wait, the batch is filled from the prefetch cache only
+	sendMessage(6, "msg-6")
+	sendMessage(7, "msg-7")
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	batch, err = r.ReceiveBatch(ctx, 5, 0)
+	cancel()
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	// the first message blocks on ctx
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	batch, err = r.ReceiveBatch(ctx, 5, time.Minute)
+	cancel()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Nil(t, batch)
+
+	// maxCount must be positive
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	batch, err = r.ReceiveBatch(ctx, 0, time.Minute)
+	cancel()
+	require.Error(t, err)
+	require.Nil(t, batch)
+
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverStrictValidationSettledTransfer(t *testing.T) {
+	const linkHandle = 0
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformAttach:
+			// echo back snd-settle-mode unsettled so it's the negotiated value
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+				Name:   tt.Name,
+				Handle: linkHandle,
+				Role:   encoding.RoleSender,
+				Source: &frames.Source{
+					Address:      "test",
+					ExpiryPolicy: encoding.ExpirySessionEnd,
+				},
+				SenderSettleMode: SenderSettleModeUnsettled.Ptr(),
+			}))
+		default:
+			return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, &ConnOptions{StrictValidation: true})
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		RequestedSenderSettleMode: SenderSettleModeUnsettled.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	// a settled transfer violates the negotiated snd-settle-mode of unsettled
+	deliveryID := uint32(1)
+	format := uint32(0)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{
+		Handle:        linkHandle,
+		DeliveryID:    &deliveryID,
+		DeliveryTag:   []byte("tag"),
+		MessageFormat: &format,
+		Settled:       true,
+		Payload:       []byte("hello"),
+	})
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	msg, err := r.Receive(ctx, nil)
+	cancel()
+	require.Nil(t, msg)
+	var linkErr *LinkError
+	require.ErrorAs(t, err, &linkErr)
+	require.Contains(t, linkErr.Error(), ErrCondNotAllowed)
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverInlineReceive(t *testing.T) {
+	const linkHandle = 0
+	deliveryID := uint32(1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeSecond)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch ff := req.(type) {
+		case *frames.PerformFlow:
+			if *ff.NextIncomingID == deliveryID {
+				// this is the first flow frame, send our payload
+				return newResponse(fake.PerformTransfer(0, linkHandle, deliveryID, []byte("hello")))
+			}
+			// ignore future flow frames as we have no response
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			if _, ok := ff.State.(*encoding.StateAccepted); !ok {
+				return fake.Response{}, fmt.Errorf("unexpected State %T", ff.State)
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleSender, 0, deliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{
+		InlineReceive:  true,
+		SettlementMode: ReceiverSettleModeSecond.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	// the message is assembled on this goroutine; no mux is running
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	msg, err := r.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), msg.GetData())
+	if c := r.countUnsettled(); c != 1 {
+		t.Fatalf("unexpected unsettled count %d", c)
+	}
+
+	// the settlement ack is also processed on this goroutine
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	err = r.AcceptMessage(ctx, msg)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, true, msg.settled)
+	if c := r.countUnsettled(); c != 0 {
+		t.Fatalf("unexpected unsettled count %d", c)
+	}
+
+	// closing hands the detach handshake to a dedicated goroutine
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	err = r.Close(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverInlineReceiveSharedLinkMux(t *testing.T) {
+	conn := fake.NewNetConn(receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst), fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, &SessionOptions{SharedLinkMux: true})
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{InlineReceive: true})
+	cancel()
+	require.ErrorContains(t, err, "InlineReceive cannot be combined with SharedLinkMux")
+	require.Nil(t, r)
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverDrainPrefetch(t *testing.T) {
+	var released int64
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			if _, ok := tt.State.(*encoding.StateReleased); !ok {
+				return fake.Response{}, fmt.Errorf("unexpected disposition state %T", tt.State)
+			}
+			atomic.AddInt64(&released, 1)
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	const msgCount = 3
+	for i := uint32(0); i < msgCount; i++ {
+		b, err := fake.PerformTransfer(0, 0, i, []byte("hello"))
+		require.NoError(t, err)
+		conn.SendFrame(b)
+	}
+	require.Eventually(t, func() bool {
+		return r.messagesQ.Len() == msgCount
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	drained, err := r.DrainPrefetch(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, msgCount, drained)
+	require.Nil(t, r.Prefetched())
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&released) == msgCount
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	require.NoError(t, r.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverReceiveStream(t *testing.T) {
+	accepted := make(chan struct{})
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			if _, ok := tt.State.(*encoding.StateAccepted); !ok {
+				return fake.Response{}, fmt.Errorf("unexpected disposition state %T", tt.State)
+			}
+			close(accepted)
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	// the stream is readable while continuation frames are still arriving
+	streamCh := make(chan *MessageStream, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		defer cancel()
+		ms, err := r.ReceiveStream(ctx)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		streamCh <- ms
+	}()
+
+	// wait for the stream waiter to register so the delivery is routed
+	// to the stream rather than decoded as a message
+	require.Eventually(t, func() bool {
+		r.streamWaiterMu.Lock()
+		defer r.streamWaiterMu.Unlock()
+		return r.streamWaiter != nil
+	}, time.Second, time.Millisecond)
+
+	deliveryID := uint32(0)
+	format := uint32(0)
+	chunks := [][]byte{[]byte("chunk1"), []byte("chunk2"), []byte("chunk3")}
+	for i, chunk := range chunks {
+		fr := frames.PerformTransfer{
+			Handle:  0,
+			More:    i < len(chunks)-1,
+			Payload: chunk,
+		}
+		if i == 0 {
+			fr.DeliveryID = &deliveryID
+			fr.DeliveryTag = []byte("tag")
+			fr.MessageFormat = &format
+		}
+		b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &fr)
+		require.NoError(t, err)
+		conn.SendFrame(b)
+	}
+
+	var ms *MessageStream
+	select {
+	case ms = <-streamCh:
+	case err := <-errCh:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for stream")
+	}
+	require.Equal(t, []byte("tag"), ms.DeliveryTag)
+
+	payload, err := io.ReadAll(ms)
+	require.NoError(t, err)
+	require.Equal(t, []byte("chunk1chunk2chunk3"), payload)
+	require.True(t, ms.Complete())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, ms.Accept(ctx))
+	cancel()
+	select {
+	case <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for disposition")
+	}
+
+	require.NoError(t, client.Close())
+}
+
+func TestReceiverReceiveStreamAborted(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch req.(type) {
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	conn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, conn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	r, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	streamCh := make(chan *MessageStream, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		defer cancel()
+		ms, err := r.ReceiveStream(ctx)
+		if err == nil {
+			streamCh <- ms
+		}
+	}()
+
+	// wait for the stream waiter to register so the delivery is routed
+	// to the stream rather than decoded as a message
+	require.Eventually(t, func() bool {
+		r.streamWaiterMu.Lock()
+		defer r.streamWaiterMu.Unlock()
+		return r.streamWaiter != nil
+	}, time.Second, time.Millisecond)
+
+	deliveryID := uint32(0)
+	format := uint32(0)
+	first := frames.PerformTransfer{
+		Handle:        0,
+		DeliveryID:    &deliveryID,
+		DeliveryTag:   []byte("tag"),
+		MessageFormat: &format,
+		More:          true,
+		Payload:       []byte("partial"),
+	}
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &first)
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	var ms *MessageStream
+	select {
+	case ms = <-streamCh:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for stream")
+	}
+
+	b, err = fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{Handle: 0, Aborted: true})
+	require.NoError(t, err)
+	conn.SendFrame(b)
+
+	_, err = io.ReadAll(ms)
+	require.ErrorContains(t, err, "aborted")
+	require.False(t, ms.Complete())
+
+	require.NoError(t, client.Close())
+}
@@ -0,0 +1,125 @@
+package amqp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Azure/go-amqp/internal/shared"
+)
+
+// RPCLinkOptions contains the optional settings for configuring an RPCLink.
+type RPCLinkOptions struct {
+	// ReplyTo is the target address of the response link; requests'
+	// reply-to property is set to it so the node routes responses back.
+	//
+	// Default: the node address suffixed with "-reply-" and a random string.
+	ReplyTo string
+}
+
+// RPCLink pairs a [Sender] and a [Receiver] on the same node for the
+// management-style request/response pattern used by nodes like
+// $management and $cbs: each request is stamped with a message-id and
+// reply-to, and the matching response is selected by correlation-id.
+//
+// Safe for concurrent use; calls are serialized on the link pair.
+type RPCLink struct {
+	sender   *Sender
+	receiver *Receiver
+	replyTo  string
+
+	mu sync.Mutex // serializes Call so responses can't be delivered to the wrong caller
+}
+
+// NewRPCLink attaches a sender and receiver to the node at address and
+// returns the pair ready for [RPCLink.Call].  Close the link when done;
+// closing the session closes it implicitly.
+//   - ctx controls waiting for the links to attach
+//   - session is the session the links are created on
+//   - address is the name of the node, e.g. "$management"
+//   - opts contains optional values, pass nil to accept the defaults
+func NewRPCLink(ctx context.Context, session *Session, address string, opts *RPCLinkOptions) (*RPCLink, error) {
+	l := &RPCLink{}
+	if opts != nil {
+		l.replyTo = opts.ReplyTo
+	}
+	if l.replyTo == "" {
+		l.replyTo = fmt.Sprintf("%s-reply-%s", address, shared.RandString(8))
+	}
+
+	var err error
+	if l.sender, err = session.NewSender(ctx, address, nil); err != nil {
+		return nil, err
+	}
+	if l.receiver, err = session.NewReceiver(ctx, address, &ReceiverOptions{
+		TargetAddress: l.replyTo,
+	}); err != nil {
+		_ = l.sender.Close(ctx)
+		return nil, err
+	}
+	return l, nil
+}
+
+// Call sends msg to the node and returns its response.  The message's
+// reply-to property is set to the link's response address and, when not
+// already populated, its message-id to a random string; responses are
+// matched to the request by correlation-id and settled, with unrelated
+// responses discarded.
+//   - ctx controls waiting for the response
+//   - msg is the request to send
+func (l *RPCLink) Call(ctx context.Context, msg *Message) (*Message, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	props := msg.ensureProperties()
+	if props.MessageID == nil {
+		props.MessageID = shared.RandString(16)
+	}
+	props.ReplyTo = &l.replyTo
+
+	if err := l.sender.Send(ctx, msg, nil); err != nil {
+		return nil, err
+	}
+
+	for {
+		resp, err := l.receiver.Receive(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.receiver.AcceptMessage(ctx, resp); err != nil {
+			return nil, err
+		}
+		// discard responses to stale requests, e.g. a previous call
+		// whose response arrived after its context was cancelled
+		if resp.Properties == nil || !matchCorrelationID(props.MessageID, resp.Properties.CorrelationID) {
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// Close closes the underlying sender and receiver.  The session the
+// links were created on is left open.
+//   - ctx controls waiting for the peer to acknowledge the links are closed
+func (l *RPCLink) Close(ctx context.Context) error {
+	senderErr := l.sender.Close(ctx)
+	if err := l.receiver.Close(ctx); senderErr == nil {
+		return err
+	}
+	return senderErr
+}
+
+// matchCorrelationID reports whether a response's correlation-id matches
+// a request's message-id.  Of the types the message-id property permits,
+// binary needs comparing by content; the rest are comparable.
+func matchCorrelationID(id, correlationID any) bool {
+	if b, ok := id.([]byte); ok {
+		cb, ok := correlationID.([]byte)
+		return ok && bytes.Equal(b, cb)
+	}
+	if _, ok := correlationID.([]byte); ok {
+		return false
+	}
+	return id == correlationID
+}
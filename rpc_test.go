@@ -0,0 +1,184 @@
+package amqp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcFrameHandler responds as a management node: it attaches link pairs,
+// grants the client's sender credit, and echoes each request's value in
+// a response correlated to the request's message-id.  When staleFirst is
+// true each response is preceded by one with an unrelated
+// correlation-id.  Requests are sent to the requests channel when
+// non-nil.
+func rpcFrameHandler(requests chan<- *Message, staleFirst bool) frameHandler {
+	var deliveryID uint32
+	sendTransfer := func(resp *Message) ([]byte, error) {
+		payload, err := resp.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		format := uint32(0)
+		id := deliveryID
+		deliveryID++
+		return fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{
+			Handle:        1,
+			DeliveryID:    &id,
+			DeliveryTag:   []byte("rpc-resp"),
+			MessageFormat: &format,
+			Payload:       payload,
+		})
+	}
+	return func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			if tt.Role == encoding.RoleSender {
+				ab, err := fake.SenderAttach(0, tt.Name, 0, encoding.SenderSettleModeUnsettled)
+				if err != nil {
+					return fake.Response{}, err
+				}
+				var (
+					nextIncoming uint32
+					count        uint32
+					handle       uint32
+					credit       uint32 = 100
+				)
+				fb, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+					NextIncomingID: &nextIncoming,
+					IncomingWindow: 1000,
+					OutgoingWindow: 1000,
+					NextOutgoingID: nextIncoming + 1,
+					Handle:         &handle,
+					DeliveryCount:  &count,
+					LinkCredit:     &credit,
+				})
+				if err != nil {
+					return fake.Response{}, err
+				}
+				return fake.Response{Payload: append(ab, fb...)}, nil
+			}
+			return newResponse(fake.ReceiverAttach(0, tt.Name, 1, encoding.ReceiverSettleModeFirst, nil))
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		case *frames.PerformDisposition:
+			return fake.Response{}, nil
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, tt.Handle, nil))
+		case *frames.PerformTransfer:
+			var msg Message
+			if err := msg.UnmarshalBinary(tt.Payload); err != nil {
+				return fake.Response{}, err
+			}
+			if requests != nil {
+				requests <- &msg
+			}
+			payload, err := fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			if staleFirst {
+				stale, err := sendTransfer(&Message{
+					Properties: &MessageProperties{CorrelationID: "unrelated"},
+					Value:      "stale",
+				})
+				if err != nil {
+					return fake.Response{}, err
+				}
+				payload = append(payload, stale...)
+			}
+			resp, err := sendTransfer(&Message{
+				Properties: &MessageProperties{CorrelationID: msg.Properties.MessageID},
+				Value:      msg.Value,
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: append(payload, resp...)}, nil
+		default:
+			return fake.Response{}, nil
+		}
+	}
+}
+
+// newTestRPCLink establishes a connection, session, and RPCLink over the
+// supplied responder.
+func newTestRPCLink(t *testing.T, responder frameHandler, opts *RPCLinkOptions) (*Conn, *RPCLink) {
+	t.Helper()
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := NewConn(ctx, netConn, nil)
+	require.NoError(t, err)
+	session, err := client.NewSession(ctx, nil)
+	require.NoError(t, err)
+	link, err := NewRPCLink(ctx, session, "$management", opts)
+	require.NoError(t, err)
+	return client, link
+}
+
+func TestRPCLinkCall(t *testing.T) {
+	requests := make(chan *Message, 1)
+	client, link := newTestRPCLink(t, rpcFrameHandler(requests, false), nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := link.Call(ctx, &Message{Value: "ping"})
+	require.NoError(t, err)
+	require.Equal(t, "ping", resp.Value)
+
+	request := <-requests
+	require.NotNil(t, request.Properties.MessageID)
+	require.NotNil(t, request.Properties.ReplyTo)
+	require.Contains(t, *request.Properties.ReplyTo, "$management-reply-")
+
+	require.NoError(t, link.Close(ctx))
+}
+
+func TestRPCLinkCallPreservesMessageID(t *testing.T) {
+	requests := make(chan *Message, 1)
+	client, link := newTestRPCLink(t, rpcFrameHandler(requests, false), &RPCLinkOptions{
+		ReplyTo: "my-replies",
+	})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := link.Call(ctx, &Message{
+		Properties: &MessageProperties{MessageID: "my-id"},
+		Value:      "ping",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "my-id", resp.Properties.CorrelationID)
+
+	request := <-requests
+	require.Equal(t, "my-id", request.Properties.MessageID)
+	require.Equal(t, "my-replies", *request.Properties.ReplyTo)
+}
+
+func TestRPCLinkCallDiscardsUnrelated(t *testing.T) {
+	client, link := newTestRPCLink(t, rpcFrameHandler(nil, true), nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := link.Call(ctx, &Message{Value: "ping"})
+	require.NoError(t, err)
+	require.Equal(t, "ping", resp.Value)
+}
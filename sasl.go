@@ -44,7 +44,7 @@ func SASLTypePlain(username, password string) SASLType {
 				Type: frames.TypeSASL,
 				Body: init,
 			}
-			debug.Log(1, "TX (ConnSASLPlain %p): %s", c, fr)
+			debug.LogC(c.correlationID, 1, "TX (ConnSASLPlain %p): %s", c, fr)
 			timeout, err := c.getWriteTimeout(ctx)
 			if err != nil {
 				return nil, err
@@ -78,7 +78,7 @@ func SASLTypeAnonymous() SASLType {
 				Type: frames.TypeSASL,
 				Body: init,
 			}
-			debug.Log(1, "TX (ConnSASLAnonymous %p): %s", c, fr)
+			debug.LogC(c.correlationID, 1, "TX (ConnSASLAnonymous %p): %s", c, fr)
 			timeout, err := c.getWriteTimeout(ctx)
 			if err != nil {
 				return nil, err
@@ -114,7 +114,7 @@ func SASLTypeExternal(resp string) SASLType {
 				Type: frames.TypeSASL,
 				Body: init,
 			}
-			debug.Log(1, "TX (ConnSASLExternal %p): %s", c, fr)
+			debug.LogC(c.correlationID, 1, "TX (ConnSASLExternal %p): %s", c, fr)
 			timeout, err := c.getWriteTimeout(ctx)
 			if err != nil {
 				return nil, err
@@ -196,7 +196,7 @@ func (s saslXOAUTH2Handler) init(ctx context.Context) (stateFunc, error) {
 
 func (s saslXOAUTH2Handler) step(ctx context.Context) (stateFunc, error) {
 	// read challenge or outcome frame
-	fr, err := s.conn.readFrame()
+	fr, err := s.conn.readSASLFrame()
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -238,6 +239,118 @@ func TestConnSASLXOAUTH2AuthFailsAdditionalErrorResponse(t *testing.T) {
 	}
 }
 
+func TestConnSASLLimitsMaxChallengeSize(t *testing.T) {
+	buf, err := peerResponse(
+		[]byte("AMQP\x03\x01\x00\x00"),
+		frames.Frame{
+			Type:    frames.TypeSASL,
+			Channel: 0,
+			Body:    &frames.SASLMechanisms{Mechanisms: []encoding.Symbol{saslMechanismXOAUTH2}},
+		},
+		frames.Frame{
+			Type:    frames.TypeSASL,
+			Channel: 0,
+			Body:    &frames.SASLChallenge{Challenge: []byte("this challenge exceeds the configured maximum")},
+		},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := testconn.New(buf)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	client, err := NewConn(ctx, c, &ConnOptions{
+		IdleTimeout: 10 * time.Minute,
+		SASLLimits:  &SASLLimitOptions{MaxChallengeSize: 8},
+		SASLType:    SASLTypeXOAUTH2("someuser@example.com", "ya29.vF9dft4qmTc2Nvb3RlckBhdHRhdmlzdGEuY29tCg", 512),
+	})
+	cancel()
+	if err == nil {
+		client.Close()
+		t.Fatal("oversized challenge is expected to fail the connection")
+	}
+	var limitErr *SASLLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxChallengeSize" {
+		t.Errorf("unexpected connection failure : %s", err)
+	}
+}
+
+func TestConnSASLLimitsMaxChallengeRounds(t *testing.T) {
+	buf, err := peerResponse(
+		[]byte("AMQP\x03\x01\x00\x00"),
+		frames.Frame{
+			Type:    frames.TypeSASL,
+			Channel: 0,
+			Body:    &frames.SASLMechanisms{Mechanisms: []encoding.Symbol{saslMechanismXOAUTH2}},
+		},
+		frames.Frame{
+			Type:    frames.TypeSASL,
+			Channel: 0,
+			Body:    &frames.SASLChallenge{Challenge: []byte("round1")},
+		},
+		frames.Frame{
+			Type:    frames.TypeSASL,
+			Channel: 0,
+			Body:    &frames.SASLChallenge{Challenge: []byte("round2")},
+		},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := testconn.New(buf)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	client, err := NewConn(ctx, c, &ConnOptions{
+		IdleTimeout: 10 * time.Minute,
+		SASLLimits:  &SASLLimitOptions{MaxChallengeRounds: 1},
+		SASLType:    SASLTypeXOAUTH2("someuser@example.com", "ya29.vF9dft4qmTc2Nvb3RlckBhdHRhdmlzdGEuY29tCg", 512),
+	})
+	cancel()
+	if err == nil {
+		client.Close()
+		t.Fatal("excessive challenge rounds are expected to fail the connection")
+	}
+	var limitErr *SASLLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxChallengeRounds" {
+		t.Errorf("unexpected connection failure : %s", err)
+	}
+}
+
+func TestConnSASLLimitsHandshakeTimeout(t *testing.T) {
+	// the peer sends the mechanisms frame and then goes silent
+	buf, err := peerResponse(
+		[]byte("AMQP\x03\x01\x00\x00"),
+		frames.Frame{
+			Type:    frames.TypeSASL,
+			Channel: 0,
+			Body:    &frames.SASLMechanisms{Mechanisms: []encoding.Symbol{saslMechanismXOAUTH2}},
+		},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := testconn.New(buf)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	client, err := NewConn(ctx, c, &ConnOptions{
+		IdleTimeout: 10 * time.Minute,
+		SASLLimits:  &SASLLimitOptions{HandshakeTimeout: 100 * time.Millisecond},
+		SASLType:    SASLTypeXOAUTH2("someuser@example.com", "ya29.vF9dft4qmTc2Nvb3RlckBhdHRhdmlzdGEuY29tCg", 512),
+	})
+	cancel()
+	if err == nil {
+		client.Close()
+		t.Fatal("stalled negotiation is expected to fail the connection")
+	}
+	var limitErr *SASLLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "HandshakeTimeout" {
+		t.Errorf("unexpected connection failure : %s", err)
+	}
+}
+
 func TestConnSASLExternal(t *testing.T) {
 	buf, err := peerResponse(
 		[]byte("AMQP\x03\x01\x00\x00"),
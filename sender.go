@@ -1,16 +1,25 @@
 package amqp
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Azure/go-amqp/internal/buffer"
 	"github.com/Azure/go-amqp/internal/debug"
 	"github.com/Azure/go-amqp/internal/encoding"
 	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/Azure/go-amqp/internal/queue"
+	"github.com/Azure/go-amqp/internal/ring"
 )
 
 // Sender sends messages on a single AMQP link.
@@ -18,10 +27,72 @@ type Sender struct {
 	l         link
 	transfers chan transferEnvelope // sender uses to send transfer frames
 
-	mu              sync.Mutex // protects buf and nextDeliveryTag
-	buf             buffer.Buffer
-	nextDeliveryTag uint64
+	mu              sync.Mutex // protects unsettledTags
+	nextDeliveryTag atomic.Uint64
+	unsettledTags   map[string]struct{}  // delivery tags of outstanding unsettled deliveries
+	resumed         []DeliveryResolution // results of the unsettled-map exchange at attach
+	journal         DeliveryJournal      // mirrors unsettledTags; see SenderOptions.DeliveryJournal
 	rollback        chan struct{}
+
+	// set once Quiesce has been called; rejects new sends
+	quiescing    atomic.Bool
+	quiesceEmpty chan struct{} // signaled when the last unsettled delivery is released
+
+	// applied to each message before encoding; see SenderOptions.SendTransforms
+	sendTransforms []func(*Message) error
+
+	sequenceStamping bool          // stamp outgoing messages with a producer sequence number
+	producerID       string        // identifies this producer in stamped messages
+	producerSeq      atomic.Uint64 // sequence number of the most recently stamped message
+
+	// serializes the transfer frames of concurrent sends; a link can only
+	// have one incomplete delivery at a time, so the frames of a
+	// multi-frame delivery must not interleave with other transfers.
+	// marshaling and delivery tag generation happen outside this lock.
+	sendMu sync.Mutex
+
+	// count of messages ready to send but awaiting link credit.
+	// reported as "available" in outgoing flow frames.
+	available atomic.Int32
+
+	// called after a drain request from the peer has been satisfied
+	onDrain func(credit uint32)
+
+	// fragmentation statistics; see Sender.FragmentationStats
+	fragMessages atomic.Uint64 // count of messages sent
+	fragSplit    atomic.Uint64 // count of messages requiring multiple frames
+	fragFrames   atomic.Uint64 // count of transfer frames sent
+	fragLargest  atomic.Uint64 // largest encoded message in bytes
+	fragWarned   atomic.Bool   // set once onFragmentation has fired
+
+	// called once when most messages are being fragmented;
+	// see SenderOptions.OnFragmentation
+	onFragmentation func(FragmentationEvent)
+
+	// credit starvation detection; see SenderOptions.CreditStarvation.
+	// starvedSince and starvationTimer are only accessed by the mux;
+	// starvationCheck wakes the mux when a message becomes available.
+	creditStarvation CreditStarvationOptions
+	starvedSince     time.Time
+	starvationTimer  *time.Timer
+	starvationCheck  chan struct{}
+
+	// captured at construction so Refresh can re-attach with identical configuration
+	refreshAddr string
+	refreshOpts SenderOptions
+
+	// set on transaction control links; the coordinator replaces the
+	// target in the attach frame.  see Session.NewTransaction.
+	coordinator     *encoding.Coordinator
+	peerCoordinator *encoding.Coordinator // the coordinator echoed in the peer's attach response
+}
+
+// availableCount returns the sender's available count for use in a flow frame.
+func (s *Sender) availableCount() uint32 {
+	if n := s.available.Load(); n > 0 {
+		return uint32(n)
+	}
+	return 0
 }
 
 // LinkName() is the name of the link used for this Sender.
@@ -29,6 +100,13 @@ func (s *Sender) LinkName() string {
 	return s.l.key.name
 }
 
+// LinkHandle returns the output handle assigned to this link.
+// Handles are allocated lowest-available as recommended by the spec,
+// so a closed link's handle is reused by subsequently created links.
+func (s *Sender) LinkHandle() uint32 {
+	return s.l.outputHandle
+}
+
 // MaxMessageSize is the maximum size of a single message.
 func (s *Sender) MaxMessageSize() uint64 {
 	return s.l.maxMessageSize
@@ -40,11 +118,29 @@ func (s *Sender) Properties() map[string]any {
 	return s.l.peerProperties
 }
 
+// PeerTargetCapabilities returns the capabilities the peer declared on its
+// target terminus when the link was attached (e.g. "queue" or "topic").
+// These can differ from the capabilities requested via
+// [SenderOptions.TargetCapabilities], depending on the node the broker
+// created or resolved.
+// Returns nil if the peer didn't declare any capabilities.
+func (s *Sender) PeerTargetCapabilities() []string {
+	return s.l.peerTargetCapabilities
+}
+
 // SendOptions contains any optional values for the Sender.Send method.
 type SendOptions struct {
 	// Indicates the message is to be sent as settled when settlement mode is SenderSettleModeMixed.
 	// If the settlement mode is SenderSettleModeUnsettled and Settled is true, an error is returned.
 	Settled bool
+
+	// TransactionID enrolls the transfer in the transaction it identifies
+	// by sending it with a transactional-state delivery state.  The
+	// delivery's outcome is provisional until the transaction is discharged.
+	// Transactional transfers cannot be sent settled.
+	//
+	// Default: nil, meaning the transfer is non-transactional.
+	TransactionID []byte
 }
 
 // Send sends a Message.
@@ -67,7 +163,7 @@ type SendOptions struct {
 // has been requested (receiver settle mode is second). In this case,
 // additional messages can be sent while the current goroutine is waiting
 // for the confirmation.
-func (s *Sender) Send(ctx context.Context, msg *Message, opts *SendOptions) error {
+func (s *Sender) Send(ctx context.Context, msg *Message, opts *SendOptions) (err error) {
 	// check if the link is dead.  while it's safe to call s.send
 	// in this case, this will avoid some allocations etc.
 	select {
@@ -77,6 +173,16 @@ func (s *Sender) Send(ctx context.Context, msg *Message, opts *SendOptions) erro
 		// link is still active
 	}
 
+	if tr := s.l.session.conn.tracer; tr != nil {
+		traceparent, end := tr.SendStarted(ctx, s.Address(), msg)
+		if traceparent != "" {
+			injectTraceParent(msg, traceparent)
+		}
+		if end != nil {
+			defer func() { end(err) }()
+		}
+	}
+
 	receipt, err := s.send(ctx, msg, opts)
 	if err != nil {
 		return err
@@ -97,13 +203,100 @@ func (s *Sender) Send(ctx context.Context, msg *Message, opts *SendOptions) erro
 	return nil
 }
 
+// SendBatchError is returned by [Sender.SendBatch] when one or more
+// messages in the batch failed to send or were not accepted by the peer.
+type SendBatchError struct {
+	// Errors maps the index of each failed message in the batch to the
+	// error for that message.  Messages whose index is absent were sent
+	// and accepted.
+	Errors map[int]error
+}
+
+// Error implements the error interface for SendBatchError.
+func (e *SendBatchError) Error() string {
+	return fmt.Sprintf("amqp: %d of the messages in the batch failed to send", len(e.Errors))
+}
+
+// SendBatch sends multiple messages, pipelining their transfers: every
+// message is transmitted before any settlement confirmation is waited on,
+// avoiding the idle disposition round trip between serial [Sender.Send]
+// calls.  It then blocks until the peer has confirmed settlement of every
+// message that was transmitted.
+//
+//   - ctx controls waiting for the messages to be sent and possibly confirmed
+//   - msgs are the messages to send
+//   - opts contains optional values, pass nil to accept the defaults
+//
+// Messages are sent in order.  If a message fails to send or is rejected
+// by the peer, the remaining messages are still sent and waited on, and a
+// *[SendBatchError] recording the per-message errors is returned.
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, the unconfirmed messages are in an unknown state of
+// transmission.
+//
+// SendBatch is safe for concurrent use.
+func (s *Sender) SendBatch(ctx context.Context, msgs []*Message, opts *SendOptions) error {
+	// check if the link is dead.  while it's safe to call s.send
+	// in this case, this will avoid some allocations etc.
+	select {
+	case <-s.l.done:
+		return s.l.doneErr
+	default:
+		// link is still active
+	}
+
+	// transmit every message before waiting on any disposition
+	var errs map[int]error
+	fail := func(i int, err error) {
+		if errs == nil {
+			errs = map[int]error{}
+		}
+		errs[i] = err
+	}
+	receipts := make([]SendReceipt, len(msgs))
+	for i, msg := range msgs {
+		receipt, err := s.send(ctx, msg, opts)
+		if err != nil {
+			fail(i, err)
+			continue
+		}
+		receipts[i] = receipt
+	}
+
+	for i := range receipts {
+		if _, failed := errs[i]; failed {
+			continue
+		}
+		state, err := receipts[i].Wait(ctx)
+		if err != nil {
+			fail(i, err)
+			continue
+		}
+		if state, ok := state.(*StateRejected); ok {
+			if state.Error != nil {
+				fail(i, state.Error)
+			} else {
+				fail(i, errors.New("the peer rejected the message without specifying an error"))
+			}
+		}
+	}
+
+	if errs != nil {
+		return &SendBatchError{Errors: errs}
+	}
+	return nil
+}
+
 // SendReceipt is returned by [Sender.SendWithReceipt] and is used
 // to defer the confirmation of settlement of a [Message].
 type SendReceipt struct {
-	l     *link
-	tag   []byte
-	done  <-chan encoding.DeliveryState
-	state DeliveryState
+	l       *link
+	tag     []byte
+	done    <-chan encoding.DeliveryState
+	state   DeliveryState
+	release func()    // releases the delivery tag once settlement is confirmed; nil for settled sends
+	sent    time.Time // when the delivery's last transfer frame was written; see ExtendedMetrics.SettlementLatency
 }
 
 // DeliveryTag returns the message's delivery tag that's
@@ -132,6 +325,14 @@ func (s *SendReceipt) Wait(ctx context.Context) (DeliveryState, error) {
 	select {
 	case state := <-s.done:
 		s.state = state
+		if s.release != nil {
+			s.release()
+			s.release = nil
+			// only unsettled deliveries measure a peer round trip
+			if m := s.l.session.conn.extMetrics; m != nil {
+				m.SettlementLatency(time.Since(s.sent))
+			}
+		}
 		return s.state, nil
 	case <-s.l.done:
 		return nil, s.l.doneErr
@@ -167,87 +368,762 @@ func (s *Sender) SendWithReceipt(ctx context.Context, msg *Message, opts *SendWi
 		return SendReceipt{}, errors.New("SendWithReceipt cannot be called from Senders configured with SenderSettleModeSettled")
 	}
 
-	// check if the link is dead.  while it's safe to call s.send
-	// in this case, this will avoid some allocations etc.
-	select {
-	case <-s.l.done:
-		return SendReceipt{}, s.l.doneErr
-	default:
-		// link is still active
+	// check if the link is dead.  while it's safe to call s.send
+	// in this case, this will avoid some allocations etc.
+	select {
+	case <-s.l.done:
+		return SendReceipt{}, s.l.doneErr
+	default:
+		// link is still active
+	}
+
+	return s.send(ctx, msg, nil)
+}
+
+// RawTransfer contains a message payload as pre-fragmented chunks
+// along with its transfer metadata. Used with [Sender.SendRaw].
+type RawTransfer struct {
+	// Chunks contains the payload split into per-frame fragments.
+	// Each chunk is sent in its own transfer frame and must fit
+	// within the peer's max frame size.
+	Chunks [][]byte
+
+	// DeliveryTag uniquely identifies the delivery on this link.
+	//
+	// Default: a monotonically increasing uint64 encoded as 8 bytes.
+	DeliveryTag []byte
+
+	// Format is the message-format code sent on the first transfer frame.
+	//
+	// Default: 0, a standard AMQP 1.0 message.
+	Format uint32
+
+	// Settled marks the delivery as settled by the sender when the
+	// settlement mode is SenderSettleModeMixed.  If the settlement mode
+	// is SenderSettleModeUnsettled and Settled is true, an error is returned.
+	Settled bool
+}
+
+// SendRaw sends a pre-fragmented payload without constructing or encoding
+// a [Message].  Each chunk is sent in its own transfer frame, enabling
+// exact replay of captured traffic and custom fragmentation strategies.
+//
+// Blocks until the payload is sent or an error occurs. If the peer is
+// configured for receiver settlement mode second, the call also blocks
+// until the peer confirms message settlement.
+//
+//   - ctx controls waiting for the payload to be sent and possibly confirmed
+//   - transfer is the pre-fragmented payload and its transfer metadata
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, the payload is in an unknown state of transmission.
+//
+// If the peer rejects the delivery, an error is returned.
+//
+// SendRaw is safe for concurrent use.
+func (s *Sender) SendRaw(ctx context.Context, transfer *RawTransfer) error {
+	// check if the link is dead.  while it's safe to call s.sendRaw
+	// in this case, this will avoid some allocations etc.
+	select {
+	case <-s.l.done:
+		return s.l.doneErr
+	default:
+		// link is still active
+	}
+
+	receipt, err := s.sendRaw(ctx, transfer)
+	if err != nil {
+		return err
+	}
+
+	// wait for transfer to be confirmed
+	state, err := receipt.Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state, ok := state.(*StateRejected); ok {
+		if state.Error != nil {
+			return state.Error
+		}
+		return errors.New("the peer rejected the delivery without specifying an error")
+	}
+	return nil
+}
+
+// SendReaderOptions contains the optional settings for streaming a payload
+// with [Sender.SendReader].
+type SendReaderOptions struct {
+	// DeliveryTag uniquely identifies the delivery on this link.
+	//
+	// Default: a monotonically increasing uint64 encoded as 8 bytes.
+	DeliveryTag []byte
+
+	// Format is the message-format code sent on the first transfer frame.
+	//
+	// Default: 0, a standard AMQP 1.0 message.
+	Format uint32
+
+	// Settled marks the delivery as settled by the sender when the
+	// settlement mode is SenderSettleModeMixed.  If the settlement mode
+	// is SenderSettleModeUnsettled and Settled is true, an error is returned.
+	Settled bool
+}
+
+// SendReader streams a pre-encoded message payload from r, sending
+// transfer frames as the payload is read instead of requiring it to be
+// marshaled into memory up front.  Intended for very large payloads
+// with brokers that allow them.
+//
+// Blocks until the payload is sent or an error occurs. If the peer is
+// configured for receiver settlement mode second, the call also blocks
+// until the peer confirms message settlement.
+//
+//   - ctx controls waiting for the payload to be sent and possibly confirmed
+//   - r supplies the encoded message bytes
+//   - size is the exact count of bytes to read from r; it must be positive
+//   - opts contains optional values, pass nil to accept the defaults
+//
+// If r fails or is exhausted before size bytes are read, the in-flight
+// delivery is aborted and the error is returned.
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, the payload is in an unknown state of transmission.
+//
+// If the peer rejects the delivery, an error is returned.
+//
+// SendReader is safe for concurrent use.
+func (s *Sender) SendReader(ctx context.Context, r io.Reader, size int64, opts *SendReaderOptions) error {
+	// check if the link is dead.  while it's safe to call s.sendReader
+	// in this case, this will avoid some allocations etc.
+	select {
+	case <-s.l.done:
+		return s.l.doneErr
+	default:
+		// link is still active
+	}
+
+	receipt, err := s.sendReader(ctx, r, size, opts)
+	if err != nil {
+		return err
+	}
+
+	// wait for transfer to be confirmed
+	state, err := receipt.Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state, ok := state.(*StateRejected); ok {
+		if state.Error != nil {
+			return state.Error
+		}
+		return errors.New("the peer rejected the delivery without specifying an error")
+	}
+	return nil
+}
+
+const (
+	maxDeliveryTagLength   = 32
+	maxTransferFrameHeader = 66 // determined by calcMaxTransferFrameHeader
+
+	// the longest ProducerID that keeps a stamped delivery tag
+	// (ProducerID + "-" + decimal uint64) within maxDeliveryTagLength
+	maxProducerIDLength = maxDeliveryTagLength - 21
+)
+
+// Annotation keys stamped into outgoing messages when
+// [SenderOptions.SequenceStamping] is enabled.
+const (
+	AnnotationProducerID       = "x-opt-producer-id"
+	AnnotationProducerSequence = "x-opt-producer-sequence"
+)
+
+// send is separated from Send so that the mutex unlock can be deferred without
+// locking the transfer confirmation that happens in Send.
+func (s *Sender) send(ctx context.Context, msg *Message, opts *SendOptions) (SendReceipt, error) {
+	if s.quiescing.Load() {
+		return SendReceipt{}, errSenderQuiesced
+	}
+	if len(msg.DeliveryTag) > maxDeliveryTagLength {
+		return SendReceipt{}, &Error{
+			Condition:   ErrCondMessageSizeExceeded,
+			Description: fmt.Sprintf("delivery tag is over the allowed %v bytes, len: %v", maxDeliveryTagLength, len(msg.DeliveryTag)),
+		}
+	}
+
+	var stampedTag []byte
+	if s.sequenceStamping {
+		if msg.Annotations == nil {
+			msg.Annotations = Annotations{}
+		}
+		// a message that already carries a sequence number keeps it, so
+		// resending a message after an ambiguous failure reuses the
+		// original stamp and dedupe-capable brokers can drop the retry
+		seq, ok := producerSequence(msg.Annotations)
+		if !ok {
+			seq = s.producerSeq.Add(1)
+			msg.Annotations.Set(AnnotationProducerID, s.producerID)
+			msg.Annotations.Set(AnnotationProducerSequence, seq)
+		}
+		if len(msg.DeliveryTag) == 0 {
+			stampedTag = []byte(fmt.Sprintf("%s-%d", s.producerID, seq))
+		}
+	}
+
+	for _, transform := range s.sendTransforms {
+		if err := transform(msg); err != nil {
+			return SendReceipt{}, err
+		}
+	}
+
+	buf := getPayloadBuffer()
+	defer buf.release()
+	if codec := lookupMessageCodec(msg.Format); codec != nil {
+		payload, err := codec.Encode(msg)
+		if err != nil {
+			return SendReceipt{}, err
+		}
+		buf.Append(payload)
+	} else if err := msg.Marshal(&buf.Buffer); err != nil {
+		return SendReceipt{}, err
+	}
+
+	if s.l.maxMessageSize != 0 && uint64(buf.Len()) > s.l.maxMessageSize {
+		return SendReceipt{}, &Error{
+			Condition:   ErrCondMessageSizeExceeded,
+			Description: fmt.Sprintf("encoded message size exceeds max of %d", s.l.maxMessageSize),
+		}
+	}
+
+	encodedSize := uint64(buf.Len())
+
+	senderSettled := senderSettleModeValue(s.l.senderSettleMode) == SenderSettleModeSettled
+	if opts != nil {
+		if opts.Settled && senderSettleModeValue(s.l.senderSettleMode) == SenderSettleModeUnsettled {
+			return SendReceipt{}, errors.New("can't send message as settled when sender settlement mode is unsettled")
+		} else if opts.Settled {
+			senderSettled = true
+		}
+	}
+
+	var txnID []byte
+	if opts != nil {
+		txnID = opts.TransactionID
+	}
+	if txnID != nil && senderSettled {
+		// the spec requires transactional transfers to remain unsettled so
+		// their outcome is determined by the discharge
+		return SendReceipt{}, errors.New("can't send transactional message as settled")
+	}
+
+	var (
+		maxPayloadSize = int64(s.l.session.conn.peerMaxFrameSize) - maxTransferFrameHeader
+	)
+
+	deliveryTag := msg.DeliveryTag
+	if len(deliveryTag) == 0 {
+		if stampedTag != nil {
+			deliveryTag = stampedTag
+		} else {
+			// use uint64 encoded as []byte as deliveryTag
+			deliveryTag = make([]byte, 8)
+			binary.BigEndian.PutUint64(deliveryTag, s.nextDeliveryTag.Add(1)-1)
+		}
+	}
+
+	if !senderSettled {
+		// reject tag reuse locally; the spec requires delivery tags to be
+		// unique among a link's outstanding unsettled deliveries, and
+		// violations produce confusing broker-side errors
+		if err := s.claimDeliveryTag(deliveryTag); err != nil {
+			return SendReceipt{}, err
+		}
+	}
+
+	fr := frames.PerformTransfer{
+		Handle:        s.l.outputHandle,
+		DeliveryID:    &needsDeliveryID,
+		DeliveryTag:   deliveryTag,
+		MessageFormat: &msg.Format,
+		More:          buf.Len() > 0,
+	}
+	if txnID != nil {
+		fr.State = &encoding.TransactionalState{TxnID: txnID}
+	}
+
+	// the message counts as available until it's been handed to the session
+	s.available.Add(1)
+	defer s.available.Add(-1)
+	s.signalStarvationCheck()
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	var fragments uint64
+	for fr.More {
+		// slice the payload directly from the marshal buffer; buf is
+		// refcounted so it's not reused while frames still alias it
+		fr.Payload, _ = buf.Next(maxPayloadSize)
+		fr.More = buf.Len() > 0
+		fragments++
+		if !fr.More {
+			// SSM=settled: overrides RSM; no acks.
+			// SSM=unsettled: sender should wait for receiver to ack
+			// RSM=first: receiver considers it settled immediately, but must still send ack (SSM=unsettled only)
+			// RSM=second: receiver sends ack and waits for return ack from sender (SSM=unsettled only)
+
+			// mark final transfer as settled when sender mode is settled
+			fr.Settled = senderSettled
+
+			// set done on last frame
+			fr.Done = make(chan encoding.DeliveryState, 1)
+		}
+
+		buf.retain()
+		if err := s.writeTransferFrame(ctx, fr, buf); err != nil {
+			if !senderSettled {
+				s.releaseDeliveryTag(deliveryTag)
+			}
+			return SendReceipt{}, err
+		}
+
+		// clear values that are only required on first message
+		fr.DeliveryID = nil
+		fr.DeliveryTag = nil
+		fr.MessageFormat = nil
+		fr.State = nil
+	}
+
+	s.noteFragmentation(encodedSize, fragments)
+
+	receipt := SendReceipt{
+		l:    &s.l,
+		tag:  deliveryTag,
+		done: fr.Done,
+		sent: time.Now(),
+	}
+	if !senderSettled {
+		receipt.release = func() { s.releaseDeliveryTag(deliveryTag) }
+	}
+	return receipt, nil
+}
+
+// FragmentationStats describes how a sender's messages have mapped onto
+// transfer frames.
+type FragmentationStats struct {
+	// Messages is the count of messages sent.
+	Messages uint64
+
+	// Fragmented is the count of messages that required more than one
+	// transfer frame.
+	Fragmented uint64
+
+	// Fragments is the total count of transfer frames sent.
+	Fragments uint64
+}
+
+// AverageFragments returns the mean count of transfer frames per message,
+// or zero when no messages have been sent.
+func (f FragmentationStats) AverageFragments() float64 {
+	if f.Messages == 0 {
+		return 0
+	}
+	return float64(f.Fragments) / float64(f.Messages)
+}
+
+// FragmentationStats returns a snapshot of how the sender's messages have
+// mapped onto transfer frames.  An average approaching one frame per
+// message indicates the negotiated max frame size is large enough to
+// carry most messages unfragmented.
+func (s *Sender) FragmentationStats() FragmentationStats {
+	return FragmentationStats{
+		Messages:   s.fragMessages.Load(),
+		Fragmented: s.fragSplit.Load(),
+		Fragments:  s.fragFrames.Load(),
+	}
+}
+
+// fragmentationWarningSampleSize is the count of sent messages required
+// before OnFragmentation can fire, so one-off bursts don't warn.
+const fragmentationWarningSampleSize = 100
+
+// noteFragmentation records that a message was sent as the given count of
+// transfer frames, firing OnFragmentation when most messages fragment.
+func (s *Sender) noteFragmentation(encodedSize, fragments uint64) {
+	s.fragMessages.Add(1)
+	s.fragFrames.Add(fragments)
+	if fragments > 1 {
+		s.fragSplit.Add(1)
+	}
+
+	// track the largest message for max-frame-size guidance
+	for {
+		cur := s.fragLargest.Load()
+		if encodedSize <= cur || s.fragLargest.CompareAndSwap(cur, encodedSize) {
+			break
+		}
+	}
+
+	if s.onFragmentation == nil {
+		return
+	}
+	msgs := s.fragMessages.Load()
+	if msgs < fragmentationWarningSampleSize || s.fragSplit.Load()*2 <= msgs {
+		return
+	}
+	if s.fragWarned.CompareAndSwap(false, true) {
+		s.onFragmentation(FragmentationEvent{
+			LinkName:        s.l.key.name,
+			Stats:           s.FragmentationStats(),
+			SingleFrameSize: s.fragLargest.Load() + uint64(maxTransferFrameHeader),
+		})
+	}
+}
+
+// payloadBuffers pools the marshal buffers shared by the transfer frames
+// of a single send.
+var payloadBuffers = sync.Pool{
+	New: func() any { return &payloadBuffer{} },
+}
+
+// payloadBuffer is a reference-counted marshal buffer.  each transfer
+// frame of a delivery slices its payload directly from the buffer rather
+// than copying it, and the buffer only returns to the pool once the last
+// referencing frame has been written to the network.
+type payloadBuffer struct {
+	buffer.Buffer
+	refs atomic.Int32
+}
+
+// getPayloadBuffer returns an empty buffer from the pool holding the
+// caller's reference.
+func getPayloadBuffer() *payloadBuffer {
+	b := payloadBuffers.Get().(*payloadBuffer)
+	b.Reset()
+	b.refs.Store(1)
+	return b
+}
+
+// retain adds a reference for a frame whose payload aliases the buffer.
+func (b *payloadBuffer) retain() {
+	b.refs.Add(1)
+}
+
+// release drops a reference, returning the buffer to the pool once no
+// frames alias it.
+func (b *payloadBuffer) release() {
+	if b.refs.Add(-1) == 0 {
+		payloadBuffers.Put(b)
+	}
+}
+
+// producerSequence returns the sequence number a previous send stamped
+// into the message's annotations, if any.
+func producerSequence(a Annotations) (uint64, bool) {
+	v, ok := a.Get(AnnotationProducerSequence)
+	if !ok {
+		return 0, false
+	}
+	seq, ok := v.(uint64)
+	return seq, ok
+}
+
+// claimDeliveryTag records tag as belonging to an outstanding unsettled
+// delivery, or returns an error if it's already in use.
+func (s *Sender) claimDeliveryTag(tag []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.unsettledTags[string(tag)]; ok {
+		return fmt.Errorf("delivery tag %q is already in use by an unsettled delivery", tag)
+	}
+	if err := s.journal.RecordUnsettled(s.l.key.name, tag, nil); err != nil {
+		return err
+	}
+	s.unsettledTags[string(tag)] = struct{}{}
+	return nil
+}
+
+// releaseDeliveryTag makes tag available for reuse once its delivery has
+// been settled.
+func (s *Sender) releaseDeliveryTag(tag []byte) {
+	s.mu.Lock()
+	delete(s.unsettledTags, string(tag))
+	// the delivery is settled on the wire regardless of a journal failure;
+	// a durable journal that fails here resends on resume, at worst
+	// duplicating a settled delivery
+	_ = s.journal.MarkSettled(s.l.key.name, tag)
+	if len(s.unsettledTags) == 0 {
+		// wake a pending Quiesce
+		select {
+		case s.quiesceEmpty <- struct{}{}:
+		default:
+		}
+	}
+	s.mu.Unlock()
+}
+
+// writeTransferFrame sends fr to our mux and blocks until it has been
+// written to the network.
+//   - payload is the refcounted buffer fr.Payload aliases, or nil if the
+//     payload was copied.  the frame's reference is released once the
+//     payload can no longer be read by the write pipeline; frames
+//     abandoned in flight never release, keeping the buffer out of the
+//     pool while a late write could still read it.
+//
+// NOTE: fr MUST be passed by value as callers modify it post send.
+func (s *Sender) writeTransferFrame(ctx context.Context, fr frames.PerformTransfer, payload *payloadBuffer) error {
+	frameCtx := frameContext{
+		Ctx:  ctx,
+		Done: make(chan struct{}),
+	}
+
+	select {
+	case s.transfers <- transferEnvelope{FrameCtx: &frameCtx, InputHandle: s.l.inputHandle, Frame: fr}:
+		// frame was sent to our mux
+	case <-s.l.done:
+		// the envelope never left this goroutine
+		if payload != nil {
+			payload.release()
+		}
+		return s.l.doneErr
+	case <-ctx.Done():
+		if payload != nil {
+			payload.release()
+		}
+		return &Error{Condition: ErrCondTransferLimitExceeded, Description: fmt.Sprintf("credit limit exceeded for sending link %s", s.l.key.name)}
+	}
+
+	select {
+	case <-frameCtx.Done:
+		// connWriter is finished with the frame, written or not
+		if payload != nil {
+			payload.release()
+		}
+		if frameCtx.Err != nil {
+			if !fr.More {
+				select {
+				case s.rollback <- struct{}{}:
+					// the write never happened so signal the mux to roll back the delivery count and link credit
+				case <-s.l.close:
+					// the link is going down
+				}
+			}
+			return frameCtx.Err
+		}
+		// frame was written to the network
+		return nil
+	case <-s.l.done:
+		return s.l.doneErr
+	}
+}
+
+// sendRaw is the raw-payload equivalent of send.  chunks are sent verbatim,
+// one transfer frame per chunk, with no encoding or re-fragmentation.
+func (s *Sender) sendRaw(ctx context.Context, transfer *RawTransfer) (SendReceipt, error) {
+	if s.quiescing.Load() {
+		return SendReceipt{}, errSenderQuiesced
+	}
+	if len(transfer.Chunks) == 0 {
+		return SendReceipt{}, errors.New("at least one chunk is required")
+	}
+	if len(transfer.DeliveryTag) > maxDeliveryTagLength {
+		return SendReceipt{}, &Error{
+			Condition:   ErrCondMessageSizeExceeded,
+			Description: fmt.Sprintf("delivery tag is over the allowed %v bytes, len: %v", maxDeliveryTagLength, len(transfer.DeliveryTag)),
+		}
+	}
+
+	maxPayloadSize := int64(s.l.session.conn.peerMaxFrameSize) - maxTransferFrameHeader
+
+	var totalSize uint64
+	for _, chunk := range transfer.Chunks {
+		if int64(len(chunk)) > maxPayloadSize {
+			return SendReceipt{}, &Error{
+				Condition:   ErrCondMessageSizeExceeded,
+				Description: fmt.Sprintf("chunk size %d exceeds max payload size of %d", len(chunk), maxPayloadSize),
+			}
+		}
+		totalSize += uint64(len(chunk))
+	}
+
+	if s.l.maxMessageSize != 0 && totalSize > s.l.maxMessageSize {
+		return SendReceipt{}, &Error{
+			Condition:   ErrCondMessageSizeExceeded,
+			Description: fmt.Sprintf("encoded message size exceeds max of %d", s.l.maxMessageSize),
+		}
+	}
+
+	senderSettled := senderSettleModeValue(s.l.senderSettleMode) == SenderSettleModeSettled
+	if transfer.Settled && senderSettleModeValue(s.l.senderSettleMode) == SenderSettleModeUnsettled {
+		return SendReceipt{}, errors.New("can't send payload as settled when sender settlement mode is unsettled")
+	} else if transfer.Settled {
+		senderSettled = true
+	}
+
+	deliveryTag := transfer.DeliveryTag
+	if len(deliveryTag) == 0 {
+		// use uint64 encoded as []byte as deliveryTag
+		deliveryTag = make([]byte, 8)
+		binary.BigEndian.PutUint64(deliveryTag, s.nextDeliveryTag.Add(1)-1)
+	}
+
+	if !senderSettled {
+		if err := s.claimDeliveryTag(deliveryTag); err != nil {
+			return SendReceipt{}, err
+		}
+	}
+
+	format := transfer.Format
+	fr := frames.PerformTransfer{
+		Handle:        s.l.outputHandle,
+		DeliveryID:    &needsDeliveryID,
+		DeliveryTag:   deliveryTag,
+		MessageFormat: &format,
+	}
+
+	// the payload counts as available until it's been handed to the session
+	s.available.Add(1)
+	defer s.available.Add(-1)
+	s.signalStarvationCheck()
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	for i, chunk := range transfer.Chunks {
+		fr.Payload = append([]byte(nil), chunk...)
+		fr.More = i < len(transfer.Chunks)-1
+		if !fr.More {
+			// mark final transfer as settled when sender mode is settled
+			fr.Settled = senderSettled
+
+			// set done on last frame
+			fr.Done = make(chan encoding.DeliveryState, 1)
+		}
+
+		if err := s.writeTransferFrame(ctx, fr, nil); err != nil {
+			if !senderSettled {
+				s.releaseDeliveryTag(deliveryTag)
+			}
+			return SendReceipt{}, err
+		}
+
+		// clear values that are only required on first message
+		fr.DeliveryID = nil
+		fr.DeliveryTag = nil
+		fr.MessageFormat = nil
 	}
 
-	return s.send(ctx, msg, nil)
+	s.noteFragmentation(totalSize, uint64(len(transfer.Chunks)))
+
+	receipt := SendReceipt{
+		l:    &s.l,
+		tag:  deliveryTag,
+		done: fr.Done,
+		sent: time.Now(),
+	}
+	if !senderSettled {
+		receipt.release = func() { s.releaseDeliveryTag(deliveryTag) }
+	}
+	return receipt, nil
 }
 
-// send is separated from Send so that the mutex unlock can be deferred without
-// locking the transfer confirmation that happens in Send.
-func (s *Sender) send(ctx context.Context, msg *Message, opts *SendOptions) (SendReceipt, error) {
-	const (
-		maxDeliveryTagLength   = 32
-		maxTransferFrameHeader = 66 // determined by calcMaxTransferFrameHeader
-	)
-	if len(msg.DeliveryTag) > maxDeliveryTagLength {
+// sendReader is the streaming equivalent of send.  the payload is read
+// from r in per-frame chunks, so only one chunk is resident at a time.
+func (s *Sender) sendReader(ctx context.Context, r io.Reader, size int64, opts *SendReaderOptions) (SendReceipt, error) {
+	if s.quiescing.Load() {
+		return SendReceipt{}, errSenderQuiesced
+	}
+	if size <= 0 {
+		return SendReceipt{}, errors.New("size must be positive")
+	}
+	if opts == nil {
+		opts = &SendReaderOptions{}
+	}
+	if len(opts.DeliveryTag) > maxDeliveryTagLength {
 		return SendReceipt{}, &Error{
 			Condition:   ErrCondMessageSizeExceeded,
-			Description: fmt.Sprintf("delivery tag is over the allowed %v bytes, len: %v", maxDeliveryTagLength, len(msg.DeliveryTag)),
+			Description: fmt.Sprintf("delivery tag is over the allowed %v bytes, len: %v", maxDeliveryTagLength, len(opts.DeliveryTag)),
 		}
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.buf.Reset()
-	err := msg.Marshal(&s.buf)
-	if err != nil {
-		return SendReceipt{}, err
-	}
-
-	if s.l.maxMessageSize != 0 && uint64(s.buf.Len()) > s.l.maxMessageSize {
+	if s.l.maxMessageSize != 0 && uint64(size) > s.l.maxMessageSize {
 		return SendReceipt{}, &Error{
 			Condition:   ErrCondMessageSizeExceeded,
 			Description: fmt.Sprintf("encoded message size exceeds max of %d", s.l.maxMessageSize),
 		}
 	}
 
+	maxPayloadSize := int64(s.l.session.conn.peerMaxFrameSize) - maxTransferFrameHeader
+
 	senderSettled := senderSettleModeValue(s.l.senderSettleMode) == SenderSettleModeSettled
-	if opts != nil {
-		if opts.Settled && senderSettleModeValue(s.l.senderSettleMode) == SenderSettleModeUnsettled {
-			return SendReceipt{}, errors.New("can't send message as settled when sender settlement mode is unsettled")
-		} else if opts.Settled {
-			senderSettled = true
-		}
+	if opts.Settled && senderSettleModeValue(s.l.senderSettleMode) == SenderSettleModeUnsettled {
+		return SendReceipt{}, errors.New("can't send payload as settled when sender settlement mode is unsettled")
+	} else if opts.Settled {
+		senderSettled = true
 	}
 
-	var (
-		maxPayloadSize = int64(s.l.session.conn.peerMaxFrameSize) - maxTransferFrameHeader
-	)
-
-	deliveryTag := msg.DeliveryTag
+	deliveryTag := opts.DeliveryTag
 	if len(deliveryTag) == 0 {
 		// use uint64 encoded as []byte as deliveryTag
 		deliveryTag = make([]byte, 8)
-		binary.BigEndian.PutUint64(deliveryTag, s.nextDeliveryTag)
-		s.nextDeliveryTag++
+		binary.BigEndian.PutUint64(deliveryTag, s.nextDeliveryTag.Add(1)-1)
 	}
 
+	if !senderSettled {
+		if err := s.claimDeliveryTag(deliveryTag); err != nil {
+			return SendReceipt{}, err
+		}
+	}
+
+	format := opts.Format
 	fr := frames.PerformTransfer{
 		Handle:        s.l.outputHandle,
 		DeliveryID:    &needsDeliveryID,
 		DeliveryTag:   deliveryTag,
-		MessageFormat: &msg.Format,
-		More:          s.buf.Len() > 0,
+		MessageFormat: &format,
 	}
 
-	for fr.More {
-		buf, _ := s.buf.Next(maxPayloadSize)
-		fr.Payload = append([]byte(nil), buf...)
-		fr.More = s.buf.Len() > 0
-		if !fr.More {
-			// SSM=settled: overrides RSM; no acks.
-			// SSM=unsettled: sender should wait for receiver to ack
-			// RSM=first: receiver considers it settled immediately, but must still send ack (SSM=unsettled only)
-			// RSM=second: receiver sends ack and waits for return ack from sender (SSM=unsettled only)
+	// the payload counts as available until it's been handed to the session
+	s.available.Add(1)
+	defer s.available.Add(-1)
+	s.signalStarvationCheck()
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	var fragments uint64
+	for remaining := size; remaining > 0; {
+		chunkSize := maxPayloadSize
+		if remaining < chunkSize {
+			chunkSize = remaining
+		}
+		// each frame gets its own slice; the write pipeline may read the
+		// payload after the frame's write has been confirmed
+		chunk := make([]byte, chunkSize)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			if fragments > 0 {
+				// the delivery is partially transmitted; abort it so the
+				// peer discards what it has buffered
+				abort := fr
+				abort.Payload = nil
+				abort.More = false
+				abort.Aborted = true
+				abort.Done = nil
+				// best effort; a failed write is tearing down the link anyway
+				_ = s.writeTransferFrame(ctx, abort, nil)
+			}
+			if !senderSettled {
+				s.releaseDeliveryTag(deliveryTag)
+			}
+			return SendReceipt{}, fmt.Errorf("reading payload: %w", err)
+		}
+		remaining -= chunkSize
 
+		fr.Payload = chunk
+		fr.More = remaining > 0
+		fragments++
+		if !fr.More {
 			// mark final transfer as settled when sender mode is settled
 			fr.Settled = senderSettled
 
@@ -255,38 +1131,11 @@ func (s *Sender) send(ctx context.Context, msg *Message, opts *SendOptions) (Sen
 			fr.Done = make(chan encoding.DeliveryState, 1)
 		}
 
-		// NOTE: we MUST send a copy of fr here since we modify it post send
-
-		frameCtx := frameContext{
-			Ctx:  ctx,
-			Done: make(chan struct{}),
-		}
-
-		select {
-		case s.transfers <- transferEnvelope{FrameCtx: &frameCtx, InputHandle: s.l.inputHandle, Frame: fr}:
-			// frame was sent to our mux
-		case <-s.l.done:
-			return SendReceipt{}, s.l.doneErr
-		case <-ctx.Done():
-			return SendReceipt{}, &Error{Condition: ErrCondTransferLimitExceeded, Description: fmt.Sprintf("credit limit exceeded for sending link %s", s.l.key.name)}
-		}
-
-		select {
-		case <-frameCtx.Done:
-			if frameCtx.Err != nil {
-				if !fr.More {
-					select {
-					case s.rollback <- struct{}{}:
-						// the write never happened so signal the mux to roll back the delivery count and link credit
-					case <-s.l.close:
-						// the link is going down
-					}
-				}
-				return SendReceipt{}, frameCtx.Err
+		if err := s.writeTransferFrame(ctx, fr, nil); err != nil {
+			if !senderSettled {
+				s.releaseDeliveryTag(deliveryTag)
 			}
-			// frame was written to the network
-		case <-s.l.done:
-			return SendReceipt{}, s.l.doneErr
+			return SendReceipt{}, err
 		}
 
 		// clear values that are only required on first message
@@ -295,19 +1144,23 @@ func (s *Sender) send(ctx context.Context, msg *Message, opts *SendOptions) (Sen
 		fr.MessageFormat = nil
 	}
 
-	return SendReceipt{
+	s.noteFragmentation(uint64(size), fragments)
+
+	receipt := SendReceipt{
 		l:    &s.l,
 		tag:  deliveryTag,
 		done: fr.Done,
-	}, nil
+		sent: time.Now(),
+	}
+	if !senderSettled {
+		receipt.release = func() { s.releaseDeliveryTag(deliveryTag) }
+	}
+	return receipt, nil
 }
 
 // Address returns the link's address.
 func (s *Sender) Address() string {
-	if s.l.target == nil {
-		return ""
-	}
-	return s.l.target.Address
+	return s.l.currentAddr()
 }
 
 // Close closes the Sender and AMQP link.
@@ -321,14 +1174,120 @@ func (s *Sender) Close(ctx context.Context) error {
 	return s.l.closeLink(ctx)
 }
 
+// Detach detaches the Sender from the session without closing the AMQP
+// link, suspending it.  Both termini retain their unsettled delivery
+// state, so the link can later be resumed by attaching a new sender with
+// the same [SenderOptions.Name], e.g. via [Sender.Refresh].  With a
+// [SenderOptions.DeliveryJournal] configured, the journal's in-doubt
+// deliveries are advertised to the peer on re-attach.
+//
+// The Sender is terminal after a detach; methods on it return a *LinkError
+// whose inner error is a *LinkSuspendedError.
+//   - ctx controls waiting for the peer to acknowledge the detach
+//
+// If the context's deadline expires or is cancelled before the operation
+// completes, an error is returned.  However, the operation will continue to
+// execute in the background.
+func (s *Sender) Detach(ctx context.Context) error {
+	return s.l.detachLink(ctx)
+}
+
+// errSenderQuiesced is returned by the send methods once Quiesce has
+// been called.
+var errSenderQuiesced = errors.New("sender is quiescing")
+
+// QuiesceReport is returned by [Sender.Quiesce] and lists the deliveries
+// that never settled.
+type QuiesceReport struct {
+	// UnsettledTags contains the delivery tags of the deliveries that
+	// hadn't settled when Quiesce stopped waiting.  Empty when every
+	// in-flight delivery settled before the sender detached.
+	UnsettledTags [][]byte
+}
+
+// Quiesce drains the sender and then detaches it: new sends fail
+// immediately, the call waits for all in-flight deliveries to settle,
+// and the link is closed with the normal detach handshake.  If the
+// context expires or the link fails before everything settles, the
+// sender is still detached and the returned report lists the deliveries
+// left unsettled, so callers can hand them off before shutting down.
+//   - ctx controls waiting for in-flight deliveries to settle and for
+//     the peer to acknowledge the detach
+//
+// Quiescing is one-way; a quiesced Sender can't be reused.
+func (s *Sender) Quiesce(ctx context.Context) (QuiesceReport, error) {
+	s.quiescing.Store(true)
+
+	var waitErr error
+wait:
+	for s.countUnsettled() > 0 {
+		select {
+		case <-s.quiesceEmpty:
+			// re-check the count
+
+		case <-s.l.done:
+			waitErr = s.l.doneErr
+			break wait
+
+		case <-ctx.Done():
+			waitErr = ctx.Err()
+			break wait
+		}
+	}
+
+	var report QuiesceReport
+	s.mu.Lock()
+	for tag := range s.unsettledTags {
+		report.UnsettledTags = append(report.UnsettledTags, []byte(tag))
+	}
+	s.mu.Unlock()
+
+	closeErr := s.l.closeLink(ctx)
+	if waitErr != nil {
+		return report, waitErr
+	}
+	return report, closeErr
+}
+
+func (s *Sender) countUnsettled() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.unsettledTags)
+}
+
+// Refresh closes the Sender and attaches a replacement link with the same
+// name and configuration on the same session, returning the new Sender with
+// freshly negotiated values.  Useful when a broker requires a re-attach to
+// pick up changed authorization or quotas.
+//
+// The original Sender is closed even when Refresh returns an error and must
+// no longer be used.
+//   - ctx controls waiting for the peer to acknowledge the detach and re-attach
+func (s *Sender) Refresh(ctx context.Context) (*Sender, error) {
+	opts := s.refreshOpts
+	opts.Name = s.l.key.name
+	if err := s.Close(ctx); err != nil {
+		// a *LinkError means the link was already detached (e.g. by the
+		// peer); attaching the replacement link is still valid
+		var linkErr *LinkError
+		if !errors.As(err, &linkErr) {
+			return nil, err
+		}
+	}
+	return newSenderForSession(ctx, s.l.session, s.refreshAddr, &opts, senderTestHooks{})
+}
+
 // newSendingLink creates a new sending link and attaches it to the session
 func newSender(target string, session *Session, opts *SenderOptions) (*Sender, error) {
 	l := newLink(session, encoding.RoleSender)
 	l.target = &frames.Target{Address: target}
 	l.source = new(frames.Source)
 	s := &Sender{
-		l:        l,
-		rollback: make(chan struct{}),
+		l:             l,
+		rollback:      make(chan struct{}),
+		unsettledTags: map[string]struct{}{},
+		journal:       NewMemoryDeliveryJournal(),
+		quiesceEmpty:  make(chan struct{}, 1),
 	}
 
 	if opts == nil {
@@ -338,6 +1297,20 @@ func newSender(target string, session *Session, opts *SenderOptions) (*Sender, e
 	for _, v := range opts.Capabilities {
 		s.l.source.Capabilities = append(s.l.source.Capabilities, encoding.Symbol(v))
 	}
+	if opts.CreditStarvation != nil {
+		if opts.CreditStarvation.Threshold <= 0 {
+			return nil, errors.New("CreditStarvation.Threshold must be positive")
+		}
+		s.creditStarvation = *opts.CreditStarvation
+		s.starvationTimer = time.NewTimer(s.creditStarvation.Threshold)
+		if !s.starvationTimer.Stop() {
+			<-s.starvationTimer.C
+		}
+		s.starvationCheck = make(chan struct{}, 1)
+	}
+	if opts.DeliveryJournal != nil {
+		s.journal = opts.DeliveryJournal
+	}
 	if opts.Durability > DurabilityUnsettledState {
 		return nil, fmt.Errorf("invalid Durability %d", opts.Durability)
 	}
@@ -362,9 +1335,21 @@ func newSender(target string, session *Session, opts *SenderOptions) (*Sender, e
 		s.l.source.ExpiryPolicy = opts.ExpiryPolicy
 	}
 	s.l.source.Timeout = opts.ExpiryTimeout
+	if opts.IdleTimeout > 0 {
+		if opts.OnIdle == nil {
+			return nil, errors.New("OnIdle is required when IdleTimeout is set")
+		}
+		s.l.idleTimeout = opts.IdleTimeout
+		s.l.onIdle = opts.OnIdle
+	} else if opts.OnIdle != nil {
+		return nil, errors.New("IdleTimeout is required when OnIdle is set")
+	}
 	if opts.Name != "" {
 		s.l.key.name = opts.Name
 	}
+	s.l.onAddressChange = opts.OnAddressChange
+	s.onDrain = opts.OnDrain
+	s.onFragmentation = opts.OnFragmentation
 	if opts.Properties != nil {
 		s.l.properties = make(map[encoding.Symbol]any)
 		for k, v := range opts.Properties {
@@ -380,6 +1365,29 @@ func newSender(target string, session *Session, opts *SenderOptions) (*Sender, e
 		}
 		s.l.receiverSettleMode = opts.RequestedReceiverSettleMode
 	}
+	if opts.RXQueue != nil {
+		segmentSize, maxIdle, err := opts.RXQueue.values(int(session.outgoingWindow))
+		if err != nil {
+			return nil, err
+		}
+		s.l.rxQ = queue.NewHolder(queue.NewWithOptions[frames.FrameBody](queue.Options{
+			SegmentSize:     segmentSize,
+			MaxIdleSegments: maxIdle,
+		}))
+	}
+	s.sendTransforms = opts.SendTransforms
+	if opts.SequenceStamping {
+		if opts.ProducerID == "" {
+			return nil, errors.New("ProducerID is required when SequenceStamping is set")
+		}
+		if len(opts.ProducerID) > maxProducerIDLength {
+			return nil, fmt.Errorf("ProducerID is over the allowed %d bytes, len: %d", maxProducerIDLength, len(opts.ProducerID))
+		}
+		s.sequenceStamping = true
+		s.producerID = opts.ProducerID
+	} else if opts.ProducerID != "" {
+		return nil, errors.New("SequenceStamping is required when ProducerID is set")
+	}
 	if opts.SettlementMode != nil {
 		if ssm := *opts.SettlementMode; ssm > SenderSettleModeMixed {
 			return nil, fmt.Errorf("invalid SettlementMode %d", ssm)
@@ -405,11 +1413,29 @@ func newSender(target string, session *Session, opts *SenderOptions) (*Sender, e
 func (s *Sender) attach(ctx context.Context) error {
 	if err := s.l.attach(ctx, func(pa *frames.PerformAttach) {
 		pa.Role = encoding.RoleSender
+		if s.coordinator != nil {
+			// the control link addresses the transaction coordinator
+			// rather than a node
+			pa.Target = nil
+			pa.Coordinator = s.coordinator
+			return
+		}
 		if pa.Target == nil {
 			pa.Target = new(frames.Target)
 		}
 		pa.Target.Dynamic = s.l.dynamicAddr
+
+		// advertise the journal's in-doubt deliveries so the peer can
+		// report their outcomes (spec section 2.6.13)
+		if unsettled, err := s.journal.Unsettled(s.l.key.name); err == nil && len(unsettled) > 0 {
+			pa.Unsettled = make(encoding.Unsettled, len(unsettled))
+			for tag, state := range unsettled {
+				pa.Unsettled[tag] = state
+			}
+		}
 	}, func(pa *frames.PerformAttach) {
+		s.peerCoordinator = pa.Coordinator
+
 		if s.l.target == nil {
 			s.l.target = new(frames.Target)
 		}
@@ -418,6 +1444,8 @@ func (s *Sender) attach(ctx context.Context) error {
 		if s.l.dynamicAddr && pa.Target != nil {
 			s.l.target.Address = pa.Target.Address
 		}
+
+		s.resolveUnsettled(pa)
 	}); err != nil {
 		return err
 	}
@@ -427,6 +1455,59 @@ func (s *Sender) attach(ctx context.Context) error {
 	return nil
 }
 
+// resolveUnsettled compares the journal's in-doubt deliveries with the
+// unsettled map in the peer's attach response (spec section 2.6.13).
+// Deliveries the peer reports a terminal outcome for are settled in the
+// journal; those the peer has no record of are flagged for resend.  The
+// resolutions are surfaced via [Sender.ResumedDeliveries].
+func (s *Sender) resolveUnsettled(resp *frames.PerformAttach) {
+	unsettled, err := s.journal.Unsettled(s.l.key.name)
+	if err != nil || len(unsettled) == 0 {
+		return
+	}
+
+	resolutions := make([]DeliveryResolution, 0, len(unsettled))
+	for tag := range unsettled {
+		resolution := DeliveryResolution{DeliveryTag: []byte(tag)}
+		state, ok := resp.Unsettled[tag]
+		switch {
+		case !ok:
+			// the peer has no record of the delivery; it must be resent
+			resolution.InDoubt = true
+		case isTerminalDeliveryState(state):
+			// the delivery reached the peer and has an outcome
+			resolution.State = state
+			_ = s.journal.MarkSettled(s.l.key.name, []byte(tag))
+		default:
+			// the peer holds the delivery but hasn't resolved it
+			resolution.State = state
+		}
+		debug.LogC(s.l.session.conn.correlationID, 1, "TX (Sender) link %s: resumed delivery tag %q, state %v, in doubt %t", s.l.key.name, tag, resolution.State, resolution.InDoubt)
+		resolutions = append(resolutions, resolution)
+	}
+	sort.Slice(resolutions, func(i, j int) bool {
+		return bytes.Compare(resolutions[i].DeliveryTag, resolutions[j].DeliveryTag) < 0
+	})
+
+	s.mu.Lock()
+	s.resumed = resolutions
+	s.mu.Unlock()
+}
+
+// ResumedDeliveries reports the outcome of the unsettled-map exchange
+// performed when the link attached.  It returns nil when the link
+// attached with no deliveries recorded in the configured
+// [SenderOptions.DeliveryJournal].
+//
+// Deliveries the peer reported a terminal outcome for have been removed
+// from the journal; deliveries flagged as in doubt should be resent with
+// their original delivery tags.
+func (s *Sender) ResumedDeliveries() []DeliveryResolution {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resumed
+}
+
 type senderTestHooks struct {
 	MuxSelect   func()
 	MuxTransfer func()
@@ -440,98 +1521,290 @@ func (s *Sender) mux(hooks senderTestHooks) {
 		hooks.MuxTransfer = nopHook
 	}
 
+	defer s.muxDone()
+
 	defer func() {
-		close(s.l.done)
+		// NOTE: this must be declared after the defer above so that it runs
+		// first, converting the panic into the mux's terminal error state.
+		// a panic leaves the link and its session in an undefined state, so
+		// close the owning connection rather than crashing the process.
+		if v := recover(); v != nil {
+			s.l.doneErr = &LinkError{inner: muxPanicError(v)}
+			s.l.session.conn.close()
+		}
 	}()
 
-Loop:
-	for {
-		var outgoingTransfers chan transferEnvelope
-		if s.l.linkCredit > 0 {
-			debug.Log(1, "TX (Sender %p) (enable): target: %q, link credit: %d, deliveryCount: %d", s, s.l.target.Address, s.l.linkCredit, s.l.deliveryCount)
-			outgoingTransfers = s.transfers
-		} else {
-			debug.Log(1, "TX (Sender %p) (pause): target: %q, link credit: %d, deliveryCount: %d", s, s.l.target.Address, s.l.linkCredit, s.l.deliveryCount)
-		}
+	var muxProgress uint64
+	if wt := s.l.session.conn.watchdogTimeout; wt > 0 {
+		go watchMux(fmt.Sprintf("sender link %s", s.l.key.name), wt, s.l.session.conn.watchdogHandler, &muxProgress, s.l.rxQ.Len, s.l.done)
+	}
 
-		closed := s.l.close
-		if s.l.closeInProgress {
-			// swap out channel so it no longer triggers
-			closed = nil
+	for {
+		atomic.AddUint64(&muxProgress, 1)
 
-			// disable sending once closing is in progress.
-			// this prevents races with mux shutdown and
-			// the peer sending disposition frames.
-			outgoingTransfers = nil
-		}
+		outgoingTransfers, closed := s.muxPrepare()
 
 		hooks.MuxSelect()
 
 		select {
 		// received frame
 		case q := <-s.l.rxQ.Wait():
-			// populated queue
-			fr := *q.Dequeue()
-			s.l.rxQ.Release(q)
-
-			// if muxHandleFrame returns an error it means the mux must terminate.
-			// note that in the case of a client-side close due to an error, nil
-			// is returned in order to keep the mux running to ack the detach frame.
-			if err := s.muxHandleFrame(fr); err != nil {
-				s.l.doneErr = err
+			if !s.muxRecvFrame(q) {
 				return
 			}
 
 		// send data
 		case env := <-outgoingTransfers:
 			hooks.MuxTransfer()
-			select {
-			case s.l.session.txTransfer <- env:
-				debug.Log(2, "TX (Sender %p): mux transfer to Session: %d, %s", s, s.l.session.channel, env.Frame)
-				// decrement link-credit after entire message transferred
-				if !env.Frame.More {
-					s.l.deliveryCount++
-					s.l.linkCredit--
-					// we are the sender and we keep track of the peer's link credit
-					debug.Log(3, "TX (Sender %p): link: %s, link credit: %d", s, s.l.key.name, s.l.linkCredit)
-				}
-				continue Loop
-			case <-s.l.close:
-				continue Loop
-			case <-s.l.session.done:
-				continue Loop
-			}
+			s.muxSendTransfer(env)
 
 		case <-closed:
-			if s.l.closeInProgress {
-				// a client-side close due to protocol error is in progress
-				continue
-			}
-
-			// sender is being closed by the client
-			s.l.closeInProgress = true
-			fr := &frames.PerformDetach{
-				Handle: s.l.outputHandle,
-				Closed: true,
-			}
-			s.l.txFrame(&frameContext{Ctx: context.Background()}, fr)
+			s.muxClientClose()
 
 		case <-s.l.session.done:
 			s.l.doneErr = s.l.session.doneErr
 			return
 
 		case <-s.rollback:
-			s.l.deliveryCount--
-			s.l.linkCredit++
-			debug.Log(3, "TX (Sender %p): rollback link: %s, link credit: %d", s, s.l.key.name, s.l.linkCredit)
+			s.muxRollback()
+
+		case <-s.starvationCheck:
+			// a message became available while the sender may be starved;
+			// the muxPrepare on the next iteration arms the timer
+
+		case <-s.starvationFired():
+			s.muxStarved()
+		}
+	}
+}
+
+// muxPrepare runs the per-wakeup bookkeeping, gating the transfers channel on
+// available link credit and while a close is in progress.
+func (s *Sender) muxPrepare() (outgoingTransfers chan transferEnvelope, closed chan struct{}) {
+	if s.l.linkCredit > 0 {
+		debug.LogC(s.l.session.conn.correlationID, 1, "TX (Sender %p) (enable): target: %q, link credit: %d, deliveryCount: %d", s, s.l.target.Address, s.l.linkCredit, s.l.deliveryCount)
+		outgoingTransfers = s.transfers
+	} else {
+		debug.LogC(s.l.session.conn.correlationID, 1, "TX (Sender %p) (pause): target: %q, link credit: %d, deliveryCount: %d", s, s.l.target.Address, s.l.linkCredit, s.l.deliveryCount)
+	}
+
+	closed = s.l.close
+	if s.l.closeInProgress {
+		// swap out channel so it no longer triggers
+		closed = nil
+
+		// disable sending once closing is in progress.
+		// this prevents races with mux shutdown and
+		// the peer sending disposition frames.
+		outgoingTransfers = nil
+	}
+
+	s.muxCheckStarvation(outgoingTransfers != nil)
+
+	return outgoingTransfers, closed
+}
+
+// signalStarvationCheck wakes the mux so it can re-evaluate starvation
+// after a message has become available.  no-op when detection is disabled.
+func (s *Sender) signalStarvationCheck() {
+	if s.starvationCheck == nil {
+		return
+	}
+	select {
+	case s.starvationCheck <- struct{}{}:
+	default:
+		// a check is already pending
+	}
+}
+
+// starvationFired returns the channel signaling that the starvation
+// threshold has elapsed, or nil when detection is disabled.
+func (s *Sender) starvationFired() <-chan time.Time {
+	if s.starvationTimer == nil {
+		return nil
+	}
+	return s.starvationTimer.C
+}
+
+// muxCheckStarvation tracks transitions in and out of credit starvation,
+// arming the timer behind starvationFired on entry and disarming it once
+// credit arrives or there's nothing left to send.
+func (s *Sender) muxCheckStarvation(sendable bool) {
+	if s.starvationTimer == nil {
+		return
+	}
+	starved := !sendable && !s.l.closeInProgress && s.available.Load() > 0
+	switch {
+	case starved && s.starvedSince.IsZero():
+		s.starvedSince = time.Now()
+		s.starvationTimer.Reset(s.creditStarvation.Threshold)
+	case !starved && !s.starvedSince.IsZero():
+		s.starvedSince = time.Time{}
+		if !s.starvationTimer.Stop() {
+			select {
+			case <-s.starvationTimer.C:
+			default:
+			}
+		}
+	}
+}
+
+// muxStarved reports that the sender has had queued messages but zero link
+// credit for the starvation threshold, then re-arms the timer so continued
+// starvation is reported each threshold interval.
+func (s *Sender) muxStarved() {
+	if s.starvedSince.IsZero() {
+		// the timer fired as starvation was clearing
+		return
+	}
+	if s.available.Load() == 0 {
+		// the queued sends were abandoned before the threshold elapsed
+		s.starvedSince = time.Time{}
+		return
+	}
+	starved := time.Since(s.starvedSince)
+	debug.LogC(s.l.session.conn.correlationID, 1, "TX (Sender %p) (starved): link: %s, available: %d, starved: %s", s, s.l.key.name, s.availableCount(), starved)
+	if m := s.l.session.conn.metrics; m != nil {
+		m.CreditStarved(starved)
+	}
+	if on := s.creditStarvation.OnStarvation; on != nil {
+		on(CreditStarvationEvent{LinkName: s.l.key.name, Starved: starved})
+	}
+	s.starvationTimer.Reset(s.creditStarvation.Threshold)
+}
+
+// muxRecvFrame processes an incoming frame from the session mux.
+// it returns false if the mux must terminate.
+func (s *Sender) muxRecvFrame(q *queue.Queue[frames.FrameBody]) bool {
+	// populated queue
+	fr := *q.Dequeue()
+	s.l.rxQ.Release(q)
+
+	// if muxHandleFrame returns an error it means the mux must terminate.
+	// note that in the case of a client-side close due to an error, nil
+	// is returned in order to keep the mux running to ack the detach frame.
+	if err := s.muxHandleFrame(fr); err != nil {
+		s.l.doneErr = err
+		return false
+	}
+
+	return true
+}
+
+// muxSendTransfer forwards env to the session mux, updating the link's
+// credit and delivery count once the final frame of a message is sent.
+func (s *Sender) muxSendTransfer(env transferEnvelope) {
+	s.l.markActive()
+
+	if s.l.session.txTransfer.Enqueue(env, s.l.close, s.l.session.done) == ring.Enqueued {
+		debug.LogC(s.l.session.conn.correlationID, 2, "TX (Sender %p): mux transfer to Session: %d, %s", s, s.l.session.channel, env.Frame)
+		if m := s.l.session.conn.metrics; m != nil {
+			m.TransferSent(len(env.Frame.Payload))
 		}
+		// decrement link-credit after entire message transferred
+		if !env.Frame.More {
+			s.l.deliveryCount++
+			s.l.linkCredit--
+			expvarAdd(expvarMessagesSent, 1)
+			// we are the sender and we keep track of the peer's link credit
+			debug.LogC(s.l.session.conn.correlationID, 3, "TX (Sender %p): link: %s, link credit: %d", s, s.l.key.name, s.l.linkCredit)
+		}
+	}
+}
+
+// muxClientClose initiates a client-side graceful close by sending a detach.
+func (s *Sender) muxClientClose() {
+	if s.l.closeInProgress {
+		// a client-side close due to protocol error is in progress
+		return
+	}
+
+	// sender is being closed by the client
+	s.l.closeInProgress = true
+	fr := &frames.PerformDetach{
+		Handle: s.l.outputHandle,
+		// a non-closing detach suspends the link instead of closing it
+		Closed: !s.l.detachRequested,
 	}
+	s.l.txFrame(&frameContext{Ctx: context.Background()}, fr)
+}
+
+// muxRollback undoes the bookkeeping for a transfer whose final frame failed to send.
+func (s *Sender) muxRollback() {
+	s.l.deliveryCount--
+	s.l.linkCredit++
+	debug.LogC(s.l.session.conn.correlationID, 3, "TX (Sender %p): rollback link: %s, link credit: %d", s, s.l.key.name, s.l.linkCredit)
+}
+
+// muxDone releases the mux's resources.
+// it's called exactly once when the mux terminates.
+func (s *Sender) muxDone() {
+	close(s.l.done)
+
+	if s.starvationTimer != nil {
+		s.starvationTimer.Stop()
+	}
+
+	if m := s.l.session.conn.metrics; m != nil {
+		m.LinkDetached()
+	}
+	s.l.session.conn.logEvent("link detached", append(s.l.logAttrs(), slog.Any("error", s.l.doneErr))...)
+	expvarAdd(expvarActiveLinks, -1)
+}
+
+// muxInit implements sharedMuxLink.
+// senders have no one-time mux initialization to perform.
+func (s *Sender) muxInit() bool {
+	return true
+}
+
+// muxPoll implements sharedMuxLink.
+// the order of the returned cases MUST match the indices in muxEvent.
+func (s *Sender) muxPoll() ([]reflect.SelectCase, bool) {
+	outgoingTransfers, closed := s.muxPrepare()
+
+	return []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.l.rxQ.Wait())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(outgoingTransfers)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(closed)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.rollback)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.starvationCheck)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.starvationFired())},
+	}, false
+}
+
+// muxEvent implements sharedMuxLink.
+func (s *Sender) muxEvent(i int, v reflect.Value) bool {
+	switch i {
+	case 0: // incoming frame
+		return !s.muxRecvFrame(v.Interface().(*queue.Queue[frames.FrameBody]))
+	case 1: // transfer to send
+		s.muxSendTransfer(v.Interface().(transferEnvelope))
+	case 2: // client-side close
+		s.muxClientClose()
+	case 3: // rollback after a failed final frame
+		s.muxRollback()
+	case 4: // a message became available; the next muxPoll arms the timer
+	case 5: // starvation threshold elapsed
+		s.muxStarved()
+	}
+	return false
+}
+
+// muxPending implements sharedMuxLink.
+func (s *Sender) muxPending() int {
+	return s.l.rxQ.Len()
+}
+
+// muxFatal implements sharedMuxLink.
+func (s *Sender) muxFatal(err error) {
+	s.l.doneErr = err
 }
 
 // muxHandleFrame processes fr based on type.
 // depending on the peer's RSM, it might return a disposition frame for sending
 func (s *Sender) muxHandleFrame(fr frames.FrameBody) error {
-	debug.Log(2, "RX (Sender %p): %s", s, fr)
+	debug.LogC(s.l.session.conn.correlationID, 2, "RX (Sender %p): %s", s, fr)
 	switch fr := fr.(type) {
 	// flow control frame
 	case *frames.PerformFlow:
@@ -545,6 +1818,41 @@ func (s *Sender) muxHandleFrame(fr frames.FrameBody) error {
 			linkCredit += *fr.DeliveryCount
 		}
 
+		if fr.Drain {
+			// "When the drain flag is set [...] the sender MUST use up all
+			// available credit by advancing its delivery-count and send its
+			// updated delivery-count in a flow frame with the drain flag set."
+			s.l.deliveryCount += linkCredit
+			s.l.linkCredit = 0
+
+			var (
+				deliveryCount = s.l.deliveryCount
+				credit        = uint32(0)
+				available     = s.availableCount()
+			)
+			resp := &frames.PerformFlow{
+				Handle:        &s.l.outputHandle,
+				DeliveryCount: &deliveryCount,
+				LinkCredit:    &credit,
+				Available:     &available,
+				Drain:         true,
+			}
+
+			switch s.l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: resp}, s.l.close, s.l.session.done) {
+			case ring.Enqueued:
+				debug.LogC(s.l.session.conn.correlationID, 2, "TX (Sender %p): mux frame to Session (%p): %d, %s", s, s.l.session, s.l.session.channel, resp)
+			case 0:
+				return nil
+			default:
+				return s.l.session.doneErr
+			}
+
+			if s.onDrain != nil {
+				s.onDrain(linkCredit)
+			}
+			return nil
+		}
+
 		s.l.linkCredit = linkCredit
 
 		if !fr.Echo {
@@ -554,6 +1862,7 @@ func (s *Sender) muxHandleFrame(fr frames.FrameBody) error {
 		var (
 			// copy because sent by pointer below; prevent race
 			deliveryCount = s.l.deliveryCount
+			available     = s.availableCount()
 		)
 
 		// send flow
@@ -561,18 +1870,21 @@ func (s *Sender) muxHandleFrame(fr frames.FrameBody) error {
 			Handle:        &s.l.outputHandle,
 			DeliveryCount: &deliveryCount,
 			LinkCredit:    &linkCredit, // max number of messages
+			Available:     &available,
 		}
 
-		select {
-		case s.l.session.tx <- frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: resp}:
-			debug.Log(2, "TX (Sender %p): mux frame to Session (%p): %d, %s", s, s.l.session, s.l.session.channel, resp)
-		case <-s.l.close:
+		switch s.l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: resp}, s.l.close, s.l.session.done) {
+		case ring.Enqueued:
+			debug.LogC(s.l.session.conn.correlationID, 2, "TX (Sender %p): mux frame to Session (%p): %d, %s", s, s.l.session, s.l.session.channel, resp)
+		case 0:
 			return nil
-		case <-s.l.session.done:
+		default:
 			return s.l.session.doneErr
 		}
 
 	case *frames.PerformDisposition:
+		s.l.markActive()
+
 		if fr.Settled {
 			return nil
 		}
@@ -588,12 +1900,12 @@ func (s *Sender) muxHandleFrame(fr frames.FrameBody) error {
 			State:   fr.State,
 		}
 
-		select {
-		case s.l.session.tx <- frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: dr}:
-			debug.Log(2, "TX (Sender %p): mux frame to Session (%p): %d, %s", s, s.l.session, s.l.session.channel, dr)
-		case <-s.l.close:
+		switch s.l.session.tx.Enqueue(frameBodyEnvelope{FrameCtx: &frameContext{Ctx: context.Background()}, FrameBody: dr}, s.l.close, s.l.session.done) {
+		case ring.Enqueued:
+			debug.LogC(s.l.session.conn.correlationID, 2, "TX (Sender %p): mux frame to Session (%p): %d, %s", s, s.l.session, s.l.session.channel, dr)
+		case 0:
 			return nil
-		case <-s.l.session.done:
+		default:
 			return s.l.session.doneErr
 		}
 
@@ -1,9 +1,11 @@
 package amqp
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"testing"
@@ -325,6 +327,50 @@ func TestSenderCloseTimeout(t *testing.T) {
 	require.NoError(t, client.Close())
 }
 
+func TestSenderDetach(t *testing.T) {
+	detachClosed := make(chan bool, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if detach, ok := req.(*frames.PerformDetach); ok {
+			detachClosed <- detach.Closed
+			// ack the non-closing detach in kind, suspending the link
+			return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformDetach{Handle: 0}))
+		}
+		return senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Detach(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.False(t, <-detachClosed)
+
+	// the sender is terminal; sends report the suspension
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("hello")), nil)
+	cancel()
+	var linkErr *LinkError
+	require.ErrorAs(t, err, &linkErr)
+	var suspendedErr *LinkSuspendedError
+	require.ErrorAs(t, err, &suspendedErr)
+	require.Nil(t, suspendedErr.RemoteErr)
+	require.NoError(t, client.Close())
+}
+
 func TestSenderAttachError(t *testing.T) {
 	detachAck := make(chan bool, 1)
 	var enqueueFrames func(string)
@@ -493,6 +539,269 @@ func TestSenderSendSuccess(t *testing.T) {
 	require.NoError(t, client.Close())
 }
 
+func TestSenderSendTransactional(t *testing.T) {
+	stateCh := make(chan encoding.DeliveryState, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeMixed)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			if tt.Settled {
+				return fake.Response{}, errors.New("didn't expect transactional transfer to be settled")
+			}
+			stateCh <- tt.State
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		SettlementMode: SenderSettleModeMixed.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("test")), &SendOptions{TransactionID: []byte("txn-1")})
+	cancel()
+	require.NoError(t, err)
+
+	// the transfer carries the enrollment in transactional-state
+	txnState, ok := (<-stateCh).(*encoding.TransactionalState)
+	require.True(t, ok)
+	require.Equal(t, []byte("txn-1"), txnState.TxnID)
+	require.Nil(t, txnState.Outcome)
+
+	// transactional transfers must remain unsettled
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("test")), &SendOptions{
+		Settled:       true,
+		TransactionID: []byte("txn-1"),
+	})
+	cancel()
+	require.EqualError(t, err, "can't send transactional message as settled")
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendBatch(t *testing.T) {
+	// the responder withholds all dispositions until every transfer in the
+	// batch has arrived, so the test only passes if SendBatch transmits the
+	// whole batch before waiting on settlement.
+	const batchSize = 3
+	var received int
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch req.(type) {
+		case *frames.PerformTransfer:
+			received++
+			if received < batchSize {
+				return fake.Response{}, nil
+			}
+			last := uint32(batchSize - 1)
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, 0, &last, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	msgs := make([]*Message, batchSize)
+	for i := range msgs {
+		msgs[i] = NewMessage([]byte("test"))
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.SendBatch(ctx, msgs, nil))
+	cancel()
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendBatchRejected(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			var state encoding.DeliveryState = &encoding.StateAccepted{}
+			if *tt.DeliveryID == 1 {
+				state = &encoding.StateRejected{
+					Error: &Error{Condition: ErrCondMessageSizeExceeded},
+				}
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, state))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	msgs := []*Message{
+		NewMessage([]byte("one")),
+		NewMessage([]byte("two")),
+		NewMessage([]byte("three")),
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.SendBatch(ctx, msgs, nil)
+	cancel()
+
+	var batchErr *SendBatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Errors, 1)
+	var amqpErr *Error
+	require.ErrorAs(t, batchErr.Errors[1], &amqpErr)
+	require.Equal(t, ErrCondMessageSizeExceeded, amqpErr.Condition)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendTransforms(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			var msg Message
+			if err := msg.UnmarshalBinary(tt.Payload); err != nil {
+				return fake.Response{}, err
+			}
+			if msg.DeliveryAnnotations["x-opt-signed-by"] != "transform" {
+				return fake.Response{}, fmt.Errorf("unexpected delivery annotations %v", msg.DeliveryAnnotations)
+			}
+			if msg.Footer["x-opt-checksum"] != uint64(3) {
+				return fake.Response{}, fmt.Errorf("unexpected footer %v", msg.Footer)
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		SendTransforms: []func(*Message) error{
+			func(msg *Message) error {
+				msg.DeliveryAnnotations = Annotations{"x-opt-signed-by": "transform"}
+				return nil
+			},
+			func(msg *Message) error {
+				// later transforms observe the effects of earlier ones
+				if msg.DeliveryAnnotations["x-opt-signed-by"] != "transform" {
+					return errors.New("expected the first transform's annotation")
+				}
+				msg.Footer = Annotations{"x-opt-checksum": uint64(len(msg.GetData()))}
+				return nil
+			},
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, NewMessage([]byte("abc")), nil))
+	cancel()
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendTransformError(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandler(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	transformErr := errors.New("signing failed")
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		SendTransforms: []func(*Message) error{
+			func(msg *Message) error { return transformErr },
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("test")), nil)
+	cancel()
+	require.ErrorIs(t, err, transformErr)
+
+	require.NoError(t, client.Close())
+}
+
 func TestSenderSendSettled(t *testing.T) {
 	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
 		resp, err := senderFrameHandler(0, SenderSettleModeSettled)(remoteChannel, req)
@@ -1457,39 +1766,306 @@ func TestSenderProperties(t *testing.T) {
 	require.NoError(t, client.Close())
 }
 
-func TestSenderSendWithReceipt(t *testing.T) {
-	tests := []struct {
-		name  string
-		state DeliveryState
-	}{
-		{
-			name:  "accepted",
-			state: &StateAccepted{},
-		},
-		{
-			name: "modified",
-			state: &StateModified{
-				MessageAnnotations: Annotations{
-					"foo": int64(123),
-				},
-				DeliveryFailed:    true,
-				UndeliverableHere: true,
-			},
-		},
-		{
-			name: "received",
-			state: &StateReceived{
-				SectionNumber: 123,
-				SectionOffset: 456,
-			},
-		},
-		{
-			name: "rejected-with-error",
-			state: &StateRejected{
-				Error: &Error{
-					Condition:   "rejected",
-					Description: "bad message",
-					Info: map[string]any{
+func TestSenderRefresh(t *testing.T) {
+	attaches := make(chan *frames.PerformAttach, 2)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if attach, ok := req.(*frames.PerformAttach); ok {
+			attaches <- attach
+		}
+		return senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "thetarget", &SenderOptions{Durability: DurabilityUnsettledState})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	refreshed, err := snd.Refresh(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.NotSame(t, snd, refreshed)
+	require.Equal(t, "thetarget", refreshed.Address())
+
+	// the original link is closed and unusable
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("test")), nil)
+	cancel()
+	var linkErr *LinkError
+	require.ErrorAs(t, err, &linkErr)
+
+	// both attaches carry the same name and configuration
+	first, second := <-attaches, <-attaches
+	require.Equal(t, first.Name, second.Name)
+	require.Equal(t, first.Source.Durable, second.Source.Durable)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, refreshed.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestSenderCreditStarvation(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if _, ok := req.(*frames.PerformTransfer); ok {
+			// settled transfers don't require a disposition
+			return fake.Response{}, nil
+		}
+		return senderFrameHandlerNoUnhandled(0, SenderSettleModeSettled)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	const threshold = 20 * time.Millisecond
+	events := make(chan CreditStarvationEvent, 10)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		CreditStarvation: &CreditStarvationOptions{
+			OnStarvation: func(e CreditStarvationEvent) { events <- e },
+			Threshold:    threshold,
+		},
+		SettlementMode: SenderSettleModeSettled.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	// an idle sender with no credit isn't starved
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected starvation event %+v", e)
+	case <-time.After(5 * threshold):
+		// expected
+	}
+
+	// queue a send; the peer never granted credit so it blocks
+	sendErr := make(chan error, 1)
+	sendCtx, sendCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer sendCancel()
+	go func() {
+		sendErr <- snd.Send(sendCtx, NewMessage([]byte("test")), nil)
+	}()
+
+	// starvation is reported at each threshold interval
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-events:
+			require.Equal(t, snd.LinkName(), e.LinkName)
+			require.GreaterOrEqual(t, e.Starved, time.Duration(i+1)*threshold)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for starvation event")
+		}
+	}
+
+	// granting credit clears the starvation and the send completes
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+	require.NoError(t, <-sendErr)
+
+	// drain any event that raced with the credit grant
+	for len(events) > 0 {
+		<-events
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected starvation event %+v", e)
+	case <-time.After(5 * threshold):
+		// expected
+	}
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderCreditStarvationInvalidOptions(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		CreditStarvation: &CreditStarvationOptions{},
+	})
+	cancel()
+	require.Error(t, err)
+	require.Nil(t, snd)
+	require.NoError(t, client.Close())
+}
+
+func TestSenderDeliveryJournal(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if _, ok := req.(*frames.PerformTransfer); ok {
+			// the test settles the delivery itself
+			return fake.Response{}, nil
+		}
+		return senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	journal := NewMemoryDeliveryJournal()
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		DeliveryJournal: journal,
+		SettlementMode:  SenderSettleModeUnsettled.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	receipt, err := snd.SendWithReceipt(ctx, NewMessage([]byte("test")), nil)
+	cancel()
+	require.NoError(t, err)
+
+	// the delivery is journaled until its settlement is confirmed
+	unsettled, err := journal.Unsettled(snd.LinkName())
+	require.NoError(t, err)
+	require.Len(t, unsettled, 1)
+	require.Contains(t, unsettled, string(receipt.DeliveryTag()))
+
+	b, err := fake.PerformDisposition(encoding.RoleReceiver, 0, 0, nil, &encoding.StateAccepted{})
+	require.NoError(t, err)
+	netConn.SendFrame(b)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	state, err := receipt.Wait(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.IsType(t, &StateAccepted{}, state)
+
+	unsettled, err = journal.Unsettled(snd.LinkName())
+	require.NoError(t, err)
+	require.Empty(t, unsettled)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderPeerTargetCapabilities(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+				Name:   tt.Name,
+				Handle: 0,
+				Role:   encoding.RoleReceiver,
+				Target: &frames.Target{
+					Address:      "test",
+					Durable:      encoding.DurabilityNone,
+					ExpiryPolicy: encoding.ExpirySessionEnd,
+					Capabilities: encoding.MultiSymbol{"queue", "shared"},
+				},
+				SenderSettleMode: SenderSettleModeUnsettled.Ptr(),
+				MaxMessageSize:   math.MaxUint32,
+			})
+			return newResponse(b, err)
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "thetarget", &SenderOptions{
+		TargetCapabilities: []string{"queue"},
+	})
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []string{"queue", "shared"}, snd.PeerTargetCapabilities())
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	require.NoError(t, snd.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendWithReceipt(t *testing.T) {
+	tests := []struct {
+		name  string
+		state DeliveryState
+	}{
+		{
+			name:  "accepted",
+			state: &StateAccepted{},
+		},
+		{
+			name: "modified",
+			state: &StateModified{
+				MessageAnnotations: Annotations{
+					"foo": int64(123),
+				},
+				DeliveryFailed:    true,
+				UndeliverableHere: true,
+			},
+		},
+		{
+			name: "received",
+			state: &StateReceived{
+				SectionNumber: 123,
+				SectionOffset: 456,
+			},
+		},
+		{
+			name: "rejected-with-error",
+			state: &StateRejected{
+				Error: &Error{
+					Condition:   "rejected",
+					Description: "bad message",
+					Info: map[string]any{
 						"ding": "dong",
 					},
 				},
@@ -1571,8 +2147,39 @@ func TestSenderSendWithReceipt(t *testing.T) {
 	}
 }
 
-func TestSenderSendWithReceipt_SenderSettleModeSettled(t *testing.T) {
-	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeSettled), fake.NetConnOptions{})
+func TestSenderFlowAvailable(t *testing.T) {
+	flowResp := make(chan error, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, tt.Name, 0, SenderSettleModeSettled))
+		case *frames.PerformFlow:
+			// the echo response; available must reflect the blocked send
+			if tt.Available == nil || *tt.Available != 1 {
+				flowResp <- fmt.Errorf("unexpected Available %v", tt.Available)
+			} else {
+				flowResp <- nil
+			}
+			return fake.Response{}, nil
+		case *frames.PerformTransfer:
+			return fake.Response{}, nil
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	client, err := NewConn(ctx, netConn, nil)
@@ -1591,10 +2198,941 @@ func TestSenderSendWithReceipt_SenderSettleModeSettled(t *testing.T) {
 	cancel()
 	require.NoError(t, err)
 
-	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	// send with no credit; the message is queued awaiting credit
+	sendDone := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		sendDone <- snd.Send(ctx, NewMessage([]byte("test")), nil)
+	}()
+
+	// wait for the send to block awaiting credit
+	time.Sleep(100 * time.Millisecond)
+
+	// link-level flow with echo; the response must report available == 1
+	nextIncoming := uint32(0)
+	count := uint32(0)
+	handle := uint32(0)
+	credit := uint32(0)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+		NextIncomingID: &nextIncoming,
+		IncomingWindow: 1000000,
+		OutgoingWindow: 1000000,
+		NextOutgoingID: nextIncoming + 1,
+		Handle:         &handle,
+		DeliveryCount:  &count,
+		LinkCredit:     &credit,
+		Echo:           true,
+	})
+	require.NoError(t, err)
+	netConn.SendFrame(b)
+
+	select {
+	case err := <-flowResp:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for flow echo response")
+	}
+
+	// grant credit so the blocked send can complete
+	sendInitialFlowFrame(t, 0, netConn, 0, 1)
+	select {
+	case err := <-sendDone:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for send to complete")
+	}
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderDrain(t *testing.T) {
+	drainResp := make(chan error, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, tt.Name, 0, SenderSettleModeUnsettled))
+		case *frames.PerformFlow:
+			// the confirming flow frame emitted after draining
+			switch {
+			case !tt.Drain:
+				drainResp <- fmt.Errorf("drain not set on confirming flow frame")
+			case tt.DeliveryCount == nil || *tt.DeliveryCount != 100:
+				drainResp <- fmt.Errorf("unexpected DeliveryCount %v", tt.DeliveryCount)
+			case tt.LinkCredit == nil || *tt.LinkCredit != 0:
+				drainResp <- fmt.Errorf("unexpected LinkCredit %v", tt.LinkCredit)
+			default:
+				drainResp <- nil
+			}
+			return fake.Response{}, nil
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	drained := make(chan uint32, 1)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewSender(ctx, "target", &SenderOptions{
+		OnDrain: func(credit uint32) {
+			drained <- credit
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	// request that the sender drain its credit
+	nextIncoming := uint32(0)
+	count := uint32(0)
+	handle := uint32(0)
+	credit := uint32(100)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+		NextIncomingID: &nextIncoming,
+		IncomingWindow: 1000000,
+		OutgoingWindow: 1000000,
+		NextOutgoingID: nextIncoming + 1,
+		Handle:         &handle,
+		DeliveryCount:  &count,
+		LinkCredit:     &credit,
+		Drain:          true,
+	})
+	require.NoError(t, err)
+	netConn.SendFrame(b)
+
+	select {
+	case err := <-drainResp:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for confirming flow frame")
+	}
+
+	select {
+	case credit := <-drained:
+		require.EqualValues(t, 100, credit)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnDrain callback")
+	}
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendDuplicateDeliveryTag(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, tt.Name, 0, SenderSettleModeUnsettled))
+		case *frames.PerformTransfer:
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	newMsg := func() *Message {
+		msg := NewMessage([]byte("test"))
+		msg.DeliveryTag = []byte("tag-1")
+		return msg
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	receipt, err := snd.SendWithReceipt(ctx, newMsg(), nil)
+	cancel()
+	require.NoError(t, err)
+
+	// tag-1 is still unsettled so reusing it must fail
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	_, err = snd.SendWithReceipt(ctx, newMsg(), nil)
+	cancel()
+	require.ErrorContains(t, err, "already in use")
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	state, err := receipt.Wait(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, &StateAccepted{}, state)
+
+	// settlement released the tag so it can be reused
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	receipt, err = snd.SendWithReceipt(ctx, newMsg(), nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	_, err = receipt.Wait(ctx)
+	cancel()
+	require.NoError(t, err)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendWithReceipt_SenderSettleModeSettled(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeSettled), fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		SettlementMode: SenderSettleModeSettled.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
 	receipt, err := snd.SendWithReceipt(ctx, NewMessage([]byte("test")), nil)
 	cancel()
 	require.Error(t, err)
 	require.Zero(t, receipt)
 	require.NoError(t, client.Close())
 }
+
+func TestSenderSendRaw(t *testing.T) {
+	var deliveryID uint32
+	var payloads [][]byte
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, tt.Name, 0, SenderSettleModeUnsettled))
+		case *frames.PerformTransfer:
+			if tt.DeliveryID != nil {
+				deliveryID = *tt.DeliveryID
+			}
+			if tt.MessageFormat != nil && *tt.MessageFormat != 3 {
+				return fake.Response{}, fmt.Errorf("unexpected MessageFormat %d", *tt.MessageFormat)
+			}
+			payloads = append(payloads, append([]byte(nil), tt.Payload...))
+			if tt.More {
+				return fake.Response{}, nil
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, deliveryID, nil, &encoding.StateAccepted{}))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	// a chunk is required
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	require.Error(t, snd.SendRaw(ctx, &RawTransfer{Format: 3}))
+	cancel()
+
+	chunks := [][]byte{[]byte("chunk1"), []byte("chunk2"), []byte("chunk3")}
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	require.NoError(t, snd.SendRaw(ctx, &RawTransfer{
+		Chunks: chunks,
+		Format: 3,
+	}))
+	cancel()
+
+	// each chunk was sent verbatim in its own transfer frame
+	require.Equal(t, chunks, payloads)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderAttachRetry(t *testing.T) {
+	attachCount := 0
+	var failAttach func(string)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			attachCount++
+			if attachCount < 3 {
+				// entity is locked for the first two attempts
+				failAttach(tt.Name)
+				return fake.Response{}, nil
+			}
+			return newResponse(fake.SenderAttach(0, tt.Name, 0, SenderSettleModeUnsettled))
+		case *frames.PerformDetach:
+			// ack for the failed attach, no response required
+			return fake.Response{}, nil
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	failAttach = func(n string) {
+		b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+			Name: n,
+			Role: encoding.RoleReceiver,
+		})
+		require.NoError(t, err)
+		netConn.SendFrame(b)
+		b, err = fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformDetach{
+			Error: &encoding.Error{
+				Condition:   ErrCondResourceLocked,
+				Description: "entity is locked",
+			},
+		})
+		require.NoError(t, err)
+		netConn.SendFrame(b)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		AttachRetry: &AttachRetryOptions{
+			MaxAttempts: 3,
+			Delay:       time.Millisecond,
+		},
+	})
+	cancel()
+	require.NoError(t, err)
+	require.NotNil(t, snd)
+	require.Equal(t, 3, attachCount)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderFragmentationStats(t *testing.T) {
+	var deliveryID uint32
+	const maxReceiverFrameSize = 128
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{
+				ChannelMax:   65535,
+				ContainerID:  "container",
+				IdleTimeout:  time.Minute,
+				MaxFrameSize: maxReceiverFrameSize, // really small max frame size
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: b}, nil
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, tt.Name, 0, SenderSettleModeUnsettled))
+		case *frames.PerformTransfer:
+			if tt.DeliveryID != nil {
+				deliveryID = *tt.DeliveryID
+			}
+			if tt.More {
+				return fake.Response{}, nil
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, deliveryID, nil, &encoding.StateAccepted{}))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// OnFragmentation is invoked from the goroutine calling Send, so no
+	// synchronization is required
+	var events []FragmentationEvent
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		OnFragmentation: func(e FragmentationEvent) { events = append(events, e) },
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 1000)
+
+	payload := make([]byte, maxReceiverFrameSize*2)
+	for i := 0; i < fragmentationWarningSampleSize; i++ {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		err = snd.Send(ctx, NewMessage(payload), nil)
+		cancel()
+		require.NoError(t, err)
+	}
+
+	stats := snd.FragmentationStats()
+	require.EqualValues(t, fragmentationWarningSampleSize, stats.Messages)
+	require.EqualValues(t, fragmentationWarningSampleSize, stats.Fragmented)
+	require.Greater(t, stats.AverageFragments(), 1.0)
+
+	// the warning fires exactly once
+	require.Len(t, events, 1)
+	require.Equal(t, snd.LinkName(), events[0].LinkName)
+	require.Greater(t, events[0].SingleFrameSize, uint64(maxReceiverFrameSize))
+
+	// a message that fits in a single frame isn't counted as fragmented
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("small")), nil)
+	cancel()
+	require.NoError(t, err)
+	stats = snd.FragmentationStats()
+	require.EqualValues(t, fragmentationWarningSampleSize+1, stats.Messages)
+	require.EqualValues(t, fragmentationWarningSampleSize, stats.Fragmented)
+	require.Len(t, events, 1)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderQuiesce(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, NewMessage([]byte("test")), nil))
+	cancel()
+
+	// everything has settled so the quiesce completes immediately
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	report, err := snd.Quiesce(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Empty(t, report.UnsettledTags)
+
+	// a quiesced sender rejects new sends
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("test")), nil)
+	cancel()
+	require.Error(t, err)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderQuiesceUnsettled(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch req.(type) {
+		case *frames.PerformTransfer:
+			// never settle the delivery
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	msg := NewMessage([]byte("test"))
+	msg.DeliveryTag = []byte("tag-1")
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	receipt, err := snd.SendWithReceipt(ctx, msg, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// the delivery never settles so the quiesce times out and reports it
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	report, err := snd.Quiesce(ctx)
+	cancel()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, [][]byte{[]byte("tag-1")}, report.UnsettledTags)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	_, err = receipt.Wait(ctx)
+	cancel()
+	require.Error(t, err)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSequenceStamping(t *testing.T) {
+	type capturedTransfer struct {
+		tag     []byte
+		payload []byte
+	}
+	transfers := make(chan capturedTransfer, 10)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeSettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			transfers <- capturedTransfer{
+				tag:     append([]byte(nil), tt.DeliveryTag...),
+				payload: append([]byte(nil), tt.Payload...),
+			}
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		ProducerID:       "prod",
+		SequenceStamping: true,
+		SettlementMode:   SenderSettleModeSettled.Ptr(),
+	})
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	waitTransfer := func() capturedTransfer {
+		select {
+		case tt := <-transfers:
+			return tt
+		case <-time.After(1 * time.Second):
+			t.Fatal("timed out waiting for transfer frame")
+			return capturedTransfer{}
+		}
+	}
+
+	msg1 := NewMessage([]byte("one"))
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, msg1, nil))
+	cancel()
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, NewMessage([]byte("two")), nil))
+	cancel()
+
+	// each message carries the producer's ID and sequence number in its
+	// annotations and delivery tag
+	tt := waitTransfer()
+	require.Equal(t, []byte("prod-1"), tt.tag)
+	received := &Message{}
+	require.NoError(t, received.UnmarshalBinary(tt.payload))
+	v, ok := received.Annotations.Get(AnnotationProducerID)
+	require.True(t, ok)
+	require.Equal(t, "prod", v)
+	v, ok = received.Annotations.Get(AnnotationProducerSequence)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), v)
+
+	tt = waitTransfer()
+	require.Equal(t, []byte("prod-2"), tt.tag)
+
+	// a resent message reuses its original stamp
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, msg1, nil))
+	cancel()
+	tt = waitTransfer()
+	require.Equal(t, []byte("prod-1"), tt.tag)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSequenceStampingValidation(t *testing.T) {
+	// ProducerID is required when stamping is enabled
+	_, err := newSender("target", newSession(nil, 0, nil), &SenderOptions{
+		SequenceStamping: true,
+	})
+	require.Error(t, err)
+
+	// the producer ID must leave room for the sequence number in the delivery tag
+	_, err = newSender("target", newSession(nil, 0, nil), &SenderOptions{
+		ProducerID:       "this-producer-id-is-too-long",
+		SequenceStamping: true,
+	})
+	require.Error(t, err)
+
+	// a producer ID without stamping is likely a misconfiguration
+	_, err = newSender("target", newSession(nil, 0, nil), &SenderOptions{
+		ProducerID: "prod",
+	})
+	require.Error(t, err)
+}
+
+func TestSenderSendReader(t *testing.T) {
+	var deliveryID uint32
+	var received []byte
+	transferCount := 0
+	const maxReceiverFrameSize = 128
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{
+				ChannelMax:   65535,
+				ContainerID:  "container",
+				IdleTimeout:  time.Minute,
+				MaxFrameSize: maxReceiverFrameSize, // really small max frame size
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: b}, nil
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, tt.Name, 0, SenderSettleModeUnsettled))
+		case *frames.PerformTransfer:
+			if tt.DeliveryID != nil {
+				if transferCount != 0 {
+					return fake.Response{}, fmt.Errorf("unexpected DeliveryID for frame number %d", transferCount)
+				}
+				deliveryID = *tt.DeliveryID
+			}
+			received = append(received, tt.Payload...)
+			transferCount++
+			if tt.More {
+				return fake.Response{}, nil
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, deliveryID, nil, &encoding.StateAccepted{}))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{
+		ChunkSize: 8,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	// size must be positive
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	require.Error(t, snd.SendReader(ctx, bytes.NewReader(nil), 0, nil))
+	cancel()
+
+	payload := make([]byte, maxReceiverFrameSize*4)
+	for i := range payload {
+		payload[i] = byte(i % 256)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	require.NoError(t, snd.SendReader(ctx, bytes.NewReader(payload), int64(len(payload)), nil))
+	cancel()
+
+	// streamed in multiple transfer frames and reassembled verbatim
+	require.Greater(t, transferCount, 1)
+	require.Equal(t, payload, received)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderSendReaderShortRead(t *testing.T) {
+	const maxReceiverFrameSize = 128
+	aborted := make(chan struct{})
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformOpen{
+				ChannelMax:   65535,
+				ContainerID:  "container",
+				IdleTimeout:  time.Minute,
+				MaxFrameSize: maxReceiverFrameSize, // really small max frame size
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: b}, nil
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, req.Name, 0, SenderSettleModeUnsettled))
+		case *frames.PerformTransfer:
+			if req.Aborted {
+				close(aborted)
+			}
+			return fake.Response{}, nil
+		case *frames.PerformFlow:
+			return fake.Response{}, nil
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, 0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	// claim more than the reader's content; the delivery spans multiple
+	// frames and is aborted when the reader is exhausted early
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.SendReader(ctx, bytes.NewReader(make([]byte, 70)), 124, nil)
+	cancel()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	select {
+	case <-aborted:
+		// the in-flight delivery was aborted
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for aborting transfer")
+	}
+
+	require.NoError(t, client.Close())
+}
+
+func TestSenderAttachUnsettledMapExchange(t *testing.T) {
+	// the journal holds three in-doubt deliveries from a previous link
+	journal := NewMemoryDeliveryJournal()
+	require.NoError(t, journal.RecordUnsettled("resume", []byte("tag-done"), nil))
+	require.NoError(t, journal.RecordUnsettled("resume", []byte("tag-held"), nil))
+	require.NoError(t, journal.RecordUnsettled("resume", []byte("tag-lost"), nil))
+
+	var advertised encoding.Unsettled
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil {
+			return resp, err
+		}
+		tt, ok := req.(*frames.PerformAttach)
+		if !ok {
+			return resp, err
+		}
+		advertised = tt.Unsettled
+		// the peer has an outcome for one delivery, still holds another
+		// unresolved, and has no record of the third
+		mode := SenderSettleModeUnsettled
+		return newResponse(fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+			Name:             tt.Name,
+			Handle:           0,
+			Role:             encoding.RoleReceiver,
+			Target:           &frames.Target{Address: "test"},
+			SenderSettleMode: &mode,
+			Unsettled: encoding.Unsettled{
+				"tag-done": &encoding.StateAccepted{},
+				"tag-held": nil,
+			},
+		}))
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", &SenderOptions{
+		Name:            "resume",
+		DeliveryJournal: journal,
+	})
+	cancel()
+	require.NoError(t, err)
+
+	// the attach advertised every journaled delivery
+	require.Len(t, advertised, 3)
+	require.Contains(t, advertised, "tag-done")
+	require.Contains(t, advertised, "tag-held")
+	require.Contains(t, advertised, "tag-lost")
+
+	resolutions := snd.ResumedDeliveries()
+	require.Equal(t, []DeliveryResolution{
+		{DeliveryTag: []byte("tag-done"), State: &StateAccepted{}},
+		{DeliveryTag: []byte("tag-held")},
+		{DeliveryTag: []byte("tag-lost"), InDoubt: true},
+	}, resolutions)
+
+	// the confirmed delivery was settled in the journal; the others remain
+	unsettled, err := journal.Unsettled("resume")
+	require.NoError(t, err)
+	require.Len(t, unsettled, 2)
+	require.Contains(t, unsettled, "tag-held")
+	require.Contains(t, unsettled, "tag-lost")
+
+	require.NoError(t, client.Close())
+}
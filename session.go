@@ -4,52 +4,133 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Azure/go-amqp/internal/bitmap"
 	"github.com/Azure/go-amqp/internal/debug"
 	"github.com/Azure/go-amqp/internal/encoding"
 	"github.com/Azure/go-amqp/internal/frames"
 	"github.com/Azure/go-amqp/internal/queue"
+	"github.com/Azure/go-amqp/internal/ring"
+	"github.com/Azure/go-amqp/internal/serial"
 )
 
 // Default session options
 const (
 	defaultWindow = 5000
+
+	// size of the bounded ring buffers links use to send frames to the
+	// session mux.  producers block while a ring is full, bounding the
+	// session's outgoing memory footprint.
+	sessionTxRingSize = 128
 )
 
 // SessionOptions contains the optional settings for configuring an AMQP session.
 type SessionOptions struct {
+	// IncomingWindow is the number of incoming transfer frames the
+	// session will buffer before session-level flow control stalls the
+	// peer.  Larger values favor throughput; smaller values bound the
+	// memory a fast sender can occupy.
+	//
+	// Default: 0, meaning 5000.
+	IncomingWindow uint32
+
 	// MaxLinks sets the maximum number of links (Senders/Receivers)
 	// allowed on the session.
 	//
 	// Minimum: 1.
 	// Default: 4294967295.
 	MaxLinks uint32
+
+	// OnFlowEcho is called each time the session automatically responds
+	// to a peer's session-level flow frame that has the echo field set.
+	// It receives the flow-control state sent in the response.
+	//
+	// The callback is invoked from the session's event loop and MUST NOT
+	// block or call methods on the Session.
+	//
+	// Default: nil.
+	OnFlowEcho func(SessionFlow)
+
+	// OutgoingWindow is the number of outgoing transfer frames the
+	// session is willing to have in flight, as advertised to the peer
+	// in the begin frame.
+	//
+	// Default: 0, meaning 5000.
+	OutgoingWindow uint32
+
+	// Pipelined causes NewSession to send its Begin and return without
+	// waiting for the peer's reply.  Links created on the session send
+	// their Attach immediately after, collapsing session and link setup
+	// into a single round trip.  The AMQP spec permits this pipelining;
+	// if the peer rejects the session, the error surfaces on the first
+	// operation that requires the peer's reply (completing a link attach,
+	// sending, receiving, or closing).
+	//
+	// Default: false.
+	Pipelined bool
+
+	// SharedLinkMux indicates that all links created on the session are
+	// driven by a single event-loop goroutine instead of one mux goroutine
+	// per link.  This reduces scheduler and memory overhead for applications
+	// with a large number of mostly idle links, at the cost of dispatching
+	// all link events serially on one goroutine.
+	//
+	// Default: false.
+	SharedLinkMux bool
+}
+
+// SessionFlow is a snapshot of a session's flow-control state as sent
+// in a session-level flow frame.
+type SessionFlow struct {
+	// NextIncomingID is the transfer-id of the next incoming transfer frame.
+	NextIncomingID uint32
+
+	// IncomingWindow is the number of incoming transfer frames the session can receive.
+	IncomingWindow uint32
+
+	// NextOutgoingID is the transfer-id of the next outgoing transfer frame.
+	NextOutgoingID uint32
+
+	// OutgoingWindow is the number of outgoing transfer frames the session can send.
+	OutgoingWindow uint32
 }
 
 // Session is an AMQP session.
 //
 // A session multiplexes Receivers.
 type Session struct {
-	channel       uint16                 // session's local channel
-	remoteChannel uint16                 // session's remote channel, owned by conn.connReader
-	conn          *Conn                  // underlying conn
-	tx            chan frameBodyEnvelope // non-transfer frames to be sent; session must track disposition
-	txTransfer    chan transferEnvelope  // transfer frames to be sent; session must track disposition
+	channel       uint16                          // session's local channel
+	remoteChannel uint16                          // session's remote channel, owned by conn.connReader
+	conn          *Conn                           // underlying conn
+	tx            *ring.Buffer[frameBodyEnvelope] // non-transfer frames to be sent; session must track disposition
+	txTransfer    *ring.Buffer[transferEnvelope]  // transfer frames to be sent; session must track disposition
 
 	// frames destined for this session are added to this queue by conn.connReader
 	rxQ *queue.Holder[frames.FrameBody]
 
-	// properties returned by the peer
-	peerProperties map[string]any
+	// properties returned by the peer.  for a pipelined session they're
+	// written by the mux when the begin ack arrives, hence the mutex.
+	peerPropertiesMu sync.RWMutex
+	peerProperties   map[string]any
+
+	// the begin was sent without waiting for the peer's reply
+	// (SessionOptions.Pipelined)
+	pipelined bool
 
 	// flow control
 	incomingWindow uint32
 	outgoingWindow uint32
 	needFlowCount  uint32
 
+	// the peer's windows as most recently reported in its begin or flow
+	// frames; written by the mux, read by Session.Windows
+	peerIncomingWindow atomic.Uint32
+	peerOutgoingWindow atomic.Uint32
+
 	handleMax uint32
 
 	// link management
@@ -57,9 +138,19 @@ type Session struct {
 	linksByKey    map[linkKey]*link // mapping of name+role link
 	outputHandles *bitmap.Bitmap    // allocated output handles
 
+	handleQuarantine quarantine // delays handle reuse; guarded by linksMu
+
 	abandonedLinksMu sync.Mutex
 	abandonedLinks   []*link
 
+	// drives all link muxes when SessionOptions.SharedLinkMux is set.
+	// created in begin() so it's immutable once links can be created.
+	linkMux       *sharedLinkMuxer
+	sharedLinkMux bool
+
+	// called when responding to a session-level flow frame with echo set
+	onFlowEcho func(SessionFlow)
+
 	// used for gracefully closing session
 	close     chan struct{} // closed by calling Close(). it signals that the end performative should be sent
 	closeOnce sync.Once
@@ -75,8 +166,8 @@ func newSession(c *Conn, channel uint16, opts *SessionOptions) *Session {
 	s := &Session{
 		conn:           c,
 		channel:        channel,
-		tx:             make(chan frameBodyEnvelope),
-		txTransfer:     make(chan transferEnvelope),
+		tx:             ring.New[frameBodyEnvelope](sessionTxRingSize),
+		txTransfer:     ring.New[transferEnvelope](sessionTxRingSize),
 		incomingWindow: defaultWindow,
 		outgoingWindow: defaultWindow,
 		handleMax:      math.MaxUint32 - 1,
@@ -88,22 +179,63 @@ func newSession(c *Conn, channel uint16, opts *SessionOptions) *Session {
 	}
 
 	if opts != nil {
+		if opts.IncomingWindow > 0 {
+			s.incomingWindow = opts.IncomingWindow
+		}
+		if opts.OutgoingWindow > 0 {
+			s.outgoingWindow = opts.OutgoingWindow
+		}
 		if opts.MaxLinks != 0 {
 			// MaxLinks is the number of total links.
 			// handleMax is the max handle ID which starts
 			// at zero.  so we decrement by one
 			s.handleMax = opts.MaxLinks - 1
 		}
+		s.pipelined = opts.Pipelined
+		s.sharedLinkMux = opts.SharedLinkMux
+		s.onFlowEcho = opts.OnFlowEcho
 	}
 
 	// create output handle map after options have been applied
 	s.outputHandles = bitmap.New(s.handleMax)
+	if c != nil {
+		s.handleQuarantine.delay = c.handleReuseDelay
+	}
 
 	s.rxQ = queue.NewHolder(queue.New[frames.FrameBody](int(s.incomingWindow)))
 
 	return s
 }
 
+// SessionWindows is a snapshot of a session's transfer windows, as
+// returned by [Session.Windows].
+type SessionWindows struct {
+	// IncomingWindow is the number of incoming transfer frames the session can buffer.
+	IncomingWindow uint32
+
+	// OutgoingWindow is the number of outgoing transfer frames the session is willing to have in flight.
+	OutgoingWindow uint32
+
+	// RemoteIncomingWindow is the number of transfer frames the peer can receive.
+	RemoteIncomingWindow uint32
+
+	// RemoteOutgoingWindow is the number of transfer frames the peer is willing to send.
+	RemoteOutgoingWindow uint32
+}
+
+// Windows returns a snapshot of the session's transfer windows for
+// diagnostics.  The remote windows reflect the peer's most recent begin
+// or flow frame; peers aren't required to send a flow frame when their
+// windows change, so the values may be stale.
+func (s *Session) Windows() SessionWindows {
+	return SessionWindows{
+		IncomingWindow:       s.incomingWindow,
+		OutgoingWindow:       s.outgoingWindow,
+		RemoteIncomingWindow: s.peerIncomingWindow.Load(),
+		RemoteOutgoingWindow: s.peerOutgoingWindow.Load(),
+	}
+}
+
 // waitForFrame waits for an incoming frame to be queued.
 // it returns the next frame from the queue, or an error.
 // the error is either from the context or conn.doneErr.
@@ -138,6 +270,19 @@ func (s *Session) begin(ctx context.Context) error {
 		return err
 	}
 
+	if s.pipelined {
+		// don't wait for the peer's reply; start the mux now so frames
+		// for pipelined links go out immediately.  the mux seeds its
+		// flow-control state from the begin ack when it arrives.
+		if s.sharedLinkMux {
+			// the event loop exits when the session mux terminates
+			s.linkMux = newSharedLinkMuxer(s)
+		}
+		s.conn.logEvent("session begun", slog.Int("channel", int(s.channel)))
+		go s.mux(nil)
+		return nil
+	}
+
 	// wait for response
 	fr, err := s.waitForFrame(ctx)
 	if err != nil {
@@ -155,7 +300,7 @@ func (s *Session) begin(ctx context.Context) error {
 		// either swallow the frame or blow up in some other way, both causing this call to hang.
 		// deallocate session on error.  we can't call
 		// s.Close() as the session mux hasn't started yet.
-		debug.Log(1, "RX (Session %p): unexpected begin response frame %T", s, fr)
+		debug.LogC(s.conn.correlationID, 1, "RX (Session %p): unexpected begin response frame %T", s, fr)
 		s.conn.deleteSession(s)
 		if err := s.conn.Close(); err != nil {
 			return err
@@ -163,13 +308,15 @@ func (s *Session) begin(ctx context.Context) error {
 		return &ConnError{inner: fmt.Errorf("unexpected begin response: %#v", fr)}
 	}
 
-	if len(begin.Properties) > 0 {
-		s.peerProperties = map[string]any{}
-		for k, v := range begin.Properties {
-			s.peerProperties[string(k)] = v
-		}
+	s.setPeerProperties(begin.Properties)
+
+	if s.sharedLinkMux {
+		// the event loop exits when the session mux terminates
+		s.linkMux = newSharedLinkMuxer(s)
 	}
 
+	s.conn.logEvent("session begun", slog.Int("channel", int(s.channel)), slog.Int("remoteChannel", int(s.remoteChannel)))
+
 	// start Session multiplexor
 	go s.mux(begin)
 
@@ -201,7 +348,7 @@ func (s *Session) Close(ctx context.Context) error {
 
 			// record that the close timed out/was cancelled.
 			// subsequent calls to Close() will return this
-			debug.Log(1, "TX (Session %p) channel %d: %v", s, s.channel, ctxErr)
+			debug.LogC(s.conn.correlationID, 1, "TX (Session %p) channel %d: %v", s, s.channel, ctxErr)
 			s.closeErr = &SessionError{inner: ctxErr}
 		}
 	})
@@ -222,7 +369,7 @@ func (s *Session) Close(ctx context.Context) error {
 //   - ctx is used to provide the write deadline
 //   - fr is the frame to write to net.Conn
 func (s *Session) txFrame(frameCtx *frameContext, fr frames.FrameBody) {
-	debug.Log(2, "TX (Session %p) mux frame to Conn (%p): %s", s, s.conn, fr)
+	debug.LogC(s.conn.correlationID, 2, "TX (Session %p) mux frame to Conn (%p): %s", s, s.conn, fr)
 	s.conn.sendFrame(frameEnvelope{
 		FrameCtx: frameCtx,
 		Frame: frames.Frame{
@@ -266,17 +413,60 @@ func (s *Session) NewReceiver(ctx context.Context, source string, opts *Receiver
 	return newReceiverForSession(ctx, s, source, opts, receiverTestHooks{})
 }
 
+// NewTemporaryReceiver opens a receiver link attached to a temporary node
+// created by the peer, covering the "temporary reply queue" idiom in one
+// call.  The source uses a dynamic address with a delete-on-close
+// (link-detach) lifetime, so the node is removed when the link closes.
+// The peer-assigned address is available via [Receiver.Address] for use
+// as a message's reply-to.
+//   - ctx controls waiting for the peer to create the node
+//   - opts contains optional values, pass nil to accept the defaults
+//
+// Any DynamicAddress, SourceDurability, or SourceExpiryPolicy values in
+// opts are overridden.
+func (s *Session) NewTemporaryReceiver(ctx context.Context, opts *ReceiverOptions) (*Receiver, error) {
+	var temp ReceiverOptions
+	if opts != nil {
+		temp = *opts
+	}
+	temp.DynamicAddress = true
+	temp.SourceDurability = DurabilityNone
+	temp.SourceExpiryPolicy = ExpiryPolicyLinkDetach
+	return newReceiverForSession(ctx, s, "", &temp, receiverTestHooks{})
+}
+
 // split out so tests can add hooks
 func newReceiverForSession(ctx context.Context, s *Session, source string, opts *ReceiverOptions, hooks receiverTestHooks) (*Receiver, error) {
-	r, err := newReceiver(source, s, opts)
+	var retry *AttachRetryOptions
+	if opts != nil {
+		retry = opts.AttachRetry
+	}
+	r, err := attachWithRetry(ctx, retry, func() (*Receiver, error) {
+		return newReceiver(source, s, opts)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err = r.attach(ctx); err != nil {
-		return nil, err
+
+	if r.inlineReceive {
+		if err := r.inlineInit(); err != nil {
+			return nil, err
+		}
+	} else if s.linkMux != nil {
+		s.linkMux.add(r)
+	} else {
+		go r.mux(hooks)
+	}
+
+	if r.l.idleTimeout > 0 {
+		r.l.markActive()
+		go r.l.monitorIdle()
 	}
 
-	go r.mux(hooks)
+	r.refreshAddr = source
+	if opts != nil {
+		r.refreshOpts = *opts
+	}
 
 	return r, nil
 }
@@ -294,28 +484,63 @@ func (s *Session) NewSender(ctx context.Context, target string, opts *SenderOpti
 }
 
 // Properties returns the peer's session properties.
-// Returns nil if the peer didn't send any properties.
+// Returns nil if the peer didn't send any properties, or if the session
+// was created with [SessionOptions.Pipelined] and the peer's begin
+// hasn't arrived yet.
 func (s *Session) Properties() map[string]any {
+	s.peerPropertiesMu.RLock()
+	defer s.peerPropertiesMu.RUnlock()
 	return s.peerProperties
 }
 
+func (s *Session) setPeerProperties(props map[encoding.Symbol]any) {
+	if len(props) == 0 {
+		return
+	}
+	s.peerPropertiesMu.Lock()
+	defer s.peerPropertiesMu.Unlock()
+	s.peerProperties = map[string]any{}
+	for k, v := range props {
+		s.peerProperties[string(k)] = v
+	}
+}
+
 // split out so tests can add hooks
 func newSenderForSession(ctx context.Context, s *Session, target string, opts *SenderOptions, hooks senderTestHooks) (*Sender, error) {
-	l, err := newSender(target, s, opts)
+	var retry *AttachRetryOptions
+	if opts != nil {
+		retry = opts.AttachRetry
+	}
+	l, err := attachWithRetry(ctx, retry, func() (*Sender, error) {
+		return newSender(target, s, opts)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err = l.attach(ctx); err != nil {
-		return nil, err
+
+	if s.linkMux != nil {
+		s.linkMux.add(l)
+	} else {
+		go l.mux(hooks)
+	}
+
+	if l.l.idleTimeout > 0 {
+		l.l.markActive()
+		go l.l.monitorIdle()
 	}
 
-	go l.mux(hooks)
+	l.refreshAddr = target
+	if opts != nil {
+		l.refreshOpts = *opts
+	}
 
 	return l, nil
 }
 
 func (s *Session) mux(remoteBegin *frames.PerformBegin) {
+	expvarAdd(expvarActiveSessions, 1)
 	defer func() {
+		expvarAdd(expvarActiveSessions, -1)
 		if s.doneErr == nil {
 			s.doneErr = &SessionError{}
 		} else if connErr := (&ConnError{}); !errors.As(s.doneErr, &connErr) {
@@ -327,10 +552,27 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 				s.doneErr = &SessionError{inner: s.doneErr}
 			}
 		}
+		s.conn.logEvent("session ended", slog.Int("channel", int(s.channel)), slog.Any("error", s.doneErr))
 		// Signal goroutines waiting on the session.
 		close(s.done)
 	}()
 
+	defer func() {
+		// NOTE: this must be declared after the defer above so that it runs
+		// first, converting the panic into the mux's terminal error state.
+		// a panic leaves the session in an undefined state, so close the
+		// owning connection rather than crashing the process.
+		if v := recover(); v != nil {
+			s.doneErr = muxPanicError(v)
+			s.conn.close()
+		}
+	}()
+
+	var muxProgress uint64
+	if s.conn.watchdogTimeout > 0 {
+		go watchMux(fmt.Sprintf("session %d", s.channel), s.conn.watchdogTimeout, s.conn.watchdogHandler, &muxProgress, s.rxQ.Len, s.done)
+	}
+
 	var (
 		// maps input (remote) handles to links
 		linkFromInputHandle = make(map[uint32]*link)
@@ -350,18 +592,28 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 		// tracks the next delivery ID for outgoing transfers
 		nextDeliveryID uint32
 
-		// flow control values
+		// flow control values.  for a pipelined session remoteBegin is nil
+		// and these are seeded when the begin ack arrives; the zero remote
+		// incoming window keeps transfers parked until then.
 		nextOutgoingID       uint32
-		nextIncomingID       = remoteBegin.NextOutgoingID
-		remoteIncomingWindow = remoteBegin.IncomingWindow
-		remoteOutgoingWindow = remoteBegin.OutgoingWindow
+		nextIncomingID       uint32
+		remoteIncomingWindow uint32
+		remoteOutgoingWindow uint32
 
 		closeInProgress bool // indicates the end performative has been sent
 	)
 
+	if remoteBegin != nil {
+		nextIncomingID = remoteBegin.NextOutgoingID
+		remoteIncomingWindow = remoteBegin.IncomingWindow
+		remoteOutgoingWindow = remoteBegin.OutgoingWindow
+		s.peerIncomingWindow.Store(remoteIncomingWindow)
+		s.peerOutgoingWindow.Store(remoteOutgoingWindow)
+	}
+
 	closeWithError := func(e1 *Error, e2 error) {
 		if closeInProgress {
-			debug.Log(3, "TX (Session %p): close already pending, discarding %v", s, e1)
+			debug.LogC(s.conn.correlationID, 3, "TX (Session %p): close already pending, discarding %v", s, e1)
 			return
 		}
 
@@ -372,15 +624,17 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 	}
 
 	for {
-		txTransfer := s.txTransfer
+		atomic.AddUint64(&muxProgress, 1)
+
+		txTransfer := s.txTransfer.Wait()
 		// disable txTransfer if flow control windows have been exceeded
 		if remoteIncomingWindow == 0 || s.outgoingWindow == 0 {
-			debug.Log(1, "TX (Session %p): disabling txTransfer - window exceeded. remoteIncomingWindow: %d outgoingWindow: %d",
+			debug.LogC(s.conn.correlationID, 1, "TX (Session %p): disabling txTransfer - window exceeded. remoteIncomingWindow: %d outgoingWindow: %d",
 				s, remoteIncomingWindow, s.outgoingWindow)
 			txTransfer = nil
 		}
 
-		tx := s.tx
+		tx := s.tx.Wait()
 		closed := s.close
 		if closeInProgress {
 			// swap out channel so it no longer triggers
@@ -420,9 +674,19 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 		case q := <-s.rxQ.Wait():
 			fr := *q.Dequeue()
 			s.rxQ.Release(q)
-			debug.Log(2, "RX (Session %p): %s", s, fr)
+			debug.LogC(s.conn.correlationID, 2, "RX (Session %p): %s", s, fr)
 
 			switch body := fr.(type) {
+			// the begin ack for a pipelined session; a non-pipelined
+			// session consumes it in begin() before the mux starts
+			case *frames.PerformBegin:
+				nextIncomingID = body.NextOutgoingID
+				remoteIncomingWindow = body.IncomingWindow
+				remoteOutgoingWindow = body.OutgoingWindow
+				s.peerIncomingWindow.Store(remoteIncomingWindow)
+				s.peerOutgoingWindow.Store(remoteOutgoingWindow)
+				s.setPeerProperties(body.Properties)
+
 			// Disposition frames can reference transfers from more than one
 			// link. Send this frame to all of them.
 			case *frames.PerformDisposition:
@@ -431,7 +695,16 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 				if body.Last != nil {
 					end = *body.Last
 				}
-				for deliveryID := start; deliveryID <= end; deliveryID++ {
+				if serial.Less(end, start) {
+					// a descending range is a protocol error; ignore it rather
+					// than iterating over nearly the full sequence number space
+					debug.LogC(s.conn.correlationID, 2, "RX (Session %p): ignoring disposition frame with descending range %d to %d", s, start, end)
+					continue
+				}
+				// delivery IDs are serial numbers, so iterate by count to
+				// remain correct when the range wraps past the max uint32
+				for i := uint64(0); i <= uint64(end-start); i++ {
+					deliveryID := start + uint32(i)
 					// find the input (remote) handle for this delivery ID.
 					// default to the map for local delivery IDs.
 					handles := inputHandleFromDeliveryID
@@ -443,7 +716,7 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 
 					inputHandle, ok := handles[deliveryID]
 					if !ok {
-						debug.Log(2, "RX (Session %p): role %s: didn't find deliveryID %d in inputHandlesByDeliveryID map", s, body.Role, deliveryID)
+						debug.LogC(s.conn.correlationID, 2, "RX (Session %p): role %s: didn't find deliveryID %d in inputHandlesByDeliveryID map", s, body.Role, deliveryID)
 						continue
 					}
 					delete(handles, deliveryID)
@@ -486,6 +759,17 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 					continue
 				}
 
+				// "If the handle field is not set, this field MUST NOT be set."
+				// applies to the link-level fields delivery-count, link-credit,
+				// available, and drain.
+				if s.conn.strictValidation && body.Handle == nil && (body.DeliveryCount != nil || body.LinkCredit != nil || body.Available != nil || body.Drain) {
+					closeWithError(&Error{
+						Condition:   ErrCondNotAllowed,
+						Description: "received flow frame with link fields but no handle",
+					}, errors.New("protocol error: received flow with link fields but no handle"))
+					continue
+				}
+
 				// "When the endpoint receives a flow frame from its peer,
 				// it MUST update the next-incoming-id directly from the
 				// next-outgoing-id of the frame, and it MUST update the
@@ -503,7 +787,9 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 				// initial-outgoing-id(endpoint) + incoming-window(flow) - next-outgoing-id(endpoint)"
 				remoteIncomingWindow = body.IncomingWindow - nextOutgoingID
 				remoteIncomingWindow += *body.NextIncomingID
-				debug.Log(3, "RX (Session %p): flow - remoteOutgoingWindow: %d remoteIncomingWindow: %d nextOutgoingID: %d", s, remoteOutgoingWindow, remoteIncomingWindow, nextOutgoingID)
+				s.peerIncomingWindow.Store(remoteIncomingWindow)
+				s.peerOutgoingWindow.Store(remoteOutgoingWindow)
+				debug.LogC(s.conn.correlationID, 3, "RX (Session %p): flow - remoteOutgoingWindow: %d remoteIncomingWindow: %d nextOutgoingID: %d", s, remoteOutgoingWindow, remoteIncomingWindow, nextOutgoingID)
 
 				// Send to link if handle is set
 				if body.Handle != nil {
@@ -529,9 +815,29 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 						OutgoingWindow: s.outgoingWindow,
 					}
 					s.txFrame(&frameContext{Ctx: context.Background()}, resp)
+					if s.onFlowEcho != nil {
+						s.onFlowEcho(SessionFlow{
+							NextIncomingID: niID,
+							IncomingWindow: s.incomingWindow,
+							NextOutgoingID: nextOutgoingID,
+							OutgoingWindow: s.outgoingWindow,
+						})
+					}
 				}
 
 			case *frames.PerformAttach:
+				// "To make it easier to monitor AMQP link attach frames, it is
+				// RECOMMENDED that implementations always assign the lowest
+				// available handle to this field."
+				// a handle above the negotiated handle-max is a framing error.
+				if s.conn.strictValidation && body.Handle > s.handleMax {
+					closeWithError(&Error{
+						Condition:   ErrCondFramingError,
+						Description: "received attach frame with handle greater than handle-max",
+					}, fmt.Errorf("protocol error: received attach with handle %d greater than handle-max %d", body.Handle, s.handleMax))
+					continue
+				}
+
 				// On Attach response link should be looked up by name, then added
 				// to the links map with the remote's handle contained in this
 				// attach frame.
@@ -555,7 +861,7 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 
 				s.muxFrameToLink(link, fr)
 
-				debug.Log(1, "RX (Session %p): link %s attached, input handle %d, output handle %d", s, link.key.name, link.inputHandle, link.outputHandle)
+				debug.LogC(s.conn.correlationID, 1, "RX (Session %p): link %s attached, input handle %d, output handle %d", s, link.key.name, link.inputHandle, link.outputHandle)
 
 			case *frames.PerformTransfer:
 				s.needFlowCount++
@@ -582,13 +888,13 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 
 				// if this message is received unsettled and link rcv-settle-mode == second, add to handlesByRemoteDeliveryID
 				if !body.Settled && body.DeliveryID != nil && link.receiverSettleMode != nil && *link.receiverSettleMode == ReceiverSettleModeSecond {
-					debug.Log(1, "RX (Session %p): adding handle %d to inputHandleFromRemoteDeliveryID. remote delivery ID: %d", s, body.Handle, *body.DeliveryID)
+					debug.LogC(s.conn.correlationID, 1, "RX (Session %p): adding handle %d to inputHandleFromRemoteDeliveryID. remote delivery ID: %d", s, body.Handle, *body.DeliveryID)
 					inputHandleFromRemoteDeliveryID[*body.DeliveryID] = body.Handle
 				}
 
 				// Update peer's outgoing window if half has been consumed.
 				if s.needFlowCount >= s.incomingWindow/2 && !closeInProgress {
-					debug.Log(3, "RX (Session %p): channel %d: flow - s.needFlowCount(%d) >= s.incomingWindow(%d)/2\n", s, s.channel, s.needFlowCount, s.incomingWindow)
+					debug.LogC(s.conn.correlationID, 3, "RX (Session %p): channel %d: flow - s.needFlowCount(%d) >= s.incomingWindow(%d)/2\n", s, s.channel, s.needFlowCount, s.incomingWindow)
 					s.needFlowCount = 0
 					nID := nextIncomingID
 					flow := &frames.PerformFlow{
@@ -641,14 +947,18 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 				return
 
 			default:
-				debug.Log(1, "RX (Session %p): unexpected frame: %s\n", s, body)
+				debug.LogC(s.conn.correlationID, 1, "RX (Session %p): unexpected frame: %s\n", s, body)
 				closeWithError(&Error{
 					Condition:   ErrCondInternalError,
 					Description: "session received unexpected frame",
 				}, fmt.Errorf("internal error: unexpected frame %T", body))
 			}
 
-		case env := <-txTransfer:
+		case <-txTransfer:
+			env, ok := s.txTransfer.Dequeue()
+			if !ok {
+				continue
+			}
 			fr := &env.Frame
 			// record current delivery ID
 			var deliveryID uint32
@@ -668,7 +978,7 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 			}
 
 			// log after the delivery ID has been assigned
-			debug.Log(2, "TX (Session %p): %d, %s", s, s.channel, fr)
+			debug.LogC(s.conn.correlationID, 2, "TX (Session %p): %d, %s", s, s.channel, fr)
 
 			// frame has been sender-settled, remove from map.
 			// this should only come into play for multi-frame transfers.
@@ -707,9 +1017,13 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 				remoteIncomingWindow--
 			}
 
-		case env := <-tx:
+		case <-tx:
+			env, ok := s.tx.Dequeue()
+			if !ok {
+				continue
+			}
 			fr := env.FrameBody
-			debug.Log(2, "TX (Session %p): %d, %s", s, s.channel, fr)
+			debug.LogC(s.conn.correlationID, 2, "TX (Session %p): %d, %s", s, s.channel, fr)
 			switch fr := env.FrameBody.(type) {
 			case *frames.PerformDisposition:
 				if fr.Settled && fr.Role == encoding.RoleSender {
@@ -718,10 +1032,13 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 					// likely in this case there will only be one.
 					start := fr.First
 					end := start
-					if fr.Last != nil {
+					if fr.Last != nil && !serial.Less(*fr.Last, start) {
 						end = *fr.Last
 					}
-					for deliveryID := start; deliveryID <= end; deliveryID++ {
+					// delivery IDs are serial numbers, so iterate by count to
+					// remain correct when the range wraps past the max uint32
+					for i := uint64(0); i <= uint64(end-start); i++ {
+						deliveryID := start + uint32(i)
 						// send delivery state to the channel and close it to signal
 						// that the delivery has completed (RSM == ModeSecond)
 						if done, ok := settlementFromDeliveryID[deliveryID]; ok {
@@ -751,6 +1068,15 @@ func (s *Session) mux(remoteBegin *frames.PerformBegin) {
 	}
 }
 
+// LinkCount returns the number of links (Senders and Receivers)
+// currently attached to the session.  Use with [SessionOptions.MaxLinks]
+// to detect link leaks before the session's handle-max is exhausted.
+func (s *Session) LinkCount() int {
+	s.linksMu.RLock()
+	defer s.linksMu.RUnlock()
+	return len(s.linksByKey)
+}
+
 func (s *Session) allocateHandle(ctx context.Context, l *link) error {
 	s.linksMu.Lock()
 	defer s.linksMu.Unlock()
@@ -761,6 +1087,11 @@ func (s *Session) allocateHandle(ctx context.Context, l *link) error {
 		return fmt.Errorf("link with name '%v' already exists", l.key.name)
 	}
 
+	// return handles whose reuse grace period has elapsed
+	for _, handle := range s.handleQuarantine.release() {
+		s.outputHandles.Remove(handle)
+	}
+
 	next, ok := s.outputHandles.Next()
 	if !ok {
 		if err := s.Close(ctx); err != nil {
@@ -781,7 +1112,9 @@ func (s *Session) deallocateHandle(l *link) {
 	defer s.linksMu.Unlock()
 
 	delete(s.linksByKey, l.key)
-	s.outputHandles.Remove(l.outputHandle)
+	if !s.handleQuarantine.hold(l.outputHandle) {
+		s.outputHandles.Remove(l.outputHandle)
+	}
 }
 
 func (s *Session) abandonLink(l *link) {
@@ -794,7 +1127,7 @@ func (s *Session) freeAbandonedLinks(ctx context.Context) error {
 	s.abandonedLinksMu.Lock()
 	defer s.abandonedLinksMu.Unlock()
 
-	debug.Log(3, "TX (Session %p): cleaning up %d abandoned links", s, len(s.abandonedLinks))
+	debug.LogC(s.conn.correlationID, 3, "TX (Session %p): cleaning up %d abandoned links", s, len(s.abandonedLinks))
 
 	for _, l := range s.abandonedLinks {
 		dr := &frames.PerformDetach{
@@ -814,7 +1147,7 @@ func (s *Session) muxFrameToLink(l *link, fr frames.FrameBody) {
 	q := l.rxQ.Acquire()
 	q.Enqueue(fr)
 	l.rxQ.Release(q)
-	debug.Log(2, "RX (Session %p): mux frame to link (%p): %s, %s", s, l, l.key.name, fr)
+	debug.LogC(s.conn.correlationID, 2, "RX (Session %p): mux frame to link (%p): %s, %s", s, l, l.key.name, fr)
 }
 
 // transferEnvelope is used by senders to send transfer frames
@@ -498,8 +498,13 @@ func TestSessionFlowFrameWithEcho(t *testing.T) {
 	cancel()
 	require.NoError(t, err)
 
+	flows := make(chan SessionFlow, 1)
 	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
-	session, err := client.NewSession(ctx, nil)
+	session, err := client.NewSession(ctx, &SessionOptions{
+		OnFlowEcho: func(f SessionFlow) {
+			flows <- f
+		},
+	})
 	cancel()
 	require.NoError(t, err)
 
@@ -513,6 +518,17 @@ func TestSessionFlowFrameWithEcho(t *testing.T) {
 	require.NoError(t, err)
 	netConn.SendFrame(b)
 
+	select {
+	case flow := <-flows:
+		require.Equal(t, SessionFlow{
+			NextIncomingID: nextOutgoingID,
+			IncomingWindow: defaultWindow,
+			OutgoingWindow: defaultWindow,
+		}, flow)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnFlowEcho callback")
+	}
+
 	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
 	err = session.Close(ctx)
 	cancel()
@@ -714,3 +730,480 @@ func TestSessionProperties(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, client.Close())
 }
+
+func TestSessionSharedLinkMux(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformAttach:
+			if tt.Role == encoding.RoleSender {
+				return newResponse(fake.SenderAttach(0, tt.Name, tt.Handle, SenderSettleModeUnsettled))
+			}
+			return newResponse(fake.ReceiverAttach(0, tt.Name, tt.Handle, ReceiverSettleModeFirst, tt.Source.Filter))
+		case *frames.PerformTransfer:
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, tt.Handle, nil))
+		case *frames.PerformFlow, *frames.PerformDisposition, *fake.KeepAlive:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, &SessionOptions{SharedLinkMux: true})
+	cancel()
+	require.NoError(t, err)
+	require.NotNil(t, session.linkMux)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	rcv, err := session.NewReceiver(ctx, "source", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	// both links are driven by the session's shared event loop
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = snd.Send(ctx, NewMessage([]byte("hello")), nil)
+	cancel()
+	require.NoError(t, err)
+
+	b, err := fake.PerformTransfer(0, 1, 1, []byte("world"))
+	require.NoError(t, err)
+	netConn.SendFrame(b)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	msg, err := rcv.Receive(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, []byte("world"), msg.GetData())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, rcv.AcceptMessage(ctx, msg))
+	cancel()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Close(ctx))
+	cancel()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, rcv.Close(ctx))
+	cancel()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, session.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestSessionStrictValidationFlowFrame(t *testing.T) {
+	netConn := fake.NewNetConn(senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled), fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{StrictValidation: true})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// link-level fields MUST NOT be set on a flow frame without a handle
+	nextIncoming := uint32(1)
+	linkCredit := uint32(100)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+		NextIncomingID: &nextIncoming,
+		IncomingWindow: 1000,
+		NextOutgoingID: 1,
+		OutgoingWindow: 1000,
+		LinkCredit:     &linkCredit,
+	})
+	require.NoError(t, err)
+	netConn.SendFrame(b)
+
+	// wait a bit for connReader to read from the mock
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	err = session.Close(ctx)
+	cancel()
+	var sessionErr *SessionError
+	require.ErrorAs(t, err, &sessionErr)
+	require.NoError(t, client.Close())
+}
+
+func TestSessionStrictValidationAttachAboveHandleMax(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformAttach:
+			// respond with a handle greater than the negotiated handle-max of zero
+			return newResponse(fake.SenderAttach(0, tt.Name, 1, SenderSettleModeUnsettled))
+		default:
+			return senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{StrictValidation: true})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, &SessionOptions{MaxLinks: 1})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.Error(t, err)
+	require.Nil(t, snd)
+	require.NoError(t, client.Close())
+}
+
+func TestConnSessionAndLinkCounts(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformAttach:
+			return newResponse(fake.SenderAttach(0, tt.Name, tt.Handle, SenderSettleModeUnsettled))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, tt.Handle, nil))
+		default:
+			return senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Zero(t, client.SessionCount())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, 1, client.SessionCount())
+	require.Zero(t, session.LinkCount())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd1, err := session.NewSender(ctx, "target1", nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewSender(ctx, "target2", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, 2, session.LinkCount())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd1.Close(ctx))
+	cancel()
+	require.Equal(t, 1, session.LinkCount())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, session.Close(ctx))
+	cancel()
+	require.Zero(t, client.SessionCount())
+
+	require.NoError(t, client.Close())
+}
+
+func TestSessionLinkHandleReuse(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformAttach:
+			// mirror the client's handle so each link is distinct
+			return newResponse(fake.SenderAttach(0, tt.Name, tt.Handle, SenderSettleModeUnsettled))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, tt.Handle, nil))
+		default:
+			return senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd1, err := session.NewSender(ctx, "target1", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), snd1.LinkHandle())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd2, err := session.NewSender(ctx, "target2", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), snd2.LinkHandle())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd1.Close(ctx))
+	cancel()
+
+	// the lowest available handle is reused
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd3, err := session.NewSender(ctx, "target3", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), snd3.LinkHandle())
+
+	require.NoError(t, client.Close())
+}
+
+func TestSessionPipelined(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, &SessionOptions{Pipelined: true})
+	cancel()
+	require.NoError(t, err)
+
+	// the attach is sent before the peer has acknowledged the begin
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	// sending requires the flow-control state seeded from the begin ack
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, NewMessage([]byte("test")), nil))
+	cancel()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, session.Close(ctx))
+	cancel()
+	require.NoError(t, client.Close())
+}
+
+func TestSessionPipelinedPeerRejection(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *frames.PerformBegin:
+			// ack the begin then immediately end the session
+			begin, err := fake.PerformBegin(0, remoteChannel)
+			if err != nil {
+				return fake.Response{}, err
+			}
+			end, err := fake.PerformEnd(0, &encoding.Error{
+				Condition:   ErrCondResourceLimitExceeded,
+				Description: "too many sessions",
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: append(begin, end...)}, nil
+		case *frames.PerformAttach:
+			// the pipelined attach can arrive after we've ended the
+			// session; a real peer ignores frames on an ended session
+			return fake.Response{}, nil
+		case *frames.PerformEnd:
+			// the client's ack to our end
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	// the session is returned before the peer has rejected it
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, &SessionOptions{Pipelined: true})
+	cancel()
+	require.NoError(t, err)
+
+	// the rejection surfaces on the first operation requiring the peer
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.Nil(t, snd)
+	var sessionErr *SessionError
+	require.ErrorAs(t, err, &sessionErr)
+	require.NotNil(t, sessionErr.RemoteErr)
+	require.Equal(t, ErrCondResourceLimitExceeded, sessionErr.RemoteErr.Condition)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSessionLinkHandleReuseDelay(t *testing.T) {
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformAttach:
+			// mirror the client's handle so each link is distinct
+			return newResponse(fake.SenderAttach(0, tt.Name, tt.Handle, SenderSettleModeUnsettled))
+		case *frames.PerformDetach:
+			return newResponse(fake.PerformDetach(0, tt.Handle, nil))
+		default:
+			return senderFrameHandlerNoUnhandled(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{
+		HandleReuseDelay: 100 * time.Millisecond,
+	})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd1, err := session.NewSender(ctx, "target1", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), snd1.LinkHandle())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd1.Close(ctx))
+	cancel()
+
+	// handle 0 is quarantined so the next link skips over it
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd2, err := session.NewSender(ctx, "target2", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), snd2.LinkHandle())
+
+	// once the grace period elapses, handle 0 becomes available again
+	time.Sleep(200 * time.Millisecond)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd3, err := session.NewSender(ctx, "target3", nil)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), snd3.LinkHandle())
+
+	require.NoError(t, client.Close())
+}
+
+func TestSessionWindows(t *testing.T) {
+	begins := make(chan *frames.PerformBegin, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			begins <- tt
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, &SessionOptions{
+		IncomingWindow: 123,
+		OutgoingWindow: 456,
+	})
+	cancel()
+	require.NoError(t, err)
+
+	// the configured windows are advertised in the begin frame
+	begin := <-begins
+	require.EqualValues(t, 123, begin.IncomingWindow)
+	require.EqualValues(t, 456, begin.OutgoingWindow)
+
+	// the remote windows are seeded from the peer's begin when the mux
+	// starts (fake.PerformBegin advertises 5000/1000)
+	windows := session.Windows()
+	require.EqualValues(t, 123, windows.IncomingWindow)
+	require.EqualValues(t, 456, windows.OutgoingWindow)
+	require.Eventually(t, func() bool {
+		w := session.Windows()
+		return w.RemoteIncomingWindow == 5000 && w.RemoteOutgoingWindow == 1000
+	}, time.Second, 10*time.Millisecond)
+
+	// a session flow frame updates the remote windows
+	nextIncomingID := uint32(1)
+	b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+		NextIncomingID: &nextIncomingID,
+		IncomingWindow: 777,
+		NextOutgoingID: 1,
+		OutgoingWindow: 888,
+	})
+	require.NoError(t, err)
+	netConn.SendFrame(b)
+
+	require.Eventually(t, func() bool {
+		w := session.Windows()
+		return w.RemoteIncomingWindow == 778 && w.RemoteOutgoingWindow == 888
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.Close())
+}
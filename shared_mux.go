@@ -0,0 +1,193 @@
+package amqp
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// sharedMuxLink is implemented by senders and receivers so a sharedLinkMuxer
+// can drive their muxes in place of a dedicated goroutine per link.
+type sharedMuxLink interface {
+	// muxInit performs one-time mux initialization.
+	// it returns false if the mux must terminate.
+	muxInit() bool
+
+	// muxPoll runs the per-wakeup bookkeeping and returns the link's current
+	// select cases.  exit is true if the mux must terminate.
+	muxPoll() (cases []reflect.SelectCase, exit bool)
+
+	// muxEvent handles the event received for cases[i] as returned by muxPoll.
+	// it returns true if the mux must terminate.
+	muxEvent(i int, v reflect.Value) bool
+
+	// muxPending returns the count of rx frames waiting to be processed.
+	muxPending() int
+
+	// muxFatal records err as the link's terminal error state.
+	muxFatal(err error)
+
+	// muxDone releases the mux's resources.
+	// it's called exactly once when the mux terminates.
+	muxDone()
+}
+
+// sharedLinkMuxer drives the muxes of all links on a session from a single
+// event-loop goroutine, trading per-event dispatch cost (linear in the number
+// of links) for the scheduler and memory overhead of one goroutine per link.
+// enabled via SessionOptions.SharedLinkMux.
+type sharedLinkMuxer struct {
+	session *Session
+	reg     chan sharedMuxLink
+
+	// links driven by the event loop.  mutated only by the event loop;
+	// the mutex exists so the watchdog can safely sample pending work.
+	mu    sync.Mutex
+	links map[sharedMuxLink]struct{}
+}
+
+func newSharedLinkMuxer(s *Session) *sharedLinkMuxer {
+	m := &sharedLinkMuxer{
+		session: s,
+		reg:     make(chan sharedMuxLink),
+		links:   map[sharedMuxLink]struct{}{},
+	}
+	go m.run()
+	return m
+}
+
+// add hands lnk over to the event loop.
+// it must only be called once the link's attach has completed.
+func (m *sharedLinkMuxer) add(lnk sharedMuxLink) {
+	select {
+	case m.reg <- lnk:
+		// the event loop now owns the link
+	case <-m.session.done:
+		// the event loop has terminated; finish the link with the session's error
+		lnk.muxFatal(m.session.doneErr)
+		lnk.muxDone()
+	}
+}
+
+// pending returns the count of rx frames waiting across all links.
+// called by the watchdog to distinguish an idle event loop from a stalled one.
+func (m *sharedLinkMuxer) pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count int
+	for lnk := range m.links {
+		count += lnk.muxPending()
+	}
+	return count
+}
+
+// guard invokes fn, converting a panic into the link's terminal error state.
+// a panic leaves the link and its session in an undefined state, so close the
+// owning connection rather than crashing the process.
+func (m *sharedLinkMuxer) guard(lnk sharedMuxLink, fn func() bool) (exit bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			lnk.muxFatal(&LinkError{inner: muxPanicError(v)})
+			m.session.conn.close()
+			exit = true
+		}
+	}()
+	return fn()
+}
+
+func (m *sharedLinkMuxer) run() {
+	type muxEntry struct {
+		link  sharedMuxLink
+		cases []reflect.SelectCase
+	}
+
+	// owned exclusively by the event loop
+	var entries []*muxEntry
+
+	var muxProgress uint64
+	if wt := m.session.conn.watchdogTimeout; wt > 0 {
+		go watchMux(fmt.Sprintf("shared link mux on session channel %d", m.session.channel), wt, m.session.conn.watchdogHandler, &muxProgress, m.pending, m.session.done)
+	}
+
+	// the fixed cases precede the per-link cases
+	const (
+		caseReg = iota
+		caseSessionDone
+		caseFixedCount
+	)
+
+	// retire removes entries[i] after its mux has terminated
+	retire := func(i int) {
+		lnk := entries[i].link
+		entries = append(entries[:i], entries[i+1:]...)
+		m.mu.Lock()
+		delete(m.links, lnk)
+		m.mu.Unlock()
+		lnk.muxDone()
+	}
+
+	// poll refreshes the select cases for entries[i],
+	// retiring the link if its mux must terminate
+	poll := func(i int) {
+		e := entries[i]
+		var cases []reflect.SelectCase
+		if exit := m.guard(e.link, func() (exit bool) {
+			cases, exit = e.link.muxPoll()
+			return
+		}); exit {
+			retire(i)
+			return
+		}
+		e.cases = cases
+	}
+
+	for {
+		atomic.AddUint64(&muxProgress, 1)
+
+		cases := make([]reflect.SelectCase, caseFixedCount, caseFixedCount+len(entries)*6)
+		cases[caseReg] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.reg)}
+		cases[caseSessionDone] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.session.done)}
+
+		// starts[i] is the index within cases of the first case for entries[i]
+		starts := make([]int, len(entries))
+		for i, e := range entries {
+			starts[i] = len(cases)
+			cases = append(cases, e.cases...)
+		}
+
+		chosen, v, _ := reflect.Select(cases)
+		switch chosen {
+		case caseReg:
+			lnk := v.Interface().(sharedMuxLink)
+			if exit := m.guard(lnk, func() bool { return !lnk.muxInit() }); exit {
+				lnk.muxDone()
+				continue
+			}
+			entries = append(entries, &muxEntry{link: lnk})
+			m.mu.Lock()
+			m.links[lnk] = struct{}{}
+			m.mu.Unlock()
+			poll(len(entries) - 1)
+
+		case caseSessionDone:
+			for _, e := range entries {
+				e.link.muxFatal(m.session.doneErr)
+				e.link.muxDone()
+			}
+			return
+
+		default:
+			// find the link owning the chosen case
+			i := len(starts) - 1
+			for starts[i] > chosen {
+				i--
+			}
+			if exit := m.guard(entries[i].link, func() bool { return entries[i].link.muxEvent(chosen-starts[i], v) }); exit {
+				retire(i)
+				continue
+			}
+			poll(i)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package amqp
+
+// Solace PubSub+ accepts most addresses but is particular about the rest
+// of the attach parameters, and misconfigurations surface as temporary
+// endpoints or silent behavioral differences rather than errors:
+//   - the "queue" and "topic" terminus capabilities select the endpoint
+//     kind the address is resolved against
+//   - durable endpoints require terminus durability
+//     DurabilityUnsettledState combined with expiry policy
+//     ExpiryPolicyNever on the broker-side terminus; other combinations
+//     bind to temporary endpoints that vanish with the link
+//   - the delivery-count header records prior delivery attempts per the
+//     spec, while the broker's max-redelivery settings are expressed in
+//     attempts; see SolaceDeliveryAttempts
+//
+// The helpers below produce attach parameters for the common endpoint
+// configurations.
+
+// terminus capabilities Solace uses to select the endpoint kind.
+const (
+	capabilitySolaceQueue = "queue"
+	capabilitySolaceTopic = "topic"
+)
+
+// SolaceQueueSenderOptions returns sender options for publishing to a
+// durable Solace queue: the target carries the "queue" capability and
+// the durability/expiry combination Solace requires for durable
+// endpoints.  Pass the returned options, with any further adjustments,
+// to [Session.NewSender].
+func SolaceQueueSenderOptions() *SenderOptions {
+	return &SenderOptions{
+		TargetCapabilities: []string{capabilitySolaceQueue},
+		TargetDurability:   DurabilityUnsettledState,
+		TargetExpiryPolicy: ExpiryPolicyNever,
+	}
+}
+
+// SolaceTopicSenderOptions returns sender options for publishing to a
+// Solace topic: the target carries the "topic" capability and no
+// durable state, as topics are routing instructions rather than
+// endpoints.  Pass the returned options, with any further adjustments,
+// to [Session.NewSender].
+func SolaceTopicSenderOptions() *SenderOptions {
+	return &SenderOptions{
+		TargetCapabilities: []string{capabilitySolaceTopic},
+	}
+}
+
+// SolaceQueueReceiverOptions returns receiver options for consuming from
+// a durable Solace queue: the source carries the "queue" capability and
+// the durability/expiry combination Solace requires for durable
+// endpoints.  Pass the returned options, with any further adjustments,
+// to [Session.NewReceiver].
+func SolaceQueueReceiverOptions() *ReceiverOptions {
+	return &ReceiverOptions{
+		SourceCapabilities: []string{capabilitySolaceQueue},
+		SourceDurability:   DurabilityUnsettledState,
+		SourceExpiryPolicy: ExpiryPolicyNever,
+	}
+}
+
+// SolaceTopicReceiverOptions returns receiver options for a non-durable
+// topic subscription: the source carries the "topic" capability, and the
+// broker binds the link to a temporary endpoint subscribed to the source
+// address.  Pass the returned options, with any further adjustments, to
+// [Session.NewReceiver].
+func SolaceTopicReceiverOptions() *ReceiverOptions {
+	return &ReceiverOptions{
+		SourceCapabilities: []string{capabilitySolaceTopic},
+	}
+}
+
+// SolaceDeliveryAttempts returns the attempt number of a delivery as
+// Solace counts it.  The delivery-count header records prior attempts,
+// so the first delivery of a message is attempt 1.  Use it when
+// comparing against the broker's max-redelivery settings, which are
+// expressed in attempts rather than redeliveries.
+func SolaceDeliveryAttempts(msg *Message) uint32 {
+	return msg.DeliveryCount() + 1
+}
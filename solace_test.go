@@ -0,0 +1,96 @@
+package amqp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolaceQueueSenderOptions(t *testing.T) {
+	attaches := make(chan *frames.PerformAttach, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if pa, ok := req.(*frames.PerformAttach); ok {
+			attaches <- pa
+		}
+		return senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewSender(ctx, "orders", SolaceQueueSenderOptions())
+	cancel()
+	require.NoError(t, err)
+
+	attach := <-attaches
+	require.Equal(t, "orders", attach.Target.Address)
+	require.Contains(t, attach.Target.Capabilities, encoding.Symbol("queue"))
+	require.Equal(t, encoding.Durability(DurabilityUnsettledState), attach.Target.Durable)
+	require.Equal(t, ExpiryPolicyNever, attach.Target.ExpiryPolicy)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSolaceQueueReceiverOptions(t *testing.T) {
+	attaches := make(chan *frames.PerformAttach, 1)
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		if pa, ok := req.(*frames.PerformAttach); ok {
+			attaches <- pa
+		}
+		return receiverFrameHandlerNoUnhandled(0, ReceiverSettleModeFirst)(remoteChannel, req)
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewReceiver(ctx, "orders", SolaceQueueReceiverOptions())
+	cancel()
+	require.NoError(t, err)
+
+	attach := <-attaches
+	require.Equal(t, "orders", attach.Source.Address)
+	require.Contains(t, attach.Source.Capabilities, encoding.Symbol("queue"))
+	require.Equal(t, encoding.Durability(DurabilityUnsettledState), attach.Source.Durable)
+	require.Equal(t, ExpiryPolicyNever, attach.Source.ExpiryPolicy)
+
+	require.NoError(t, client.Close())
+}
+
+func TestSolaceTopicOptions(t *testing.T) {
+	sndOpts := SolaceTopicSenderOptions()
+	require.Equal(t, []string{"topic"}, sndOpts.TargetCapabilities)
+	require.Equal(t, DurabilityNone, sndOpts.TargetDurability)
+
+	rcvOpts := SolaceTopicReceiverOptions()
+	require.Equal(t, []string{"topic"}, rcvOpts.SourceCapabilities)
+	require.Equal(t, DurabilityNone, rcvOpts.SourceDurability)
+}
+
+func TestSolaceDeliveryAttempts(t *testing.T) {
+	// a message never delivered before is attempt 1
+	require.EqualValues(t, 1, SolaceDeliveryAttempts(&Message{}))
+	require.EqualValues(t, 3, SolaceDeliveryAttempts(&Message{
+		Header: &MessageHeader{DeliveryCount: 2},
+	}))
+}
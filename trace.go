@@ -0,0 +1,51 @@
+package amqp
+
+import "context"
+
+// traceParentKey is the application-properties key carrying the W3C
+// trace context (traceparent) of the producer that sent a message.
+const traceParentKey = "traceparent"
+
+// Tracer bridges go-amqp to a tracing system such as OpenTelemetry
+// without the module depending on one.  Set an implementation on a
+// connection via [ConnOptions.Tracer] to trace sends through settlement
+// and to recover the producer's trace context on receive.
+//
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	// SendStarted is called at the start of each send, before the
+	// message is encoded.  The returned traceparent, when non-empty, is
+	// injected into the message's application properties under
+	// "traceparent" so consumers can join the trace.  The returned end
+	// function, when non-nil, is called exactly once when the send
+	// completes — after settlement is confirmed for unsettled sends —
+	// with the send's error, ending the span.
+	//   - ctx is the context passed to the send
+	//   - address is the sender's target address
+	//   - msg is the message being sent
+	SendStarted(ctx context.Context, address string, msg *Message) (traceparent string, end func(err error))
+
+	// MessageReceived is called for each message surfaced by
+	// [Receiver.Receive], with the traceparent extracted from the
+	// message's application properties, or "" when the message doesn't
+	// carry one.
+	//   - address is the receiver's source address
+	//   - msg is the received message
+	MessageReceived(traceparent string, address string, msg *Message)
+}
+
+// injectTraceParent stamps traceparent into the message's application
+// properties.
+func injectTraceParent(msg *Message, traceparent string) {
+	if msg.ApplicationProperties == nil {
+		msg.ApplicationProperties = map[string]any{}
+	}
+	msg.ApplicationProperties[traceParentKey] = traceparent
+}
+
+// extractTraceParent returns the traceparent carried in the message's
+// application properties, or "" when absent.
+func extractTraceParent(msg *Message) string {
+	traceparent, _ := msg.ApplicationProperties[traceParentKey].(string)
+	return traceparent
+}
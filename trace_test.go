@@ -0,0 +1,155 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTracer records the callbacks it receives.
+type recordingTracer struct {
+	mu          sync.Mutex
+	traceparent string   // injected into sent messages
+	sendAddrs   []string // addresses passed to SendStarted
+	endErrs     []error  // errors passed to end functions
+	received    []string // traceparents passed to MessageReceived
+}
+
+func (r *recordingTracer) SendStarted(ctx context.Context, address string, msg *Message) (string, func(error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sendAddrs = append(r.sendAddrs, address)
+	return r.traceparent, func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.endErrs = append(r.endErrs, err)
+	}
+}
+
+func (r *recordingTracer) MessageReceived(traceparent string, address string, msg *Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, traceparent)
+}
+
+func TestTracerSend(t *testing.T) {
+	const traceparent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	tracer := &recordingTracer{traceparent: traceparent}
+
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		if err != nil || resp.Payload != nil {
+			return resp, err
+		}
+		switch tt := req.(type) {
+		case *frames.PerformTransfer:
+			var msg Message
+			if err := msg.UnmarshalBinary(tt.Payload); err != nil {
+				return fake.Response{}, err
+			}
+			if msg.ApplicationProperties[traceParentKey] != traceparent {
+				return fake.Response{}, fmt.Errorf("missing traceparent in %v", msg.ApplicationProperties)
+			}
+			return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{Tracer: tracer})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	snd, err := session.NewSender(ctx, "target", nil)
+	cancel()
+	require.NoError(t, err)
+
+	sendInitialFlowFrame(t, 0, netConn, 0, 100)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	require.NoError(t, snd.Send(ctx, NewMessage([]byte("test")), nil))
+	cancel()
+
+	require.NoError(t, client.Close())
+
+	require.Equal(t, []string{"target"}, tracer.sendAddrs)
+	require.Equal(t, []error{nil}, tracer.endErrs)
+}
+
+func TestTracerReceive(t *testing.T) {
+	const traceparent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	tracer := &recordingTracer{}
+
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		resp, err := receiverFrameHandler(0, ReceiverSettleModeFirst)(remoteChannel, req)
+		if resp.Payload != nil || err != nil {
+			return resp, err
+		}
+		switch req.(type) {
+		case *frames.PerformFlow, *frames.PerformDisposition:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, &ConnOptions{Tracer: tracer})
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	rcv, err := session.NewReceiver(ctx, "source", &ReceiverOptions{Credit: 10})
+	cancel()
+	require.NoError(t, err)
+
+	sendMessage := func(deliveryID uint32, msg *Message) {
+		payload, err := msg.MarshalBinary()
+		require.NoError(t, err)
+		format := uint32(0)
+		b, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformTransfer{
+			Handle:        0,
+			DeliveryID:    &deliveryID,
+			DeliveryTag:   []byte("tag"),
+			MessageFormat: &format,
+			Payload:       payload,
+		})
+		require.NoError(t, err)
+		netConn.SendFrame(b)
+	}
+
+	sendMessage(1, &Message{
+		ApplicationProperties: map[string]any{traceParentKey: traceparent},
+		Data:                  [][]byte{[]byte("traced")},
+	})
+	sendMessage(2, NewMessage([]byte("untraced")))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = rcv.Receive(ctx, nil)
+	require.NoError(t, err)
+	_, err = rcv.Receive(ctx, nil)
+	require.NoError(t, err)
+	cancel()
+
+	require.NoError(t, client.Close())
+	require.Equal(t, []string{traceparent, ""}, tracer.received)
+}
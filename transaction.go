@@ -0,0 +1,233 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+)
+
+// localTransactionCapability is requested on the coordinator when the
+// transaction control link attaches.
+const localTransactionCapability encoding.Symbol = "amqp:local-transactions"
+
+// Transaction is a transaction declared with the session peer's transaction
+// coordinator.  Work is enrolled in the transaction by tagging transfers and
+// dispositions with its ID — see [Transaction.Sender] and
+// [Transaction.Receiver], or use [Transaction.ID] directly via
+// [SendOptions.TransactionID] and [DispositionOptions.TransactionID].  The
+// provisional outcomes take effect on Commit and are discarded on Rollback.
+type Transaction struct {
+	controller *Sender // the control link the transaction was declared on
+	id         []byte
+	discharged atomic.Bool
+}
+
+// NewTransaction declares a transaction with the session peer's transaction
+// coordinator, attaching a control link to carry the declaration.  The
+// returned Transaction must be completed with [Transaction.Commit] or
+// [Transaction.Rollback], which discharges it and closes the control link.
+//   - ctx controls waiting for the control link to attach and for the
+//     coordinator to allocate the transaction
+func (s *Session) NewTransaction(ctx context.Context) (*Transaction, error) {
+	controller, err := newTransactionController(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	// when the coordinator describes its capabilities, verify it supports
+	// local transactions before doing any work
+	if peer := controller.peerCoordinator; peer != nil && len(peer.Capabilities) > 0 {
+		supported := false
+		for _, c := range peer.Capabilities {
+			if c == localTransactionCapability {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			_ = controller.Close(ctx)
+			return nil, fmt.Errorf("transaction coordinator doesn't support %s", localTransactionCapability)
+		}
+	}
+
+	receipt, err := controller.send(ctx, &Message{Value: &encoding.Declare{}}, nil)
+	if err != nil {
+		_ = controller.Close(ctx)
+		return nil, err
+	}
+	state, err := receipt.Wait(ctx)
+	if err != nil {
+		_ = controller.Close(ctx)
+		return nil, err
+	}
+	declared, ok := state.(*encoding.Declared)
+	if !ok {
+		_ = controller.Close(ctx)
+		return nil, fmt.Errorf("unexpected response to declare: %v", state)
+	}
+
+	return &Transaction{controller: controller, id: declared.TxnID}, nil
+}
+
+// newTransactionController attaches the transaction control link: a sender
+// whose target is the peer's transaction coordinator.
+func newTransactionController(ctx context.Context, s *Session) (*Sender, error) {
+	controller, err := attachWithRetry(ctx, nil, func() (*Sender, error) {
+		snd, err := newSender("", s, nil)
+		if err != nil {
+			return nil, err
+		}
+		snd.coordinator = &encoding.Coordinator{
+			Capabilities: encoding.MultiSymbol{localTransactionCapability},
+		}
+		return snd, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.linkMux != nil {
+		s.linkMux.add(controller)
+	} else {
+		go controller.mux(senderTestHooks{})
+	}
+	return controller, nil
+}
+
+// ID returns the transaction's ID as allocated by the coordinator.
+func (t *Transaction) ID() []byte {
+	return t.id
+}
+
+// Commit discharges the transaction, making the provisional outcomes of all
+// enrolled work effective, and closes the control link.  A transaction can
+// only be discharged once.
+//   - ctx controls waiting for the coordinator to acknowledge the discharge
+func (t *Transaction) Commit(ctx context.Context) error {
+	return t.discharge(ctx, false)
+}
+
+// Rollback discharges the transaction, discarding the provisional outcomes
+// of all enrolled work, and closes the control link.  A transaction can
+// only be discharged once.
+//   - ctx controls waiting for the coordinator to acknowledge the discharge
+func (t *Transaction) Rollback(ctx context.Context) error {
+	return t.discharge(ctx, true)
+}
+
+func (t *Transaction) discharge(ctx context.Context, fail bool) error {
+	if t.discharged.Swap(true) {
+		return errors.New("transaction already discharged")
+	}
+
+	receipt, err := t.controller.send(ctx, &Message{Value: &encoding.Discharge{TxnID: t.id, Fail: fail}}, nil)
+	if err != nil {
+		return err
+	}
+	state, err := receipt.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if rejected, ok := state.(*encoding.StateRejected); ok {
+		if rejected.Error != nil {
+			return rejected.Error
+		}
+		return errors.New("the coordinator rejected the discharge")
+	}
+
+	return t.controller.Close(ctx)
+}
+
+// Sender returns a wrapper around s whose sends are enrolled in the
+// transaction.
+func (t *Transaction) Sender(s *Sender) *TransactionalSender {
+	return &TransactionalSender{s: s, txn: t}
+}
+
+// TransactionalSender wraps a [Sender] so every transfer it sends is
+// enrolled in a transaction.  Created by [Transaction.Sender].
+type TransactionalSender struct {
+	s   *Sender
+	txn *Transaction
+}
+
+// Send sends a Message enrolled in the transaction; its delivery to the
+// target is provisional until the transaction is committed.
+// See [Sender.Send] for the send semantics.
+//   - ctx controls waiting for the message to be sent and possibly confirmed
+//   - msg is the message to send
+//   - opts contains optional values, pass nil to accept the defaults
+func (ts *TransactionalSender) Send(ctx context.Context, msg *Message, opts *SendOptions) error {
+	var o SendOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.TransactionID = ts.txn.id
+	return ts.s.Send(ctx, msg, &o)
+}
+
+// Receiver returns a wrapper around r whose dispositions are enrolled in
+// the transaction.
+func (t *Transaction) Receiver(r *Receiver) *TransactionalReceiver {
+	return &TransactionalReceiver{r: r, txn: t}
+}
+
+// TransactionalReceiver wraps a [Receiver] so every disposition it sends is
+// enrolled in a transaction.  Created by [Transaction.Receiver].
+type TransactionalReceiver struct {
+	r   *Receiver
+	txn *Transaction
+}
+
+// Receive returns the next message from the sender's queue.
+// See [Receiver.Receive] for the receive semantics.
+func (tr *TransactionalReceiver) Receive(ctx context.Context, opts *ReceiveOptions) (*Message, error) {
+	return tr.r.Receive(ctx, opts)
+}
+
+// AcceptMessage notifies the server that the message has been accepted
+// within the transaction; the outcome is provisional until the transaction
+// is committed.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to accept
+func (tr *TransactionalReceiver) AcceptMessage(ctx context.Context, msg *Message) error {
+	return tr.r.AcceptMessageWithOptions(ctx, msg, &DispositionOptions{TransactionID: tr.txn.id})
+}
+
+// RejectMessage notifies the server that the message is invalid, with the
+// outcome enrolled in the transaction.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to reject
+//   - e is an optional rejection error
+func (tr *TransactionalReceiver) RejectMessage(ctx context.Context, msg *Message, e *Error) error {
+	return tr.r.RejectMessageWithOptions(ctx, msg, e, &DispositionOptions{TransactionID: tr.txn.id})
+}
+
+// ReleaseMessage releases the message back to the server, with the outcome
+// enrolled in the transaction.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to release
+func (tr *TransactionalReceiver) ReleaseMessage(ctx context.Context, msg *Message) error {
+	return tr.r.ReleaseMessageWithOptions(ctx, msg, &DispositionOptions{TransactionID: tr.txn.id})
+}
+
+// ModifyMessage notifies the server that the message was not acted upon and
+// should be modified, with the outcome enrolled in the transaction.
+//   - ctx controls waiting for the peer to acknowledge the disposition
+//   - msg is the message to modify
+//   - options contains the optional settings to modify; pass nil to apply
+//     [ReceiverOptions.ModifiedOutcomeDefaults], if configured
+func (tr *TransactionalReceiver) ModifyMessage(ctx context.Context, msg *Message, options *ModifyMessageOptions) error {
+	var o ModifyMessageOptions
+	if options != nil {
+		o = *options
+	} else if tr.r.modifiedDefaults != nil {
+		// passing non-nil options would bypass the configured defaults
+		o = *tr.r.modifiedDefaults
+	}
+	o.TransactionID = tr.txn.id
+	return tr.r.ModifyMessage(ctx, msg, &o)
+}
@@ -0,0 +1,167 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/encoding"
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/stretchr/testify/require"
+)
+
+// transactionResponder handles the frames of a transaction control link:
+// the coordinator attach handshake, the declare, and the discharge.
+func transactionResponder(txnID []byte, caps encoding.MultiSymbol, dischargeCh chan *encoding.Discharge) frameHandler {
+	return func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *frames.PerformAttach:
+			if tt.Coordinator == nil {
+				return fake.Response{}, fmt.Errorf("expected coordinator attach, got %+v", tt)
+			}
+			mode := SenderSettleModeUnsettled
+			attach, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformAttach{
+				Name:             tt.Name,
+				Handle:           tt.Handle,
+				Role:             encoding.RoleReceiver,
+				Coordinator:      &encoding.Coordinator{Capabilities: caps},
+				SenderSettleMode: &mode,
+				MaxMessageSize:   math.MaxUint32,
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			// grant the control link credit in the same payload so the
+			// declare can be sent immediately
+			nextIncoming, count, available := uint32(0), uint32(0), uint32(0)
+			credit := uint32(100)
+			flow, err := fake.EncodeFrame(frames.TypeAMQP, 0, &frames.PerformFlow{
+				NextIncomingID: &nextIncoming,
+				IncomingWindow: 1000000,
+				OutgoingWindow: 1000000,
+				NextOutgoingID: nextIncoming + 1,
+				Handle:         &tt.Handle,
+				DeliveryCount:  &count,
+				LinkCredit:     &credit,
+				Available:      &available,
+			})
+			if err != nil {
+				return fake.Response{}, err
+			}
+			return fake.Response{Payload: append(attach, flow...)}, nil
+		case *frames.PerformTransfer:
+			var msg Message
+			if err := msg.UnmarshalBinary(tt.Payload); err != nil {
+				return fake.Response{}, err
+			}
+			switch body := msg.Value.(type) {
+			case *encoding.Declare:
+				return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.Declared{TxnID: txnID}))
+			case *encoding.Discharge:
+				dischargeCh <- body
+				return newResponse(fake.PerformDisposition(encoding.RoleReceiver, 0, *tt.DeliveryID, nil, &encoding.StateAccepted{}))
+			default:
+				return fake.Response{}, fmt.Errorf("unexpected message body %T", msg.Value)
+			}
+		default:
+			return senderFrameHandler(0, SenderSettleModeUnsettled)(remoteChannel, req)
+		}
+	}
+}
+
+func TestSessionNewTransactionCommit(t *testing.T) {
+	txnID := []byte("txn-1")
+	dischargeCh := make(chan *encoding.Discharge, 1)
+	responder := transactionResponder(txnID, encoding.MultiSymbol{localTransactionCapability}, dischargeCh)
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	txn, err := session.NewTransaction(ctx)
+	cancel()
+	require.NoError(t, err)
+	require.Equal(t, txnID, txn.ID())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = txn.Commit(ctx)
+	cancel()
+	require.NoError(t, err)
+
+	discharge := <-dischargeCh
+	require.Equal(t, txnID, discharge.TxnID)
+	require.False(t, discharge.Fail)
+
+	// a transaction can only be discharged once
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = txn.Rollback(ctx)
+	cancel()
+	require.EqualError(t, err, "transaction already discharged")
+	require.NoError(t, client.Close())
+}
+
+func TestSessionNewTransactionRollback(t *testing.T) {
+	txnID := []byte("txn-2")
+	dischargeCh := make(chan *encoding.Discharge, 1)
+	responder := transactionResponder(txnID, encoding.MultiSymbol{localTransactionCapability}, dischargeCh)
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	txn, err := session.NewTransaction(ctx)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	err = txn.Rollback(ctx)
+	cancel()
+	require.NoError(t, err)
+
+	discharge := <-dischargeCh
+	require.Equal(t, txnID, discharge.TxnID)
+	require.True(t, discharge.Fail)
+	require.NoError(t, client.Close())
+}
+
+func TestSessionNewTransactionUnsupported(t *testing.T) {
+	// the coordinator declares its capabilities but local transactions
+	// aren't among them
+	responder := transactionResponder(nil, encoding.MultiSymbol{"amqp:distributed-transactions"}, nil)
+	netConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := NewConn(ctx, netConn, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	_, err = session.NewTransaction(ctx)
+	cancel()
+	require.ErrorContains(t, err, "amqp:local-transactions")
+	require.NoError(t, client.Close())
+}
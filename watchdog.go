@@ -0,0 +1,78 @@
+package amqp
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// WatchdogEvent contains diagnostic information captured when a mux
+// goroutine appears to be stuck. See [ConnOptions.WatchdogTimeout].
+type WatchdogEvent struct {
+	// Entity identifies the mux that stalled, e.g. "session 2" or a link name.
+	Entity string
+
+	// Stalled is how long the mux has gone without making progress
+	// while work was queued.
+	Stalled time.Duration
+
+	// Stack contains a capture of all goroutine stacks taken when the
+	// stall was detected.
+	Stack []byte
+}
+
+// watchMux monitors a mux goroutine for lack of progress.
+//   - entity identifies the mux in emitted events
+//   - timeout is the minimum period without progress before an event is emitted
+//   - handler receives the diagnostic event; invoked from the watchdog's goroutine
+//   - progress is incremented by the mux on every loop iteration; MUST be atomically accessed
+//   - pending reports the amount of queued work; MUST be safe for concurrent use
+//   - done signals the watchdog to exit
+//
+// once a stall has been reported, no further events are emitted until the
+// mux makes progress again.
+func watchMux(entity string, timeout time.Duration, handler func(WatchdogEvent), progress *uint64, pending func() int, done <-chan struct{}) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	var (
+		last       = atomic.LoadUint64(progress)
+		lastChange = time.Now()
+		reported   bool
+	)
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// fall through to check for progress
+		}
+
+		if cur := atomic.LoadUint64(progress); cur != last {
+			last = cur
+			lastChange = time.Now()
+			reported = false
+			continue
+		}
+
+		if pending() == 0 {
+			// an idle mux isn't a stall; reset the clock so a stall is
+			// only measured from the time work was queued
+			lastChange = time.Now()
+			continue
+		}
+
+		if stalled := time.Since(lastChange); !reported && stalled >= timeout {
+			// 1MB is the same cap used by the runtime for GOTRACEBACK=all
+			buf := make([]byte, 1<<20)
+			n := runtime.Stack(buf, true)
+			handler(WatchdogEvent{
+				Entity:  entity,
+				Stalled: stalled,
+				Stack:   buf[:n],
+			})
+			reported = true
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package amqp
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchMux(t *testing.T) {
+	var (
+		progress uint64
+		pending  int32
+		events   = make(chan WatchdogEvent, 1)
+		done     = make(chan struct{})
+	)
+	defer close(done)
+
+	go watchMux("test mux", 20*time.Millisecond, func(e WatchdogEvent) {
+		select {
+		case events <- e:
+		default:
+		}
+	}, &progress, func() int { return int(atomic.LoadInt32(&pending)) }, done)
+
+	// no queued work means no stall is reported
+	select {
+	case <-events:
+		t.Fatal("unexpected watchdog event for idle mux")
+	case <-time.After(100 * time.Millisecond):
+		// expected
+	}
+
+	// queued work with no progress triggers an event
+	atomic.StoreInt32(&pending, 1)
+	select {
+	case e := <-events:
+		require.Equal(t, "test mux", e.Entity)
+		require.GreaterOrEqual(t, e.Stalled, 20*time.Millisecond)
+		require.NotEmpty(t, e.Stack)
+	case <-time.After(time.Second):
+		t.Fatal("expected watchdog event")
+	}
+
+	// no duplicate events until progress is made
+	select {
+	case <-events:
+		t.Fatal("unexpected duplicate watchdog event")
+	case <-time.After(100 * time.Millisecond):
+		// expected
+	}
+
+	// making progress re-arms the watchdog
+	atomic.AddUint64(&progress, 1)
+	select {
+	case e := <-events:
+		require.Equal(t, "test mux", e.Entity)
+	case <-time.After(time.Second):
+		t.Fatal("expected watchdog event after progress")
+	}
+}
+
+func TestConnOptionsWatchdog(t *testing.T) {
+	// handler is required when the watchdog is enabled
+	_, err := newConn(nil, &ConnOptions{WatchdogTimeout: time.Second})
+	require.Error(t, err)
+
+	conn, err := newConn(nil, &ConnOptions{
+		WatchdogTimeout: time.Second,
+		WatchdogHandler: func(WatchdogEvent) {},
+	})
+	require.NoError(t, err)
+	require.Equal(t, time.Second, conn.watchdogTimeout)
+	require.NotNil(t, conn.watchdogHandler)
+}
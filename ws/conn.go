@@ -0,0 +1,341 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// WebSocket frame opcodes from RFC 6455 section 5.2.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// appended to a compressed message before inflating: the four bytes
+// stripped after deflate's sync flush, plus an empty final block so the
+// decompressor terminates (RFC 7692 section 7.2.2).
+var flateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+// Conn is a WebSocket connection carrying a byte stream as binary
+// messages.  It implements [net.Conn]: Read returns message payloads in
+// order, and each Write sends one binary message.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader // wraps conn; may hold bytes buffered during the handshake
+
+	subprotocol string
+	compress    bool
+	maxFrame    int64
+
+	msgBuf  []byte // assembly buffer for the current incoming message
+	readRem []byte // unread remainder of the current incoming message
+
+	writeMu sync.Mutex // serializes frame writes; data messages and control frames interleave
+
+	done      chan struct{} // closed by Close; stops the ping loop
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Subprotocol returns the subprotocol selected by the server during the
+// handshake.  Returns the empty string if none was selected.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Read implements the [net.Conn] interface.
+// It returns payload bytes of incoming binary messages, transparently
+// answering control frames.  Receipt of a close frame is reported as
+// [io.EOF] after echoing the close to the server.
+func (c *Conn) Read(p []byte) (int, error) {
+	for len(c.readRem) == 0 {
+		if err := c.readMessage(); err != nil {
+			return 0, err
+		}
+		c.readRem = c.msgBuf
+	}
+	n := copy(p, c.readRem)
+	c.readRem = c.readRem[n:]
+	return n, nil
+}
+
+// readMessage assembles the next complete data message into c.msgBuf,
+// handling any interleaved control frames.
+func (c *Conn) readMessage() error {
+	c.msgBuf = c.msgBuf[:0]
+	var (
+		inMessage  bool
+		compressed bool
+	)
+	for {
+		fin, op, rsv1, payload, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeControl(opPong, payload); err != nil {
+				return err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			// echo the close (best effort) so the server can tear down
+			// cleanly, then surface end-of-stream to the caller
+			_ = c.writeControl(opClose, payload)
+			return io.EOF
+		}
+
+		if !inMessage {
+			if op != opBinary && op != opText {
+				return fmt.Errorf("ws: unexpected opcode %#x", op)
+			}
+			compressed = rsv1
+			inMessage = true
+		} else if op != opContinuation {
+			return fmt.Errorf("ws: expected continuation frame, got opcode %#x", op)
+		}
+
+		c.msgBuf = append(c.msgBuf, payload...)
+		if fin {
+			break
+		}
+	}
+
+	if compressed {
+		inflated, err := c.inflate(c.msgBuf)
+		if err != nil {
+			return err
+		}
+		c.msgBuf = inflated
+	}
+	return nil
+}
+
+// readFrame reads a single frame from the wire.
+func (c *Conn) readFrame() (fin bool, op byte, rsv1 bool, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(c.br, hdr[:]); err != nil {
+		return false, 0, false, nil, err
+	}
+
+	fin = hdr[0]&0x80 != 0
+	rsv1 = hdr[0]&0x40 != 0
+	op = hdr[0] & 0x0f
+	if hdr[0]&0x30 != 0 {
+		return false, 0, false, nil, fmt.Errorf("ws: unexpected reserved bits %#x", hdr[0]&0x30)
+	}
+	if rsv1 && !c.compress {
+		return false, 0, false, nil, fmt.Errorf("ws: compressed frame on an uncompressed connection")
+	}
+	if hdr[1]&0x80 != 0 {
+		// RFC 6455 section 5.1: a server MUST NOT mask its frames
+		return false, 0, false, nil, fmt.Errorf("ws: received masked frame from server")
+	}
+
+	length := int64(hdr[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, false, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, false, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if length < 0 || (c.maxFrame > 0 && length > c.maxFrame) {
+		return false, 0, false, nil, fmt.Errorf("ws: frame payload of %d bytes exceeds the %d-byte cap", length, c.maxFrame)
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return false, 0, false, nil, err
+	}
+	return fin, op, rsv1, payload, nil
+}
+
+// Write implements the [net.Conn] interface.
+// The payload is sent as a single binary message, split across multiple
+// frames if it exceeds the configured maximum frame size.
+func (c *Conn) Write(p []byte) (int, error) {
+	payload := p
+	var compressed bool
+	if c.compress && len(p) > 0 {
+		deflated, err := c.deflate(p)
+		if err != nil {
+			return 0, err
+		}
+		payload, compressed = deflated, true
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	first := true
+	for {
+		chunk := payload
+		if c.maxFrame > 0 && int64(len(chunk)) > c.maxFrame {
+			chunk = chunk[:c.maxFrame]
+		}
+		payload = payload[len(chunk):]
+		fin := len(payload) == 0
+
+		op := byte(opContinuation)
+		var rsv byte
+		if first {
+			op = opBinary
+			if compressed {
+				rsv = 0x40
+			}
+			first = false
+		}
+		if err := c.writeFrame(fin, op, rsv, chunk); err != nil {
+			return 0, err
+		}
+		if fin {
+			return len(p), nil
+		}
+	}
+}
+
+// writeControl sends a single control frame.
+func (c *Conn) writeControl(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeFrame(true, op, 0, payload)
+}
+
+// writeFrame writes a single masked frame to the wire.
+// The caller must hold writeMu.
+func (c *Conn) writeFrame(fin bool, op, rsv byte, payload []byte) error {
+	buf := make([]byte, 0, 14+len(payload))
+
+	b0 := op | rsv
+	if fin {
+		b0 |= 0x80
+	}
+	buf = append(buf, b0)
+
+	switch l := len(payload); {
+	case l < 126:
+		buf = append(buf, 0x80|byte(l))
+	case l < 1<<16:
+		buf = append(buf, 0x80|126, byte(l>>8), byte(l))
+	default:
+		buf = append(buf, 0x80|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(l))
+	}
+
+	var key [4]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return err
+	}
+	buf = append(buf, key[:]...)
+	for i, b := range payload {
+		buf = append(buf, b^key[i&3])
+	}
+
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// deflate compresses a message payload, stripping the trailing bytes of
+// the sync flush per RFC 7692 section 7.2.1.
+func (c *Conn) deflate(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	b := buf.Bytes()
+	return b[:len(b)-4], nil
+}
+
+// inflate decompresses a message payload.
+func (c *Conn) inflate(p []byte) ([]byte, error) {
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(p), bytes.NewReader(flateTail)))
+	defer fr.Close()
+	return io.ReadAll(fr)
+}
+
+// pingLoop periodically sends ping frames until the connection is closed.
+func (c *Conn) pingLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.writeControl(opPing, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Close implements the [net.Conn] interface.
+// It sends a best-effort close frame before closing the underlying
+// connection.  The error returned by subsequent calls to Close is
+// idempotent, so the same value will always be returned.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		// status code 1000: normal closure.  the write is bounded by a
+		// deadline so a peer that has stopped reading can't stall Close.
+		_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_ = c.writeControl(opClose, []byte{0x03, 0xe8})
+		c.closeErr = c.conn.Close()
+	})
+	return c.closeErr
+}
+
+// LocalAddr implements the [net.Conn] interface.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr implements the [net.Conn] interface.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetDeadline implements the [net.Conn] interface.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline implements the [net.Conn] interface.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements the [net.Conn] interface.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
@@ -0,0 +1,270 @@
+// Package ws provides a minimal WebSocket client transport for tunneling
+// AMQP connections through HTTP intermediaries.
+//
+// The connection returned by [Dial] implements [net.Conn] and can be
+// passed directly to [amqp.NewConn]:
+//
+//	wsConn, err := ws.Dial(ctx, "wss://example.com/$servicebus/websocket", &ws.Options{
+//		Subprotocols: []string{"amqp"},
+//	})
+//	conn, err := amqp.NewConn(ctx, wsConn, nil)
+//
+// The package has no dependency on a WebSocket client library; it
+// implements the client side of RFC 6455 directly, including the
+// permessage-deflate compression extension (RFC 7692).  The tuning
+// options cover the knobs gateways and reverse proxies commonly care
+// about: subprotocol selection, compression, ping interval, and maximum
+// frame size.
+package ws
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Options contains the optional settings for establishing a WebSocket
+// connection.
+type Options struct {
+	// Compression enables the permessage-deflate extension.  It's
+	// offered with no context takeover in both directions, and is in
+	// effect only if the server accepts the offer.
+	//
+	// Default: false.
+	Compression bool
+
+	// MaxFrameSize caps the payload size of a single WebSocket frame.
+	// Outgoing messages larger than the cap are split across multiple
+	// frames, and incoming frames over the cap terminate the
+	// connection.  Useful behind gateways that reject large frames.
+	//
+	// Default: 0, meaning no cap is applied.
+	MaxFrameSize int64
+
+	// PingInterval is the interval at which ping frames are sent,
+	// keeping intermediaries from idling out an otherwise quiet
+	// tunnel.
+	//
+	// Default: 0, meaning no pings are sent.
+	PingInterval time.Duration
+
+	// Subprotocols is the list of subprotocols offered during the
+	// handshake, in preference order.  The one selected by the server
+	// is available via [Conn.Subprotocol].
+	//
+	// Default: nil, meaning no subprotocol is offered.
+	Subprotocols []string
+
+	// TLSConfig is the TLS configuration used by [Dial] for "wss"
+	// URLs.  It's cloned before use; if its ServerName is empty, the
+	// host from the dialed URL is filled in.
+	//
+	// Default: nil, meaning a minimal configuration is constructed.
+	TLSConfig *tls.Config
+}
+
+// Dial connects to the provided URL and performs the WebSocket opening
+// handshake.
+//   - ctx controls the duration of dialing and the handshake
+//   - rawURL is the URL to connect to; the scheme must be "ws" or "wss"
+//   - opts contains optional values, pass nil to accept the defaults
+func Dial(ctx context.Context, rawURL string, opts *Options) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+
+	host := u.Hostname()
+	port := u.Port()
+	switch u.Scheme {
+	case "ws":
+		if port == "" {
+			port = "80"
+		}
+	case "wss":
+		if port == "" {
+			port = "443"
+		}
+	default:
+		return nil, fmt.Errorf("ws: unsupported scheme %q", u.Scheme)
+	}
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "wss" {
+		tlsConfig := o.TLSConfig.Clone()
+		if tlsConfig == nil {
+			tlsConfig = new(tls.Config)
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = host
+		}
+		tlsConn := tls.Client(netConn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+		netConn = tlsConn
+	}
+
+	wsConn, err := Client(ctx, netConn, rawURL, &o)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return wsConn, nil
+}
+
+// Client performs the client side of the WebSocket opening handshake
+// over an established connection.  Use it instead of [Dial] when the
+// underlying transport requires custom establishment, e.g. a proxied
+// or pre-dialed connection.
+//   - ctx controls the duration of the handshake
+//   - conn is the established connection to hand over to the WebSocket
+//   - rawURL is the URL the request line and Host header are built from
+//   - opts contains optional values, pass nil to accept the defaults
+func Client(ctx context.Context, conn net.Conn, rawURL string, opts *Options) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	var req strings.Builder
+	fmt.Fprintf(&req, "GET %s HTTP/1.1\r\n", u.RequestURI())
+	fmt.Fprintf(&req, "Host: %s\r\n", u.Host)
+	req.WriteString("Upgrade: websocket\r\n")
+	req.WriteString("Connection: Upgrade\r\n")
+	fmt.Fprintf(&req, "Sec-WebSocket-Key: %s\r\n", key)
+	req.WriteString("Sec-WebSocket-Version: 13\r\n")
+	if len(o.Subprotocols) > 0 {
+		fmt.Fprintf(&req, "Sec-WebSocket-Protocol: %s\r\n", strings.Join(o.Subprotocols, ", "))
+	}
+	if o.Compression {
+		req.WriteString("Sec-WebSocket-Extensions: permessage-deflate; client_no_context_takeover; server_no_context_takeover\r\n")
+	}
+	req.WriteString("\r\n")
+
+	if _, err := conn.Write([]byte(req.String())); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("ws: unexpected handshake status %q", resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return nil, fmt.Errorf("ws: unexpected Upgrade header %q", resp.Header.Get("Upgrade"))
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != secWebSocketAccept(key) {
+		return nil, fmt.Errorf("ws: invalid Sec-WebSocket-Accept %q", accept)
+	}
+
+	subprotocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if subprotocol != "" {
+		var offered bool
+		for _, p := range o.Subprotocols {
+			if p == subprotocol {
+				offered = true
+				break
+			}
+		}
+		if !offered {
+			return nil, fmt.Errorf("ws: server selected unrequested subprotocol %q", subprotocol)
+		}
+	}
+
+	var compress bool
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); ext != "" {
+		if err := validateDeflateExtension(ext, o.Compression); err != nil {
+			return nil, err
+		}
+		compress = true
+	}
+
+	c := &Conn{
+		conn:        conn,
+		br:          br,
+		subprotocol: subprotocol,
+		compress:    compress,
+		maxFrame:    o.MaxFrameSize,
+		done:        make(chan struct{}),
+	}
+	if o.PingInterval > 0 {
+		go c.pingLoop(o.PingInterval)
+	}
+	return c, nil
+}
+
+// validateDeflateExtension verifies that the extensions accepted by the
+// server are limited to the permessage-deflate offer we made.
+func validateDeflateExtension(ext string, offered bool) error {
+	if !offered {
+		return fmt.Errorf("ws: server accepted unoffered extension %q", ext)
+	}
+	params := strings.Split(ext, ";")
+	if name := strings.TrimSpace(params[0]); name != "permessage-deflate" {
+		return fmt.Errorf("ws: server accepted unoffered extension %q", name)
+	}
+	for _, param := range params[1:] {
+		switch p := strings.TrimSpace(param); p {
+		case "client_no_context_takeover", "server_no_context_takeover":
+			// both were offered
+		default:
+			return fmt.Errorf("ws: server requested unsupported extension parameter %q", p)
+		}
+	}
+	return nil
+}
+
+// GUID from RFC 6455 section 1.3, used to prove the handshake reached a
+// WebSocket-aware server.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// secWebSocketAccept computes the expected Sec-WebSocket-Accept value
+// for a handshake key.
+func secWebSocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key))
+	h.Write([]byte(acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,360 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// serverHandshake performs a minimal server side of the opening handshake.
+func serverHandshake(conn net.Conn, protocol string, deflate bool) (*bufio.Reader, error) {
+	br := bufio.NewReader(conn)
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	resp.WriteString("Upgrade: websocket\r\n")
+	resp.WriteString("Connection: Upgrade\r\n")
+	fmt.Fprintf(&resp, "Sec-WebSocket-Accept: %s\r\n", secWebSocketAccept(req.Header.Get("Sec-WebSocket-Key")))
+	if protocol != "" {
+		fmt.Fprintf(&resp, "Sec-WebSocket-Protocol: %s\r\n", protocol)
+	}
+	if deflate {
+		resp.WriteString("Sec-WebSocket-Extensions: permessage-deflate; client_no_context_takeover; server_no_context_takeover\r\n")
+	}
+	resp.WriteString("\r\n")
+
+	if _, err := conn.Write([]byte(resp.String())); err != nil {
+		return nil, err
+	}
+	return br, nil
+}
+
+// readClientFrame reads and unmasks a single client frame.
+func readClientFrame(br *bufio.Reader) (fin bool, op byte, rsv1 bool, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(br, hdr[:]); err != nil {
+		return false, 0, false, nil, err
+	}
+	fin = hdr[0]&0x80 != 0
+	rsv1 = hdr[0]&0x40 != 0
+	op = hdr[0] & 0x0f
+
+	masked := hdr[1]&0x80 != 0
+	length := int64(hdr[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(br, ext[:]); err != nil {
+			return false, 0, false, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(br, ext[:]); err != nil {
+			return false, 0, false, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if !masked {
+		return false, 0, false, nil, errors.New("client frame wasn't masked")
+	}
+
+	var key [4]byte
+	if _, err = io.ReadFull(br, key[:]); err != nil {
+		return false, 0, false, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(br, payload); err != nil {
+		return false, 0, false, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= key[i&3]
+	}
+	return fin, op, rsv1, payload, nil
+}
+
+// writeServerFrame writes a single unmasked server frame.
+func writeServerFrame(w io.Writer, fin bool, op, rsv byte, payload []byte) error {
+	buf := make([]byte, 0, 10+len(payload))
+	b0 := op | rsv
+	if fin {
+		b0 |= 0x80
+	}
+	buf = append(buf, b0)
+	switch l := len(payload); {
+	case l < 126:
+		buf = append(buf, byte(l))
+	case l < 1<<16:
+		buf = append(buf, 126, byte(l>>8), byte(l))
+	default:
+		buf = append(buf, 127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(l))
+	}
+	buf = append(buf, payload...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// echoServer echoes each data message back verbatim, preserving the
+// compression bit, and answers pings with pongs.
+func echoServer(conn net.Conn, br *bufio.Reader, pings chan<- struct{}) {
+	var (
+		msg       []byte
+		inMessage bool
+		rsv1      bool
+	)
+	for {
+		fin, op, rsv, payload, err := readClientFrame(br)
+		if err != nil {
+			return
+		}
+		switch op {
+		case opPing:
+			if pings != nil {
+				pings <- struct{}{}
+			}
+			if err := writeServerFrame(conn, true, opPong, 0, payload); err != nil {
+				return
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			_ = writeServerFrame(conn, true, opClose, 0, payload)
+			return
+		}
+		if !inMessage {
+			rsv1 = rsv
+			inMessage = true
+		}
+		msg = append(msg, payload...)
+		if fin {
+			var r byte
+			if rsv1 {
+				r = 0x40
+			}
+			if err := writeServerFrame(conn, true, opBinary, r, msg); err != nil {
+				return
+			}
+			msg, inMessage = nil, false
+		}
+	}
+}
+
+// newTestConn establishes a client over an in-memory pipe backed by echoServer.
+func newTestConn(t *testing.T, opts *Options, protocol string, deflate bool, pings chan<- struct{}) *Conn {
+	client, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+	go func() {
+		br, err := serverHandshake(server, protocol, deflate)
+		if err != nil {
+			return
+		}
+		echoServer(server, br, pings)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	conn, err := Client(ctx, client, "ws://fake/path", opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestDial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		br, err := serverHandshake(conn, "amqp", false)
+		if err != nil {
+			return
+		}
+		echoServer(conn, br, nil)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	conn, err := Dial(ctx, "ws://"+l.Addr().String()+"/path", &Options{
+		Subprotocols: []string{"amqp"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "amqp", conn.Subprotocol())
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), buf[:n])
+	require.NoError(t, conn.Close())
+}
+
+func TestDialBadScheme(t *testing.T) {
+	_, err := Dial(context.Background(), "amqp://example.com", nil)
+	require.Error(t, err)
+}
+
+func TestClientUnrequestedSubprotocol(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		_, _ = serverHandshake(server, "mqtt", false)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := Client(ctx, client, "ws://fake/path", &Options{Subprotocols: []string{"amqp"}})
+	require.ErrorContains(t, err, "subprotocol")
+}
+
+func TestConnEcho(t *testing.T) {
+	conn := newTestConn(t, nil, "", false, nil)
+	require.Empty(t, conn.Subprotocol())
+
+	for _, payload := range []string{"hello", "world", strings.Repeat("x", 200)} {
+		n, err := conn.Write([]byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, len(payload), n)
+
+		buf := make([]byte, len(payload))
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		require.Equal(t, payload, string(buf))
+	}
+}
+
+func TestConnFragmentation(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	type frame struct {
+		fin bool
+		op  byte
+	}
+	frames := make(chan frame, 10)
+	go func() {
+		br, err := serverHandshake(server, "", false)
+		if err != nil {
+			return
+		}
+		var msg []byte
+		for {
+			fin, op, _, payload, err := readClientFrame(br)
+			if err != nil {
+				return
+			}
+			frames <- frame{fin: fin, op: op}
+			msg = append(msg, payload...)
+			if fin {
+				break
+			}
+		}
+		_ = writeServerFrame(server, true, opBinary, 0, msg)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	conn, err := Client(ctx, client, "ws://fake/path", &Options{MaxFrameSize: 4})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// ten bytes split across three frames of at most four bytes
+	payload := []byte("0123456789")
+	_, err = conn.Write(payload)
+	require.NoError(t, err)
+	require.Equal(t, frame{fin: false, op: opBinary}, <-frames)
+	require.Equal(t, frame{fin: false, op: opContinuation}, <-frames)
+	require.Equal(t, frame{fin: true, op: opContinuation}, <-frames)
+
+	// the echoed single ten-byte frame exceeds the incoming cap
+	buf := make([]byte, len(payload))
+	_, err = io.ReadFull(conn, buf)
+	require.ErrorContains(t, err, "exceeds")
+}
+
+func TestConnCompression(t *testing.T) {
+	conn := newTestConn(t, &Options{Compression: true}, "", true, nil)
+	require.True(t, conn.compress)
+
+	payload := bytes.Repeat([]byte("compress me "), 100)
+	n, err := conn.Write(payload)
+	require.NoError(t, err)
+	require.Equal(t, len(payload), n)
+
+	buf := make([]byte, len(payload))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, payload, buf)
+}
+
+func TestConnCompressionRejected(t *testing.T) {
+	// the server accepting an unoffered extension fails the handshake
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		_, _ = serverHandshake(server, "", true)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := Client(ctx, client, "ws://fake/path", nil)
+	require.ErrorContains(t, err, "extension")
+}
+
+func TestConnPing(t *testing.T) {
+	pings := make(chan struct{}, 10)
+	conn := newTestConn(t, &Options{PingInterval: 10 * time.Millisecond}, "", false, pings)
+	_ = conn
+
+	select {
+	case <-pings:
+		// the tunnel is being kept alive
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for ping frame")
+	}
+}
+
+func TestConnRemoteClose(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		br, err := serverHandshake(server, "", false)
+		if err != nil {
+			return
+		}
+		// close the connection without sending any data
+		_ = writeServerFrame(server, true, opClose, 0, []byte{0x03, 0xe8})
+		// consume the client's close echo
+		_, _, _, _, _ = readClientFrame(br)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	conn, err := Client(ctx, client, "ws://fake/path", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	buf := make([]byte, 1)
+	_, err = conn.Read(buf)
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,75 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Azure/go-amqp/ws"
+)
+
+// WSOptions contains the optional settings for [DialWS].
+type WSOptions struct {
+	// Conn contains the options for the AMQP connection tunneled over
+	// the WebSocket.  If its HostName is empty, the host from the
+	// dialed URL is sent in the AMQP Open frame.
+	//
+	// Default: nil, accepting the AMQP connection defaults.
+	Conn *ConnOptions
+
+	// WS contains the options for the WebSocket transport itself.  The
+	// "amqp" subprotocol is always offered first; any subprotocols
+	// listed here are offered after it.
+	//
+	// Default: nil, accepting the WebSocket transport defaults.
+	WS *ws.Options
+}
+
+// DialWS connects to the provided URL, performs the WebSocket opening
+// handshake offering the "amqp" subprotocol, and establishes an AMQP
+// connection over the resulting tunnel, per the AMQP WebSocket binding.
+// It's the one-call equivalent of [ws.Dial] followed by [NewConn], for
+// brokers reachable only through HTTPS intermediaries on port 443.
+//   - ctx controls the duration of dialing, the WebSocket handshake, and the AMQP negotiation
+//   - rawURL is the URL to connect to; the scheme must be "ws" or "wss"
+//   - opts contains optional values, pass nil to accept the defaults
+func DialWS(ctx context.Context, rawURL string, opts *WSOptions) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var o WSOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	var wsOpts ws.Options
+	if o.WS != nil {
+		wsOpts = *o.WS
+	}
+	wsOpts.Subprotocols = append([]string{"amqp"}, wsOpts.Subprotocols...)
+
+	wsConn, err := ws.Dial(ctx, rawURL, &wsOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// per the binding, the server must select the "amqp" subprotocol.
+	// a server that selected one of the caller's extras is answering a
+	// different protocol, so don't attempt AMQP negotiation over it.
+	if sp := wsConn.Subprotocol(); sp != "" && sp != "amqp" {
+		wsConn.Close()
+		return nil, fmt.Errorf("amqp: unexpected WebSocket subprotocol %q", sp)
+	}
+
+	connOpts := ConnOptions{}
+	if o.Conn != nil {
+		connOpts = *o.Conn
+	}
+	if connOpts.HostName == "" {
+		connOpts.HostName = u.Hostname()
+	}
+
+	return NewConn(ctx, wsConn, &connOpts)
+}
@@ -0,0 +1,233 @@
+package amqp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp/internal/fake"
+	"github.com/Azure/go-amqp/internal/frames"
+	"github.com/Azure/go-amqp/ws"
+	"github.com/stretchr/testify/require"
+)
+
+// wsAccept computes the Sec-WebSocket-Accept value for a client key.
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// wsServerHandshake accepts the WebSocket upgrade, selecting protocol.
+func wsServerHandshake(conn net.Conn, protocol string) (*bufio.Reader, error) {
+	br := bufio.NewReader(conn)
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	resp.WriteString("Upgrade: websocket\r\n")
+	resp.WriteString("Connection: Upgrade\r\n")
+	fmt.Fprintf(&resp, "Sec-WebSocket-Accept: %s\r\n", wsAccept(req.Header.Get("Sec-WebSocket-Key")))
+	if protocol != "" {
+		fmt.Fprintf(&resp, "Sec-WebSocket-Protocol: %s\r\n", protocol)
+	}
+	resp.WriteString("\r\n")
+
+	if _, err := conn.Write([]byte(resp.String())); err != nil {
+		return nil, err
+	}
+	return br, nil
+}
+
+// wsReadClientFrame reads and unmasks a single client frame.  The tests
+// don't enable compression or a frame size cap, so every message is a
+// single uncompressed frame.
+func wsReadClientFrame(br *bufio.Reader) (op byte, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(br, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	op = hdr[0] & 0x0f
+
+	masked := hdr[1]&0x80 != 0
+	length := int64(hdr[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if !masked {
+		return 0, nil, errors.New("client frame wasn't masked")
+	}
+
+	var key [4]byte
+	if _, err = io.ReadFull(br, key[:]); err != nil {
+		return 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(br, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= key[i&3]
+	}
+	return op, payload, nil
+}
+
+// wsWriteServerFrame writes a single unmasked server frame.
+func wsWriteServerFrame(w io.Writer, op byte, payload []byte) error {
+	buf := make([]byte, 0, 10+len(payload))
+	buf = append(buf, 0x80|op)
+	switch l := len(payload); {
+	case l < 126:
+		buf = append(buf, byte(l))
+	case l < 1<<16:
+		buf = append(buf, 126, byte(l>>8), byte(l))
+	default:
+		buf = append(buf, 127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(l))
+	}
+	buf = append(buf, payload...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// wsBridge shuttles AMQP frames between the server side of a WebSocket
+// connection and a fake net.Conn, so the standard responders can drive
+// a tunneled AMQP exchange.
+func wsBridge(conn net.Conn, br *bufio.Reader, fakeConn *fake.NetConn) {
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := fakeConn.Read(buf)
+			if err != nil {
+				return
+			}
+			if err := wsWriteServerFrame(conn, 0x2, buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+	defer fakeConn.Close()
+
+	for {
+		op, payload, err := wsReadClientFrame(br)
+		if err != nil {
+			return
+		}
+		switch op {
+		case 0x9: // ping
+			if err := wsWriteServerFrame(conn, 0xa, payload); err != nil {
+				return
+			}
+		case 0x8: // close
+			_ = wsWriteServerFrame(conn, 0x8, payload)
+			return
+		case 0x2: // binary
+			if _, err := fakeConn.Write(payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestDialWS(t *testing.T) {
+	var hostname string
+	responder := func(remoteChannel uint16, req frames.FrameBody) (fake.Response, error) {
+		switch tt := req.(type) {
+		case *fake.AMQPProto:
+			return newResponse(fake.ProtoHeader(fake.ProtoAMQP))
+		case *frames.PerformOpen:
+			hostname = tt.Hostname
+			return newResponse(fake.PerformOpen("container"))
+		case *frames.PerformBegin:
+			return newResponse(fake.PerformBegin(0, remoteChannel))
+		case *frames.PerformEnd:
+			return newResponse(fake.PerformEnd(0, nil))
+		case *frames.PerformClose:
+			return newResponse(fake.PerformClose(nil))
+		case *fake.KeepAlive:
+			return fake.Response{}, nil
+		default:
+			return fake.Response{}, fmt.Errorf("unhandled frame %T", req)
+		}
+	}
+	fakeConn := fake.NewNetConn(responder, fake.NetConnOptions{})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		br, err := wsServerHandshake(conn, "amqp")
+		if err != nil {
+			return
+		}
+		wsBridge(conn, br, fakeConn)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	client, err := DialWS(ctx, "ws://"+l.Addr().String()+"/$servicebus/websocket", nil)
+	cancel()
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
+	session, err := client.NewSession(ctx, nil)
+	cancel()
+	require.NoError(t, err)
+	require.NotNil(t, session)
+
+	require.NoError(t, client.Close())
+	require.Equal(t, "127.0.0.1", hostname)
+}
+
+func TestDialWSUnexpectedSubprotocol(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		br, err := wsServerHandshake(conn, "mqtt")
+		if err != nil {
+			return
+		}
+		// hold the connection open until the client abandons it
+		_, _ = io.Copy(io.Discard, br)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err = DialWS(ctx, "ws://"+l.Addr().String()+"/path", &WSOptions{
+		WS: &ws.Options{Subprotocols: []string{"mqtt"}},
+	})
+	require.ErrorContains(t, err, "subprotocol")
+}